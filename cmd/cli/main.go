@@ -2,25 +2,62 @@ package main
 
 import (
 	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
 
+	"github.com/scopweb/mcp-go-pdf-tools/internal/config"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
 	"github.com/scopweb/mcp-go-pdf-tools/internal/pdf"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
 )
 
 func usage() {
 	fmt.Println("Usage:")
 	fmt.Println("  cli split -i <input.pdf> [-outdir <dir>] [-zip <zipfile>]")
 	fmt.Println("  cli remove-pages -i <input.pdf> -o <output.pdf> -pages <selection> [-mode remove|keep]")
+	fmt.Println("  cli collate -front <fronts.pdf> -back <backs.pdf> -o <output.pdf> [-reverse-back=false]")
+	fmt.Println("  cli compress -i <input.pdf> -o <output.pdf> [-profile lossless|print|ebook|screen|custom] [-quality 1-100] [-max-dpi <dpi>] [-grayscale] [-linearize]")
+	fmt.Println("  cli repair -i <damaged.pdf> -o <output.pdf>")
+	fmt.Println("  cli linearize -i <input.pdf> -o <output.pdf>")
+	fmt.Println("  cli batch -op <operation> [-glob <pattern>] [-i <a.pdf,b.pdf>] [-outdir <dir>] [-template <name>] [-args <json>] [-workers N]")
 	fmt.Println("Examples:")
 	fmt.Println("  cli split -i test.pdf -outdir output")
 	fmt.Println("  cli split -i test.pdf -zip split.zip")
 	fmt.Println("  cli remove-pages -i test.pdf -o result.pdf -pages '2,5-8,11'")
 	fmt.Println("  cli remove-pages -i test.pdf -o result.pdf -pages '1,3,5' -mode keep")
+	fmt.Println("  cli remove-pages -i test.pdf -o result.pdf -pages 'even,!last'")
+	fmt.Println("  cli collate -front fronts.pdf -back backs.pdf -o document.pdf")
+	fmt.Println("  cli compress -i test.pdf -o small.pdf -profile ebook")
+	fmt.Println("  cli compress -i test.pdf -o small.pdf -profile custom -quality 60 -max-dpi 120 -grayscale")
+	fmt.Println("  cli repair -i damaged.pdf -o repaired.pdf")
+	fmt.Println("  cli linearize -i test.pdf -o web.pdf")
+	fmt.Println("  cli batch -op compress -glob 'scans/*.pdf' -outdir small -args '{\"profile\":\"ebook\"}'")
+	fmt.Println("  cli batch -op rotate -i a.pdf,b.pdf -outdir rotated -template '{name}-r{ext}' -args '{\"degrees\":90}'")
+}
+
+// newProcessor crea un procesador PDF con la configuración de la CLI.
+func newProcessor() *pdf.Processor {
+	cfg := config.NewCLIConfig()
+	return pdf.NewProcessor(cfg.PDF, logging.New(cfg.LogLevel))
+}
+
+// fatal termina por el error de una operación. Si se ha interrumpido con
+// Ctrl-C sale con el código 130, como hacen las shells.
+func fatal(format string, err error) {
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "interrupted")
+		os.Exit(130)
+	}
+	log.Fatalf(format, err)
 }
 
 func zipFiles(zipPath string, files []string) error {
@@ -78,6 +115,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Ctrl-C cancela la operación en curso, que borra sus temporales y la
+	// salida a medio escribir.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	cmd := os.Args[1]
 	switch cmd {
 	case "split":
@@ -93,9 +135,9 @@ func main() {
 			os.Exit(2)
 		}
 
-		parts, err := pdf.SplitPDFFile(*in)
+		parts, err := newProcessor().Split(ctx, *in)
 		if err != nil {
-			log.Fatalf("split failed: %v", err)
+			fatal("split failed: %v", err)
 		}
 
 		// If outdir specified, move files
@@ -131,7 +173,7 @@ func main() {
 		fs := flag.NewFlagSet("remove-pages", flag.ExitOnError)
 		in := fs.String("i", "", "input PDF file")
 		out := fs.String("o", "", "output PDF file")
-		pages := fs.String("pages", "", "page selection, e.g. '2,5-8,11'")
+		pages := fs.String("pages", "", "page selection. "+pdf.PageSelectionSyntax)
 		mode := fs.String("mode", "remove", "mode: 'remove' (default) or 'keep'")
 		fs.Parse(os.Args[2:])
 
@@ -140,18 +182,25 @@ func main() {
 			fs.Usage()
 			os.Exit(2)
 		}
+		if _, err := pdf.ParsePageSelection(*pages); err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
 
-		keepMode := *mode == "keep"
-		result, err := pdf.RemovePagesFromFile(*in, *out, *pages, keepMode)
+		removalMode := types.ModeRemove
+		if *mode == "keep" {
+			removalMode = types.ModeKeep
+		}
+		result, err := newProcessor().RemovePages(ctx, *in, *out, *pages, removalMode)
 		if err != nil {
-			log.Fatalf("remove-pages failed: %v", err)
+			fatal("remove-pages failed: %v", err)
 		}
 
-		fmt.Printf("Mode: %s\n", result["mode"])
-		fmt.Printf("Original pages: %d\n", result["original_pages"])
-		fmt.Printf("Removed: %d pages\n", result["removed_count"])
-		fmt.Printf("Remaining: %d pages\n", result["remaining_pages"])
-		fmt.Printf("Output: %s\n", result["output_path"])
+		fmt.Printf("Mode: %s\n", result.Mode)
+		fmt.Printf("Original pages: %d\n", result.OriginalPages)
+		fmt.Printf("Removed: %d pages\n", result.RemovedCount)
+		fmt.Printf("Remaining: %d pages\n", result.RemainingPages)
+		fmt.Printf("Output: %s\n", result.OutputPath)
 
 	case "merge":
 		fs := flag.NewFlagSet("merge", flag.ExitOnError)
@@ -175,11 +224,185 @@ func main() {
 			os.Exit(2)
 		}
 
-		if err := pdf.MergePDFFiles(inputPaths, *out); err != nil {
-			log.Fatalf("merge failed: %v", err)
+		if _, err := newProcessor().Merge(ctx, inputPaths, *out, types.MergeOptions{}); err != nil {
+			fatal("merge failed: %v", err)
 		}
 		fmt.Printf("merged %d files -> %s\n", len(inputPaths), *out)
 
+	case "collate":
+		fs := flag.NewFlagSet("collate", flag.ExitOnError)
+		front := fs.String("front", "", "PDF with the front sides")
+		back := fs.String("back", "", "PDF with the back sides")
+		out := fs.String("o", "", "output PDF file")
+		reverseBack := fs.Bool("reverse-back", true, "back sides were scanned in reverse order")
+		fs.Parse(os.Args[2:])
+
+		if *front == "" || *back == "" || *out == "" {
+			fmt.Println("front, back and output are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		result, err := newProcessor().Collate(ctx, *front, *back, *reverseBack, *out)
+		if err != nil {
+			fatal("collate failed: %v", err)
+		}
+
+		fmt.Printf("Front pages: %d\n", result.PagesA)
+		fmt.Printf("Back pages: %d\n", result.PagesB)
+		fmt.Printf("Total pages: %d\n", result.TotalPages)
+		if result.CountMismatch {
+			fmt.Printf("Warning: %s\n", result.Warning)
+		}
+		fmt.Printf("Output: %s\n", result.OutputPath)
+
+	case "compress":
+		fs := flag.NewFlagSet("compress", flag.ExitOnError)
+		in := fs.String("i", "", "input PDF file")
+		out := fs.String("o", "", "output PDF file")
+		profileName := fs.String("profile", "lossless", "compression profile: lossless, print, ebook, screen or custom")
+		quality := fs.Int("quality", 0, "JPEG quality 1-100 (overrides the profile)")
+		maxDPI := fs.Int("max-dpi", 0, "downsample images above this resolution (overrides the profile)")
+		grayscale := fs.Bool("grayscale", false, "convert color images to grayscale")
+		linearize := fs.Bool("linearize", false, "linearize the output for fast web view")
+		fs.Parse(os.Args[2:])
+
+		if *in == "" || *out == "" {
+			fmt.Println("input and output are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+		profile, ok := types.ParseCompressProfile(*profileName)
+		if !ok {
+			fmt.Println("invalid profile: must be lossless, print, ebook, screen or custom")
+			os.Exit(2)
+		}
+
+		result, err := newProcessor().Compress(ctx, *in, *out, types.CompressOptions{
+			Profile:      profile,
+			ImageQuality: *quality,
+			MaxDPI:       *maxDPI,
+			Grayscale:    *grayscale,
+			Linearize:    *linearize,
+		})
+		if err != nil {
+			fatal("compress failed: %v", err)
+		}
+
+		fmt.Printf("Profile: %s\n", result.Profile)
+		fmt.Printf("Original size: %d bytes\n", result.OriginalSize)
+		fmt.Printf("Compressed size: %d bytes (%.1f%% smaller)\n", result.CompressedSize, result.CompressionRatio*100)
+		fmt.Printf("  images: %d bytes (%d re-encoded, %d downsampled, %d grayscale)\n",
+			result.Savings.Images, result.ImagesReencoded, result.ImagesDownsampled, result.ImagesGrayscale)
+		fmt.Printf("  duplicate images: %d bytes (%d merged)\n", result.Savings.DuplicateImages, result.DuplicateImages)
+		fmt.Printf("  unused fonts: %d bytes (%d removed)\n", result.Savings.UnusedFonts, result.FontsRemoved)
+		fmt.Printf("  structure: %d bytes\n", result.Savings.Structure)
+		for _, w := range result.Warnings {
+			fmt.Printf("Warning: %s\n", w)
+		}
+		fmt.Printf("Output: %s\n", result.OutputPath)
+
+	case "repair":
+		fs := flag.NewFlagSet("repair", flag.ExitOnError)
+		in := fs.String("i", "", "damaged PDF file")
+		out := fs.String("o", "", "output PDF file")
+		fs.Parse(os.Args[2:])
+
+		if *in == "" || *out == "" {
+			fmt.Println("input and output are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		result, err := newProcessor().Repair(ctx, *in, *out)
+		if err != nil {
+			fatal("repair failed: %v", err)
+		}
+
+		fmt.Printf("Objects found: %d\n", result.ObjectsFound)
+		fmt.Printf("Objects written: %d\n", result.ObjectsWritten)
+		fmt.Printf("Stream lengths fixed: %d\n", result.StreamLengthsFixed)
+		fmt.Printf("Corrupt objects dropped: %d\n", result.CorruptDropped)
+		fmt.Printf("Unreachable objects dropped: %d\n", result.UnreachableDropped)
+		for _, c := range result.Changes {
+			fmt.Printf("  object %d: %s %s\n", c.Object, c.Action, c.Detail)
+		}
+		fmt.Printf("Pages: %d\n", result.PageCount)
+		fmt.Printf("Output: %s\n", result.OutputPath)
+
+	case "linearize":
+		fs := flag.NewFlagSet("linearize", flag.ExitOnError)
+		in := fs.String("i", "", "input PDF file")
+		out := fs.String("o", "", "output PDF file")
+		fs.Parse(os.Args[2:])
+
+		if *in == "" || *out == "" {
+			fmt.Println("input and output are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+
+		result, err := newProcessor().Linearize(ctx, *in, *out)
+		if err != nil {
+			fatal("linearize failed: %v", err)
+		}
+
+		fmt.Printf("Pages: %d\n", result.TotalPages)
+		fmt.Printf("Original size: %d bytes\n", result.OriginalSize)
+		fmt.Printf("Output size: %d bytes\n", result.OutputSize)
+		fmt.Printf("Output: %s\n", result.OutputPath)
+
+	case "batch":
+		fs := flag.NewFlagSet("batch", flag.ExitOnError)
+		op := fs.String("op", "", "operation: "+strings.Join(pdf.BatchOperations(), ", "))
+		glob := fs.String("glob", "", "glob of input PDF files")
+		in := fs.String("i", "", "comma-separated input PDF files")
+		outdir := fs.String("outdir", "", "output directory (not needed for report-only operations)")
+		tmpl := fs.String("template", "", "output file name with {name}, {ext}, {index} and {op} (default {name}-{op}.pdf)")
+		args := fs.String("args", "", "operation arguments as JSON, e.g. '{\"profile\":\"ebook\"}'")
+		workers := fs.Int("workers", 0, "files processed at the same time (default 4)")
+		fs.Parse(os.Args[2:])
+
+		if *op == "" || (*glob == "" && *in == "") {
+			fmt.Println("operation and -glob or -i are required")
+			fs.Usage()
+			os.Exit(2)
+		}
+		inputs, err := pdf.ResolveBatchInputs(*glob, splitCSV(*in))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		step := types.PipelineStep{Operation: *op}
+		if *args != "" {
+			step.Args = json.RawMessage(*args)
+		}
+
+		result, err := newProcessor().Batch(ctx, inputs, step, types.BatchOptions{
+			OutputDir:        *outdir,
+			FilenameTemplate: *tmpl,
+			Workers:          *workers,
+		})
+		if err != nil {
+			fatal("batch failed: %v", err)
+		}
+
+		for _, f := range result.Files {
+			switch {
+			case f.Status != types.StepOK:
+				fmt.Printf("FAIL %s: %s\n", f.InputPath, f.Error)
+			case f.OutputPath != "":
+				fmt.Printf("ok   %s -> %s (%d ms)\n", f.InputPath, f.OutputPath, f.DurationMs)
+			default:
+				report, _ := json.Marshal(f.Result)
+				fmt.Printf("ok   %s: %s\n", f.InputPath, report)
+			}
+		}
+		fmt.Printf("%d succeeded, %d failed in %d ms\n", result.Succeeded, result.Failed, result.DurationMs)
+		if result.Failed > 0 {
+			os.Exit(1)
+		}
+
 	default:
 		usage()
 		os.Exit(1)
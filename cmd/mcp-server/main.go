@@ -1,13 +1,11 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
-	"fmt"
-	"io"
+	"context"
 	"log/slog"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
 
 	"github.com/scopweb/mcp-go-pdf-tools/internal/config"
 	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
@@ -29,60 +27,34 @@ func main() {
 	// Inicializar procesador PDF
 	processor := pdf.NewProcessor(cfg.PDF, logger)
 
+	// Los archivos generados se exponen como recursos hasta que caducan.
+	resources := NewResourceRegistry(cfg.ResourceTTL, cfg.PDF.TempDir, logger)
+	defer resources.Close()
+
+	// Plantillas de prompts: las integradas más las de MCP_PROMPTS_DIR.
+	prompts, err := NewPromptRegistry(cfg.PromptsDir, logger)
+	if err != nil {
+		logger.Error("failed to load prompts", err)
+		os.Exit(1)
+	}
+
 	// Crear servidor MCP
-	server := NewMCPServer(processor, logger)
+	server := NewMCPServer(processor, resources, prompts, logger)
+	server.toolsPageSize = cfg.ToolsPageSize
 
 	logger.Info("starting MCP stdio server",
 		slog.String("log_level", cfg.LogLevel),
+		slog.Int("max_in_flight", cfg.MaxInFlight),
 		slog.String("supported_versions", "2025-11-25,2025-06-18,2025-03-26"))
 
-	// Escáner con buffer grande para payloads grandes
-	scanner := bufio.NewScanner(os.Stdin)
-	// Buffer de 10MB para manejar grandes payloads JSON-RPC (ej: base64)
-	scanner.Buffer(make([]byte, 0, 64*1024), cfg.BufferSize)
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-
-		// Parsear solicitud
-		var req Request
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			logger.Error("failed to parse JSON", err)
-			continue
-		}
-
-		logger.Debug("received request",
-			slog.String("method", req.Method),
-			slog.Any("id", req.ID))
+	// Ctrl-C / SIGTERM cancelan las operaciones en curso antes de salir.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		// Validar ID requerido
-		if req.ID == nil && RequiresID(req.Method) {
-			logger.Warn("request missing required id",
-				slog.String("method", req.Method))
-			continue
-		}
-
-		// Procesar solicitud
-		resp := server.HandleRequest(&req)
-
-		// Enviar respuesta si es necesario
-		if resp != nil {
-			respBytes, err := json.Marshal(resp)
-			if err != nil {
-				logger.Error("failed to marshal response", err)
-				continue
-			}
-			fmt.Println(string(respBytes))
-		}
-	}
-
-	// Manejar errores del escáner
-	if err := scanner.Err(); err != nil && err != io.EOF {
+	stdio := newStdioServer(server, logger, os.Stdout, cfg.MaxInFlight, cfg.BufferSize)
+	if err := stdio.Serve(ctx, os.Stdin); err != nil {
 		logger.Error("scanner error", err)
-	} else {
+	} else if ctx.Err() == nil {
 		logger.Info("stdin closed (EOF). Shutting down.")
 	}
 }
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
+)
+
+// builtinPrompts son las plantillas que trae el servidor. Un archivo con el
+// mismo nombre de prompt en el directorio de la configuración las sustituye.
+//
+//go:embed prompts/*.json
+var builtinPrompts embed.FS
+
+// promptArgumentName son los nombres de argumento válidos: se usan como
+// {{.nombre}} en las plantillas.
+var promptArgumentName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// promptFile es el formato de un archivo de plantilla (<nombre>.json). El
+// texto de cada mensaje es una plantilla de text/template que recibe los
+// argumentos como mapa; los argumentos opcionales que el cliente no envía
+// valen "", así que se pueden usar en {{if .nombre}}.
+type promptFile struct {
+	Name        string           `json:"name"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Arguments   []PromptArgument `json:"arguments"`
+	Messages    []struct {
+		Role string `json:"role"`
+		Text string `json:"text"`
+	} `json:"messages"`
+}
+
+// promptTemplate es una plantilla ya validada.
+type promptTemplate struct {
+	prompt   Prompt
+	source   string
+	roles    []string
+	messages []*template.Template
+}
+
+// PromptRegistry guarda las plantillas de prompts/list y prompts/get: las
+// integradas y las de un directorio, que se cargan una vez al arrancar.
+type PromptRegistry struct {
+	prompts map[string]*promptTemplate
+}
+
+// NewPromptRegistry carga las plantillas integradas y, si dir no está vacío,
+// los archivos *.json de dir, que añaden prompts o sustituyen a los integrados
+// con el mismo nombre. Un archivo inválido se descarta con un aviso en el log
+// para que una plantilla mal escrita no impida arrancar; solo falla si dir no
+// se puede leer.
+func NewPromptRegistry(dir string, logger logging.Logger) (*PromptRegistry, error) {
+	r := &PromptRegistry{prompts: make(map[string]*promptTemplate)}
+	if err := r.loadDir(builtinPrompts, "prompts", "builtin", logger); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := r.loadDir(os.DirFS(dir), ".", dir, logger); err != nil {
+			return nil, fmt.Errorf("failed to load prompts from %s: %w", dir, err)
+		}
+	}
+	return r, nil
+}
+
+func (r *PromptRegistry) loadDir(fsys fs.FS, dir, source string, logger logging.Logger) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		tmpl, err := loadPrompt(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			logger.Warn("skipping invalid prompt template",
+				slog.String("file", entry.Name()),
+				slog.String("source", source),
+				slog.Any("error", err))
+			continue
+		}
+		tmpl.source = source
+		if prev, ok := r.prompts[tmpl.prompt.Name]; ok {
+			logger.Info("prompt overridden",
+				slog.String("prompt", tmpl.prompt.Name),
+				slog.String("previous", prev.source),
+				slog.String("source", source))
+		}
+		r.prompts[tmpl.prompt.Name] = tmpl
+	}
+	return nil
+}
+
+func loadPrompt(fsys fs.FS, name string) (*promptTemplate, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return parsePrompt(path.Base(name), data)
+}
+
+// parsePrompt valida un archivo de plantilla. El nombre del prompt es el del
+// archivo sin .json si el archivo no indica otro.
+func parsePrompt(fileName string, data []byte) (*promptTemplate, error) {
+	var file promptFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if file.Name == "" {
+		file.Name = strings.TrimSuffix(fileName, ".json")
+	}
+	if strings.TrimSpace(file.Name) == "" || strings.ContainsAny(file.Name, " \t\r\n") {
+		return nil, fmt.Errorf("invalid prompt name %q", file.Name)
+	}
+	if len(file.Messages) == 0 {
+		return nil, fmt.Errorf("prompt %s has no messages", file.Name)
+	}
+
+	sample := map[string]string{}
+	for _, arg := range file.Arguments {
+		if !promptArgumentName.MatchString(arg.Name) {
+			return nil, fmt.Errorf("invalid argument name %q", arg.Name)
+		}
+		if _, dup := sample[arg.Name]; dup {
+			return nil, fmt.Errorf("duplicate argument %q", arg.Name)
+		}
+		sample[arg.Name] = "x"
+	}
+
+	tmpl := &promptTemplate{prompt: Prompt{
+		Name:        file.Name,
+		Title:       file.Title,
+		Description: file.Description,
+		Arguments:   file.Arguments,
+	}}
+	for i, msg := range file.Messages {
+		if msg.Role != "user" && msg.Role != "assistant" {
+			return nil, fmt.Errorf("message %d: role must be user or assistant, got %q", i+1, msg.Role)
+		}
+		t, err := template.New(fmt.Sprintf("%s[%d]", file.Name, i)).Option("missingkey=error").Parse(msg.Text)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i+1, err)
+		}
+		// Con todos los argumentos presentes, una referencia a uno que no
+		// está declarado falla aquí y no en prompts/get.
+		if err := t.Execute(&strings.Builder{}, sample); err != nil {
+			return nil, fmt.Errorf("message %d: %w", i+1, err)
+		}
+		tmpl.roles = append(tmpl.roles, msg.Role)
+		tmpl.messages = append(tmpl.messages, t)
+	}
+	return tmpl, nil
+}
+
+// List devuelve los prompts ordenados por nombre.
+func (r *PromptRegistry) List() []Prompt {
+	prompts := make([]Prompt, 0, len(r.prompts))
+	for _, t := range r.prompts {
+		prompts = append(prompts, t.prompt)
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	return prompts
+}
+
+// Get rellena el prompt name con args. Faltar un argumento obligatorio o
+// enviar uno que el prompt no declara es un error.
+func (r *PromptRegistry) Get(name string, args map[string]string) (*GetPromptResponse, error) {
+	t, ok := r.prompts[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt: %s", name)
+	}
+	data := make(map[string]string, len(t.prompt.Arguments))
+	for _, arg := range t.prompt.Arguments {
+		value := strings.TrimSpace(args[arg.Name])
+		if arg.Required && value == "" {
+			return nil, fmt.Errorf("missing required argument %q", arg.Name)
+		}
+		data[arg.Name] = value
+	}
+	for k := range args {
+		if _, ok := data[k]; !ok {
+			return nil, fmt.Errorf("unknown argument %q for prompt %s", k, name)
+		}
+	}
+
+	resp := &GetPromptResponse{Description: t.prompt.Description}
+	for i, msg := range t.messages {
+		var text strings.Builder
+		if err := msg.Execute(&text, data); err != nil {
+			return nil, fmt.Errorf("failed to render prompt %s: %w", name, err)
+		}
+		resp.Messages = append(resp.Messages, PromptMessage{
+			Role:    t.roles[i],
+			Content: ToolContent{Type: "text", Text: strings.TrimSpace(text.String())},
+		})
+	}
+	return resp, nil
+}
+
+// handlePromptsList procesa la solicitud prompts/list.
+func (s *MCPServer) handlePromptsList(req *Request) *Response {
+	s.logger.Debug("listing prompts")
+	return NewSuccessResponse(req.ID, PromptsListResponse{Prompts: s.prompts.List()})
+}
+
+// handlePromptsGet procesa la solicitud prompts/get.
+func (s *MCPServer) handlePromptsGet(req *Request) *Response {
+	var getReq GetPromptRequest
+	if err := UnmarshalParams(req.Params, &getReq); err != nil || getReq.Name == "" {
+		return NewErrorResponse(req.ID, InvalidParams, "prompts/get needs a name")
+	}
+	s.logger.Debug("getting prompt", slog.String("prompt", getReq.Name))
+
+	resp, err := s.prompts.Get(getReq.Name, getReq.Arguments)
+	if err != nil {
+		return NewErrorResponse(req.ID, InvalidParams, err.Error())
+	}
+	return NewSuccessResponse(req.ID, resp)
+}
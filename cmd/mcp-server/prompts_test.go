@@ -0,0 +1,197 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/pdf"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// newTestPrompts carga las plantillas integradas y las de dir.
+func newTestPrompts(t *testing.T, dir string) *PromptRegistry {
+	t.Helper()
+	prompts, err := NewPromptRegistry(dir, logging.New("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prompts
+}
+
+func TestPrompts(t *testing.T) {
+	t.Run("builtin prompts are listed and rendered", func(t *testing.T) {
+		server := newTestServer(t)
+		list := call(t, server, "prompts/list", nil).Result.(PromptsListResponse).Prompts
+		var names []string
+		for _, p := range list {
+			names = append(names, p.Name)
+			if p.Title == "" || p.Description == "" || len(p.Arguments) == 0 {
+				t.Errorf("incomplete prompt %+v", p)
+			}
+		}
+		if got := strings.Join(names, ","); got != "prepare_for_sharing,split_contract,summarize_structure" {
+			t.Fatalf("prompts = %s", got)
+		}
+
+		resp := call(t, server, "prompts/get", map[string]interface{}{
+			"name":      "prepare_for_sharing",
+			"arguments": map[string]string{"input_path": "/in/contract.pdf", "output_path": "/out/contract.pdf", "watermark": "CONFIDENTIAL"},
+		})
+		if resp.Error != nil {
+			t.Fatalf("prompts/get: %+v", resp.Error)
+		}
+		got := resp.Result.(*GetPromptResponse)
+		text := got.Messages[0].Content.Text
+		if got.Messages[0].Role != "user" || got.Messages[0].Content.Type != "text" {
+			t.Errorf("message = %+v", got.Messages[0])
+		}
+		for _, want := range []string{"/in/contract.pdf", "/out/contract.pdf", `watermark with text "CONFIDENTIAL"`, "ebook profile", "pdf_pipeline", "strip_metadata", "flatten_forms"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("prompt text lacks %q:\n%s", want, text)
+			}
+		}
+	})
+
+	t.Run("pipeline steps are real operations", func(t *testing.T) {
+		ops := map[string]bool{}
+		for _, name := range pdf.PipelineOperations() {
+			ops[name] = true
+		}
+		for _, args := range []map[string]string{
+			{"input_path": "/a.pdf", "output_path": "/b.pdf", "watermark": "DRAFT"},
+			{"input_path": "/a.pdf", "output_path": "/b.pdf"},
+		} {
+			got, err := newTestPrompts(t, "").Get("prepare_for_sharing", args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := got.Messages[0].Content.Text
+			var steps []string
+			for _, line := range strings.Split(text, "\n") {
+				if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok {
+					step, _, _ := strings.Cut(rest, " ")
+					if !ops[step] {
+						t.Errorf("step %q is not a pipeline operation", step)
+					}
+					steps = append(steps, step)
+				}
+			}
+			want := "sanitize,compress"
+			if args["watermark"] != "" {
+				want = "sanitize,watermark,compress"
+			}
+			if got := strings.Join(steps, ","); got != want {
+				t.Errorf("watermark=%q: steps = %s, want %s", args["watermark"], got, want)
+			}
+		}
+	})
+
+	t.Run("profile hints are real compress profiles", func(t *testing.T) {
+		prompts := newTestPrompts(t, "")
+		checked := 0
+		for _, p := range prompts.List() {
+			for _, arg := range p.Arguments {
+				if arg.Name != "profile" {
+					continue
+				}
+				list, ok := strings.CutPrefix(arg.Description, "pdf_compress profile: ")
+				if !ok {
+					t.Errorf("%s: profile description %q does not list the profiles", p.Name, arg.Description)
+					continue
+				}
+				list, def, _ := strings.Cut(list, " (default: ")
+				names := strings.FieldsFunc(strings.ReplaceAll(list, " or ", ","), func(r rune) bool { return r == ',' || r == ' ' })
+				for _, name := range append(names, strings.TrimSuffix(def, ")")) {
+					if _, ok := types.ParseCompressProfile(name); !ok {
+						t.Errorf("%s: %q is not a compress profile", p.Name, name)
+					}
+				}
+				checked++
+			}
+		}
+		if checked == 0 {
+			t.Fatal("no prompt takes a profile")
+		}
+
+		got, err := prompts.Get("prepare_for_sharing", map[string]string{"input_path": "/a.pdf", "output_path": "/b.pdf", "profile": "print"})
+		if err != nil || !strings.Contains(got.Messages[0].Content.Text, "using the print profile") {
+			t.Errorf("prepare_for_sharing with profile=print = %+v, %v", got, err)
+		}
+	})
+
+	t.Run("default heading pattern matches headings inside a page", func(t *testing.T) {
+		got, err := newTestPrompts(t, "").Get("split_contract", map[string]string{"input_path": "/a.pdf", "output_dir": "/out"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, rest, _ := strings.Cut(got.Messages[0].Content.Text, "a pattern such as ")
+		pattern, _, ok := strings.Cut(rest, ". Each section")
+		if !ok {
+			t.Fatalf("no default pattern in %q", got.Messages[0].Content.Text)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			t.Fatalf("default pattern %q: %v", pattern, err)
+		}
+		page := "Contract of services\nClause 1. Scope\nThe provider...\nClause 2. Term\nTwo years."
+		if n := len(re.FindAllString(page, -1)); n != 2 {
+			t.Errorf("pattern %q found %d of 2 headings", pattern, n)
+		}
+	})
+
+	t.Run("bad arguments", func(t *testing.T) {
+		server := newTestServer(t)
+		for name, params := range map[string]map[string]interface{}{
+			"missing required": {"name": "summarize_structure"},
+			"undeclared":       {"name": "summarize_structure", "arguments": map[string]string{"input_path": "/a.pdf", "pages": "1"}},
+			"unknown prompt":   {"name": "nope"},
+		} {
+			if resp := call(t, server, "prompts/get", params); resp.Error == nil || resp.Error.Code != InvalidParams {
+				t.Errorf("%s: got %+v, want InvalidParams", name, resp)
+			}
+		}
+	})
+
+	t.Run("directory adds and overrides prompts", func(t *testing.T) {
+		dir := t.TempDir()
+		files := map[string]string{
+			"ocr_check.json":           `{"title":"OCR check","arguments":[{"name":"input_path","required":true}],"messages":[{"role":"user","text":"Search {{.input_path}} for any text."}]}`,
+			"summarize_structure.json": `{"messages":[{"role":"user","text":"Team version"}]}`,
+			"broken.json":              `{"messages":[{"role":"user","text":"{{.undeclared}}"}]}`,
+			"bad_role.json":            `{"messages":[{"role":"system","text":"hi"}]}`,
+			"notes.txt":                `not a prompt`,
+		}
+		for name, data := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		prompts := newTestPrompts(t, dir)
+
+		var names []string
+		for _, p := range prompts.List() {
+			names = append(names, p.Name)
+		}
+		if got := strings.Join(names, ","); got != "ocr_check,prepare_for_sharing,split_contract,summarize_structure" {
+			t.Errorf("prompts = %s", got)
+		}
+		got, err := prompts.Get("ocr_check", map[string]string{"input_path": "/a.pdf"})
+		if err != nil || got.Messages[0].Content.Text != "Search /a.pdf for any text." {
+			t.Errorf("ocr_check = %+v, %v", got, err)
+		}
+		got, err = prompts.Get("summarize_structure", nil)
+		if err != nil || got.Messages[0].Content.Text != "Team version" {
+			t.Errorf("override = %+v, %v", got, err)
+		}
+	})
+
+	t.Run("missing directory fails", func(t *testing.T) {
+		if _, err := NewPromptRegistry(filepath.Join(t.TempDir(), "missing"), logging.New("error")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
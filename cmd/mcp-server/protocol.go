@@ -40,6 +40,9 @@ const (
 	InvalidParams   = -32602
 	InternalError   = -32603
 	ServerErrorBase = -32000
+
+	// ResourceNotFound es el código MCP para resources/read de un URI desconocido.
+	ResourceNotFound = -32002
 )
 
 // ServerInfo contiene información sobre el servidor MCP.
@@ -65,32 +68,166 @@ type InitializeResponse struct {
 // Tool es una herramienta disponible a través de MCP.
 type Tool struct {
 	Name        string      `json:"name"`
+	Title       string      `json:"title,omitempty"` // Nombre para mostrar
 	Description string      `json:"description"`
 	InputSchema interface{} `json:"inputSchema"` // Puede ser un JSON schema
+	// OutputSchema describe structuredContent (protocolo 2025-06-18 o posterior)
+	OutputSchema interface{}      `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations son las pistas con las que el cliente decide si pide
+// confirmación antes de llamar una herramienta. Se envían siempre los cuatro
+// valores porque los valores por defecto de la especificación
+// (destructiveHint y openWorldHint true) no describen estas herramientas.
+type ToolAnnotations struct {
+	ReadOnlyHint    bool `json:"readOnlyHint"`
+	DestructiveHint bool `json:"destructiveHint"`
+	IdempotentHint  bool `json:"idempotentHint"`
+	OpenWorldHint   bool `json:"openWorldHint"`
+}
+
+// ListRequest son los parámetros de las solicitudes */list paginadas.
+type ListRequest struct {
+	Cursor string `json:"cursor,omitempty"`
 }
 
-// ToolsListResponse es la respuesta de tools/list.
+// ToolsListResponse es la respuesta de tools/list. NextCursor, si no está
+// vacío, se envía como cursor para pedir la página siguiente.
 type ToolsListResponse struct {
-	Tools []Tool `json:"tools"`
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // CallToolRequest es la solicitud para llamar una herramienta.
 type CallToolRequest struct {
 	Name      string          `json:"name"`
 	Arguments json.RawMessage `json:"arguments"`
+	Meta      *RequestMeta    `json:"_meta,omitempty"`
+}
+
+// RequestMeta es el campo _meta de una solicitud. Con ProgressToken el cliente
+// pide notifications/progress para esa solicitud.
+type RequestMeta struct {
+	ProgressToken interface{} `json:"progressToken,omitempty"`
+}
+
+// Notification es una notificación JSON-RPC 2.0 (sin id) que envía el servidor.
+type Notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// ProgressNotification son los parámetros de notifications/progress.
+type ProgressNotification struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      int         `json:"progress"`
+	Total         int         `json:"total,omitempty"`
 }
 
 // ToolContent es el contenido de una herramienta en la respuesta.
 type ToolContent struct {
-	Type string `json:"type"` // "text", "image", "resource"
+	Type string `json:"type"` // "text", "image", "resource", "resource_link"
 	Text string `json:"text,omitempty"`
 	Data interface{} `json:"data,omitempty"`
+
+	// Campos de resource_link
+	URI      string `json:"uri,omitempty"`
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+// Resource es un recurso MCP: un archivo generado por una herramienta.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+}
+
+// ResourceTemplate describe URIs con parámetros que resources/read acepta.
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourcesListResponse es la respuesta de resources/list.
+type ResourcesListResponse struct {
+	Resources []Resource `json:"resources"`
+}
+
+// ResourceTemplatesListResponse es la respuesta de resources/templates/list.
+type ResourceTemplatesListResponse struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+}
+
+// ReadResourceRequest es la solicitud de resources/read.
+type ReadResourceRequest struct {
+	URI string `json:"uri"`
+}
+
+// ResourceContents es el contenido de un recurso: Text para texto y JSON,
+// Blob (base64) para binarios.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// ReadResourceResponse es la respuesta de resources/read.
+type ReadResourceResponse struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+// Prompt describe una plantilla de prompt MCP en prompts/list.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument es un parámetro de una plantilla de prompt.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptsListResponse es la respuesta de prompts/list.
+type PromptsListResponse struct {
+	Prompts []Prompt `json:"prompts"`
+}
+
+// GetPromptRequest es la solicitud de prompts/get.
+type GetPromptRequest struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// PromptMessage es un mensaje de un prompt ya rellenado.
+type PromptMessage struct {
+	Role    string      `json:"role"`
+	Content ToolContent `json:"content"`
+}
+
+// GetPromptResponse es la respuesta de prompts/get.
+type GetPromptResponse struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
 }
 
 // ToolResult es el resultado de llamar una herramienta.
 type ToolResult struct {
-	Content []ToolContent `json:"content"`
-	IsError bool          `json:"isError,omitempty"`
+	Content           []ToolContent `json:"content"`
+	StructuredContent interface{}   `json:"structuredContent,omitempty"`
+	IsError           bool          `json:"isError,omitempty"`
 }
 
 // NewSuccessResponse crea una respuesta exitosa.
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/util"
+)
+
+// defaultResourceTTL es la vida de un recurso si la configuración no indica otra.
+const defaultResourceTTL = time.Hour
+
+// pdfInfoURIPrefix es el prefijo de la plantilla pdf://info/{+path}.
+const pdfInfoURIPrefix = "pdf://info/"
+
+// serverTempPrefixes son los prefijos de os.MkdirTemp de los directorios
+// temporales cuyas salidas se devuelven al cliente sin moverlas (pdf_split sin
+// output_dir). Solo esos directorios se borran al caducar.
+var serverTempPrefixes = []string{"pdf-split-"}
+
+// outputKeys son las claves de los resultados de las herramientas que
+// contienen rutas de archivos generados.
+var outputKeys = map[string]bool{
+	"output_path":           true,
+	"annotated_output_path": true,
+	"files":                 true,
+	"zip":                   true,
+	"zip_path":              true,
+}
+
+// ResourceRegistry guarda los archivos que generan las herramientas para
+// exponerlos como recursos MCP con URI file://. Solo se pueden leer los
+// archivos registrados. Cada recurso caduca ttl después de registrarse: deja
+// de listarse y, si está en un directorio temporal que creó el servidor
+// (serverTempPrefixes), util.ResourceCleaner borra ese directorio. Las salidas que el
+// cliente pidió en una ruta propia nunca se borran. Close borra al momento los
+// temporales pendientes.
+type ResourceRegistry struct {
+	ttl       time.Duration
+	tempRoots []string
+	logger    logging.Logger
+
+	ctx    context.Context // se cancela en Close y detiene las limpiezas programadas
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	entries  map[string]*resourceEntry
+	cleaners []pendingCleanup
+}
+
+type resourceEntry struct {
+	Resource
+	path    string
+	expires time.Time
+}
+
+type pendingCleanup struct {
+	cleaner *util.ResourceCleaner
+	expires time.Time
+}
+
+// NewResourceRegistry crea el registro. ttl menor o igual que 0 usa
+// defaultResourceTTL; tempDir es PDF_TEMP_DIR (puede estar vacío).
+func NewResourceRegistry(ttl time.Duration, tempDir string, logger logging.Logger) *ResourceRegistry {
+	if ttl <= 0 {
+		ttl = defaultResourceTTL
+	}
+	roots := []string{filepath.Clean(os.TempDir())}
+	if tempDir != "" {
+		if abs, err := filepath.Abs(tempDir); err == nil {
+			roots = append(roots, abs)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ResourceRegistry{
+		ttl:       ttl,
+		tempRoots: roots,
+		logger:    logger,
+		ctx:       ctx,
+		cancel:    cancel,
+		entries:   make(map[string]*resourceEntry),
+	}
+}
+
+// RegisterOutputs registra los archivos que existan entre paths y devuelve sus
+// recursos en el mismo orden. Todos caducan a la vez.
+func (r *ResourceRegistry) RegisterOutputs(paths []string) []Resource {
+	now := time.Now()
+	expires := now.Add(r.ttl)
+	cleaner := util.NewResourceCleaner(r.logger)
+	owned := map[string]bool{}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweepLocked(now)
+
+	var resources []Resource
+	seen := map[string]bool{}
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(abs)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		uri := fileURI(abs)
+		if seen[uri] {
+			continue
+		}
+		seen[uri] = true
+
+		res := Resource{
+			URI:      uri,
+			Name:     filepath.Base(abs),
+			MimeType: mimeTypeFor(abs),
+			Size:     info.Size(),
+		}
+		r.entries[uri] = &resourceEntry{Resource: res, path: abs, expires: expires}
+		resources = append(resources, res)
+
+		if dir := filepath.Dir(abs); r.isServerTempDir(dir) && !owned[dir] {
+			owned[dir] = true
+			cleaner.AddDirectory(dir)
+		}
+	}
+
+	if len(owned) > 0 {
+		cleaner.CleanupAsync(r.ctx, r.ttl)
+		r.cleaners = append(r.cleaners, pendingCleanup{cleaner: cleaner, expires: expires})
+	}
+	return resources
+}
+
+// List devuelve los recursos vigentes ordenados por URI.
+func (r *ResourceRegistry) List() []Resource {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweepLocked(time.Now())
+
+	resources := make([]Resource, 0, len(r.entries))
+	for _, e := range r.entries {
+		resources = append(resources, e.Resource)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].URI < resources[j].URI })
+	return resources
+}
+
+// Lookup devuelve el recurso vigente con ese URI y la ruta de su archivo.
+func (r *ResourceRegistry) Lookup(uri string) (Resource, string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweepLocked(time.Now())
+
+	e, ok := r.entries[uri]
+	if !ok {
+		return Resource{}, "", false
+	}
+	return e.Resource, e.path, true
+}
+
+// Forget da de baja un recurso cuyo archivo ya no existe.
+func (r *ResourceRegistry) Forget(uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, uri)
+}
+
+// Close cancela las limpiezas programadas y borra ya los temporales pendientes.
+func (r *ResourceRegistry) Close() {
+	r.cancel()
+	r.mu.Lock()
+	cleaners := r.cleaners
+	r.cleaners = nil
+	r.entries = make(map[string]*resourceEntry)
+	r.mu.Unlock()
+
+	for _, c := range cleaners {
+		if err := c.cleaner.CleanupSync(); err != nil {
+			r.logger.Warn("failed to clean up resources", slog.Any("error", err))
+		}
+	}
+}
+
+// sweepLocked quita los recursos caducados. Sus temporales los borra la
+// limpieza programada al registrarlos.
+func (r *ResourceRegistry) sweepLocked(now time.Time) {
+	for uri, e := range r.entries {
+		if now.After(e.expires) {
+			delete(r.entries, uri)
+		}
+	}
+	kept := r.cleaners[:0]
+	for _, c := range r.cleaners {
+		if now.Before(c.expires) {
+			kept = append(kept, c)
+		}
+	}
+	r.cleaners = kept
+}
+
+// isServerTempDir indica si dir tiene la forma de un directorio de
+// os.MkdirTemp con un prefijo de serverTempPrefixes (el prefijo seguido solo
+// de dígitos) y está directamente dentro de un directorio temporal.
+func (r *ResourceRegistry) isServerTempDir(dir string) bool {
+	base := filepath.Base(dir)
+	matched := false
+	for _, prefix := range serverTempPrefixes {
+		if rest, ok := strings.CutPrefix(base, prefix); ok && rest != "" && strings.Trim(rest, "0123456789") == "" {
+			matched = true
+		}
+	}
+	if !matched {
+		return false
+	}
+	parent := filepath.Dir(dir)
+	for _, root := range r.tempRoots {
+		if parent == root {
+			return true
+		}
+	}
+	return false
+}
+
+// fileURI construye el URI file:// de una ruta absoluta.
+func fileURI(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // C:/... en Windows
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// mimeTypeFor deduce el tipo MIME por la extensión.
+func mimeTypeFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf":
+		return "application/pdf"
+	case ".zip":
+		return "application/zip"
+	case ".json":
+		return "application/json"
+	}
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// isTextMimeType indica si el contenido se devuelve como texto en resources/read.
+func isTextMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") || strings.HasPrefix(mimeType, "application/json")
+}
+
+// collectOutputPaths recorre el JSON de un resultado y devuelve las rutas de
+// las claves de outputKeys (las listas solo si son de cadenas). Las claves de
+// cada objeto se recorren en orden alfabético.
+func collectOutputPaths(v interface{}) []string {
+	var paths []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if outputKeys[k] {
+					switch val := t[k].(type) {
+					case string:
+						if val != "" {
+							paths = append(paths, val)
+						}
+						continue
+					case []interface{}:
+						if len(val) > 0 {
+							if _, ok := val[0].(string); ok {
+								for _, s := range val {
+									if s, ok := s.(string); ok && s != "" {
+										paths = append(paths, s)
+									}
+								}
+								continue
+							}
+						}
+					}
+				}
+				walk(t[k])
+			}
+		case []interface{}:
+			for _, e := range t {
+				walk(e)
+			}
+		}
+	}
+	walk(v)
+	return paths
+}
+
+// attachResourceLinks registra como recursos los archivos que menciona un
+// resultado correcto de tools/call y añade un resource_link por cada uno.
+func (s *MCPServer) attachResourceLinks(resp *Response) {
+	if resp == nil || resp.Error != nil {
+		return
+	}
+	result, ok := resp.Result.(ToolResult)
+	if !ok || result.IsError || len(result.Content) == 0 || result.Content[0].Type != "text" {
+		return
+	}
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &parsed); err != nil {
+		return
+	}
+	paths := collectOutputPaths(parsed)
+	if len(paths) == 0 {
+		return
+	}
+	for _, res := range s.resources.RegisterOutputs(paths) {
+		result.Content = append(result.Content, ToolContent{
+			Type:     "resource_link",
+			URI:      res.URI,
+			Name:     res.Name,
+			MimeType: res.MimeType,
+			Size:     res.Size,
+		})
+	}
+	resp.Result = result
+}
+
+// handleResourcesList procesa la solicitud resources/list.
+func (s *MCPServer) handleResourcesList(req *Request) *Response {
+	s.logger.Debug("listing resources")
+	return NewSuccessResponse(req.ID, ResourcesListResponse{Resources: s.resources.List()})
+}
+
+// handleResourceTemplatesList procesa la solicitud resources/templates/list.
+func (s *MCPServer) handleResourceTemplatesList(req *Request) *Response {
+	return NewSuccessResponse(req.ID, ResourceTemplatesListResponse{
+		ResourceTemplates: []ResourceTemplate{{
+			URITemplate: pdfInfoURIPrefix + "{+path}",
+			Name:        "PDF info",
+			Description: "Page count, size and linearization of the PDF at path (same as pdf_info)",
+			MimeType:    "application/json",
+		}},
+	})
+}
+
+// handleResourcesRead procesa la solicitud resources/read: archivos
+// registrados y la plantilla pdf://info/{+path}. Como tools/call, una lectura
+// cancelada no recibe respuesta.
+func (s *MCPServer) handleResourcesRead(ctx context.Context, req *Request) *Response {
+	var readReq ReadResourceRequest
+	if err := UnmarshalParams(req.Params, &readReq); err != nil || readReq.URI == "" {
+		return NewErrorResponse(req.ID, InvalidParams, "resources/read needs a uri")
+	}
+	s.logger.Debug("reading resource", slog.String("uri", readReq.URI))
+
+	if rest, ok := strings.CutPrefix(readReq.URI, pdfInfoURIPrefix); ok {
+		path, err := url.PathUnescape(rest)
+		if err != nil || path == "" {
+			return NewErrorResponse(req.ID, InvalidParams, fmt.Sprintf("invalid uri: %s", readReq.URI))
+		}
+		info, err := s.processor.GetInfo(ctx, path)
+		if ctx.Err() != nil {
+			s.logger.Info("resource read cancelled", slog.Any("id", req.ID))
+			return nil
+		}
+		if err != nil {
+			return NewErrorResponse(req.ID, ResourceNotFound, fmt.Sprintf("resource not found: %v", err))
+		}
+		data, _ := json.Marshal(info)
+		return NewSuccessResponse(req.ID, ReadResourceResponse{Contents: []ResourceContents{{
+			URI: readReq.URI, MimeType: "application/json", Text: string(data),
+		}}})
+	}
+
+	res, path, ok := s.resources.Lookup(readReq.URI)
+	if !ok {
+		return NewErrorResponse(req.ID, ResourceNotFound, fmt.Sprintf("resource not found: %s", readReq.URI))
+	}
+	data, err := os.ReadFile(path)
+	if ctx.Err() != nil {
+		s.logger.Info("resource read cancelled", slog.Any("id", req.ID))
+		return nil
+	}
+	if err != nil {
+		s.logger.Warn("registered resource is gone", slog.String("uri", res.URI), slog.Any("error", err))
+		s.resources.Forget(res.URI)
+		return NewErrorResponse(req.ID, ResourceNotFound, fmt.Sprintf("resource not found: %s", readReq.URI))
+	}
+	contents := ResourceContents{URI: res.URI, MimeType: res.MimeType}
+	if isTextMimeType(res.MimeType) {
+		contents.Text = string(data)
+	} else {
+		contents.Blob = base64.StdEncoding.EncodeToString(data)
+	}
+	return NewSuccessResponse(req.ID, ReadResourceResponse{Contents: []ResourceContents{contents}})
+}
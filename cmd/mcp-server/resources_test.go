@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/config"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/pdf"
+)
+
+func call(t *testing.T, server *MCPServer, method string, params interface{}) *Response {
+	t.Helper()
+	raw, _ := json.Marshal(params)
+	resp := server.HandleRequest(context.Background(), &Request{JSONRPC: "2.0", ID: 1, Method: method, Params: raw})
+	if resp == nil {
+		t.Fatalf("%s: no response", method)
+	}
+	return resp
+}
+
+// splitWithLinks ejecuta pdf_split sin output_dir (las partes quedan en un
+// directorio temporal del servidor) y devuelve sus resource_link.
+func splitWithLinks(t *testing.T, server *MCPServer, input string) []ToolContent {
+	t.Helper()
+	resp := call(t, server, "tools/call", map[string]interface{}{
+		"name":      "pdf_split",
+		"arguments": map[string]interface{}{"pdf_path": input, "zip": true},
+	})
+	result := resp.Result.(ToolResult)
+	if result.IsError {
+		t.Fatalf("pdf_split failed: %s", result.Content[0].Text)
+	}
+	var links []ToolContent
+	for _, c := range result.Content {
+		if c.Type == "resource_link" {
+			links = append(links, c)
+		}
+	}
+	return links
+}
+
+func TestResources(t *testing.T) {
+	logger := logging.New("error")
+	processor := pdf.NewProcessor(config.PDFConfig{ValidationMode: "relaxed"}, logger)
+	input := writeBlankPDF(t, 2)
+
+	t.Run("tool outputs become readable resources", func(t *testing.T) {
+		resources := NewResourceRegistry(time.Hour, "", logger)
+		defer resources.Close()
+		server := NewMCPServer(processor, resources, newTestPrompts(t, ""), logger)
+
+		links := splitWithLinks(t, server, input)
+		if len(links) != 3 {
+			t.Fatalf("got %d resource links, want 2 pages and the zip: %+v", len(links), links)
+		}
+		var pdfLink ToolContent
+		for _, l := range links {
+			if !strings.HasPrefix(l.URI, "file://") || l.Size == 0 {
+				t.Errorf("bad link %+v", l)
+			}
+			if l.MimeType == "application/pdf" {
+				pdfLink = l
+			}
+		}
+
+		list := call(t, server, "resources/list", nil).Result.(ResourcesListResponse)
+		if len(list.Resources) != 3 {
+			t.Errorf("resources/list = %+v", list.Resources)
+		}
+
+		read := call(t, server, "resources/read", map[string]string{"uri": pdfLink.URI}).Result.(ReadResourceResponse)
+		data, err := base64.StdEncoding.DecodeString(read.Contents[0].Blob)
+		if err != nil || !bytes.HasPrefix(data, []byte("%PDF")) {
+			t.Errorf("resources/read did not return the PDF (err %v)", err)
+		}
+
+		if resp := call(t, server, "resources/read", map[string]string{"uri": "file:///etc/passwd"}); resp.Error == nil || resp.Error.Code != ResourceNotFound {
+			t.Errorf("unregistered file readable: %+v", resp)
+		}
+
+		info := call(t, server, "resources/read", map[string]string{"uri": pdfInfoURIPrefix + filepath.ToSlash(input)}).Result.(ReadResourceResponse)
+		if !strings.Contains(info.Contents[0].Text, `"total_pages":2`) {
+			t.Errorf("pdf://info = %+v", info.Contents)
+		}
+	})
+
+	t.Run("expired temp outputs are removed", func(t *testing.T) {
+		resources := NewResourceRegistry(50*time.Millisecond, "", logger)
+		defer resources.Close()
+		server := NewMCPServer(processor, resources, newTestPrompts(t, ""), logger)
+
+		links := splitWithLinks(t, server, input)
+		dir := filepath.Dir(strings.TrimPrefix(links[0].URI, "file://"))
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			_, err := os.Stat(dir)
+			if os.IsNotExist(err) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("temp dir %s not removed after expiry", dir)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if list := resources.List(); len(list) != 0 {
+			t.Errorf("expired resources still listed: %+v", list)
+		}
+	})
+
+	t.Run("close removes temp outputs but not user outputs", func(t *testing.T) {
+		resources := NewResourceRegistry(time.Hour, "", logger)
+		server := NewMCPServer(processor, resources, newTestPrompts(t, ""), logger)
+
+		links := splitWithLinks(t, server, input)
+		dir := filepath.Dir(strings.TrimPrefix(links[0].URI, "file://"))
+		userOut := filepath.Join(t.TempDir(), "merged.pdf")
+		resp := call(t, server, "tools/call", map[string]interface{}{
+			"name":      "pdf_merge",
+			"arguments": map[string]interface{}{"input_paths": []string{input, input}, "output_path": userOut},
+		})
+		if resp.Result.(ToolResult).IsError {
+			t.Fatalf("pdf_merge failed: %+v", resp.Result)
+		}
+
+		resources.Close()
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("temp dir survived Close: %v", err)
+		}
+		if _, err := os.Stat(userOut); err != nil {
+			t.Errorf("user output removed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// structuredOutputVersion es la primera versión de protocolo con outputSchema
+// y structuredContent. Las versiones son fechas, así que se comparan como
+// cadenas.
+const structuredOutputVersion = "2025-06-18"
+
+// blobFields son las propiedades de primer nivel con datos binarios en base64
+// (el ZIP de pdf_split con zip_b64). Solo van en el texto: en
+// structuredContent duplicarían la parte más grande de la respuesta, así que
+// tampoco figuran en outputSchema.
+var blobFields = map[string]bool{"zip_b64": true}
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+// outputSchema genera el JSON Schema (outputSchema de tools/list) del
+// resultado v a partir de su tipo Go y de sus etiquetas json, de modo que no
+// se desincroniza de lo que devuelve json.Marshal:
+//
+//   - los campos sin omitempty son obligatorios;
+//   - los slices, mapas y punteros sin omitempty admiten además null, que es
+//     como encoding/json serializa el valor nil;
+//   - los structs no admiten propiedades adicionales;
+//   - any y json.RawMessage admiten cualquier valor;
+//   - las propiedades de blobFields no aparecen.
+func outputSchema(v interface{}) map[string]interface{} {
+	s := schemaForType(reflect.TypeOf(v), map[reflect.Type]bool{})
+	if properties, ok := s["properties"].(map[string]interface{}); ok {
+		required := s["required"].([]string)[:0]
+		for _, name := range s["required"].([]string) {
+			if !blobFields[name] {
+				required = append(required, name)
+			}
+		}
+		s["required"] = required
+		for name := range blobFields {
+			delete(properties, name)
+		}
+	}
+	return s
+}
+
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == rawMessageType:
+		return map[string]interface{}{}
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"} // []byte va en base64
+		}
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := map[string]interface{}{}
+		required := []string{}
+		addStructFields(t, properties, &required, visiting)
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// addStructFields añade las propiedades de t; los structs embebidos sin
+// etiqueta json se aplanan, como hace encoding/json.
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, properties, required, visiting)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		omitempty := strings.Contains(","+opts+",", ",omitempty,")
+
+		s := schemaForType(f.Type, visiting)
+		if !omitempty && nullable(f.Type) {
+			if typ, ok := s["type"].(string); ok {
+				s["type"] = []string{typ, "null"}
+			}
+		}
+		properties[name] = s
+		if !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable indica si encoding/json puede serializar un valor de t como null.
+func nullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Interface:
+		return true
+	case reflect.Slice:
+		return t != rawMessageType
+	}
+	return false
+}
+
+// structuredOutput indica si la versión negociada admite outputSchema y
+// structuredContent. Antes de initialize se supone la más reciente.
+func (s *MCPServer) structuredOutput() bool {
+	s.mu.Lock()
+	version := s.protocolVersion
+	s.mu.Unlock()
+	if version == "" {
+		version = s.supportedVersions[0]
+	}
+	return version >= structuredOutputVersion
+}
+
+// attachStructuredContent copia en structuredContent el JSON que la
+// herramienta devuelve como texto, sin las propiedades de blobFields; el texto
+// se mantiene entero para los clientes que no leen structuredContent. Los
+// errores no llevan structuredContent porque no siguen el outputSchema.
+func attachStructuredContent(resp *Response) {
+	if resp == nil || resp.Error != nil {
+		return
+	}
+	result, ok := resp.Result.(ToolResult)
+	if !ok || result.IsError || len(result.Content) == 0 || result.Content[0].Type != "text" {
+		return
+	}
+	text := []byte(result.Content[0].Text)
+	if !bytes.HasPrefix(bytes.TrimSpace(text), []byte("{")) || !json.Valid(text) {
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(text, &fields); err != nil {
+		return
+	}
+	dropped := false
+	for name := range blobFields {
+		if _, ok := fields[name]; ok {
+			delete(fields, name)
+			dropped = true
+		}
+	}
+	if dropped {
+		text, _ = json.Marshal(fields)
+	}
+	result.StructuredContent = json.RawMessage(text)
+	resp.Result = result
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputSchema(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	a := writeBlankPDF(t, 3)
+	b := writeBlankPDF(t, 3)
+	certPath, keyPath := writeTestSigningKey(t, dir)
+	out := func(name string) string { return filepath.Join(dir, name) }
+	area := map[string]interface{}{"page": 1, "llx": 10, "lly": 10, "urx": 100, "ury": 100}
+
+	calls := map[string]map[string]interface{}{
+		"pdf_split":             {"pdf_path": a, "output_dir": out("split"), "zip": true},
+		"pdf_info":              {"pdf_path": a},
+		"pdf_compress":          {"pdf_path": a, "output_path": out("compressed.pdf")},
+		"pdf_remove_pages":      {"pdf_path": a, "output_path": out("removed.pdf"), "pages": "2"},
+		"pdf_merge":             {"input_paths": []string{a, b}, "output_path": out("merged.pdf")},
+		"pdf_collate":           {"front_path": a, "back_path": b, "output_path": out("collated.pdf")},
+		"pdf_redact":            {"input_path": a, "output_path": out("redacted.pdf"), "areas": []interface{}{area}},
+		"pdf_scan_pii":          {"input_path": a},
+		"pdf_search":            {"query": "x", "input_paths": []string{a, b}},
+		"pdf_validate":          {"input_path": a},
+		"pdf_repair":            {"input_path": a, "output_path": out("repaired.pdf")},
+		"pdf_linearize":         {"input_path": a, "output_path": out("linearized.pdf")},
+		"pdf_size_report":       {"input_path": a},
+		"pdf_pdfa_check":        {"input_path": a},
+		"pdf_pdfa_convert":      {"input_path": a, "output_path": out("pdfa.pdf")},
+		"pdf_verify_signatures": {"input_path": a},
+		"pdf_sign":              {"input_path": a, "output_path": out("signed.pdf"), "cert_path": certPath, "key_path": keyPath},
+		"pdf_security_scan":     {"input_path": a},
+		"pdf_sanitize":          {"input_path": a, "output_path": out("sanitized.pdf")},
+		"pdf_diff":              {"path_a": a, "path_b": b, "annotated_output_path": out("diff.pdf")},
+		"pdf_page_labels_get":   {"input_path": a},
+		"pdf_page_labels_set": {"input_path": a, "output_path": out("labelled.pdf"), "ranges": []interface{}{
+			map[string]interface{}{"start_page": 1, "style": "roman_lower"},
+		}},
+		"pdf_pipeline": {"input_path": a, "output_path": out("pipeline.pdf"), "steps": []interface{}{
+			map[string]interface{}{"operation": "rotate", "args": map[string]interface{}{"degrees": 90}},
+			map[string]interface{}{"operation": "info"},
+		}},
+		"pdf_batch": {"input_paths": []string{a, b}, "output_dir": out("batch"), "filename_template": "{index}-{name}.pdf", "operation": "rotate", "args": map[string]interface{}{"degrees": 90}},
+	}
+
+	defs := call(t, server, "tools/list", nil).Result.(ToolsListResponse).Tools
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	for _, def := range defs {
+		def := def
+		t.Run(def.Name, func(t *testing.T) {
+			if def.OutputSchema == nil {
+				t.Fatal("no outputSchema")
+			}
+			args, ok := calls[def.Name]
+			if !ok {
+				t.Fatal("no test call for this tool")
+			}
+			resp := call(t, server, "tools/call", map[string]interface{}{"name": def.Name, "arguments": args})
+			result := resp.Result.(ToolResult)
+			if result.IsError {
+				t.Fatalf("call failed: %s", result.Content[0].Text)
+			}
+			if result.StructuredContent == nil {
+				t.Fatal("no structuredContent")
+			}
+
+			schema := roundTrip(t, def.OutputSchema)
+			for _, v := range []interface{}{roundTrip(t, result.StructuredContent), roundTrip(t, json.RawMessage(result.Content[0].Text))} {
+				if err := validateSchema(schema, v, "$"); err != nil {
+					t.Errorf("result does not match outputSchema: %v\n%s", err, result.Content[0].Text)
+				}
+			}
+		})
+	}
+
+	t.Run("blobs only in the text", func(t *testing.T) {
+		resp := call(t, server, "tools/call", map[string]interface{}{"name": "pdf_split", "arguments": map[string]interface{}{"pdf_path": a, "output_dir": out("split-b64"), "zip": true, "zip_b64": true}})
+		result := resp.Result.(ToolResult)
+		if result.IsError {
+			t.Fatalf("call failed: %s", result.Content[0].Text)
+		}
+		if !strings.Contains(result.Content[0].Text, `"zip_b64"`) {
+			t.Error("text lacks zip_b64")
+		}
+		structured := roundTrip(t, result.StructuredContent).(map[string]interface{})
+		if _, ok := structured["zip_b64"]; ok {
+			t.Error("structuredContent repeats zip_b64")
+		}
+		if structured["zip"] == "" || structured["total_pages"] != float64(3) {
+			t.Errorf("structuredContent = %v", structured)
+		}
+	})
+
+	t.Run("older protocol versions get plain text", func(t *testing.T) {
+		server := newTestServer(t)
+		call(t, server, "initialize", map[string]interface{}{"protocolVersion": "2025-03-26"})
+		for _, def := range call(t, server, "tools/list", nil).Result.(ToolsListResponse).Tools {
+			if def.OutputSchema != nil {
+				t.Errorf("%s: outputSchema sent to a 2025-03-26 client", def.Name)
+			}
+		}
+		resp := call(t, server, "tools/call", map[string]interface{}{"name": "pdf_info", "arguments": map[string]interface{}{"pdf_path": a}})
+		if result := resp.Result.(ToolResult); result.IsError || result.StructuredContent != nil {
+			t.Errorf("unexpected result %+v", result)
+		}
+	})
+}
+
+// roundTrip devuelve v tal como lo vería el cliente tras serializarlo.
+func roundTrip(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// validateSchema comprueba v contra el subconjunto de JSON Schema que genera
+// outputSchema: type (también en lista), properties, required,
+// additionalProperties e items.
+func validateSchema(schema, v interface{}, path string) error {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: bad schema %v", path, schema)
+	}
+	if typ, ok := s["type"]; ok {
+		var allowed []interface{}
+		if list, isList := typ.([]interface{}); isList {
+			allowed = list
+		} else {
+			allowed = []interface{}{typ}
+		}
+		matched := false
+		for _, a := range allowed {
+			if jsonTypeMatches(a.(string), v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: %T does not match type %v", path, v, typ)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		props, _ := s["properties"].(map[string]interface{})
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, r := range required {
+				if _, ok := val[r.(string)]; !ok {
+					return fmt.Errorf("%s: missing required %q", path, r)
+				}
+			}
+		}
+		for k, item := range val {
+			if ps, ok := props[k]; ok {
+				if err := validateSchema(ps, item, path+"."+k); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := s["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					return fmt.Errorf("%s: unexpected property %q", path, k)
+				}
+			case map[string]interface{}:
+				if err := validateSchema(extra, item, path+"."+k); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if items, ok := s["items"]; ok {
+			for i, item := range val {
+				if err := validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func jsonTypeMatches(typ string, v interface{}) bool {
+	switch typ {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+// writeTestSigningKey escribe un certificado autofirmado y su clave sin cifrar
+// en PEM para pdf_sign.
+func writeTestSigningKey(t *testing.T, dir string) (certPath, keyPath string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "Schema Test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath = filepath.Join(dir, "cert.pem")
+	keyPath = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
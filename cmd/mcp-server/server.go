@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
 	"github.com/scopweb/mcp-go-pdf-tools/internal/pdf"
@@ -14,34 +17,82 @@ import (
 type MCPServer struct {
 	supportedVersions []string
 	tools             *ToolsRegistry
+	resources         *ResourceRegistry
+	prompts           *PromptRegistry
+	processor         *pdf.Processor
 	logger            logging.Logger
+
+	// toolsPageSize es el número máximo de herramientas por página de
+	// tools/list; con 0 se envían todas en una página.
+	toolsPageSize int
+
+	// inFlight guarda la función de cancelación de cada tools/call en curso
+	// (ver beginRequest), indexada por requestKey, para atender
+	// notifications/cancelled.
+	mu       sync.Mutex
+	inFlight map[string]context.CancelFunc
+
+	// protocolVersion es la versión negociada en initialize (protegida por
+	// mu); decide si se anuncian outputSchema y structuredContent.
+	protocolVersion string
+
+	// notify envía una notificación al cliente; lo asigna el transporte
+	// (newStdioServer). Sin transporte las notificaciones se descartan.
+	notify func(*Notification)
+}
+
+// progressInterval es el tiempo mínimo entre dos notifications/progress de
+// una misma solicitud; el aviso final se envía siempre.
+const progressInterval = 100 * time.Millisecond
+
+// CancelledNotification son los parámetros de notifications/cancelled.
+type CancelledNotification struct {
+	RequestID RequestID `json:"requestId"`
+	Reason    string    `json:"reason,omitempty"`
 }
 
-// NewMCPServer crea un nuevo servidor MCP.
-func NewMCPServer(processor *pdf.Processor, logger logging.Logger) *MCPServer {
+// NewMCPServer crea un nuevo servidor MCP. Los archivos que generan las
+// herramientas se registran en resources; prompts/list y prompts/get sirven
+// las plantillas de prompts.
+func NewMCPServer(processor *pdf.Processor, resources *ResourceRegistry, prompts *PromptRegistry, logger logging.Logger) *MCPServer {
 	return &MCPServer{
 		supportedVersions: []string{"2025-11-25", "2025-06-18", "2025-03-26"},
 		tools:             NewToolsRegistry(processor, logger),
+		resources:         resources,
+		prompts:           prompts,
+		processor:         processor,
 		logger:            logger,
+		inFlight:          make(map[string]context.CancelFunc),
 	}
 }
 
-// HandleRequest procesa una solicitud MCP.
-func (s *MCPServer) HandleRequest(req *Request) *Response {
+// HandleRequest procesa una solicitud MCP. ctx se propaga a las herramientas:
+// al cancelarlo (cierre del servidor) se detienen las operaciones en curso.
+func (s *MCPServer) HandleRequest(ctx context.Context, req *Request) *Response {
 	switch req.Method {
 	case "initialize":
 		return s.handleInitialize(req)
 	case "tools/list":
 		return s.handleToolsList(req)
 	case "tools/call":
-		return s.handleToolsCall(req)
+		return s.handleToolsCall(ctx, req)
+	case "resources/list":
+		return s.handleResourcesList(req)
+	case "resources/templates/list":
+		return s.handleResourceTemplatesList(req)
+	case "resources/read":
+		return s.handleResourcesRead(ctx, req)
+	case "prompts/list":
+		return s.handlePromptsList(req)
+	case "prompts/get":
+		return s.handlePromptsGet(req)
 	case "ping":
 		return s.handlePing(req)
 	case "notifications/initialized":
 		s.logger.Debug("received notifications/initialized")
 		return nil
 	case "notifications/cancelled":
-		s.logger.Debug("received notifications/cancelled")
+		s.handleCancelled(req)
 		return nil
 	default:
 		if req.ID != nil {
@@ -65,6 +116,9 @@ func (s *MCPServer) handleInitialize(req *Request) *Response {
 
 	// Negociar versión de protocolo
 	negotiated := s.negotiateVersion(initReq.ProtocolVersion)
+	s.mu.Lock()
+	s.protocolVersion = negotiated
+	s.mu.Unlock()
 
 	s.logger.Info("initialized",
 		slog.String("protocol_version", negotiated),
@@ -76,6 +130,13 @@ func (s *MCPServer) handleInitialize(req *Request) *Response {
 			"tools": map[string]interface{}{
 				"listChanged": false,
 			},
+			"resources": map[string]interface{}{
+				"subscribe":   false,
+				"listChanged": false,
+			},
+			"prompts": map[string]interface{}{
+				"listChanged": false,
+			},
 		},
 		ServerInfo: ServerInfo{
 			Name:    "mcp-go-pdf-tools",
@@ -88,13 +149,26 @@ func (s *MCPServer) handleInitialize(req *Request) *Response {
 
 // handleToolsList procesa la solicitud tools/list.
 func (s *MCPServer) handleToolsList(req *Request) *Response {
-	s.logger.Debug("listing tools")
-	toolDefs := s.tools.GetToolDefinitions()
-	return NewSuccessResponse(req.ID, ToolsListResponse{Tools: toolDefs})
+	var listReq ListRequest
+	if err := UnmarshalParams(req.Params, &listReq); err != nil {
+		return NewErrorResponse(req.ID, InvalidParams, fmt.Sprintf("invalid tools/list params: %v", err))
+	}
+	s.logger.Debug("listing tools", slog.String("cursor", listReq.Cursor))
+	toolDefs, next, err := s.tools.ListTools(listReq.Cursor, s.toolsPageSize)
+	if err != nil {
+		return NewErrorResponse(req.ID, InvalidParams, err.Error())
+	}
+	if !s.structuredOutput() {
+		for i := range toolDefs {
+			toolDefs[i].OutputSchema = nil
+		}
+	}
+	return NewSuccessResponse(req.ID, ToolsListResponse{Tools: toolDefs, NextCursor: next})
 }
 
-// handleToolsCall procesa la solicitud tools/call.
-func (s *MCPServer) handleToolsCall(req *Request) *Response {
+// handleToolsCall procesa la solicitud tools/call. Si el cliente cancela la
+// solicitud no se envía respuesta, como indica la especificación.
+func (s *MCPServer) handleToolsCall(ctx context.Context, req *Request) *Response {
 	var callReq CallToolRequest
 	if err := json.Unmarshal(req.Params, &callReq); err != nil {
 		s.logger.Warn("failed to unmarshal tools/call params",
@@ -105,7 +179,105 @@ func (s *MCPServer) handleToolsCall(req *Request) *Response {
 	s.logger.Debug("calling tool",
 		slog.String("tool", callReq.Name))
 
-	return s.tools.CallTool(req.ID, callReq.Name, callReq.Arguments)
+	if callReq.Meta != nil && callReq.Meta.ProgressToken != nil {
+		ctx = pdf.WithProgress(ctx, s.progressReporter(callReq.Meta.ProgressToken))
+	}
+	resp := s.tools.CallTool(ctx, req.ID, callReq.Name, callReq.Arguments)
+	if ctx.Err() != nil {
+		s.logger.Info("tool call cancelled",
+			slog.String("tool", callReq.Name),
+			slog.Any("id", req.ID))
+		return nil
+	}
+	if s.structuredOutput() && s.tools.hasOutputSchema(callReq.Name) {
+		attachStructuredContent(resp)
+	}
+	s.attachResourceLinks(resp)
+	return resp
+}
+
+// progressReporter convierte el avance de las operaciones en
+// notifications/progress con el token del cliente. La especificación exige
+// que progress crezca en cada aviso, así que se descartan los que no avanzan
+// (por ejemplo, cuando una herramienta reintenta la operación tras reparar el
+// archivo) y se limitan a uno cada progressInterval.
+func (s *MCPServer) progressReporter(token interface{}) pdf.ProgressFunc {
+	var mu sync.Mutex
+	last := -1
+	var lastSent time.Time
+	return func(done, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if done <= last || (done < total && time.Since(lastSent) < progressInterval) {
+			return
+		}
+		last, lastSent = done, time.Now()
+		s.sendNotification("notifications/progress", ProgressNotification{
+			ProgressToken: token,
+			Progress:      done,
+			Total:         total,
+		})
+	}
+}
+
+// sendNotification envía una notificación al cliente si hay transporte.
+func (s *MCPServer) sendNotification(method string, params interface{}) {
+	if s.notify == nil {
+		return
+	}
+	s.notify(&Notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// beginRequest registra una solicitud en curso para que notifications/cancelled
+// pueda cancelarla por su ID. end la da de baja y libera el contexto; hay que
+// llamarla siempre. Un ID que ya está en curso se rechaza.
+func (s *MCPServer) beginRequest(ctx context.Context, id RequestID) (_ context.Context, end func(), err error) {
+	key := requestKey(id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, dup := s.inFlight[key]; dup {
+		return nil, nil, fmt.Errorf("request id %s is already in flight", key)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.inFlight[key] = cancel
+	return ctx, func() {
+		s.mu.Lock()
+		delete(s.inFlight, key)
+		s.mu.Unlock()
+		cancel()
+	}, nil
+}
+
+// handleCancelled cancela la tools/call o resources/read indicada en
+// notifications/cancelled.
+// Las solicitudes desconocidas o ya terminadas se ignoran.
+func (s *MCPServer) handleCancelled(req *Request) {
+	var params CancelledNotification
+	if err := UnmarshalParams(req.Params, &params); err != nil || params.RequestID == nil {
+		s.logger.Warn("invalid notifications/cancelled params",
+			slog.Any("error", err))
+		return
+	}
+
+	s.mu.Lock()
+	cancel, ok := s.inFlight[requestKey(params.RequestID)]
+	s.mu.Unlock()
+	if !ok {
+		s.logger.Debug("cancellation for unknown request",
+			slog.Any("id", params.RequestID))
+		return
+	}
+	s.logger.Info("cancelling request",
+		slog.Any("id", params.RequestID),
+		slog.String("reason", params.Reason))
+	cancel()
+}
+
+// requestKey normaliza un ID JSON-RPC para usarlo como clave: 1 y "1" son
+// solicitudes distintas.
+func requestKey(id RequestID) string {
+	b, _ := json.Marshal(id)
+	return string(b)
 }
 
 // handlePing procesa la solicitud ping.
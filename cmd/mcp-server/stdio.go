@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
+)
+
+// concurrentMethods son los métodos que pueden tardar (procesan o leen
+// archivos): van en segundo plano, ocupan plaza y se pueden cancelar.
+var concurrentMethods = map[string]bool{
+	"tools/call":     true,
+	"resources/read": true,
+}
+
+// stdioServer lee solicitudes JSON-RPC línea a línea y las despacha en
+// paralelo. Cada tools/call o resources/read ocupa una de maxInFlight plazas y
+// espera turno sin bloquear la lectura; el resto de métodos (ping, tools/list,
+// notificaciones) se atienden en el acto, de modo que una operación larga no
+// los retrasa.
+// Las respuestas se escriben de una en una y pueden salir en distinto orden
+// que las solicitudes: el cliente las empareja por ID.
+type stdioServer struct {
+	server      *MCPServer
+	logger      logging.Logger
+	maxInFlight int
+	bufferSize  int
+
+	writeMu sync.Mutex
+	out     io.Writer
+}
+
+// newStdioServer crea el despachador y conecta las notificaciones de server
+// (progreso) a out. maxInFlight menor que 1 se trata como 1.
+func newStdioServer(server *MCPServer, logger logging.Logger, out io.Writer, maxInFlight, bufferSize int) *stdioServer {
+	s := &stdioServer{
+		server:      server,
+		logger:      logger,
+		maxInFlight: max(maxInFlight, 1),
+		bufferSize:  bufferSize,
+		out:         out,
+	}
+	server.notify = s.notify
+	return s
+}
+
+// Serve atiende las solicitudes de in hasta EOF o hasta que ctx se cancela, y
+// espera a las solicitudes en curso antes de volver (si ctx se ha cancelado,
+// ya están canceladas y solo limpian sus temporales). Devuelve el error de
+// lectura, si lo hay.
+func (s *stdioServer) Serve(ctx context.Context, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), s.bufferSize)
+
+	// La lectura va en su propia goroutine para poder atender ctx mientras
+	// se espera entrada.
+	lines := make(chan string)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	slots := make(chan struct{}, s.maxInFlight)
+	var inFlight sync.WaitGroup
+	defer inFlight.Wait()
+
+	for {
+		var line string
+		select {
+		case <-ctx.Done():
+			s.logger.Info("shutting down, cancelling in-flight requests")
+			return nil
+		case l, ok := <-lines:
+			if !ok {
+				inFlight.Wait()
+				if err := scanner.Err(); err != nil && err != io.EOF {
+					return err
+				}
+				return nil
+			}
+			line = strings.TrimSpace(l)
+		}
+		if line == "" {
+			continue
+		}
+
+		// Parsear solicitud
+		var req Request
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			s.logger.Error("failed to parse JSON", err)
+			continue
+		}
+
+		s.logger.Debug("received request",
+			slog.String("method", req.Method),
+			slog.Any("id", req.ID))
+
+		// Validar ID requerido
+		if req.ID == nil && RequiresID(req.Method) {
+			s.logger.Warn("request missing required id",
+				slog.String("method", req.Method))
+			continue
+		}
+
+		if !concurrentMethods[req.Method] {
+			s.write(s.server.HandleRequest(ctx, &req))
+			continue
+		}
+
+		// El registro se hace antes de esperar plaza para que la solicitud se
+		// pueda cancelar también mientras espera.
+		reqCtx, end, err := s.server.beginRequest(ctx, req.ID)
+		if err != nil {
+			s.logger.Warn("rejected request",
+				slog.String("method", req.Method),
+				slog.Any("error", err))
+			s.write(NewErrorResponse(req.ID, InvalidRequest, err.Error()))
+			continue
+		}
+		inFlight.Add(1)
+		go func() {
+			defer inFlight.Done()
+			defer end()
+			select {
+			case slots <- struct{}{}:
+			case <-reqCtx.Done():
+				s.logger.Info("request cancelled before it started",
+					slog.String("method", req.Method),
+					slog.Any("id", req.ID))
+				return
+			}
+			defer func() { <-slots }()
+			s.write(s.server.HandleRequest(reqCtx, &req))
+		}()
+	}
+}
+
+// write envía resp, si la hay.
+func (s *stdioServer) write(resp *Response) {
+	if resp == nil {
+		return
+	}
+	s.send(resp)
+}
+
+// notify envía una notificación del servidor al cliente.
+func (s *stdioServer) notify(n *Notification) {
+	s.send(n)
+}
+
+// send serializa msg en una sola línea. Las escrituras no se intercalan.
+func (s *stdioServer) send(msg interface{}) {
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		s.logger.Error("failed to marshal message", err)
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if _, err := fmt.Fprintln(s.out, string(msgBytes)); err != nil {
+		s.logger.Error("failed to write message", err)
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/config"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/pdf"
+)
+
+// testTool es una herramienta de prueba cuyo comportamiento decide run.
+type testTool struct {
+	name string
+	run  func(ctx context.Context) string
+}
+
+func (t *testTool) GetDefinition() Tool {
+	return Tool{Name: t.name, InputSchema: map[string]interface{}{"type": "object"}}
+}
+
+func (t *testTool) Handle(ctx context.Context, id RequestID, _ json.RawMessage) *Response {
+	return NewToolResult(id, t.run(ctx))
+}
+
+// lineWriter envía cada respuesta escrita a un canal.
+type lineWriter chan string
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	w <- strings.TrimSpace(string(p))
+	return len(p), nil
+}
+
+func newTestServer(t *testing.T, tools ...ToolHandler) *MCPServer {
+	t.Helper()
+	cfg := config.PDFConfig{ValidationMode: "relaxed", TempDir: t.TempDir()}
+	logger := logging.New("error")
+	server := NewMCPServer(pdf.NewProcessor(cfg, logger), NewResourceRegistry(0, "", logger), newTestPrompts(t, ""), logger)
+	for _, tool := range tools {
+		server.tools.registerTool(tool)
+	}
+	return server
+}
+
+// writeBlankPDF genera un PDF válido con pages páginas en blanco.
+func writeBlankPDF(t *testing.T, pages int) string {
+	t.Helper()
+	kids := make([]string, pages)
+	objs := []string{"<< /Type /Catalog /Pages 2 0 R >>", ""}
+	for i := range kids {
+		kids[i] = fmt.Sprintf("%d 0 R", i+3)
+		objs = append(objs, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>")
+	}
+	objs[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pages)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+
+	path := filepath.Join(t.TempDir(), "blank.pdf")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func toolCall(id interface{}, name string, args interface{}) string {
+	b, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": name, "arguments": args},
+	})
+	return string(b)
+}
+
+func cancelNotification(id interface{}) string {
+	b, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/cancelled",
+		"params":  map[string]interface{}{"requestId": id, "reason": "test"},
+	})
+	return string(b)
+}
+
+type testResponse struct {
+	ID     interface{} `json:"id"`
+	Result *ToolResult `json:"result"`
+	Error  *RpcError   `json:"error"`
+}
+
+func TestStdioServerParallelToolCalls(t *testing.T) {
+	const limit = 3
+	var running, peak atomic.Int32
+	slow := &testTool{name: "slow", run: func(ctx context.Context) string {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return "done"
+	}}
+	server := newTestServer(t, slow)
+	input := writeBlankPDF(t, 3)
+
+	var in strings.Builder
+	want := map[string]bool{}
+	for i := 1; i <= 40; i++ {
+		var id interface{} = i
+		if i%2 == 0 {
+			id = fmt.Sprintf("req-%d", i)
+		}
+		if i%4 == 0 {
+			in.WriteString(toolCall(id, "slow", map[string]interface{}{}) + "\n")
+		} else {
+			in.WriteString(toolCall(id, "pdf_info", map[string]interface{}{"pdf_path": input}) + "\n")
+		}
+		want[fmt.Sprint(id)] = true
+	}
+	in.WriteString(`{"jsonrpc":"2.0","id":"ping","method":"ping"}` + "\n")
+	want["ping"] = true
+
+	var out bytes.Buffer
+	stdio := newStdioServer(server, server.logger, &out, limit, 1<<20)
+	if err := stdio.Serve(context.Background(), strings.NewReader(in.String())); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d responses, want %d", len(lines), len(want))
+	}
+	for _, line := range lines {
+		var resp testResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("response is not a single JSON line: %q", line)
+		}
+		key := fmt.Sprint(resp.ID)
+		if !want[key] {
+			t.Fatalf("unexpected or duplicate response id %v", resp.ID)
+		}
+		delete(want, key)
+		if resp.Error != nil || (resp.Result != nil && resp.Result.IsError) {
+			t.Errorf("request %v failed: %s", resp.ID, line)
+		}
+		if key != "ping" && !strings.Contains(line, `total_pages\":3`) && !strings.Contains(line, "done") {
+			t.Errorf("request %v: unexpected result %s", resp.ID, line)
+		}
+	}
+	if p := peak.Load(); p > limit {
+		t.Errorf("%d tool calls ran at once, limit is %d", p, limit)
+	}
+}
+
+func TestStdioServerCancellation(t *testing.T) {
+	started := make(chan struct{}, 4)
+	var cancelled atomic.Int32
+	block := &testTool{name: "block", run: func(ctx context.Context) string {
+		started <- struct{}{}
+		<-ctx.Done()
+		cancelled.Add(1)
+		return "cancelled"
+	}}
+	server := newTestServer(t, block)
+
+	inR, inW := io.Pipe()
+	out := make(lineWriter, 16)
+	stdio := newStdioServer(server, server.logger, out, 1, 1<<20)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := stdio.Serve(context.Background(), inR); err != nil {
+			t.Errorf("Serve failed: %v", err)
+		}
+	}()
+
+	send := func(line string) {
+		t.Helper()
+		if _, err := io.WriteString(inW, line+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	next := func() testResponse {
+		t.Helper()
+		select {
+		case line := <-out:
+			var resp testResponse
+			if err := json.Unmarshal([]byte(line), &resp); err != nil {
+				t.Fatalf("invalid response %q", line)
+			}
+			return resp
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a response")
+		}
+		return testResponse{}
+	}
+
+	// La primera llamada ocupa la única plaza; la segunda espera turno.
+	send(toolCall(1, "block", map[string]interface{}{}))
+	<-started
+	send(toolCall(2, "block", map[string]interface{}{}))
+
+	// ping no espera a las herramientas en curso.
+	send(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	if resp := next(); fmt.Sprint(resp.ID) != "3" {
+		t.Fatalf("expected ping response, got id %v", resp.ID)
+	}
+
+	// Un ID en curso no se puede reutilizar.
+	send(toolCall(1, "block", map[string]interface{}{}))
+	if resp := next(); fmt.Sprint(resp.ID) != "1" || resp.Error == nil {
+		t.Fatalf("expected duplicate id error, got %+v", resp)
+	}
+
+	// Cancelar la solicitud en espera y después la que está en curso.
+	send(cancelNotification(2))
+	send(cancelNotification(1))
+	send(`{"jsonrpc":"2.0","id":4,"method":"ping"}`)
+	if resp := next(); fmt.Sprint(resp.ID) != "4" {
+		t.Fatalf("cancelled request got a response: id %v", resp.ID)
+	}
+
+	inW.Close()
+	wg.Wait()
+	select {
+	case line := <-out:
+		t.Fatalf("unexpected response after cancellation: %s", line)
+	default:
+	}
+	if n := cancelled.Load(); n != 1 {
+		t.Errorf("tool observed %d cancellations, want 1 (the queued call must not start)", n)
+	}
+}
+
+func TestStdioServerProgress(t *testing.T) {
+	server := newTestServer(t)
+	input := writeBlankPDF(t, 5)
+
+	call := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      7,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "pdf_split",
+			"arguments": map[string]interface{}{"pdf_path": input, "output_dir": t.TempDir()},
+			"_meta":     map[string]interface{}{"progressToken": "split-1"},
+		},
+	}
+	line, _ := json.Marshal(call)
+	plain := toolCall(8, "pdf_split", map[string]interface{}{"pdf_path": input, "output_dir": t.TempDir()})
+
+	var out bytes.Buffer
+	stdio := newStdioServer(server, server.logger, &out, 1, 1<<20)
+	if err := stdio.Serve(context.Background(), strings.NewReader(string(line)+"\n"+plain+"\n")); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	var progress []ProgressNotification
+	answered := map[string]bool{}
+	for _, l := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var msg struct {
+			ID     interface{}     `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal([]byte(l), &msg); err != nil {
+			t.Fatalf("invalid line %q", l)
+		}
+		if msg.Method == "" {
+			answered[fmt.Sprint(msg.ID)] = true
+			continue
+		}
+		if msg.Method != "notifications/progress" {
+			t.Fatalf("unexpected notification %s", l)
+		}
+		if answered["7"] {
+			t.Fatalf("progress sent after the response: %s", l)
+		}
+		var p ProgressNotification
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			t.Fatal(err)
+		}
+		if p.ProgressToken != "split-1" {
+			t.Fatalf("progress token = %v", p.ProgressToken)
+		}
+		if len(progress) > 0 && p.Progress <= progress[len(progress)-1].Progress {
+			t.Fatalf("progress did not increase: %v then %v", progress[len(progress)-1], p)
+		}
+		progress = append(progress, p)
+	}
+
+	if !answered["7"] || !answered["8"] {
+		t.Fatalf("missing responses: %v", answered)
+	}
+	if len(progress) == 0 {
+		t.Fatal("no progress notifications for a call with a progress token")
+	}
+	if last := progress[len(progress)-1]; last.Progress != 5 || last.Total != 5 {
+		t.Errorf("last progress = %+v, want 5/5", last)
+	}
+}
+
+func TestStdioServerResourceReadInBackground(t *testing.T) {
+	if _, err := exec.LookPath("mkfifo"); err != nil {
+		t.Skip("mkfifo not available")
+	}
+	logger := logging.New("error")
+	resources := NewResourceRegistry(time.Hour, "", logger)
+	defer resources.Close()
+	server := NewMCPServer(pdf.NewProcessor(config.PDFConfig{ValidationMode: "relaxed"}, logger), resources, newTestPrompts(t, ""), logger)
+
+	// El recurso se registra como archivo normal y se cambia por una FIFO:
+	// la lectura queda bloqueada hasta que el test escribe los datos.
+	path := filepath.Join(t.TempDir(), "large.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	res := resources.RegisterOutputs([]string{path})
+	if len(res) != 1 {
+		t.Fatalf("registered %+v", res)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := exec.Command("mkfifo", path).Run(); err != nil {
+		t.Skipf("mkfifo failed: %v", err)
+	}
+
+	inR, inW := io.Pipe()
+	out := make(lineWriter, 4)
+	stdio := newStdioServer(server, logger, out, 2, 1<<20)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := stdio.Serve(context.Background(), inR); err != nil {
+			t.Errorf("Serve failed: %v", err)
+		}
+	}()
+	send := func(line string) {
+		t.Helper()
+		if _, err := io.WriteString(inW, line+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	next := func() string {
+		t.Helper()
+		select {
+		case line := <-out:
+			return line
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a response")
+		}
+		return ""
+	}
+
+	send(fmt.Sprintf(`{"jsonrpc":"2.0","id":"read","method":"resources/read","params":{"uri":%q}}`, res[0].URI))
+	send(`{"jsonrpc":"2.0","id":"ping","method":"ping"}`)
+	if line := next(); !strings.Contains(line, `"id":"ping"`) {
+		t.Fatalf("expected the ping response while the read is blocked, got %.200s", line)
+	}
+
+	data := bytes.Repeat([]byte("x"), 8<<20)
+	fifo, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fifo.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	fifo.Close()
+
+	var resp struct {
+		ID     string               `json:"id"`
+		Result ReadResourceResponse `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(next()), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "read" || len(resp.Result.Contents) != 1 || resp.Result.Contents[0].Blob != base64.StdEncoding.EncodeToString(data) {
+		t.Errorf("unexpected resources/read response for id %q", resp.ID)
+	}
+
+	inW.Close()
+	wg.Wait()
+}
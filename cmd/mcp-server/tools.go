@@ -2,6 +2,7 @@ package main
 
 import (
 	"archive/zip"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -9,6 +10,8 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
@@ -24,7 +27,7 @@ type ToolsRegistry struct {
 
 // ToolHandler es la interfaz para un manejador de herramienta.
 type ToolHandler interface {
-	Handle(id RequestID, rawArgs json.RawMessage) *Response
+	Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response
 	GetDefinition() Tool
 }
 
@@ -41,6 +44,25 @@ func NewToolsRegistry(processor *pdf.Processor, logger logging.Logger) *ToolsReg
 	registry.registerTool(&PDFCompressHandler{processor: processor, logger: logger})
 	registry.registerTool(&PDFRemovePagesHandler{processor: processor, logger: logger})
 	registry.registerTool(&PDFMergeHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFCollateHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFRedactHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFScanPIIHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFSearchHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFValidateHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFRepairHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFLinearizeHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFSizeReportHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFACheckHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFAConvertHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFVerifySignaturesHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFSignHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFSecurityScanHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFSanitizeHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFDiffHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFPageLabelsGetHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFPageLabelsSetHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFPipelineHandler{processor: processor, logger: logger})
+	registry.registerTool(&PDFBatchHandler{processor: processor, logger: logger})
 
 	return registry
 }
@@ -50,7 +72,8 @@ func (r *ToolsRegistry) registerTool(handler ToolHandler) {
 	r.tools[def.Name] = &handler
 }
 
-// GetToolDefinitions retorna la lista de herramientas disponibles.
+// GetToolDefinitions retorna la lista de herramientas disponibles ordenada por
+// nombre, para que tools/list no cambie de una llamada a otra.
 func (r *ToolsRegistry) GetToolDefinitions() []Tool {
 	var tools []Tool
 	for _, handler := range r.tools {
@@ -58,11 +81,54 @@ func (r *ToolsRegistry) GetToolDefinitions() []Tool {
 			tools = append(tools, (*handler).GetDefinition())
 		}
 	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
 	return tools
 }
 
+// ListTools devuelve hasta pageSize herramientas (todas si pageSize <= 0)
+// a partir de cursor, y el cursor de la página siguiente, vacío en la última.
+// El cursor codifica el nombre de la última herramienta enviada; uno que no
+// corresponde a ninguna herramienta es un error.
+func (r *ToolsRegistry) ListTools(cursor string, pageSize int) ([]Tool, string, error) {
+	tools := r.GetToolDefinitions()
+	if cursor != "" {
+		name, err := base64.RawURLEncoding.DecodeString(cursor)
+		if _, ok := r.tools[string(name)]; err != nil || !ok {
+			return nil, "", fmt.Errorf("invalid cursor: %q", cursor)
+		}
+		start := sort.Search(len(tools), func(i int) bool { return tools[i].Name > string(name) })
+		tools = tools[start:]
+	}
+	if pageSize <= 0 || len(tools) <= pageSize {
+		return tools, "", nil
+	}
+	tools = tools[:pageSize]
+	return tools, base64.RawURLEncoding.EncodeToString([]byte(tools[pageSize-1].Name)), nil
+}
+
+// readOnlyAnnotations son las anotaciones de las herramientas que solo leen
+// sus entradas. Ninguna herramienta accede a la red, así que openWorldHint es
+// siempre false.
+func readOnlyAnnotations() *ToolAnnotations {
+	return &ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true}
+}
+
+// writeAnnotations son las de las herramientas que escriben archivos y
+// sobrescriben output_path (o los archivos de output_dir) si ya existen.
+// idempotent indica si repetir la llamada con los mismos argumentos deja el
+// mismo resultado.
+func writeAnnotations(idempotent bool) *ToolAnnotations {
+	return &ToolAnnotations{DestructiveHint: true, IdempotentHint: idempotent}
+}
+
+// hasOutputSchema indica si la herramienta declara outputSchema.
+func (r *ToolsRegistry) hasOutputSchema(name string) bool {
+	handler, ok := r.tools[name]
+	return ok && handler != nil && (*handler).GetDefinition().OutputSchema != nil
+}
+
 // CallTool llama una herramienta por nombre.
-func (r *ToolsRegistry) CallTool(id RequestID, name string, rawArgs json.RawMessage) *Response {
+func (r *ToolsRegistry) CallTool(ctx context.Context, id RequestID, name string, rawArgs json.RawMessage) *Response {
 	handler, ok := r.tools[name]
 	if !ok {
 		return NewToolErrorResult(id, fmt.Sprintf("unknown tool: %s", name))
@@ -70,7 +136,7 @@ func (r *ToolsRegistry) CallTool(id RequestID, name string, rawArgs json.RawMess
 	if handler == nil {
 		return NewToolErrorResult(id, fmt.Sprintf("tool handler not initialized: %s", name))
 	}
-	return (*handler).Handle(id, rawArgs)
+	return (*handler).Handle(ctx, id, rawArgs)
 }
 
 // PDFSplitHandler maneja pdf_split
@@ -80,33 +146,38 @@ type PDFSplitHandler struct {
 }
 
 type pdfsplitArgs struct {
-	PDFPath   string `json:"pdf_path"`
-	OutputDir string `json:"output_dir,omitempty"`
-	Zip       bool   `json:"zip,omitempty"`
-	ZipName   string `json:"zip_name,omitempty"`
-	ZipB64    bool   `json:"zip_b64,omitempty"`
+	PDFPath    string `json:"pdf_path"`
+	OutputDir  string `json:"output_dir,omitempty"`
+	Zip        bool   `json:"zip,omitempty"`
+	ZipName    string `json:"zip_name,omitempty"`
+	ZipB64     bool   `json:"zip_b64,omitempty"`
+	AutoRepair bool   `json:"auto_repair,omitempty"`
 }
 
 func (h *PDFSplitHandler) GetDefinition() Tool {
 	return Tool{
 		Name:        "pdf_split",
+		Title:       "Split PDF",
 		Description: "Split a PDF into single-page PDFs and optionally create a ZIP archive",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
-				"pdf_path":   map[string]interface{}{"type": "string", "description": "Absolute path to input PDF"},
-				"output_dir": map[string]interface{}{"type": "string", "description": "Optional directory to move page PDFs"},
-				"zip":        map[string]interface{}{"type": "boolean", "description": "Create ZIP archive with parts (default false)"},
-				"zip_name":   map[string]interface{}{"type": "string", "description": "Optional ZIP filename"},
-				"zip_b64":    map[string]interface{}{"type": "boolean", "description": "Return ZIP content as base64 in response"},
+				"pdf_path":    map[string]interface{}{"type": "string", "description": "Absolute path to input PDF"},
+				"output_dir":  map[string]interface{}{"type": "string", "description": "Optional directory to move page PDFs"},
+				"zip":         map[string]interface{}{"type": "boolean", "description": "Create ZIP archive with parts (default false)"},
+				"zip_name":    map[string]interface{}{"type": "string", "description": "Optional ZIP filename"},
+				"zip_b64":     map[string]interface{}{"type": "boolean", "description": "Return ZIP content as base64 in response. Prefer the resource_link in the result (read it with resources/read): large archives can exceed the client message limit"},
+				"auto_repair": autoRepairSchema,
 			},
 			"required":             []string{"pdf_path"},
 			"additionalProperties": false,
 		},
+		OutputSchema: outputSchema(types.SplitResult{}),
+		Annotations:  writeAnnotations(false),
 	}
 }
 
-func (h *PDFSplitHandler) Handle(id RequestID, rawArgs json.RawMessage) *Response {
+func (h *PDFSplitHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
 	var args pdfsplitArgs
 	if err := UnmarshalParams(rawArgs, &args); err != nil {
 		h.logger.Error("failed to unmarshal pdf_split args", err)
@@ -122,7 +193,9 @@ func (h *PDFSplitHandler) Handle(id RequestID, rawArgs json.RawMessage) *Respons
 		slog.Bool("zip", args.Zip))
 
 	// Split PDF
-	parts, err := h.processor.Split(args.PDFPath)
+	parts, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.PDFPath}, func(inputs []string) ([]string, error) {
+		return h.processor.Split(ctx, inputs[0])
+	})
 	if err != nil {
 		h.logger.Error("pdf_split failed", err)
 		return NewToolErrorResult(id, err.Error())
@@ -147,7 +220,11 @@ func (h *PDFSplitHandler) Handle(id RequestID, rawArgs json.RawMessage) *Respons
 		}
 	}
 
-	result := map[string]interface{}{"files": parts}
+	result := types.SplitResult{
+		Files:      parts,
+		TotalPages: len(parts),
+		OutputDir:  filepath.Dir(parts[0]),
+	}
 
 	// Opcionalmente crear ZIP
 	if args.Zip {
@@ -164,7 +241,7 @@ func (h *PDFSplitHandler) Handle(id RequestID, rawArgs json.RawMessage) *Respons
 			return NewToolErrorResult(id, fmt.Sprintf("failed to create ZIP: %v", err))
 		}
 
-		result["zip"] = zipPath
+		result.ZipPath = zipPath
 
 		// Opcionalmente codificar como base64
 		if args.ZipB64 {
@@ -173,7 +250,7 @@ func (h *PDFSplitHandler) Handle(id RequestID, rawArgs json.RawMessage) *Respons
 				h.logger.Error("failed to read zip file", err)
 				return NewToolErrorResult(id, fmt.Sprintf("failed to read ZIP: %v", err))
 			}
-			result["zip_b64"] = base64.StdEncoding.EncodeToString(data)
+			result.ZipB64 = base64.StdEncoding.EncodeToString(data)
 		}
 	}
 
@@ -221,25 +298,30 @@ type PDFInfoHandler struct {
 }
 
 type pdfInfoArgs struct {
-	PDFPath string `json:"pdf_path"`
+	PDFPath    string `json:"pdf_path"`
+	AutoRepair bool   `json:"auto_repair,omitempty"`
 }
 
 func (h *PDFInfoHandler) GetDefinition() Tool {
 	return Tool{
 		Name:        "pdf_info",
+		Title:       "PDF Info",
 		Description: "Return basic PDF information (page count, file size)",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
-				"pdf_path": map[string]interface{}{"type": "string", "description": "Path to the PDF file"},
+				"pdf_path":    map[string]interface{}{"type": "string", "description": "Path to the PDF file"},
+				"auto_repair": autoRepairSchema,
 			},
 			"required":             []string{"pdf_path"},
 			"additionalProperties": false,
 		},
+		OutputSchema: outputSchema(types.PDFInfoResult{}),
+		Annotations:  readOnlyAnnotations(),
 	}
 }
 
-func (h *PDFInfoHandler) Handle(id RequestID, rawArgs json.RawMessage) *Response {
+func (h *PDFInfoHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
 	var args pdfInfoArgs
 	if err := UnmarshalParams(rawArgs, &args); err != nil {
 		h.logger.Error("failed to unmarshal pdf_info args", err)
@@ -253,7 +335,9 @@ func (h *PDFInfoHandler) Handle(id RequestID, rawArgs json.RawMessage) *Response
 	h.logger.Debug("executing pdf_info",
 		slog.String("pdf_path", args.PDFPath))
 
-	info, err := h.processor.GetInfo(args.PDFPath)
+	info, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.PDFPath}, func(inputs []string) (*types.PDFInfoResult, error) {
+		return h.processor.GetInfo(ctx, inputs[0])
+	})
 	if err != nil {
 		h.logger.Error("pdf_info failed", err)
 		return NewToolErrorResult(id, err.Error())
@@ -270,27 +354,46 @@ type PDFCompressHandler struct {
 }
 
 type pdfCompressArgs struct {
-	PDFPath    string `json:"pdf_path"`
-	OutputPath string `json:"output_path"`
+	PDFPath      string `json:"pdf_path"`
+	OutputPath   string `json:"output_path"`
+	Profile      string `json:"profile,omitempty"`
+	ImageQuality int    `json:"image_quality,omitempty"`
+	MaxDPI       int    `json:"max_dpi,omitempty"`
+	Grayscale    bool   `json:"grayscale,omitempty"`
+	Linearize    bool   `json:"linearize,omitempty"`
+	AutoRepair   bool   `json:"auto_repair,omitempty"`
 }
 
 func (h *PDFCompressHandler) GetDefinition() Tool {
 	return Tool{
 		Name:        "pdf_compress",
-		Description: "Compress a PDF by optimizing images, removing metadata, and cleaning structure (30-70% reduction)",
+		Title:       "Compress PDF",
+		Description: "Compress a PDF with a profile: lossless (structure, unused fonts, duplicate images) or lossy JPEG re-encoding and image downsampling (print, ebook, screen, custom). The result breaks savings down by category",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
 				"pdf_path":    map[string]interface{}{"type": "string", "description": "Absolute path to input PDF"},
 				"output_path": map[string]interface{}{"type": "string", "description": "Absolute path where compressed PDF will be saved"},
+				"profile": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"lossless", "print", "ebook", "screen", "custom"},
+					"description": "Compression profile (default: lossless). print: JPEG 85 at 300 DPI, ebook: JPEG 75 at 150 DPI, screen: JPEG 50 at 72 DPI, custom: PDF_IMAGE_QUALITY without downsampling",
+				},
+				"image_quality": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 100, "description": "JPEG quality, overrides the profile (not allowed with lossless)"},
+				"max_dpi":       map[string]interface{}{"type": "integer", "minimum": 1, "description": "Downsample images drawn above this resolution, overrides the profile (not allowed with lossless)"},
+				"grayscale":     map[string]interface{}{"type": "boolean", "description": "Convert color images to grayscale (not allowed with lossless)"},
+				"linearize":     map[string]interface{}{"type": "boolean", "description": "Linearize the output for fast web view (default: false)"},
+				"auto_repair":   autoRepairSchema,
 			},
 			"required":             []string{"pdf_path", "output_path"},
 			"additionalProperties": false,
 		},
+		OutputSchema: outputSchema(types.CompressResult{}),
+		Annotations:  writeAnnotations(true),
 	}
 }
 
-func (h *PDFCompressHandler) Handle(id RequestID, rawArgs json.RawMessage) *Response {
+func (h *PDFCompressHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
 	var args pdfCompressArgs
 	if err := UnmarshalParams(rawArgs, &args); err != nil {
 		h.logger.Error("failed to unmarshal pdf_compress args", err)
@@ -304,11 +407,28 @@ func (h *PDFCompressHandler) Handle(id RequestID, rawArgs json.RawMessage) *Resp
 		return NewToolErrorResult(id, "missing or invalid output_path")
 	}
 
+	opts := types.CompressOptions{
+		ImageQuality: args.ImageQuality,
+		MaxDPI:       args.MaxDPI,
+		Grayscale:    args.Grayscale,
+		Linearize:    args.Linearize,
+	}
+	if args.Profile != "" {
+		profile, ok := types.ParseCompressProfile(args.Profile)
+		if !ok {
+			return NewToolErrorResult(id, "invalid profile: must be lossless, print, ebook, screen or custom")
+		}
+		opts.Profile = profile
+	}
+
 	h.logger.Debug("executing pdf_compress",
 		slog.String("pdf_path", args.PDFPath),
-		slog.String("output_path", args.OutputPath))
+		slog.String("output_path", args.OutputPath),
+		slog.String("profile", args.Profile))
 
-	result, err := h.processor.Compress(args.PDFPath, args.OutputPath)
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.PDFPath}, func(inputs []string) (*types.CompressResult, error) {
+		return h.processor.Compress(ctx, inputs[0], args.OutputPath, opts)
+	})
 	if err != nil {
 		h.logger.Error("pdf_compress failed", err)
 		return NewToolErrorResult(id, err.Error())
@@ -329,27 +449,32 @@ type pdfRemovePagesArgs struct {
 	OutputPath string `json:"output_path"`
 	Pages      string `json:"pages"`
 	Mode       string `json:"mode,omitempty"`
+	AutoRepair bool   `json:"auto_repair,omitempty"`
 }
 
 func (h *PDFRemovePagesHandler) GetDefinition() Tool {
 	return Tool{
 		Name:        "pdf_remove_pages",
+		Title:       "Remove or Keep Pages",
 		Description: "Remove or keep specific pages from a PDF. Modes: 'remove' deletes listed pages, 'keep' keeps only listed pages",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
 				"pdf_path":    map[string]interface{}{"type": "string", "description": "Absolute path to input PDF"},
 				"output_path": map[string]interface{}{"type": "string", "description": "Absolute path where the result will be saved"},
-				"pages":       map[string]interface{}{"type": "string", "description": "Comma-separated pages or ranges: '2', '5-8', '2,5-8,11'"},
+				"pages":       map[string]interface{}{"type": "string", "description": pdf.PageSelectionSyntax},
 				"mode":        map[string]interface{}{"type": "string", "enum": []string{"remove", "keep"}, "description": "Operation mode (default: 'remove')"},
+				"auto_repair": autoRepairSchema,
 			},
 			"required":             []string{"pdf_path", "output_path", "pages"},
 			"additionalProperties": false,
 		},
+		OutputSchema: outputSchema(types.RemovePagesResult{}),
+		Annotations:  writeAnnotations(true),
 	}
 }
 
-func (h *PDFRemovePagesHandler) Handle(id RequestID, rawArgs json.RawMessage) *Response {
+func (h *PDFRemovePagesHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
 	var args pdfRemovePagesArgs
 	if err := UnmarshalParams(rawArgs, &args); err != nil {
 		h.logger.Error("failed to unmarshal pdf_remove_pages args", err)
@@ -365,6 +490,9 @@ func (h *PDFRemovePagesHandler) Handle(id RequestID, rawArgs json.RawMessage) *R
 	if strings.TrimSpace(args.Pages) == "" {
 		return NewToolErrorResult(id, "missing or invalid pages")
 	}
+	if _, err := pdf.ParsePageSelection(args.Pages); err != nil {
+		return NewToolErrorResult(id, err.Error())
+	}
 
 	// Parsear modo
 	modeStr := strings.TrimSpace(args.Mode)
@@ -381,7 +509,9 @@ func (h *PDFRemovePagesHandler) Handle(id RequestID, rawArgs json.RawMessage) *R
 		slog.String("mode", string(mode)),
 		slog.String("pages", args.Pages))
 
-	result, err := h.processor.RemovePages(args.PDFPath, args.OutputPath, args.Pages, mode)
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.PDFPath}, func(inputs []string) (*types.RemovePagesResult, error) {
+		return h.processor.RemovePages(ctx, inputs[0], args.OutputPath, args.Pages, mode)
+	})
 	if err != nil {
 		h.logger.Error("pdf_remove_pages failed", err)
 		return NewToolErrorResult(id, err.Error())
@@ -400,25 +530,32 @@ type PDFMergeHandler struct {
 type pdfMergeArgs struct {
 	InputPaths  []string `json:"input_paths"`
 	OutputPath  string   `json:"output_path"`
+	Linearize   bool     `json:"linearize,omitempty"`
+	AutoRepair  bool     `json:"auto_repair,omitempty"`
 }
 
 func (h *PDFMergeHandler) GetDefinition() Tool {
 	return Tool{
 		Name:        "pdf_merge",
+		Title:       "Merge PDFs",
 		Description: "Merge multiple PDF files into a single output PDF",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
-				"input_paths":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Array of absolute paths to input PDF files"},
-				"output_path":  map[string]interface{}{"type": "string", "description": "Absolute path where the merged PDF will be saved"},
+				"input_paths": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Array of absolute paths to input PDF files"},
+				"output_path": map[string]interface{}{"type": "string", "description": "Absolute path where the merged PDF will be saved"},
+				"linearize":   map[string]interface{}{"type": "boolean", "description": "Linearize the output for fast web view (default: false)"},
+				"auto_repair": autoRepairSchema,
 			},
 			"required":             []string{"input_paths", "output_path"},
 			"additionalProperties": false,
 		},
+		OutputSchema: outputSchema(types.MergeResult{}),
+		Annotations:  writeAnnotations(true),
 	}
 }
 
-func (h *PDFMergeHandler) Handle(id RequestID, rawArgs json.RawMessage) *Response {
+func (h *PDFMergeHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
 	var args pdfMergeArgs
 	if err := UnmarshalParams(rawArgs, &args); err != nil {
 		h.logger.Error("failed to unmarshal pdf_merge args", err)
@@ -436,7 +573,9 @@ func (h *PDFMergeHandler) Handle(id RequestID, rawArgs json.RawMessage) *Respons
 		slog.Int("input_count", len(args.InputPaths)),
 		slog.String("output", args.OutputPath))
 
-	result, err := h.processor.Merge(args.InputPaths, args.OutputPath)
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, args.InputPaths, func(inputs []string) (*types.MergeResult, error) {
+		return h.processor.Merge(ctx, inputs, args.OutputPath, types.MergeOptions{Linearize: args.Linearize})
+	})
 	if err != nil {
 		h.logger.Error("pdf_merge failed", err)
 		return NewToolErrorResult(id, err.Error())
@@ -445,3 +584,1481 @@ func (h *PDFMergeHandler) Handle(id RequestID, rawArgs json.RawMessage) *Respons
 	resultJSON, _ := json.Marshal(result)
 	return NewToolResult(id, string(resultJSON))
 }
+
+// PDFCollateHandler maneja pdf_collate
+type PDFCollateHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfCollateArgs struct {
+	FrontPath   string `json:"front_path"`
+	BackPath    string `json:"back_path"`
+	OutputPath  string `json:"output_path"`
+	ReverseBack *bool  `json:"reverse_back,omitempty"`
+	AutoRepair  bool   `json:"auto_repair,omitempty"`
+}
+
+func (h *PDFCollateHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_collate",
+		Title:       "Collate Scanned Fronts and Backs",
+		Description: "Interleave two separately scanned PDFs (fronts and backs) into a single document. Reports page count mismatches",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"front_path":   map[string]interface{}{"type": "string", "description": "Absolute path to the PDF with the front sides"},
+				"back_path":    map[string]interface{}{"type": "string", "description": "Absolute path to the PDF with the back sides"},
+				"output_path":  map[string]interface{}{"type": "string", "description": "Absolute path where the collated PDF will be saved"},
+				"reverse_back": map[string]interface{}{"type": "boolean", "description": "Back sides were scanned in reverse order (default: true)"},
+				"auto_repair":  autoRepairSchema,
+			},
+			"required":             []string{"front_path", "back_path", "output_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.CollateResult{}),
+		Annotations:  writeAnnotations(true),
+	}
+}
+
+func (h *PDFCollateHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfCollateArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_collate args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.FrontPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid front_path")
+	}
+	if strings.TrimSpace(args.BackPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid back_path")
+	}
+	if strings.TrimSpace(args.OutputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid output_path")
+	}
+
+	reverseBack := true
+	if args.ReverseBack != nil {
+		reverseBack = *args.ReverseBack
+	}
+
+	h.logger.Debug("executing pdf_collate",
+		slog.String("front_path", args.FrontPath),
+		slog.String("back_path", args.BackPath),
+		slog.Bool("reverse_back", reverseBack))
+
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.FrontPath, args.BackPath}, func(inputs []string) (*types.CollateResult, error) {
+		return h.processor.Collate(ctx, inputs[0], inputs[1], reverseBack, args.OutputPath)
+	})
+	if err != nil {
+		h.logger.Error("pdf_collate failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFRedactHandler maneja pdf_redact
+type PDFRedactHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfRedactArgs struct {
+	InputPath  string                `json:"input_path"`
+	OutputPath string                `json:"output_path"`
+	Areas      []types.PageRect      `json:"areas,omitempty"`
+	Patterns   []types.RedactPattern `json:"patterns,omitempty"`
+	DryRun     bool                  `json:"dry_run,omitempty"`
+	AutoRepair bool                  `json:"auto_repair,omitempty"`
+}
+
+// redactRectSchema describe un rectángulo en puntos PDF asociado a una página.
+var redactRectSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"page": map[string]interface{}{"type": "integer", "minimum": 1, "description": "Page number (1-based)"},
+		"llx":  map[string]interface{}{"type": "number", "description": "Lower-left x in PDF points"},
+		"lly":  map[string]interface{}{"type": "number", "description": "Lower-left y in PDF points"},
+		"urx":  map[string]interface{}{"type": "number", "description": "Upper-right x in PDF points"},
+		"ury":  map[string]interface{}{"type": "number", "description": "Upper-right y in PDF points"},
+	},
+	"required":             []string{"page", "llx", "lly", "urx", "ury"},
+	"additionalProperties": false,
+}
+
+func (h *PDFRedactHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_redact",
+		Title:       "Redact PDF",
+		Description: "Permanently remove text and image content under page rectangles or matching text patterns, draw black boxes over it and clear metadata that contains the removed text. Use dry_run to preview matches",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":  map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file to redact"},
+				"output_path": map[string]interface{}{"type": "string", "description": "Absolute path where the redacted PDF will be saved (not required with dry_run)"},
+				"areas": map[string]interface{}{
+					"type":        "array",
+					"description": "Rectangles to redact, in PDF points with the origin at the bottom-left corner of the page",
+					"items":       redactRectSchema,
+				},
+				"patterns": map[string]interface{}{
+					"type":        "array",
+					"description": "Text to redact on every page",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"pattern":          map[string]interface{}{"type": "string", "description": "Literal text or regular expression"},
+							"regex":            map[string]interface{}{"type": "boolean", "description": "Treat pattern as a Go regular expression (default: false)"},
+							"case_insensitive": map[string]interface{}{"type": "boolean", "description": "Match ignoring case (default: false)"},
+						},
+						"required":             []string{"pattern"},
+						"additionalProperties": false,
+					},
+				},
+				"dry_run":     map[string]interface{}{"type": "boolean", "description": "Report what would be redacted without writing the output (default: false)"},
+				"auto_repair": autoRepairSchema,
+			},
+			"required":             []string{"input_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.RedactResult{}),
+		Annotations:  writeAnnotations(true),
+	}
+}
+
+func (h *PDFRedactHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfRedactArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_redact args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+	if !args.DryRun && strings.TrimSpace(args.OutputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid output_path")
+	}
+	if len(args.Areas) == 0 && len(args.Patterns) == 0 {
+		return NewToolErrorResult(id, "at least one of areas or patterns is required")
+	}
+
+	h.logger.Debug("executing pdf_redact",
+		slog.String("input_path", args.InputPath),
+		slog.Int("areas", len(args.Areas)),
+		slog.Int("patterns", len(args.Patterns)),
+		slog.Bool("dry_run", args.DryRun))
+
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.InputPath}, func(inputs []string) (*types.RedactResult, error) {
+		return h.processor.Redact(ctx, inputs[0], args.OutputPath, types.RedactOptions{
+			Areas:    args.Areas,
+			Patterns: args.Patterns,
+			DryRun:   args.DryRun,
+		})
+	})
+	if err != nil {
+		h.logger.Error("pdf_redact failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFScanPIIHandler maneja pdf_scan_pii
+type PDFScanPIIHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfScanPIIArgs struct {
+	InputPath  string                    `json:"input_path"`
+	Detectors  []string                  `json:"detectors,omitempty"`
+	Custom     []types.PIICustomDetector `json:"custom,omitempty"`
+	AutoRepair bool                      `json:"auto_repair,omitempty"`
+}
+
+func (h *PDFScanPIIHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_scan_pii",
+		Title:       "Scan for Personal Data",
+		Description: "Scan the text of a PDF for personal data (emails, phone numbers, IBANs, credit cards, national IDs and custom patterns). Returns findings with page and bounding boxes; the 'areas' field can be passed directly to pdf_redact",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path": map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file to scan"},
+				"detectors": map[string]interface{}{
+					"type":        "array",
+					"description": "Built-in detectors to run (default: all)",
+					"items":       map[string]interface{}{"type": "string", "enum": pdf.PIIDetectorNames()},
+				},
+				"custom": map[string]interface{}{
+					"type":        "array",
+					"description": "Additional detectors defined by a Go regular expression",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"name":    map[string]interface{}{"type": "string", "description": "Finding type reported for matches"},
+							"pattern": map[string]interface{}{"type": "string", "description": "Go regular expression"},
+						},
+						"required":             []string{"name", "pattern"},
+						"additionalProperties": false,
+					},
+				},
+				"auto_repair": autoRepairSchema,
+			},
+			"required":             []string{"input_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.PIIScanResult{}),
+		Annotations:  readOnlyAnnotations(),
+	}
+}
+
+func (h *PDFScanPIIHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfScanPIIArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_scan_pii args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+
+	h.logger.Debug("executing pdf_scan_pii",
+		slog.String("input_path", args.InputPath),
+		slog.Any("detectors", args.Detectors),
+		slog.Int("custom", len(args.Custom)))
+
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.InputPath}, func(inputs []string) (*types.PIIScanResult, error) {
+		return h.processor.ScanPII(ctx, inputs[0], types.PIIScanOptions{
+			Detectors: args.Detectors,
+			Custom:    args.Custom,
+		})
+	})
+	if err != nil {
+		h.logger.Error("pdf_scan_pii failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFSearchHandler maneja pdf_search
+type PDFSearchHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfSearchArgs struct {
+	Query        string   `json:"query"`
+	InputPath    string   `json:"input_path,omitempty"`
+	InputPaths   []string `json:"input_paths,omitempty"`
+	Directory    string   `json:"directory,omitempty"`
+	Recursive    bool     `json:"recursive,omitempty"`
+	Mode         string   `json:"mode,omitempty"`
+	ContextChars int      `json:"context_chars,omitempty"`
+	MaxResults   int      `json:"max_results,omitempty"`
+	AutoRepair   bool     `json:"auto_repair,omitempty"`
+}
+
+func (h *PDFSearchHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_search",
+		Title:       "Search PDFs",
+		Description: "Search text in one or more PDFs (a file, a list of files or a directory). Returns, grouped by file, the page number, a snippet with surrounding context and the bounding boxes of each match",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query":       map[string]interface{}{"type": "string", "description": "Text or regular expression to search for"},
+				"input_path":  map[string]interface{}{"type": "string", "description": "Absolute path to a PDF file"},
+				"input_paths": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Absolute paths to several PDF files"},
+				"directory":   map[string]interface{}{"type": "string", "description": "Absolute path to a directory whose PDF files will be searched"},
+				"recursive":   map[string]interface{}{"type": "boolean", "description": "Also search subdirectories of directory (default: false)"},
+				"mode": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"literal", "case_insensitive", "regex"},
+					"description": "How the query is matched (default: literal)",
+				},
+				"context_chars": map[string]interface{}{"type": "integer", "minimum": 1, "description": "Characters of context on each side of the match (default: 40)"},
+				"max_results":   map[string]interface{}{"type": "integer", "minimum": 1, "description": "Maximum matches returned per file (default: 100)"},
+				"auto_repair":   autoRepairSchema,
+			},
+			"required":             []string{"query"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.MultiSearchResult{}),
+		Annotations:  readOnlyAnnotations(),
+	}
+}
+
+func (h *PDFSearchHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfSearchArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_search args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if args.Query == "" {
+		return NewToolErrorResult(id, "missing or invalid query")
+	}
+
+	var paths []string
+	if strings.TrimSpace(args.InputPath) != "" {
+		paths = append(paths, args.InputPath)
+	}
+	for _, p := range args.InputPaths {
+		if strings.TrimSpace(p) == "" {
+			return NewToolErrorResult(id, "missing or invalid input_paths")
+		}
+		paths = append(paths, p)
+	}
+	if strings.TrimSpace(args.Directory) != "" {
+		files, err := pdf.FindPDFFiles(args.Directory, args.Recursive)
+		if err != nil {
+			return NewToolErrorResult(id, err.Error())
+		}
+		paths = append(paths, files...)
+	}
+	if len(paths) == 0 {
+		return NewToolErrorResult(id, "one of input_path, input_paths or directory is required")
+	}
+
+	h.logger.Debug("executing pdf_search",
+		slog.Int("files", len(paths)),
+		slog.String("mode", args.Mode))
+
+	opts := types.SearchOptions{
+		Mode:         types.SearchMode(args.Mode),
+		ContextChars: args.ContextChars,
+		MaxResults:   args.MaxResults,
+	}
+	result, err := h.processor.SearchFiles(ctx, paths, args.Query, opts)
+	if err != nil {
+		h.logger.Error("pdf_search failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+	if args.AutoRepair {
+		h.retrySearchErrors(ctx, result, args.Query, opts)
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// retrySearchErrors repara una vez los archivos que fallaron y repite la búsqueda en ellos.
+func (h *PDFSearchHandler) retrySearchErrors(ctx context.Context, result *types.MultiSearchResult, query string, opts types.SearchOptions) {
+	var remaining []types.FileError
+	for _, fe := range result.Errors {
+		r, err := withAutoRepair(ctx, h.processor, h.logger, true, []string{fe.Path}, func(inputs []string) (*types.SearchResult, error) {
+			return h.processor.Search(ctx, inputs[0], query, opts)
+		})
+		if err != nil {
+			remaining = append(remaining, fe)
+			continue
+		}
+		if r.TotalHits > 0 {
+			result.FilesWithHits++
+			result.TotalHits += r.TotalHits
+			result.Files = append(result.Files, *r)
+		}
+	}
+	result.Errors = remaining
+}
+
+// PDFValidateHandler maneja pdf_validate
+type PDFValidateHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfValidateArgs struct {
+	InputPath string `json:"input_path"`
+}
+
+func (h *PDFValidateHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_validate",
+		Title:       "Validate PDF",
+		Description: "Validate a PDF in strict and relaxed mode and report each problem with its category and object number, the xref/trailer health, the PDF version, whether only relaxed mode accepts the file (so PDF_VALIDATION_MODE can be chosen per file) and whether it can be repaired",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path": map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file"},
+			},
+			"required":             []string{"input_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.ValidationReport{}),
+		Annotations:  readOnlyAnnotations(),
+	}
+}
+
+func (h *PDFValidateHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfValidateArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_validate args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+
+	h.logger.Debug("executing pdf_validate",
+		slog.String("input_path", args.InputPath))
+
+	report, err := h.processor.Validate(ctx, args.InputPath)
+	if err != nil {
+		h.logger.Error("pdf_validate failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(report)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFRepairHandler maneja pdf_repair
+type PDFRepairHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfRepairArgs struct {
+	InputPath  string `json:"input_path"`
+	OutputPath string `json:"output_path"`
+}
+
+func (h *PDFRepairHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_repair",
+		Title:       "Repair PDF",
+		Description: "Repair a damaged or truncated PDF: rebuild the cross-reference table by scanning for objects, fix wrong stream lengths, drop unreachable or corrupt objects and report every change",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":  map[string]interface{}{"type": "string", "description": "Absolute path to the damaged PDF file"},
+				"output_path": map[string]interface{}{"type": "string", "description": "Absolute path where the repaired PDF will be saved"},
+			},
+			"required":             []string{"input_path", "output_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.RepairResult{}),
+		Annotations:  writeAnnotations(true),
+	}
+}
+
+func (h *PDFRepairHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfRepairArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_repair args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+	if strings.TrimSpace(args.OutputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid output_path")
+	}
+
+	h.logger.Debug("executing pdf_repair",
+		slog.String("input_path", args.InputPath),
+		slog.String("output_path", args.OutputPath))
+
+	result, err := h.processor.Repair(ctx, args.InputPath, args.OutputPath)
+	if err != nil {
+		h.logger.Error("pdf_repair failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFLinearizeHandler maneja pdf_linearize
+type PDFLinearizeHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfLinearizeArgs struct {
+	InputPath  string `json:"input_path"`
+	OutputPath string `json:"output_path"`
+	AutoRepair bool   `json:"auto_repair,omitempty"`
+}
+
+func (h *PDFLinearizeHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_linearize",
+		Title:       "Linearize PDF",
+		Description: "Linearize a PDF (fast web view) so viewers can display the first page, and then each page, before the whole file is downloaded",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":  map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file"},
+				"output_path": map[string]interface{}{"type": "string", "description": "Absolute path where the linearized PDF will be saved"},
+				"auto_repair": autoRepairSchema,
+			},
+			"required":             []string{"input_path", "output_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.LinearizeResult{}),
+		Annotations:  writeAnnotations(true),
+	}
+}
+
+func (h *PDFLinearizeHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfLinearizeArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_linearize args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+	if strings.TrimSpace(args.OutputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid output_path")
+	}
+
+	h.logger.Debug("executing pdf_linearize",
+		slog.String("input_path", args.InputPath),
+		slog.String("output_path", args.OutputPath))
+
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.InputPath}, func(inputs []string) (*types.LinearizeResult, error) {
+		return h.processor.Linearize(ctx, inputs[0], args.OutputPath)
+	})
+	if err != nil {
+		h.logger.Error("pdf_linearize failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFSizeReportHandler maneja pdf_size_report
+type PDFSizeReportHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfSizeReportArgs struct {
+	InputPath     string `json:"input_path"`
+	TopN          int    `json:"top_n,omitempty"`
+	SkipEstimates bool   `json:"skip_estimates,omitempty"`
+	AutoRepair    bool   `json:"auto_repair,omitempty"`
+}
+
+func (h *PDFSizeReportHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_size_report",
+		Title:       "PDF Size Report",
+		Description: "Explain where the bytes of a PDF go: size by category (images, fonts, content streams, metadata, attachments, unused objects), the largest objects with the pages that use them, and the estimated savings of each pdf_compress profile",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":     map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file"},
+				"top_n":          map[string]interface{}{"type": "integer", "minimum": 1, "description": "Number of largest objects to list (default: 10)"},
+				"skip_estimates": map[string]interface{}{"type": "boolean", "description": "Skip the per-profile compression estimates, which compress the file in memory once per profile (default: false)"},
+				"auto_repair":    autoRepairSchema,
+			},
+			"required":             []string{"input_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.SizeReport{}),
+		Annotations:  readOnlyAnnotations(),
+	}
+}
+
+func (h *PDFSizeReportHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfSizeReportArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_size_report args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+	if args.TopN < 0 {
+		return NewToolErrorResult(id, "top_n must be positive")
+	}
+
+	h.logger.Debug("executing pdf_size_report",
+		slog.String("input_path", args.InputPath),
+		slog.Int("top_n", args.TopN),
+		slog.Bool("skip_estimates", args.SkipEstimates))
+
+	opts := types.SizeReportOptions{TopN: args.TopN, SkipEstimates: args.SkipEstimates}
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.InputPath}, func(inputs []string) (*types.SizeReport, error) {
+		return h.processor.AnalyzeSize(ctx, inputs[0], opts)
+	})
+	if err != nil {
+		h.logger.Error("pdf_size_report failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// pdfaLevelSchema es la propiedad level de las herramientas PDF/A.
+var pdfaLevelSchema = map[string]interface{}{
+	"type":        "string",
+	"enum":        []string{"1b", "2b", "2u", "3b", "3u"},
+	"description": "PDF/A part and conformance level (default: 2b)",
+}
+
+// PDFACheckHandler maneja pdf_pdfa_check
+type PDFACheckHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfaCheckArgs struct {
+	InputPath  string `json:"input_path"`
+	Level      string `json:"level,omitempty"`
+	AutoRepair bool   `json:"auto_repair,omitempty"`
+}
+
+func (h *PDFACheckHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_pdfa_check",
+		Title:       "Check PDF/A Conformance",
+		Description: "Check a PDF against a PDF/A level (default 2b) and list the rule violations: missing embedded fonts, transparency, encryption, missing OutputIntent, XMP metadata that does not match the Info dictionary, JavaScript and forbidden actions. Violations marked fixable can be corrected with pdf_pdfa_convert",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":  map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file"},
+				"level":       pdfaLevelSchema,
+				"auto_repair": autoRepairSchema,
+			},
+			"required":             []string{"input_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.PDFAReport{}),
+		Annotations:  readOnlyAnnotations(),
+	}
+}
+
+func (h *PDFACheckHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfaCheckArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_pdfa_check args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+	level, ok := types.ParsePDFALevel(strings.ToLower(args.Level))
+	if !ok {
+		return NewToolErrorResult(id, "missing or invalid level (use 1b, 2b, 2u, 3b or 3u)")
+	}
+
+	h.logger.Debug("executing pdf_pdfa_check",
+		slog.String("input_path", args.InputPath),
+		slog.String("level", string(level)))
+
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.InputPath}, func(inputs []string) (*types.PDFAReport, error) {
+		return h.processor.CheckPDFA(ctx, inputs[0], level)
+	})
+	if err != nil {
+		h.logger.Error("pdf_pdfa_check failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFAConvertHandler maneja pdf_pdfa_convert
+type PDFAConvertHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfaConvertArgs struct {
+	InputPath  string `json:"input_path"`
+	OutputPath string `json:"output_path"`
+	Level      string `json:"level,omitempty"`
+	AutoRepair bool   `json:"auto_repair,omitempty"`
+}
+
+func (h *PDFAConvertHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_pdfa_convert",
+		Title:       "Convert to PDF/A",
+		Description: "Best-effort conversion to PDF/A (default 2b): embeds an sRGB OutputIntent, syncs the XMP metadata with the Info dictionary and removes JavaScript, forbidden actions and other fixable constructs. Refuses, without writing output, when a violation cannot be fixed (for example fonts that are not embedded), and re-checks the output before returning",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":  map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file"},
+				"output_path": map[string]interface{}{"type": "string", "description": "Absolute path where the PDF/A file will be saved"},
+				"level":       pdfaLevelSchema,
+				"auto_repair": autoRepairSchema,
+			},
+			"required":             []string{"input_path", "output_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.PDFAConvertResult{}),
+		Annotations:  writeAnnotations(true),
+	}
+}
+
+func (h *PDFAConvertHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfaConvertArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_pdfa_convert args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+	if strings.TrimSpace(args.OutputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid output_path")
+	}
+	level, ok := types.ParsePDFALevel(strings.ToLower(args.Level))
+	if !ok {
+		return NewToolErrorResult(id, "missing or invalid level (use 1b, 2b, 2u, 3b or 3u)")
+	}
+
+	h.logger.Debug("executing pdf_pdfa_convert",
+		slog.String("input_path", args.InputPath),
+		slog.String("output_path", args.OutputPath),
+		slog.String("level", string(level)))
+
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.InputPath}, func(inputs []string) (*types.PDFAConvertResult, error) {
+		return h.processor.ConvertPDFA(ctx, inputs[0], args.OutputPath, level)
+	})
+	if err != nil {
+		h.logger.Error("pdf_pdfa_convert failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFVerifySignaturesHandler maneja pdf_verify_signatures
+type PDFVerifySignaturesHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfVerifySignaturesArgs struct {
+	InputPath     string `json:"input_path"`
+	TrustStoreDir string `json:"trust_store_dir,omitempty"`
+}
+
+func (h *PDFVerifySignaturesHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_verify_signatures",
+		Title:       "Verify Signatures",
+		Description: "List the digital signatures of a PDF and verify them offline: signer certificate subject, signing time, signature type, byte range coverage, whether the document was modified after signing, and whether the certificate chains to a PEM certificate of the local trust store (trust_store_dir or PDF_TRUST_STORE_DIR)",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":      map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file"},
+				"trust_store_dir": map[string]interface{}{"type": "string", "description": "Directory of trusted PEM certificates (.pem, .crt, .cer). Defaults to PDF_TRUST_STORE_DIR"},
+			},
+			"required":             []string{"input_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.SignatureReport{}),
+		Annotations:  readOnlyAnnotations(),
+	}
+}
+
+func (h *PDFVerifySignaturesHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfVerifySignaturesArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_verify_signatures args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+
+	h.logger.Debug("executing pdf_verify_signatures",
+		slog.String("input_path", args.InputPath),
+		slog.String("trust_store_dir", args.TrustStoreDir))
+
+	// Sin auto_repair: reescribir el archivo invalidaría las firmas.
+	result, err := h.processor.ListSignatures(ctx, args.InputPath, args.TrustStoreDir)
+	if err != nil {
+		h.logger.Error("pdf_verify_signatures failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFSignHandler maneja pdf_sign
+type PDFSignHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+// pdfSignArgs no incluye contraseña: la de la clave se lee de PDF_SIGN_KEY_PASSWORD.
+type pdfSignArgs struct {
+	InputPath   string          `json:"input_path"`
+	OutputPath  string          `json:"output_path"`
+	PKCS12Path  string          `json:"pkcs12_path,omitempty"`
+	CertPath    string          `json:"cert_path,omitempty"`
+	KeyPath     string          `json:"key_path,omitempty"`
+	FieldName   string          `json:"field_name,omitempty"`
+	Reason      string          `json:"reason,omitempty"`
+	Location    string          `json:"location,omitempty"`
+	ContactInfo string          `json:"contact_info,omitempty"`
+	Appearance  *types.PageRect `json:"appearance,omitempty"`
+}
+
+func (h *PDFSignHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_sign",
+		Title:       "Sign PDF",
+		Description: "Digitally sign a PDF (PAdES-B-B, ETSI.CAdES.detached) with a local PKCS#12 file or PEM certificate and key, as an incremental update that keeps existing signatures. The key password is read from PDF_SIGN_KEY_PASSWORD and is never accepted as an argument. Without key paths, PDF_SIGN_PKCS12 or PDF_SIGN_CERT/PDF_SIGN_KEY are used. appearance (page and rectangle in PDF points) makes the signature visible",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":   map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file to sign"},
+				"output_path":  map[string]interface{}{"type": "string", "description": "Absolute path where the signed PDF will be saved"},
+				"pkcs12_path":  map[string]interface{}{"type": "string", "description": "PKCS#12 (.p12/.pfx) file with the key and certificate chain"},
+				"cert_path":    map[string]interface{}{"type": "string", "description": "PEM certificate (optionally followed by the chain); used with key_path"},
+				"key_path":     map[string]interface{}{"type": "string", "description": "PEM private key (PKCS#8, PKCS#1 or EC); used with cert_path"},
+				"field_name":   map[string]interface{}{"type": "string", "description": "Name of the new signature field (default: SignatureN)"},
+				"reason":       map[string]interface{}{"type": "string", "description": "Reason for signing"},
+				"location":     map[string]interface{}{"type": "string", "description": "Location of signing"},
+				"contact_info": map[string]interface{}{"type": "string", "description": "Contact information of the signer"},
+				"appearance":   redactRectSchema,
+			},
+			"required":             []string{"input_path", "output_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.SignResult{}),
+		Annotations:  writeAnnotations(true),
+	}
+}
+
+func (h *PDFSignHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfSignArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_sign args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+	if strings.TrimSpace(args.OutputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid output_path")
+	}
+
+	h.logger.Debug("executing pdf_sign",
+		slog.String("input_path", args.InputPath),
+		slog.String("output_path", args.OutputPath),
+		slog.Bool("visible", args.Appearance != nil))
+
+	result, err := h.processor.Sign(ctx, args.InputPath, args.OutputPath,
+		types.SignKeySource{PKCS12Path: args.PKCS12Path, CertPath: args.CertPath, KeyPath: args.KeyPath},
+		types.SignOptions{
+			FieldName:   args.FieldName,
+			Reason:      args.Reason,
+			Location:    args.Location,
+			ContactInfo: args.ContactInfo,
+			Appearance:  args.Appearance,
+		})
+	if err != nil {
+		h.logger.Error("pdf_sign failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFSecurityScanHandler maneja pdf_security_scan
+type PDFSecurityScanHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfSecurityScanArgs struct {
+	InputPath string `json:"input_path"`
+}
+
+func (h *PDFSecurityScanHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_security_scan",
+		Title:       "Security Scan",
+		Description: "Inspect an untrusted PDF for active or suspicious content: document, page and field JavaScript, Launch/URI/SubmitForm/ImportData/GoToR actions, OpenAction, embedded files and executables, RichMedia and other multimedia annotations, XFA forms, excessive object streams and suspicious filter chains. Returns every finding with its location and a 0-100 risk score. Use pdf_sanitize to remove them",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path": map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file to scan"},
+			},
+			"required":             []string{"input_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.SecurityReport{}),
+		Annotations:  readOnlyAnnotations(),
+	}
+}
+
+func (h *PDFSecurityScanHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfSecurityScanArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_security_scan args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+
+	h.logger.Debug("executing pdf_security_scan", slog.String("input_path", args.InputPath))
+
+	// Sin auto_repair: el escaneo no valida el archivo y recurre a un escaneo de bytes si no se puede leer.
+	result, err := h.processor.SecurityScan(ctx, args.InputPath)
+	if err != nil {
+		h.logger.Error("pdf_security_scan failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFSanitizeHandler maneja pdf_sanitize
+type PDFSanitizeHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfSanitizeArgs struct {
+	InputPath       string `json:"input_path"`
+	OutputPath      string `json:"output_path"`
+	KeepLinks       bool   `json:"keep_links,omitempty"`
+	KeepAttachments bool   `json:"keep_attachments,omitempty"`
+	StripMetadata   bool   `json:"strip_metadata,omitempty"`
+	FlattenForms    bool   `json:"flatten_forms,omitempty"`
+}
+
+func (h *PDFSanitizeHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_sanitize",
+		Title:       "Sanitize PDF",
+		Description: "Write a clean copy of a PDF without active content: removes JavaScript, OpenAction, Launch/SubmitForm/ImportData/GoToR actions, multimedia annotations, XFA forms, embedded files and unreferenced objects, and re-encodes streams with suspicious filter chains. Optionally strips document metadata and flattens form fields into the page. The copy is re-scanned and anything left is reported",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":       map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file to sanitize"},
+				"output_path":      map[string]interface{}{"type": "string", "description": "Absolute path where the clean PDF will be saved"},
+				"keep_links":       map[string]interface{}{"type": "boolean", "description": "Keep URI link actions (default: false)"},
+				"keep_attachments": map[string]interface{}{"type": "boolean", "description": "Keep embedded files that are not executables or scripts (default: false)"},
+				"strip_metadata":   map[string]interface{}{"type": "boolean", "description": "Remove the Info dictionary entries (author, title...), XMP metadata and private application data (default: false)"},
+				"flatten_forms":    map[string]interface{}{"type": "boolean", "description": "Draw form field appearances into the page content and remove the form, so values can no longer be edited (default: false)"},
+			},
+			"required":             []string{"input_path", "output_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.SanitizeResult{}),
+		Annotations:  writeAnnotations(true),
+	}
+}
+
+func (h *PDFSanitizeHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfSanitizeArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_sanitize args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+	if strings.TrimSpace(args.OutputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid output_path")
+	}
+
+	h.logger.Debug("executing pdf_sanitize",
+		slog.String("input_path", args.InputPath),
+		slog.String("output_path", args.OutputPath),
+		slog.Bool("keep_links", args.KeepLinks),
+		slog.Bool("keep_attachments", args.KeepAttachments),
+		slog.Bool("strip_metadata", args.StripMetadata),
+		slog.Bool("flatten_forms", args.FlattenForms))
+
+	result, err := h.processor.Sanitize(ctx, args.InputPath, args.OutputPath, types.SanitizeOptions{
+		KeepLinks:       args.KeepLinks,
+		KeepAttachments: args.KeepAttachments,
+		StripMetadata:   args.StripMetadata,
+		FlattenForms:    args.FlattenForms,
+	})
+	if err != nil {
+		h.logger.Error("pdf_sanitize failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFDiffHandler maneja pdf_diff
+type PDFDiffHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfDiffArgs struct {
+	PathA               string `json:"path_a"`
+	PathB               string `json:"path_b"`
+	AnnotatedOutputPath string `json:"annotated_output_path,omitempty"`
+	AutoRepair          bool   `json:"auto_repair,omitempty"`
+}
+
+func (h *PDFDiffHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_diff",
+		Title:       "Compare PDFs",
+		Description: "Compare two versions of a PDF: page count, per-page text (line diff, with pages aligned by content), metadata, form field values, annotations and attachments. Optionally writes a copy of the second PDF with the added or changed text highlighted and a note listing the removed lines",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path_a":                map[string]interface{}{"type": "string", "description": "Absolute path to the original PDF"},
+				"path_b":                map[string]interface{}{"type": "string", "description": "Absolute path to the revised PDF"},
+				"annotated_output_path": map[string]interface{}{"type": "string", "description": "Absolute path where a copy of path_b with the changes highlighted will be saved (optional)"},
+				"auto_repair":           autoRepairSchema,
+			},
+			"required":             []string{"path_a", "path_b"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.DiffResult{}),
+		Annotations:  writeAnnotations(true),
+	}
+}
+
+func (h *PDFDiffHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfDiffArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_diff args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.PathA) == "" {
+		return NewToolErrorResult(id, "missing or invalid path_a")
+	}
+	if strings.TrimSpace(args.PathB) == "" {
+		return NewToolErrorResult(id, "missing or invalid path_b")
+	}
+
+	h.logger.Debug("executing pdf_diff",
+		slog.String("path_a", args.PathA),
+		slog.String("path_b", args.PathB),
+		slog.String("annotated_output_path", args.AnnotatedOutputPath))
+
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.PathA, args.PathB}, func(inputs []string) (*types.DiffResult, error) {
+		return h.processor.Diff(ctx, inputs[0], inputs[1], types.DiffOptions{
+			AnnotatedOutputPath: args.AnnotatedOutputPath,
+		})
+	})
+	if err != nil {
+		h.logger.Error("pdf_diff failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFPageLabelsGetHandler maneja pdf_page_labels_get
+type PDFPageLabelsGetHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfPageLabelsGetArgs struct {
+	InputPath  string `json:"input_path"`
+	AutoRepair bool   `json:"auto_repair,omitempty"`
+}
+
+func (h *PDFPageLabelsGetHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_page_labels_get",
+		Title:       "Get Page Labels",
+		Description: "Read the page labels of a PDF (e.g. 'i, ii, iii, 1, 2'): the label ranges with style, prefix and start value, and the resulting label of every page. Without labels, each page is labelled with its number",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":  map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file"},
+				"auto_repair": autoRepairSchema,
+			},
+			"required":             []string{"input_path"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.PageLabelsResult{}),
+		Annotations:  readOnlyAnnotations(),
+	}
+}
+
+func (h *PDFPageLabelsGetHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfPageLabelsGetArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_page_labels_get args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+
+	h.logger.Debug("executing pdf_page_labels_get",
+		slog.String("input_path", args.InputPath))
+
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.InputPath}, func(inputs []string) (*types.PageLabelsResult, error) {
+		return h.processor.GetPageLabels(ctx, inputs[0])
+	})
+	if err != nil {
+		h.logger.Error("pdf_page_labels_get failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFPageLabelsSetHandler maneja pdf_page_labels_set
+type PDFPageLabelsSetHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfPageLabelsSetArgs struct {
+	InputPath  string                 `json:"input_path"`
+	OutputPath string                 `json:"output_path"`
+	Ranges     []types.PageLabelRange `json:"ranges"`
+	AutoRepair bool                   `json:"auto_repair,omitempty"`
+}
+
+func (h *PDFPageLabelsSetHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_page_labels_set",
+		Title:       "Set Page Labels",
+		Description: "Replace the page labels of a PDF with the given ranges. Each range applies from its start page until the next range; the first range must start at page 1. An empty list removes the labels",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":  map[string]interface{}{"type": "string", "description": "Absolute path to the PDF file"},
+				"output_path": map[string]interface{}{"type": "string", "description": "Absolute path where the labelled PDF will be saved"},
+				"ranges": map[string]interface{}{
+					"type":        "array",
+					"description": "Label ranges, e.g. [{\"start_page\":1,\"style\":\"roman_lower\"},{\"start_page\":5,\"style\":\"decimal\"}]",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"start_page": map[string]interface{}{"type": "integer", "minimum": 1, "description": "First page of the range (1-based)"},
+							"style": map[string]interface{}{
+								"type":        "string",
+								"enum":        []string{"decimal", "roman_upper", "roman_lower", "letters_upper", "letters_lower", "none"},
+								"description": "Numbering style; 'none' shows only the prefix (default: decimal)",
+							},
+							"prefix":      map[string]interface{}{"type": "string", "description": "Text placed before the number, e.g. 'A-'"},
+							"start_value": map[string]interface{}{"type": "integer", "minimum": 1, "description": "Number of the first page of the range (default: 1)"},
+						},
+						"required":             []string{"start_page"},
+						"additionalProperties": false,
+					},
+				},
+				"auto_repair": autoRepairSchema,
+			},
+			"required":             []string{"input_path", "output_path", "ranges"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.PageLabelsResult{}),
+		Annotations:  writeAnnotations(true),
+	}
+}
+
+func (h *PDFPageLabelsSetHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfPageLabelsSetArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_page_labels_set args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.InputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid input_path")
+	}
+	if strings.TrimSpace(args.OutputPath) == "" {
+		return NewToolErrorResult(id, "missing or invalid output_path")
+	}
+
+	h.logger.Debug("executing pdf_page_labels_set",
+		slog.String("input_path", args.InputPath),
+		slog.String("output_path", args.OutputPath),
+		slog.Int("ranges", len(args.Ranges)))
+
+	result, err := withAutoRepair(ctx, h.processor, h.logger, args.AutoRepair, []string{args.InputPath}, func(inputs []string) (*types.PageLabelsResult, error) {
+		return h.processor.SetPageLabels(ctx, inputs[0], args.OutputPath, args.Ranges)
+	})
+	if err != nil {
+		h.logger.Error("pdf_page_labels_set failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFPipelineHandler maneja pdf_pipeline
+type PDFPipelineHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfPipelineArgs struct {
+	InputPath  string               `json:"input_path,omitempty"`
+	InputPaths []string             `json:"input_paths,omitempty"`
+	OutputPath string               `json:"output_path,omitempty"`
+	Steps      []types.PipelineStep `json:"steps"`
+}
+
+func (h *PDFPipelineHandler) GetDefinition() Tool {
+	return Tool{
+		Name:  "pdf_pipeline",
+		Title: "Run PDF Pipeline",
+		Description: "Run several operations in one call, e.g. merge, then remove pages, then compress. Each step takes the previous step's output; " +
+			"intermediate files live in a temp dir that is removed at the end. Report-only steps (info, validate, ...) inspect the current document. " +
+			"Returns per-step results and timings; the first failing step aborts the pipeline and nothing is written",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_path":  map[string]interface{}{"type": "string", "description": "Absolute path to the input PDF"},
+				"input_paths": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Absolute paths to several input PDFs; the first step must then be merge or collate"},
+				"output_path": map[string]interface{}{"type": "string", "description": "Absolute path where the final PDF will be saved (required if any step modifies the document)"},
+				"steps": map[string]interface{}{
+					"type":        "array",
+					"minItems":    1,
+					"description": "Ordered steps, e.g. [{\"operation\":\"merge\"},{\"operation\":\"remove_pages\",\"args\":{\"pages\":\"1\"}},{\"operation\":\"compress\",\"args\":{\"profile\":\"ebook\"}}]",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"operation": map[string]interface{}{"type": "string", "enum": pdf.PipelineOperations()},
+							"args": map[string]interface{}{
+								"type":        "object",
+								"description": "Arguments of the equivalent tool without input or output paths. merge: append, linearize; collate: reverse_back (default: true); sign uses the configured key only",
+							},
+						},
+						"required":             []string{"operation"},
+						"additionalProperties": false,
+					},
+				},
+			},
+			"required":             []string{"steps"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.PipelineResult{}),
+		Annotations:  writeAnnotations(true),
+	}
+}
+
+func (h *PDFPipelineHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfPipelineArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_pipeline args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	inputs := args.InputPaths
+	if strings.TrimSpace(args.InputPath) != "" {
+		inputs = append([]string{args.InputPath}, inputs...)
+	}
+	if len(inputs) == 0 {
+		return NewToolErrorResult(id, "missing or invalid input_path or input_paths")
+	}
+	if len(args.Steps) == 0 {
+		return NewToolErrorResult(id, "missing or invalid steps")
+	}
+
+	h.logger.Debug("executing pdf_pipeline",
+		slog.Int("input_count", len(inputs)),
+		slog.Int("steps", len(args.Steps)),
+		slog.String("output_path", args.OutputPath))
+
+	result, err := h.processor.RunPipeline(ctx, inputs, args.OutputPath, args.Steps)
+	if err != nil {
+		h.logger.Error("pdf_pipeline failed", err)
+		if result == nil {
+			return NewToolErrorResult(id, err.Error())
+		}
+		// El informe indica qué paso falló y los resultados de los anteriores.
+		resultJSON, _ := json.Marshal(result)
+		return NewToolErrorResult(id, string(resultJSON))
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// PDFBatchHandler maneja pdf_batch
+type PDFBatchHandler struct {
+	processor *pdf.Processor
+	logger    logging.Logger
+}
+
+type pdfBatchArgs struct {
+	InputGlob        string          `json:"input_glob,omitempty"`
+	InputPaths       []string        `json:"input_paths,omitempty"`
+	OutputDir        string          `json:"output_dir,omitempty"`
+	FilenameTemplate string          `json:"filename_template,omitempty"`
+	Operation        string          `json:"operation"`
+	Args             json.RawMessage `json:"args,omitempty"`
+	Workers          int             `json:"workers,omitempty"`
+}
+
+func (h *PDFBatchHandler) GetDefinition() Tool {
+	return Tool{
+		Name:        "pdf_batch",
+		Title:       "Batch Process PDFs",
+		Description: "Apply one single-file operation (compress, rotate, remove_pages, info, ...) to many PDFs selected by a glob or a list. Files are processed in parallel; each file reports success or its error and a failure does not stop the others",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"input_glob":        map[string]interface{}{"type": "string", "description": "Glob of input files, e.g. C:\\scans\\*.pdf (no ** recursion)"},
+				"input_paths":       map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Absolute paths to input PDFs (combined with input_glob)"},
+				"output_dir":        map[string]interface{}{"type": "string", "description": "Directory for the output files (required unless the operation is report-only)"},
+				"filename_template": map[string]interface{}{"type": "string", "description": "Output file name with {name}, {ext}, {index} and {op} placeholders (default: {name}-{op}.pdf)"},
+				"operation":         map[string]interface{}{"type": "string", "enum": pdf.BatchOperations()},
+				"args":              map[string]interface{}{"type": "object", "description": "Arguments of the equivalent tool without input or output paths, e.g. {\"profile\":\"ebook\"} for compress or {\"degrees\":90} for rotate"},
+				"workers":           map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 16, "description": "Files processed at the same time (default: 4)"},
+			},
+			"required":             []string{"operation"},
+			"additionalProperties": false,
+		},
+		OutputSchema: outputSchema(types.BatchResult{}),
+		Annotations:  writeAnnotations(true),
+	}
+}
+
+func (h *PDFBatchHandler) Handle(ctx context.Context, id RequestID, rawArgs json.RawMessage) *Response {
+	var args pdfBatchArgs
+	if err := UnmarshalParams(rawArgs, &args); err != nil {
+		h.logger.Error("failed to unmarshal pdf_batch args", err)
+		return NewToolErrorResult(id, fmt.Sprintf("invalid arguments: %v", err))
+	}
+
+	if strings.TrimSpace(args.Operation) == "" {
+		return NewToolErrorResult(id, "missing or invalid operation")
+	}
+	inputs, err := pdf.ResolveBatchInputs(args.InputGlob, args.InputPaths)
+	if err != nil {
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	h.logger.Debug("executing pdf_batch",
+		slog.String("operation", args.Operation),
+		slog.Int("input_count", len(inputs)),
+		slog.String("output_dir", args.OutputDir))
+
+	result, err := h.processor.Batch(ctx, inputs, types.PipelineStep{Operation: args.Operation, Args: args.Args}, types.BatchOptions{
+		OutputDir:        args.OutputDir,
+		FilenameTemplate: args.FilenameTemplate,
+		Workers:          args.Workers,
+	})
+	if err != nil {
+		h.logger.Error("pdf_batch failed", err)
+		return NewToolErrorResult(id, err.Error())
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return NewToolResult(id, string(resultJSON))
+}
+
+// autoRepairSchema es la propiedad auto_repair común a las herramientas que leen PDFs.
+var autoRepairSchema = map[string]interface{}{
+	"type":        "boolean",
+	"description": "If the operation fails, repair the invalid input files (as pdf_repair does) and retry once (default: false)",
+}
+
+// withAutoRepair ejecuta run con las rutas de entrada. Si falla y autoRepair
+// está activo, repara en temporales las entradas que no pasan la validación y
+// reintenta una sola vez con las copias reparadas. En el resultado del
+// reintento las rutas de las copias se sustituyen por las originales, que son
+// las que conoce el cliente; si el reintento también falla se devuelve el
+// primer error. No reintenta si ctx se ha cancelado.
+func withAutoRepair[T any](ctx context.Context, processor *pdf.Processor, logger logging.Logger, autoRepair bool, inputs []string, run func(inputs []string) (T, error)) (T, error) {
+	result, err := run(inputs)
+	if err == nil || !autoRepair || ctx.Err() != nil {
+		return result, err
+	}
+
+	repaired := make([]string, len(inputs))
+	copy(repaired, inputs)
+	originals := map[string]string{}
+	for i, in := range inputs {
+		if processor.ValidateFile(in) == nil {
+			continue
+		}
+		path, result, cleanup, rerr := processor.RepairToTemp(ctx, in)
+		if rerr != nil {
+			logger.Warn("auto repair failed",
+				slog.String("input", in),
+				slog.Any("error", rerr))
+			continue
+		}
+		defer cleanup()
+		logger.Warn("input repaired, retrying operation",
+			slog.String("input", in),
+			slog.Int("changes", len(result.Changes)))
+		repaired[i] = path
+		originals[path] = in
+	}
+	if len(originals) == 0 {
+		return result, err
+	}
+
+	retried, rerr := run(repaired)
+	if rerr != nil {
+		if ctx.Err() != nil {
+			return retried, rerr
+		}
+		logger.Warn("operation failed on repaired input", slog.Any("error", rerr))
+		return result, err
+	}
+	restoreInputPaths(reflect.ValueOf(&retried).Elem(), originals)
+	return retried, nil
+}
+
+// restoreInputPaths recorre v (structs, punteros y slices) y cambia las
+// cadenas que son rutas de copias reparadas por la ruta original.
+func restoreInputPaths(v reflect.Value, originals map[string]string) {
+	switch v.Kind() {
+	case reflect.String:
+		if original, ok := originals[v.String()]; ok && v.CanSet() {
+			v.SetString(original)
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			restoreInputPaths(v.Elem(), originals)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			restoreInputPaths(v.Field(i), originals)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			restoreInputPaths(v.Index(i), originals)
+		}
+	}
+}
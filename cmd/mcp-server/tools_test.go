@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestToolDefinitions(t *testing.T) {
+	server := newTestServer(t)
+	all := call(t, server, "tools/list", nil).Result.(ToolsListResponse)
+	if all.NextCursor != "" {
+		t.Errorf("nextCursor = %q without a page size", all.NextCursor)
+	}
+
+	t.Run("sorted and stable", func(t *testing.T) {
+		if !sort.SliceIsSorted(all.Tools, func(i, j int) bool { return all.Tools[i].Name < all.Tools[j].Name }) {
+			t.Error("tools/list is not sorted by name")
+		}
+		for i := 0; i < 5; i++ {
+			again := call(t, server, "tools/list", nil).Result.(ToolsListResponse).Tools
+			for j := range again {
+				if again[j].Name != all.Tools[j].Name {
+					t.Fatalf("order changed between calls at %d: %s != %s", j, again[j].Name, all.Tools[j].Name)
+				}
+			}
+		}
+	})
+
+	t.Run("titles and annotations", func(t *testing.T) {
+		for _, tool := range all.Tools {
+			a := tool.Annotations
+			if tool.Title == "" || a == nil {
+				t.Errorf("%s: missing title or annotations", tool.Name)
+				continue
+			}
+			props := tool.InputSchema.(map[string]interface{})["properties"].(map[string]interface{})
+			writes := false
+			for _, key := range []string{"output_path", "output_dir", "annotated_output_path"} {
+				if _, ok := props[key]; ok {
+					writes = true
+				}
+			}
+			if a.ReadOnlyHint == writes || a.DestructiveHint != writes {
+				t.Errorf("%s: writes output %v but annotations %+v", tool.Name, writes, *a)
+			}
+			if a.OpenWorldHint {
+				t.Errorf("%s: openWorldHint set for a local tool", tool.Name)
+			}
+		}
+	})
+
+	t.Run("cursor pagination", func(t *testing.T) {
+		server := newTestServer(t)
+		server.toolsPageSize = 5
+		var names []string
+		cursor := ""
+		for pages := 0; ; pages++ {
+			if pages > len(all.Tools) {
+				t.Fatal("pagination does not end")
+			}
+			params := map[string]string{}
+			if cursor != "" {
+				params["cursor"] = cursor
+			}
+			page := call(t, server, "tools/list", params).Result.(ToolsListResponse)
+			if len(page.Tools) > 5 {
+				t.Fatalf("page of %d tools", len(page.Tools))
+			}
+			for _, tool := range page.Tools {
+				names = append(names, tool.Name)
+			}
+			if cursor = page.NextCursor; cursor == "" {
+				break
+			}
+		}
+		if len(names) != len(all.Tools) {
+			t.Fatalf("paginated %d tools, want %d", len(names), len(all.Tools))
+		}
+		for i, name := range names {
+			if name != all.Tools[i].Name {
+				t.Errorf("tool %d = %s, want %s", i, name, all.Tools[i].Name)
+			}
+		}
+
+		if resp := call(t, server, "tools/list", map[string]string{"cursor": "not-a-cursor"}); resp.Error == nil || resp.Error.Code != InvalidParams {
+			t.Errorf("invalid cursor: got %+v, want InvalidParams", resp)
+		}
+	})
+}
+
+func TestWithAutoRepair(t *testing.T) {
+	server := newTestServer(t)
+	good := writeBlankPDF(t, 2)
+	data, err := os.ReadFile(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// La entrada xref del catálogo apunta a un desplazamiento incorrecto.
+	damaged := filepath.Join(t.TempDir(), "damaged.pdf")
+	if err := os.WriteFile(damaged, bytes.Replace(data, []byte("0000000009 00000 n"), []byte("0000000001 00000 n"), 1), 0600); err != nil {
+		t.Fatal(err)
+	}
+	inputs := []string{good, damaged}
+
+	var calls [][]string
+	run := func(paths []string) (*types.MergeResult, error) {
+		calls = append(calls, append([]string(nil), paths...))
+		if paths[1] == damaged {
+			return nil, fmt.Errorf("cannot read %s", damaged)
+		}
+		return &types.MergeResult{InputFiles: paths, OutputPath: "out.pdf"}, nil
+	}
+	result, err := withAutoRepair(context.Background(), server.processor, server.logger, true, inputs, run)
+	if err != nil {
+		t.Fatalf("withAutoRepair failed: %v", err)
+	}
+	if len(calls) != 2 || calls[1][0] != good || calls[1][1] == damaged {
+		t.Fatalf("calls = %v, want a retry with only the damaged input replaced", calls)
+	}
+	if !reflect.DeepEqual(result.InputFiles, inputs) || result.OutputPath != "out.pdf" {
+		t.Errorf("result = %+v, want the caller's input paths", result)
+	}
+
+	// Si el reintento también falla, el error es el de la entrada original.
+	_, err = withAutoRepair(context.Background(), server.processor, server.logger, true, inputs, func(paths []string) (*types.MergeResult, error) {
+		return nil, fmt.Errorf("cannot read %s", paths[1])
+	})
+	if err == nil || err.Error() != "cannot read "+damaged {
+		t.Errorf("error after a failed retry = %v", err)
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/scopweb/mcp-go-pdf-tools/internal/config"
@@ -82,7 +85,7 @@ func (h *Handlers) Split(w http.ResponseWriter, r *http.Request) {
 	tmpFile.Close()
 
 	// Dividir PDF
-	parts, err := h.processor.Split(tmpPath)
+	parts, err := h.processor.Split(r.Context(), tmpPath)
 	if err != nil {
 		h.logger.Error("PDF split failed", err)
 		http.Error(w, "failed to split PDF", http.StatusBadRequest)
@@ -162,6 +165,10 @@ func (h *Handlers) RemovePages(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "missing pages field", http.StatusBadRequest)
 		return
 	}
+	if _, err := pdf.ParsePageSelection(pageSelection); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Parse modo
 	modeStr := r.FormValue("mode")
@@ -205,7 +212,7 @@ func (h *Handlers) RemovePages(w http.ResponseWriter, r *http.Request) {
 	defer os.Remove(tmpOutputPath)
 
 	// Remover páginas
-	result, err := h.processor.RemovePages(tmpInputPath, tmpOutputPath, pageSelection, mode)
+	result, err := h.processor.RemovePages(r.Context(), tmpInputPath, tmpOutputPath, pageSelection, mode)
 	if err != nil {
 		h.logger.Error("page removal failed", err)
 		http.Error(w, "failed to remove pages: "+err.Error(), http.StatusBadRequest)
@@ -288,8 +295,14 @@ func (h *Handlers) Compress(w http.ResponseWriter, r *http.Request) {
 	tmpOutputFile.Close()
 	defer os.Remove(tmpOutputPath)
 
+	opts, err := compressOptionsFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Comprimir PDF
-	result, err := h.processor.Compress(tmpInputPath, tmpOutputPath)
+	result, err := h.processor.Compress(r.Context(), tmpInputPath, tmpOutputPath, opts)
 	if err != nil {
 		h.logger.Error("compression failed", err)
 		http.Error(w, "failed to compress PDF", http.StatusInternalServerError)
@@ -314,12 +327,60 @@ func (h *Handlers) Compress(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Original-Size", fmt.Sprintf("%d", result.OriginalSize))
 	w.Header().Set("X-Compressed-Size", fmt.Sprintf("%d", result.CompressedSize))
 	w.Header().Set("X-Compression-Ratio", fmt.Sprintf("%.2f%%", result.CompressionRatio*100))
+	w.Header().Set("X-Compress-Profile", string(result.Profile))
+	w.Header().Set("X-Savings-Images", fmt.Sprintf("%d", result.Savings.Images))
+	w.Header().Set("X-Savings-Duplicate-Images", fmt.Sprintf("%d", result.Savings.DuplicateImages))
+	w.Header().Set("X-Savings-Unused-Fonts", fmt.Sprintf("%d", result.Savings.UnusedFonts))
+	w.Header().Set("X-Savings-Structure", fmt.Sprintf("%d", result.Savings.Structure))
+	w.Header().Set("X-Linearized", fmt.Sprintf("%t", result.Linearized))
 
 	if _, err := io.Copy(w, compressedFile); err != nil {
 		h.logger.Error("error writing response", err)
 	}
 }
 
+// compressOptionsFromForm lee los parámetros de compresión del formulario:
+// profile, image_quality, max_dpi, grayscale y linearize.
+func compressOptionsFromForm(r *http.Request) (types.CompressOptions, error) {
+	opts := types.CompressOptions{
+		Grayscale: r.FormValue("grayscale") == "true",
+		Linearize: r.FormValue("linearize") == "true",
+	}
+
+	if v := r.FormValue("profile"); v != "" {
+		profile, ok := types.ParseCompressProfile(v)
+		if !ok {
+			return opts, fmt.Errorf("invalid profile: must be lossless, print, ebook, screen or custom")
+		}
+		opts.Profile = profile
+	}
+
+	var err error
+	if opts.ImageQuality, err = positiveFormInt(r, "image_quality"); err != nil {
+		return opts, err
+	}
+	if opts.MaxDPI, err = positiveFormInt(r, "max_dpi"); err != nil {
+		return opts, err
+	}
+	if opts.ImageQuality > 100 {
+		return opts, fmt.Errorf("invalid image_quality: must be between 1 and 100")
+	}
+	return opts, nil
+}
+
+// positiveFormInt lee un entero positivo opcional del formulario (0 si falta).
+func positiveFormInt(r *http.Request, field string) (int, error) {
+	v := r.FormValue(field)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be a positive integer", field)
+	}
+	return n, nil
+}
+
 // MergeFileEntry representa un archivo individual en el request de merge.
 type MergeFileEntry struct {
 	Path   string `json:"path"`
@@ -334,6 +395,8 @@ type MergeRequest struct {
 	Files []MergeFileEntry `json:"files"`
 	// OutputFilename nombre del archivo de salida
 	OutputFilename string `json:"output_filename"`
+	// Linearize genera la salida linealizada (fast web view)
+	Linearize bool `json:"linearize"`
 }
 
 // Merge combina múltiples PDFs y devuelve el resultado como PDF binario.
@@ -384,7 +447,7 @@ func (h *Handlers) Merge(w http.ResponseWriter, r *http.Request) {
 	defer os.Remove(tmpOutputPath)
 
 	// Merge PDFs
-	result, err := h.processor.Merge(expandedPaths, tmpOutputPath)
+	result, err := h.processor.Merge(r.Context(), expandedPaths, tmpOutputPath, types.MergeOptions{Linearize: req.Linearize})
 	if err != nil {
 		h.logger.Error("PDF merge failed", err)
 		http.Error(w, "failed to merge PDFs: "+err.Error(), http.StatusBadRequest)
@@ -416,6 +479,587 @@ func (h *Handlers) Merge(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Linearize linealiza un PDF (fast web view) y lo devuelve como descarga.
+func (h *Handlers) Linearize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(h.config.MaxUploadSize); err != nil {
+		h.logger.Error("failed to parse multipart form", err)
+		http.Error(w, "invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.logger.Warn("missing file field", slog.Any("error", err))
+		http.Error(w, "missing file field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// Crear archivo temporal de entrada
+	tmpInputFile, err := os.CreateTemp("", "upload-*.pdf")
+	if err != nil {
+		h.logger.Error("failed to create temp file", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	tmpInputPath := tmpInputFile.Name()
+	defer os.Remove(tmpInputPath)
+
+	if _, err := io.Copy(tmpInputFile, file); err != nil {
+		tmpInputFile.Close()
+		h.logger.Error("failed to save uploaded file", err)
+		http.Error(w, "failed to save file", http.StatusInternalServerError)
+		return
+	}
+	tmpInputFile.Close()
+
+	// Crear archivo temporal de salida
+	tmpOutputFile, err := os.CreateTemp("", "linearized-*.pdf")
+	if err != nil {
+		h.logger.Error("failed to create output temp file", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	tmpOutputPath := tmpOutputFile.Name()
+	tmpOutputFile.Close()
+	defer os.Remove(tmpOutputPath)
+
+	result, err := h.processor.Linearize(r.Context(), tmpInputPath, tmpOutputPath)
+	if err != nil {
+		h.logger.Error("linearization failed", err)
+		http.Error(w, "failed to linearize PDF", http.StatusInternalServerError)
+		return
+	}
+
+	resultFile, err := os.Open(tmpOutputPath)
+	if err != nil {
+		h.logger.Error("failed to open linearized file", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer resultFile.Close()
+
+	w.Header().Set("Content-Type", "application/pdf")
+	linearName := sanitizeFilename(filepath.Base(header.Filename)) + "-linear.pdf"
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", linearName))
+	w.Header().Set("X-Total-Pages", fmt.Sprintf("%d", result.TotalPages))
+	w.Header().Set("X-Original-Size", fmt.Sprintf("%d", result.OriginalSize))
+	w.Header().Set("X-Output-Size", fmt.Sprintf("%d", result.OutputSize))
+
+	if _, err := io.Copy(w, resultFile); err != nil {
+		h.logger.Error("error writing response", err)
+	}
+}
+
+// VerifySignatures verifica las firmas digitales de un PDF y devuelve el informe en JSON.
+// El almacén de confianza es el de la configuración del servidor (PDF_TRUST_STORE_DIR).
+func (h *Handlers) VerifySignatures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(h.config.MaxUploadSize); err != nil {
+		h.logger.Error("failed to parse multipart form", err)
+		http.Error(w, "invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.logger.Warn("missing file field", slog.Any("error", err))
+		http.Error(w, "missing file field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// Crear archivo temporal de entrada
+	tmpInputFile, err := os.CreateTemp("", "upload-*.pdf")
+	if err != nil {
+		h.logger.Error("failed to create temp file", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	tmpInputPath := tmpInputFile.Name()
+	defer os.Remove(tmpInputPath)
+
+	if _, err := io.Copy(tmpInputFile, file); err != nil {
+		tmpInputFile.Close()
+		h.logger.Error("failed to save uploaded file", err)
+		http.Error(w, "failed to save file", http.StatusInternalServerError)
+		return
+	}
+	tmpInputFile.Close()
+
+	report, err := h.processor.ListSignatures(r.Context(), tmpInputPath, "")
+	if err != nil {
+		h.logger.Error("signature verification failed", err)
+		http.Error(w, "failed to verify signatures", http.StatusInternalServerError)
+		return
+	}
+	report.InputPath = filepath.Base(header.Filename)
+	report.TrustStoreDir = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Signatures", fmt.Sprintf("%d", len(report.Signatures)))
+	w.Header().Set("X-All-Valid", strconv.FormatBool(report.AllValid))
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		h.logger.Error("error writing response", err)
+	}
+}
+
+// SecurityScan inspecciona un PDF subido en busca de contenido activo y devuelve
+// el informe con la puntuación de riesgo en JSON.
+func (h *Handlers) SecurityScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(h.config.MaxUploadSize); err != nil {
+		h.logger.Error("failed to parse multipart form", err)
+		http.Error(w, "invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.logger.Warn("missing file field", slog.Any("error", err))
+		http.Error(w, "missing file field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// Crear archivo temporal de entrada
+	tmpInputFile, err := os.CreateTemp("", "upload-*.pdf")
+	if err != nil {
+		h.logger.Error("failed to create temp file", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	tmpInputPath := tmpInputFile.Name()
+	defer os.Remove(tmpInputPath)
+
+	if _, err := io.Copy(tmpInputFile, file); err != nil {
+		tmpInputFile.Close()
+		h.logger.Error("failed to save uploaded file", err)
+		http.Error(w, "failed to save file", http.StatusInternalServerError)
+		return
+	}
+	tmpInputFile.Close()
+
+	report, err := h.processor.SecurityScan(r.Context(), tmpInputPath)
+	if err != nil {
+		h.logger.Error("security scan failed", err)
+		http.Error(w, "failed to scan PDF", http.StatusInternalServerError)
+		return
+	}
+	report.InputPath = filepath.Base(header.Filename)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Risk-Score", strconv.Itoa(report.RiskScore))
+	w.Header().Set("X-Risk-Level", report.RiskLevel)
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		h.logger.Error("error writing response", err)
+	}
+}
+
+// Sanitize elimina el contenido activo de un PDF subido y devuelve la copia
+// limpia como descarga. Campos opcionales: keep_links, keep_attachments,
+// strip_metadata y flatten_forms.
+func (h *Handlers) Sanitize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(h.config.MaxUploadSize); err != nil {
+		h.logger.Error("failed to parse multipart form", err)
+		http.Error(w, "invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.logger.Warn("missing file field", slog.Any("error", err))
+		http.Error(w, "missing file field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	opts := types.SanitizeOptions{
+		KeepLinks:       r.FormValue("keep_links") == "true",
+		KeepAttachments: r.FormValue("keep_attachments") == "true",
+		StripMetadata:   r.FormValue("strip_metadata") == "true",
+		FlattenForms:    r.FormValue("flatten_forms") == "true",
+	}
+
+	// Crear archivo temporal de entrada
+	tmpInputFile, err := os.CreateTemp("", "upload-*.pdf")
+	if err != nil {
+		h.logger.Error("failed to create temp file", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	tmpInputPath := tmpInputFile.Name()
+	defer os.Remove(tmpInputPath)
+
+	if _, err := io.Copy(tmpInputFile, file); err != nil {
+		tmpInputFile.Close()
+		h.logger.Error("failed to save uploaded file", err)
+		http.Error(w, "failed to save file", http.StatusInternalServerError)
+		return
+	}
+	tmpInputFile.Close()
+
+	// Crear archivo temporal de salida
+	tmpOutputFile, err := os.CreateTemp("", "sanitized-*.pdf")
+	if err != nil {
+		h.logger.Error("failed to create output temp file", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	tmpOutputPath := tmpOutputFile.Name()
+	tmpOutputFile.Close()
+	defer os.Remove(tmpOutputPath)
+
+	result, err := h.processor.Sanitize(r.Context(), tmpInputPath, tmpOutputPath, opts)
+	if err != nil {
+		h.logger.Error("sanitization failed", err)
+		http.Error(w, "failed to sanitize PDF", http.StatusInternalServerError)
+		return
+	}
+
+	resultFile, err := os.Open(tmpOutputPath)
+	if err != nil {
+		h.logger.Error("failed to open sanitized file", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer resultFile.Close()
+
+	w.Header().Set("Content-Type", "application/pdf")
+	cleanName := sanitizeFilename(filepath.Base(header.Filename)) + "-sanitized.pdf"
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", cleanName))
+	w.Header().Set("X-Removed", strconv.Itoa(len(result.Removed)))
+	w.Header().Set("X-Risk-Score-Before", strconv.Itoa(result.RiskScoreBefore))
+	w.Header().Set("X-Risk-Score-After", strconv.Itoa(result.RiskScoreAfter))
+
+	if _, err := io.Copy(w, resultFile); err != nil {
+		h.logger.Error("error writing response", err)
+	}
+}
+
+// Pipeline ejecuta una secuencia de operaciones sobre uno o varios PDFs subidos
+// (campos "file", en orden) en una sola petición. El campo "steps" es el JSON
+// con los pasos, como en la herramienta pdf_pipeline. Si algún paso modifica el
+// documento, la respuesta es el PDF final con la duración de cada paso en
+// X-Pipeline-Timings; si no, o con report=json, es el informe en JSON. Un paso
+// fallido devuelve 422 con el informe. Los pasos que trabajan con archivos o
+// claves del servidor se rechazan (ver checkHTTPPipelineStep).
+func (h *Handlers) Pipeline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(h.config.MaxUploadSize); err != nil {
+		h.logger.Error("failed to parse multipart form", err)
+		http.Error(w, "invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	var steps []types.PipelineStep
+	if err := json.Unmarshal([]byte(r.FormValue("steps")), &steps); err != nil || len(steps) == 0 {
+		http.Error(w, "missing or invalid steps field", http.StatusBadRequest)
+		return
+	}
+	for i, step := range steps {
+		if err := checkHTTPPipelineStep(step); err != nil {
+			http.Error(w, fmt.Sprintf("step %d: %v", i+1, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	headers := r.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		http.Error(w, "missing file field", http.StatusBadRequest)
+		return
+	}
+
+	tmpDir, err := os.MkdirTemp("", "pipeline-upload-")
+	if err != nil {
+		h.logger.Error("failed to create temp directory", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inputs := make([]string, len(headers))
+	names := make([]string, len(headers))
+	for i, header := range headers {
+		names[i] = filepath.Base(header.Filename)
+		inputs[i] = filepath.Join(tmpDir, fmt.Sprintf("input-%d.pdf", i+1))
+		if err := saveUpload(header, inputs[i]); err != nil {
+			h.logger.Error("failed to save uploaded file", err)
+			http.Error(w, "failed to save file", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	tmpOutputPath := filepath.Join(tmpDir, "output.pdf")
+	result, err := h.processor.RunPipeline(r.Context(), inputs, tmpOutputPath, steps)
+	if result == nil && err != nil {
+		h.logger.Error("pipeline failed", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Las rutas temporales no se exponen al cliente.
+	result.InputPaths = names
+	hasOutput := result.OutputPath != ""
+	result.OutputPath = ""
+
+	if err != nil || !hasOutput || r.FormValue("report") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			h.logger.Error("error writing response", err)
+		}
+		return
+	}
+
+	resultFile, err := os.Open(tmpOutputPath)
+	if err != nil {
+		h.logger.Error("failed to open pipeline output", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer resultFile.Close()
+
+	timings := make([]string, len(result.Steps))
+	for i, s := range result.Steps {
+		timings[i] = fmt.Sprintf("%s=%dms", s.Operation, s.DurationMs)
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	cleanName := sanitizeFilename(names[0]) + "-pipeline.pdf"
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", cleanName))
+	w.Header().Set("X-Pipeline-Steps", strconv.Itoa(len(result.Steps)))
+	w.Header().Set("X-Pipeline-Duration-Ms", strconv.FormatInt(result.DurationMs, 10))
+	w.Header().Set("X-Pipeline-Timings", strings.Join(timings, ","))
+
+	if _, err := io.Copy(w, resultFile); err != nil {
+		h.logger.Error("error writing response", err)
+	}
+}
+
+// Batch aplica una operación de un solo archivo a varios PDFs subidos (campos
+// "file"). Campos: operation, args (JSON con los argumentos de la operación),
+// filename_template y workers. Si la operación genera PDFs, la respuesta es un
+// ZIP con las salidas y report.json; si es de solo lectura, el informe en JSON.
+// Los archivos que fallan se anotan en el informe sin detener el resto. Solo
+// se admiten las operaciones de httpBatchOperations.
+func (h *Handlers) Batch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(h.config.MaxUploadSize); err != nil {
+		h.logger.Error("failed to parse multipart form", err)
+		http.Error(w, "invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	headers := r.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		http.Error(w, "missing file field", http.StatusBadRequest)
+		return
+	}
+	step := types.PipelineStep{Operation: r.FormValue("operation")}
+	if !httpBatchOperations[step.Operation] {
+		http.Error(w, fmt.Sprintf("operation %q is not available for HTTP batches", step.Operation), http.StatusBadRequest)
+		return
+	}
+	if args := r.FormValue("args"); args != "" {
+		if !json.Valid([]byte(args)) {
+			http.Error(w, "invalid args field: must be a JSON object", http.StatusBadRequest)
+			return
+		}
+		step.Args = json.RawMessage(args)
+	}
+	workers := 0
+	if v := r.FormValue("workers"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid workers field", http.StatusBadRequest)
+			return
+		}
+		workers = n
+	}
+
+	tmpDir, err := os.MkdirTemp("", "batch-upload-")
+	if err != nil {
+		h.logger.Error("failed to create temp directory", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Cada subida va en su propio directorio para conservar el nombre original,
+	// que usa la plantilla de nombres ({name}).
+	inputs := make([]string, len(headers))
+	names := make([]string, len(headers))
+	for i, header := range headers {
+		names[i] = filepath.Base(header.Filename)
+		inputs[i] = filepath.Join(tmpDir, "in", strconv.Itoa(i+1), names[i])
+		if err := os.MkdirAll(filepath.Dir(inputs[i]), 0700); err != nil {
+			h.logger.Error("failed to create temp directory", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if err := saveUpload(header, inputs[i]); err != nil {
+			h.logger.Error("failed to save uploaded file", err)
+			http.Error(w, "failed to save file", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	result, err := h.processor.Batch(r.Context(), inputs, step, types.BatchOptions{
+		OutputDir:        filepath.Join(tmpDir, "out"),
+		FilenameTemplate: r.FormValue("filename_template"),
+		Workers:          workers,
+	})
+	if err != nil {
+		h.logger.Error("batch failed", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Las rutas temporales no se exponen al cliente.
+	var outputs []string
+	for i := range result.Files {
+		f := &result.Files[i]
+		f.InputPath = names[i]
+		if f.OutputPath != "" {
+			outputs = append(outputs, f.OutputPath)
+			f.OutputPath = filepath.Base(f.OutputPath)
+		}
+	}
+
+	w.Header().Set("X-Batch-Succeeded", strconv.Itoa(result.Succeeded))
+	w.Header().Set("X-Batch-Failed", strconv.Itoa(result.Failed))
+
+	if len(outputs) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			h.logger.Error("error writing response", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"batch-%s.zip\"", result.Operation))
+
+	zw := zip.NewWriter(w)
+	for _, out := range outputs {
+		f, err := os.Open(out)
+		if err != nil {
+			h.logger.Warn("cannot open batch output", slog.Any("error", err))
+			continue
+		}
+		fw, err := zw.Create(filepath.Base(out))
+		if err == nil {
+			_, err = io.Copy(fw, f)
+		}
+		if err != nil {
+			h.logger.Warn("cannot write zip entry", slog.Any("error", err))
+		}
+		f.Close()
+	}
+	if fw, err := zw.Create("report.json"); err == nil {
+		if err := json.NewEncoder(fw).Encode(result); err != nil {
+			h.logger.Warn("cannot write batch report", slog.Any("error", err))
+		}
+	}
+	if err := zw.Close(); err != nil {
+		h.logger.Error("error writing response", err)
+	}
+}
+
+// checkHTTPPipelineStep rechaza los pasos que en HTTP darían al cliente acceso
+// a recursos del servidor: sign firma con la clave configurada (PDF_SIGN_*) y
+// merge.append lee rutas de su disco. Los dos siguen disponibles en MCP y en
+// la CLI, que trabajan con archivos locales.
+func checkHTTPPipelineStep(step types.PipelineStep) error {
+	switch step.Operation {
+	case "sign":
+		return fmt.Errorf("operation sign is not available over HTTP")
+	case "merge":
+		var args struct {
+			Append []string `json:"append"`
+		}
+		// Unos argumentos inválidos los rechaza después RunPipeline.
+		if err := json.Unmarshal(step.Args, &args); err == nil && len(args.Append) > 0 {
+			return fmt.Errorf("merge append is not available over HTTP: upload every document as a file field")
+		}
+	}
+	return nil
+}
+
+// httpBatchOperations son las operaciones de un solo archivo que admite
+// /api/v1/pdf/batch. No está sign, que firmaría con la clave del servidor.
+var httpBatchOperations = map[string]bool{
+	"compress":          true,
+	"rotate":            true,
+	"watermark":         true,
+	"remove_pages":      true,
+	"linearize":         true,
+	"repair":            true,
+	"sanitize":          true,
+	"redact":            true,
+	"pdfa_convert":      true,
+	"set_page_labels":   true,
+	"info":              true,
+	"validate":          true,
+	"security_scan":     true,
+	"page_labels":       true,
+	"verify_signatures": true,
+	"pdfa_check":        true,
+	"size_report":       true,
+	"scan_pii":          true,
+	"search":            true,
+}
+
+// saveUpload guarda un archivo subido en path.
+func saveUpload(header *multipart.FileHeader, path string) error {
+	src, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
 // sanitizeFilename limpia un nombre de archivo para evitar caracteres problemáticos.
 func sanitizeFilename(filename string) string {
 	// Remover extensión si existe
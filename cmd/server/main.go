@@ -35,6 +35,12 @@ func main() {
 	mux.HandleFunc("/api/v1/pdf/compress", handlers.Compress)
 	mux.HandleFunc("/api/v1/pdf/remove-pages", handlers.RemovePages)
 	mux.HandleFunc("/api/v1/pdf/merge", handlers.Merge)
+	mux.HandleFunc("/api/v1/pdf/linearize", handlers.Linearize)
+	mux.HandleFunc("/api/v1/pdf/verify-signatures", handlers.VerifySignatures)
+	mux.HandleFunc("/api/v1/pdf/security-scan", handlers.SecurityScan)
+	mux.HandleFunc("/api/v1/pdf/sanitize", handlers.Sanitize)
+	mux.HandleFunc("/api/v1/pdf/pipeline", handlers.Pipeline)
+	mux.HandleFunc("/api/v1/pdf/batch", handlers.Batch)
 
 	// Create HTTP server with configuration
 	addr := net.JoinHostPort(cfg.Host, cfg.Port)
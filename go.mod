@@ -4,17 +4,19 @@ go 1.24.0
 
 toolchain go1.24.6
 
-require github.com/pdfcpu/pdfcpu v0.11.1
+require (
+	github.com/hhrutter/pkcs7 v0.2.0
+	github.com/pdfcpu/pdfcpu v0.11.1
+	golang.org/x/crypto v0.47.0
+)
 
 require (
 	github.com/clipperhouse/stringish v0.1.1 // indirect
 	github.com/clipperhouse/uax29/v2 v2.5.0 // indirect
 	github.com/hhrutter/lzw v1.0.0 // indirect
-	github.com/hhrutter/pkcs7 v0.2.0 // indirect
 	github.com/hhrutter/tiff v1.0.2 // indirect
 	github.com/mattn/go-runewidth v0.0.19 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
-	golang.org/x/crypto v0.47.0 // indirect
 	golang.org/x/image v0.35.0 // indirect
 	golang.org/x/text v0.33.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
@@ -17,6 +17,16 @@ type PDFConfig struct {
 
 	// Temp directory for split operations
 	TempDir string
+
+	// Directory of trusted PEM certificates for signature verification
+	TrustStoreDir string
+
+	// Default signing key (PKCS#12 file, or PEM certificate and key) and its
+	// password. The password is only read from the environment.
+	SignPKCS12Path string
+	SignCertPath string
+	SignKeyPath string
+	SignKeyPassword string
 }
 
 // ServerConfig contiene configuración para el servidor HTTP.
@@ -50,6 +60,18 @@ type MCPServerConfig struct {
 	// Buffer size for large payloads
 	BufferSize int
 
+	// Maximum tools/call and resources/read requests processed at the same time
+	MaxInFlight int
+
+	// Lifetime of the resources that expose generated files
+	ResourceTTL time.Duration
+
+	// Directory with extra prompt templates (*.json)
+	PromptsDir string
+
+	// Maximum tools per tools/list page (0 = all tools in one page)
+	ToolsPageSize int
+
 	// Protocol versions to support (comma-separated)
 	ProtocolVersions string
 
@@ -82,6 +104,11 @@ func NewServerConfig() *ServerConfig {
 			RemoveMetadata: getBool("PDF_REMOVE_METADATA", true),
 			ValidationMode: getEnv("PDF_VALIDATION_MODE", "relaxed"),
 			TempDir: getEnv("PDF_TEMP_DIR", ""),
+			TrustStoreDir: getEnv("PDF_TRUST_STORE_DIR", ""),
+			SignPKCS12Path: getEnv("PDF_SIGN_PKCS12", ""),
+			SignCertPath: getEnv("PDF_SIGN_CERT", ""),
+			SignKeyPath: getEnv("PDF_SIGN_KEY", ""),
+			SignKeyPassword: getEnv("PDF_SIGN_KEY_PASSWORD", ""),
 		},
 	}
 }
@@ -92,12 +119,21 @@ func NewMCPServerConfig() *MCPServerConfig {
 		LogLevel: getEnv("LOG_LEVEL", "info"),
 		LogFormat: getEnv("LOG_FORMAT", "text"),
 		BufferSize: getInt("MCP_BUFFER_SIZE", 10<<20), // 10MB
+		MaxInFlight: getInt("MCP_MAX_IN_FLIGHT", 4),
+		ResourceTTL: getDuration("MCP_RESOURCE_TTL", time.Hour),
+		PromptsDir: getEnv("MCP_PROMPTS_DIR", ""),
+		ToolsPageSize: getInt("MCP_TOOLS_PAGE_SIZE", 0),
 		ProtocolVersions: getEnv("MCP_PROTOCOL_VERSIONS", "2025-11-25,2025-06-18,2025-03-26"),
 		PDF: PDFConfig{
 			ImageQuality: getInt("PDF_IMAGE_QUALITY", 75),
 			RemoveMetadata: getBool("PDF_REMOVE_METADATA", true),
 			ValidationMode: getEnv("PDF_VALIDATION_MODE", "relaxed"),
 			TempDir: getEnv("PDF_TEMP_DIR", ""),
+			TrustStoreDir: getEnv("PDF_TRUST_STORE_DIR", ""),
+			SignPKCS12Path: getEnv("PDF_SIGN_PKCS12", ""),
+			SignCertPath: getEnv("PDF_SIGN_CERT", ""),
+			SignKeyPath: getEnv("PDF_SIGN_KEY", ""),
+			SignKeyPassword: getEnv("PDF_SIGN_KEY_PASSWORD", ""),
 		},
 	}
 }
@@ -111,6 +147,11 @@ func NewCLIConfig() *CLIConfig {
 			RemoveMetadata: getBool("PDF_REMOVE_METADATA", true),
 			ValidationMode: getEnv("PDF_VALIDATION_MODE", "relaxed"),
 			TempDir: getEnv("PDF_TEMP_DIR", ""),
+			TrustStoreDir: getEnv("PDF_TRUST_STORE_DIR", ""),
+			SignPKCS12Path: getEnv("PDF_SIGN_PKCS12", ""),
+			SignCertPath: getEnv("PDF_SIGN_CERT", ""),
+			SignKeyPath: getEnv("PDF_SIGN_KEY", ""),
+			SignKeyPassword: getEnv("PDF_SIGN_KEY_PASSWORD", ""),
 		},
 	}
 }
@@ -46,11 +46,8 @@ func (e *PdfError) Is(target error) bool {
 
 // IsValidationError verifica si el error es de validación.
 func IsValidationError(err error) bool {
-	var target error
-	if errors.As(err, &target) {
-		return target.Error() == "validation error"
-	}
-	return false
+	var target *ValidationError
+	return errors.As(err, &target)
 }
 
 // ValidationError representa un error de validación de entrada.
@@ -0,0 +1,263 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+const (
+	// defaultBatchFilenameTemplate es el nombre de salida si no se indica otro.
+	defaultBatchFilenameTemplate = "{name}-{op}.pdf"
+	// defaultBatchWorkers y maxBatchWorkers acotan los archivos en proceso a la vez.
+	defaultBatchWorkers = 4
+	maxBatchWorkers     = 16
+)
+
+// ResolveBatchInputs combina los archivos que coinciden con inputGlob (sintaxis
+// de filepath.Match, sin "**") con inputPaths. Se ignoran los directorios y
+// las rutas repetidas; los archivos del patrón se ordenan por nombre.
+func ResolveBatchInputs(inputGlob string, inputPaths []string) ([]string, error) {
+	var inputs []string
+	seen := map[string]bool{}
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			inputs = append(inputs, path)
+		}
+	}
+
+	for _, path := range inputPaths {
+		if strings.TrimSpace(path) != "" {
+			add(path)
+		}
+	}
+	if strings.TrimSpace(inputGlob) != "" {
+		matches, err := filepath.Glob(inputGlob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input_glob %q: %w", inputGlob, err)
+		}
+		sort.Strings(matches)
+		found := 0
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && !info.IsDir() {
+				add(m)
+				found++
+			}
+		}
+		if found == 0 {
+			return nil, fmt.Errorf("input_glob %q matches no files", inputGlob)
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no input files: set input_glob or input_paths")
+	}
+	return inputs, nil
+}
+
+// Batch aplica una operación de un solo archivo (las mismas que admite
+// RunPipeline) a cada entrada con un número acotado de workers. Cada archivo
+// se procesa de forma independiente: un fallo se anota en su resultado y no
+// detiene los demás. Las salidas se nombran con opts.FilenameTemplate dentro
+// de opts.OutputDir; los nombres repetidos o que coinciden con una entrada se
+// rechazan antes de procesar nada. Si ctx se cancela no se inician más
+// archivos: los pendientes se marcan como fallidos, los que estaban en curso
+// eliminan su salida parcial y se devuelve el informe junto con el error. El
+// avance se cuenta por archivo terminado, con éxito o no.
+func (p *Processor) Batch(ctx context.Context, inputPaths []string, step types.PipelineStep, opts types.BatchOptions) (*types.BatchResult, error) {
+	p.logger.Debug("running batch operation",
+		slog.String("operation", step.Operation),
+		slog.Int("inputs", len(inputPaths)),
+		slog.String("output_dir", opts.OutputDir))
+
+	if len(inputPaths) == 0 {
+		return nil, fmt.Errorf("batch needs at least one input file")
+	}
+	op, ok := pipelineOperations[step.Operation]
+	if !ok || op.inputs != 1 {
+		return nil, fmt.Errorf("unsupported batch operation %q (supported: %s)", step.Operation, strings.Join(BatchOperations(), ", "))
+	}
+	run, err := op.prepare(step.Args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid args for %s: %w", step.Operation, err)
+	}
+
+	outputs := make([]string, len(inputPaths))
+	if op.writes {
+		if outputs, err = batchOutputPaths(inputPaths, step.Operation, opts); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(opts.OutputDir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+	workers = min(workers, maxBatchWorkers, len(inputPaths))
+
+	start := time.Now()
+	result := &types.BatchResult{
+		Operation: step.Operation,
+		Total:     len(inputPaths),
+		Files:     make([]types.BatchFileResult, len(inputPaths)),
+	}
+
+	progress := newProgressCounter(ctx, len(inputPaths))
+	fileCtx := withoutProgress(ctx)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result.Files[i] = p.runBatchFile(fileCtx, run, inputPaths[i], outputs[i])
+				progress.step()
+			}
+		}()
+	}
+	dispatched := 0
+dispatch:
+	for dispatched < len(inputPaths) {
+		select {
+		case jobs <- dispatched:
+			dispatched++
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	progress.finish()
+	for i := dispatched; i < len(inputPaths); i++ {
+		result.Files[i] = types.BatchFileResult{
+			InputPath: inputPaths[i],
+			Status:    types.StepFailed,
+			Error:     checkCancelled(ctx).Error(),
+		}
+	}
+
+	for _, f := range result.Files {
+		if f.Status == types.StepOK {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	p.logger.Debug("batch operation complete",
+		slog.String("operation", step.Operation),
+		slog.Int("succeeded", result.Succeeded),
+		slog.Int("failed", result.Failed))
+
+	if err := checkCancelled(ctx); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// BatchOperations devuelve las operaciones admitidas por Batch, ordenadas.
+func BatchOperations() []string {
+	var names []string
+	for _, name := range PipelineOperations() {
+		if pipelineOperations[name].inputs == 1 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// runBatchFile procesa un archivo del lote. Si la operación falla, se borra la
+// salida parcial salvo que ya existiera antes.
+func (p *Processor) runBatchFile(ctx context.Context, run pipelineRun, input, output string) types.BatchFileResult {
+	fr := types.BatchFileResult{InputPath: input, OutputPath: output}
+	existed := false
+	if output != "" {
+		_, err := os.Stat(output)
+		existed = err == nil
+	}
+
+	start := time.Now()
+	res, err := run(ctx, p, []string{input}, output)
+	fr.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		p.logger.Warn("batch file failed",
+			slog.String("input", input),
+			slog.Any("error", err))
+		if output != "" && !existed {
+			os.Remove(output)
+		}
+		fr.Status = types.StepFailed
+		fr.Error = err.Error()
+		fr.OutputPath = ""
+		return fr
+	}
+	fr.Status = types.StepOK
+	fr.Result = res
+	return fr
+}
+
+// batchOutputPaths aplica la plantilla de nombres a cada entrada.
+func batchOutputPaths(inputs []string, operation string, opts types.BatchOptions) ([]string, error) {
+	if strings.TrimSpace(opts.OutputDir) == "" {
+		return nil, fmt.Errorf("output_dir is required for %s", operation)
+	}
+	tmpl := opts.FilenameTemplate
+	if tmpl == "" {
+		tmpl = defaultBatchFilenameTemplate
+	}
+	if strings.ContainsAny(tmpl, `/\`) {
+		return nil, fmt.Errorf("invalid filename template %q: must be a file name, not a path", tmpl)
+	}
+
+	inputSet := map[string]bool{}
+	for _, in := range inputs {
+		if abs, err := filepath.Abs(in); err == nil {
+			inputSet[abs] = true
+		}
+	}
+
+	outputs := make([]string, len(inputs))
+	byOutput := map[string]string{}
+	for i, in := range inputs {
+		base := filepath.Base(in)
+		ext := filepath.Ext(base)
+		name := strings.NewReplacer(
+			"{name}", strings.TrimSuffix(base, ext),
+			"{ext}", ext,
+			"{index}", strconv.Itoa(i+1),
+			"{op}", operation,
+		).Replace(tmpl)
+		if name == "" || name == "." || name == ".." {
+			return nil, fmt.Errorf("invalid filename template %q", tmpl)
+		}
+
+		out := filepath.Join(opts.OutputDir, name)
+		abs, err := filepath.Abs(out)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output path %q: %w", out, err)
+		}
+		if prev, ok := byOutput[abs]; ok {
+			return nil, fmt.Errorf("inputs %q and %q map to the same output %q; add {index} to the filename template", prev, in, out)
+		}
+		if inputSet[abs] {
+			return nil, fmt.Errorf("output %q would overwrite an input file", out)
+		}
+		byOutput[abs] = in
+		outputs[i] = out
+	}
+	return outputs, nil
+}
@@ -0,0 +1,117 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestBatch(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	if err := os.MkdirAll(in, 0700); err != nil {
+		t.Fatal(err)
+	}
+	p := newTestProcessor()
+
+	writeTestPDF(t, in, "a.pdf", []string{"a1", "a2"})
+	writeTestPDF(t, in, "b.pdf", []string{"b1"})
+	writeTestPDF(t, in, "c.pdf", []string{"c1", "c2", "c3"})
+	if err := os.WriteFile(filepath.Join(in, "broken.pdf"), []byte("not a pdf"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	inputs, err := ResolveBatchInputs(filepath.Join(in, "*.pdf"), nil)
+	if err != nil {
+		t.Fatalf("ResolveBatchInputs failed: %v", err)
+	}
+	if len(inputs) != 4 || filepath.Base(inputs[0]) != "a.pdf" {
+		t.Fatalf("inputs = %v", inputs)
+	}
+
+	t.Run("failures do not stop the batch", func(t *testing.T) {
+		out := filepath.Join(dir, "rotated")
+		result, err := p.Batch(context.Background(), inputs, types.PipelineStep{Operation: "rotate", Args: json.RawMessage(`{"degrees":90,"pages":"1"}`)},
+			types.BatchOptions{OutputDir: out, FilenameTemplate: "{index}-{name}-r{ext}", Workers: 2})
+		if err != nil {
+			t.Fatalf("Batch failed: %v", err)
+		}
+		if result.Total != 4 || result.Succeeded != 3 || result.Failed != 1 {
+			t.Fatalf("result = %+v", result)
+		}
+		broken := result.Files[2]
+		if filepath.Base(broken.InputPath) != "broken.pdf" || broken.Status != types.StepFailed || broken.Error == "" {
+			t.Errorf("broken file result = %+v", broken)
+		}
+		if _, err := os.Stat(filepath.Join(out, "3-broken-r.pdf")); !os.IsNotExist(err) {
+			t.Errorf("partial output left for a failed file: %v", err)
+		}
+
+		c := result.Files[3]
+		if c.Status != types.StepOK || c.OutputPath != filepath.Join(out, "4-c-r.pdf") {
+			t.Fatalf("c.pdf result = %+v", c)
+		}
+		ctx, err := api.ReadContextFile(c.OutputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for page, want := range map[int]int{1: 90, 2: 0} {
+			d, _, _, err := ctx.PageDict(page, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := 0
+			if r := d.IntEntry("Rotate"); r != nil {
+				got = *r
+			}
+			if got != want {
+				t.Errorf("page %d rotation = %d, want %d", page, got, want)
+			}
+		}
+	})
+
+	t.Run("report only operation", func(t *testing.T) {
+		result, err := p.Batch(context.Background(), inputs[:1], types.PipelineStep{Operation: "info"}, types.BatchOptions{})
+		if err != nil {
+			t.Fatalf("Batch failed: %v", err)
+		}
+		info, ok := result.Files[0].Result.(*types.PDFInfoResult)
+		if !ok || info.TotalPages != 2 || result.Files[0].OutputPath != "" {
+			t.Errorf("result = %+v", result.Files[0])
+		}
+	})
+
+	t.Run("rejected before processing", func(t *testing.T) {
+		tests := []struct {
+			name string
+			step types.PipelineStep
+			opts types.BatchOptions
+			want string
+		}{
+			{"multi-input operation", types.PipelineStep{Operation: "merge"}, types.BatchOptions{OutputDir: dir}, "unsupported batch operation"},
+			{"missing output dir", types.PipelineStep{Operation: "compress"}, types.BatchOptions{}, "output_dir is required"},
+			{"colliding names", types.PipelineStep{Operation: "compress"}, types.BatchOptions{OutputDir: dir, FilenameTemplate: "out.pdf"}, "map to the same output"},
+			{"overwrites input", types.PipelineStep{Operation: "compress"}, types.BatchOptions{OutputDir: in, FilenameTemplate: "{name}{ext}"}, "would overwrite an input"},
+			{"bad args", types.PipelineStep{Operation: "rotate", Args: json.RawMessage(`{"degrees":45}`)}, types.BatchOptions{OutputDir: dir}, "multiple of 90"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := p.Batch(context.Background(), inputs, tt.step, tt.opts)
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Fatalf("error = %v, want %q", err, tt.want)
+				}
+			})
+		}
+	})
+
+	if _, err := ResolveBatchInputs(filepath.Join(dir, "*.docx"), nil); err == nil {
+		t.Error("expected error for a glob without matches")
+	}
+}
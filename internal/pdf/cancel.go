@@ -0,0 +1,53 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// checkCancelled devuelve un error si ctx se ha cancelado o ha vencido. Las
+// operaciones lo comprueban entre páginas y antes de cada llamada costosa a
+// pdfcpu, que no se puede interrumpir una vez iniciada. El error envuelve
+// ctx.Err(), de modo que errors.Is(err, context.Canceled) funciona.
+func checkCancelled(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("operation cancelled: %w", err)
+	}
+	return nil
+}
+
+// outputGuard recuerda el estado de un archivo de salida antes de una
+// operación para poder eliminar lo que haya escrito si se cancela.
+type outputGuard struct {
+	ctx     context.Context
+	path    string
+	before  os.FileInfo
+	existed bool
+}
+
+// guardOutput registra el estado actual de path.
+func guardOutput(ctx context.Context, path string) *outputGuard {
+	g := &outputGuard{ctx: ctx, path: path}
+	if info, err := os.Stat(path); err == nil {
+		g.before, g.existed = info, true
+	}
+	return g
+}
+
+// cleanup elimina la salida parcial si la operación terminó con error por
+// cancelación y llegó a crear o modificar el archivo. Se usa con defer y el
+// error con nombre de la operación.
+func (g *outputGuard) cleanup(err *error) {
+	if *err == nil || g.ctx.Err() == nil || g.path == "" {
+		return
+	}
+	info, statErr := os.Stat(g.path)
+	if statErr != nil {
+		return
+	}
+	if g.existed && info.Size() == g.before.Size() && info.ModTime().Equal(g.before.ModTime()) {
+		return
+	}
+	_ = os.Remove(g.path)
+}
@@ -0,0 +1,94 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestCancellation(t *testing.T) {
+	dir := t.TempDir()
+	p := newTestProcessor()
+	p.config.TempDir = filepath.Join(dir, "tmp")
+	if err := os.MkdirAll(p.config.TempDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	a := writeTestPDF(t, dir, "a.pdf", []string{"a1", "a2"})
+	b := writeTestPDF(t, dir, "b.pdf", []string{"b1"})
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("operations stop before writing", func(t *testing.T) {
+		out := filepath.Join(dir, "out.pdf")
+		ops := map[string]func() error{
+			"compress": func() error {
+				_, err := p.Compress(cancelled, a, out, types.CompressOptions{})
+				return err
+			},
+			"merge": func() error {
+				_, err := p.Merge(cancelled, []string{a, b}, out, types.MergeOptions{})
+				return err
+			},
+			"search": func() error {
+				_, err := p.Search(cancelled, a, "a1", types.SearchOptions{})
+				return err
+			},
+			"pipeline": func() error {
+				_, err := p.RunPipeline(cancelled, []string{a}, out, []types.PipelineStep{{Operation: "linearize"}})
+				return err
+			},
+		}
+		for name, run := range ops {
+			if err := run(); !errors.Is(err, context.Canceled) {
+				t.Errorf("%s: error = %v, want context.Canceled", name, err)
+			}
+			if _, err := os.Stat(out); !os.IsNotExist(err) {
+				t.Errorf("%s: output written after cancellation", name)
+			}
+		}
+		entries, err := os.ReadDir(p.config.TempDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("temp files left behind: %v", entries)
+		}
+	})
+
+	t.Run("batch marks pending files", func(t *testing.T) {
+		result, err := p.Batch(cancelled, []string{a, b}, types.PipelineStep{Operation: "info"}, types.BatchOptions{})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("error = %v, want context.Canceled", err)
+		}
+		if result == nil || result.Failed != 2 || result.Files[1].Status != types.StepFailed {
+			t.Errorf("result = %+v", result)
+		}
+	})
+
+	t.Run("partial output is removed", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		partial := filepath.Join(dir, "partial.pdf")
+		g := guardOutput(ctx, partial)
+		if err := os.WriteFile(partial, []byte("%PDF-1.7\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		err := checkCancelled(ctx)
+		g.cleanup(&err)
+		if _, err := os.Stat(partial); !os.IsNotExist(err) {
+			t.Error("partial output was not removed")
+		}
+
+		// Un archivo previo que la operación no llegó a tocar se conserva.
+		g = guardOutput(ctx, a)
+		g.cleanup(&err)
+		if _, err := os.Stat(a); err != nil {
+			t.Errorf("untouched existing file removed: %v", err)
+		}
+	})
+}
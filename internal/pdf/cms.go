@@ -0,0 +1,131 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"sort"
+)
+
+// Identificadores CMS (RFC 5652) y ESS (RFC 5035) usados al firmar.
+var (
+	oidCMSData              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidCMSSignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidCMSContentType       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidCMSMessageDigest     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidSHA256               = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// buildCAdES genera una firma CMS separada (detached) con el perfil CAdES que
+// exige PAdES-B-B: atributos firmados content-type, message-digest (SHA-256) y
+// signing-certificate-v2, sin signing-time (la hora va en /M). chain empieza por
+// el certificado del firmante.
+func buildCAdES(signer crypto.Signer, chain []*x509.Certificate, data []byte) ([]byte, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("missing signer certificate")
+	}
+	cert := chain[0]
+
+	var sigAlg []byte
+	switch signer.Public().(type) {
+	case *rsa.PublicKey:
+		sigAlg = derSequence(derMarshal(oidRSAEncryption), asn1.NullBytes)
+	case *ecdsa.PublicKey:
+		sigAlg = derSequence(derMarshal(oidECDSAWithSHA256))
+	default:
+		return nil, fmt.Errorf("unsupported key type %T (use RSA or ECDSA)", signer.Public())
+	}
+
+	digest := sha256.Sum256(data)
+	certHash := sha256.Sum256(cert.Raw)
+	issuerSerial := derSequence(
+		derSequence(derContext(4, cert.RawIssuer)),
+		derMarshal(cert.SerialNumber),
+	)
+	attrs := derSet(
+		derAttribute(oidCMSContentType, derMarshal(oidCMSData)),
+		derAttribute(oidCMSMessageDigest, derMarshal(digest[:])),
+		derAttribute(oidSigningCertificateV2, derSequence(derSequence(derSequence(
+			derMarshal(certHash[:]), issuerSerial)))),
+	)
+
+	// La firma se calcula sobre el SET de atributos; en SignerInfo va como [0] IMPLICIT.
+	attrsDigest := sha256.Sum256(attrs)
+	signature, err := signer.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+
+	var certs []byte
+	for _, c := range chain {
+		certs = append(certs, c.Raw...)
+	}
+
+	digestAlg := derSequence(derMarshal(oidSHA256))
+	signerInfo := derSequence(
+		derMarshal(1),
+		derSequence(cert.RawIssuer, derMarshal(cert.SerialNumber)),
+		digestAlg,
+		derContext(0, derContents(attrs)),
+		sigAlg,
+		derMarshal(signature),
+	)
+	signedData := derSequence(
+		derMarshal(1),
+		derSet(digestAlg),
+		derSequence(derMarshal(oidCMSData)),
+		derContext(0, certs),
+		derSet(signerInfo),
+	)
+	return derSequence(
+		derMarshal(oidCMSSignedData),
+		derContext(0, signedData),
+	), nil
+}
+
+// derMarshal codifica un valor simple; los tipos usados nunca fallan.
+func derMarshal(v interface{}) []byte {
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func derSequence(parts ...[]byte) []byte {
+	return derMarshal(asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: bytes.Join(parts, nil)})
+}
+
+// derSet codifica un SET OF con los elementos ordenados, como exige DER.
+func derSet(parts ...[]byte) []byte {
+	sorted := append([][]byte{}, parts...)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+	return derMarshal(asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: bytes.Join(sorted, nil)})
+}
+
+// derContext codifica un elemento construido con etiqueta de contexto [tag].
+func derContext(tag int, content []byte) []byte {
+	return derMarshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: true, Bytes: content})
+}
+
+func derAttribute(oid asn1.ObjectIdentifier, value []byte) []byte {
+	return derSequence(derMarshal(oid), derSet(value))
+}
+
+// derContents devuelve el contenido de un elemento DER sin su cabecera.
+func derContents(der []byte) []byte {
+	var raw asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &raw); err != nil {
+		panic(err)
+	}
+	return raw.Bytes
+}
@@ -0,0 +1,140 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// Collate intercala las páginas de dos PDFs escaneados por separado (anversos y reversos).
+// Si reverseB es true, las páginas de b se toman en orden inverso, como ocurre al
+// escanear a una cara dando la vuelta al taco de hojas.
+func (p *Processor) Collate(ctx context.Context, inputA, inputB string, reverseB bool, outputPath string) (_ *types.CollateResult, err error) {
+	p.logger.Debug("collating PDFs",
+		slog.String("input_a", inputA),
+		slog.String("input_b", inputB),
+		slog.Bool("reverse_b", reverseB),
+		slog.String("output", outputPath))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(inputA); err != nil {
+		return nil, fmt.Errorf("input file validation failed: %w", err)
+	}
+	if err := p.ValidateFile(inputB); err != nil {
+		return nil, fmt.Errorf("input file validation failed: %w", err)
+	}
+
+	pagesA, err := api.PageCountFile(inputA)
+	if err != nil {
+		p.logger.Error("failed to count pages", err)
+		return nil, fmt.Errorf("failed to count pages of %s: %w", inputA, err)
+	}
+	pagesB, err := api.PageCountFile(inputB)
+	if err != nil {
+		p.logger.Error("failed to count pages", err)
+		return nil, fmt.Errorf("failed to count pages of %s: %w", inputB, err)
+	}
+
+	// Asegurar directorio de salida
+	if err := ensureOutputDir(outputPath); err != nil {
+		p.logger.Error("failed to create output directory", err)
+		return nil, err
+	}
+
+	// pdfcpu no intercala directamente: se concatena a+b en un temporal y
+	// después se recolectan las páginas en el orden intercalado.
+	tmpDir, err := os.MkdirTemp(p.config.TempDir, "pdf-collate-")
+	if err != nil {
+		p.logger.Error("failed to create temp directory", err)
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	combined := filepath.Join(tmpDir, "combined.pdf")
+	conf := p.newConfiguration()
+	if err := api.MergeCreateFile([]string{inputA, inputB}, combined, false, conf); err != nil {
+		p.logger.Error("PDF merge failed", err)
+		return nil, fmt.Errorf("merge failed: %w", err)
+	}
+
+	order := collateOrder(pagesA, pagesB, reverseB)
+	selection := make([]string, len(order))
+	for i, n := range order {
+		selection[i] = strconv.Itoa(n)
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	if err := api.CollectFile(combined, outputPath, selection, p.newConfiguration()); err != nil {
+		p.logger.Error("PDF collate failed", err)
+		return nil, fmt.Errorf("collate failed: %w", err)
+	}
+
+	resultInfo, err := os.Stat(outputPath)
+	if err != nil {
+		p.logger.Error("failed to stat output file", err)
+		return nil, fmt.Errorf("failed to stat output file: %w", err)
+	}
+
+	result := &types.CollateResult{
+		OutputPath:    outputPath,
+		PagesA:        pagesA,
+		PagesB:        pagesB,
+		TotalPages:    len(order),
+		ReverseB:      reverseB,
+		CountMismatch: pagesA != pagesB,
+		OutputSize:    resultInfo.Size(),
+	}
+	if result.CountMismatch {
+		result.Warning = fmt.Sprintf("page count mismatch: %d pages in first input, %d in second; unmatched pages appended at the end", pagesA, pagesB)
+		p.logger.Warn("collate page count mismatch",
+			slog.Int("pages_a", pagesA),
+			slog.Int("pages_b", pagesB))
+	}
+
+	p.logger.Debug("PDF collate complete",
+		slog.Int("total_pages", result.TotalPages),
+		slog.Int64("output_size", result.OutputSize))
+
+	return result, nil
+}
+
+// collateOrder calcula el orden de páginas sobre el documento concatenado a+b
+// (páginas 1..pagesA de a, pagesA+1..pagesA+pagesB de b).
+// e.g. collateOrder(3, 3, true) -> [1,6,2,5,3,4]
+// Si los recuentos no coinciden, las páginas sobrantes se añaden al final.
+func collateOrder(pagesA, pagesB int, reverseB bool) []int {
+	order := make([]int, 0, pagesA+pagesB)
+	n := pagesA
+	if pagesB > n {
+		n = pagesB
+	}
+
+	for i := 0; i < n; i++ {
+		if i < pagesA {
+			order = append(order, i+1)
+		}
+		if i < pagesB {
+			if reverseB {
+				order = append(order, pagesA+pagesB-i)
+			} else {
+				order = append(order, pagesA+i+1)
+			}
+		}
+	}
+	return order
+}
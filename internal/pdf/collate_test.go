@@ -0,0 +1,88 @@
+package pdf
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestCollateOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		pagesA   int
+		pagesB   int
+		reverseB bool
+		want     []int
+	}{
+		{
+			name:     "reversed backs",
+			pagesA:   3,
+			pagesB:   3,
+			reverseB: true,
+			want:     []int{1, 6, 2, 5, 3, 4},
+		},
+		{
+			name:   "backs in order",
+			pagesA: 3,
+			pagesB: 3,
+			want:   []int{1, 4, 2, 5, 3, 6},
+		},
+		{
+			name:   "more fronts than backs",
+			pagesA: 3,
+			pagesB: 2,
+			want:   []int{1, 4, 2, 5, 3},
+		},
+		{
+			name:     "more backs than fronts reversed",
+			pagesA:   1,
+			pagesB:   3,
+			reverseB: true,
+			want:     []int{1, 4, 3, 2},
+		},
+		{
+			name:   "empty",
+			pagesA: 0,
+			pagesB: 0,
+			want:   []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := collateOrder(tt.pagesA, tt.pagesB, tt.reverseB)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collateOrder(%d, %d, %v) = %v, want %v", tt.pagesA, tt.pagesB, tt.reverseB, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollateIntegration(t *testing.T) {
+	dir := t.TempDir()
+	fronts := writeTestPDF(t, dir, "fronts.pdf", []string{"front 1", "front 2", "front 3"})
+	backs := writeTestPDF(t, dir, "backs.pdf", []string{"back 3", "back 2"})
+	out := dir + "/collated.pdf"
+
+	result, err := newTestProcessor().Collate(context.Background(), fronts, backs, true, out)
+	if err != nil {
+		t.Fatalf("Collate failed: %v", err)
+	}
+
+	if result.TotalPages != 5 {
+		t.Errorf("TotalPages = %d, want 5", result.TotalPages)
+	}
+	if !result.CountMismatch || result.Warning == "" {
+		t.Errorf("expected page count mismatch to be reported, got %+v", result)
+	}
+
+	n, err := api.PageCountFile(out)
+	if err != nil {
+		t.Fatalf("PageCountFile failed: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("output has %d pages, want 5", n)
+	}
+}
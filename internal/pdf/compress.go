@@ -1,10 +1,12 @@
 package pdf
 
 import (
+	"context"
 	"path/filepath"
 
 	"github.com/scopweb/mcp-go-pdf-tools/internal/config"
 	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
 )
 
 // CompressOptions holds configuration for PDF compression
@@ -35,7 +37,7 @@ func CompressPDFFile(inputPath string, outputPath string, opts CompressOptions)
 	}
 	processor := NewProcessor(cfg, logging.New("info"))
 
-	result, err := processor.Compress(inputPath, outputPath)
+	result, err := processor.Compress(context.Background(), inputPath, outputPath, types.CompressOptions{})
 	if err != nil {
 		return nil, err
 	}
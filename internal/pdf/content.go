@@ -0,0 +1,407 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// operandKind identifica el tipo de un operando en un content stream.
+type operandKind int
+
+const (
+	operandNumber operandKind = iota
+	operandString
+	operandName
+	operandArray
+	operandDict
+	operandBool
+	operandNull
+)
+
+// operand es un operando de un operador de content stream.
+// Solo se interpretan los tipos necesarios para texto, imágenes y CMaps;
+// los diccionarios se conservan como bytes sin interpretar.
+type operand struct {
+	kind operandKind
+	num  float64
+	str  []byte // string decodificado (literal o hex)
+	hex  bool   // el string venía en notación hexadecimal
+	name string
+	arr  []operand
+	raw  []byte // bytes originales (diccionarios)
+}
+
+// contentOp es un operador con sus operandos y los bytes originales que ocupa en el stream.
+type contentOp struct {
+	op   string
+	args []operand
+	raw  []byte
+}
+
+// parseContent tokeniza un content stream PDF en una secuencia de operadores.
+// Las imágenes inline (BI ... ID ... EI) se devuelven como un único operador "BI"
+// cuyo raw incluye los datos binarios.
+func parseContent(data []byte) ([]contentOp, error) {
+	l := &contentLexer{data: data}
+	var ops []contentOp
+	var args []operand
+	start := -1
+
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			break
+		}
+		if start < 0 {
+			start = l.pos
+		}
+
+		o, keyword, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if keyword == "" {
+			args = append(args, o)
+			continue
+		}
+
+		switch keyword {
+		case "true", "false":
+			args = append(args, operand{kind: operandBool, num: boolNum(keyword == "true")})
+			continue
+		case "null":
+			args = append(args, operand{kind: operandNull})
+			continue
+		case "BI":
+			if err := l.skipInlineImage(); err != nil {
+				return nil, err
+			}
+		}
+
+		ops = append(ops, contentOp{op: keyword, args: args, raw: l.data[start:l.pos]})
+		args = nil
+		start = -1
+	}
+
+	if len(args) > 0 {
+		return nil, fmt.Errorf("content stream ends with %d dangling operands", len(args))
+	}
+	return ops, nil
+}
+
+func boolNum(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type contentLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *contentLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFSpace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		break
+	}
+}
+
+// next lee el siguiente token. Si es una palabra clave (operador, true/false/null)
+// se devuelve en keyword; en otro caso se devuelve el operando.
+func (l *contentLexer) next() (operand, string, error) {
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		s, err := l.readLiteralString()
+		return operand{kind: operandString, str: s}, "", err
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		raw, err := l.readDict()
+		return operand{kind: operandDict, raw: raw}, "", err
+	case c == '<':
+		s, err := l.readHexString()
+		return operand{kind: operandString, str: s, hex: true}, "", err
+	case c == '[':
+		l.pos++
+		var arr []operand
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return operand{}, "", fmt.Errorf("unterminated array")
+			}
+			if l.data[l.pos] == ']' {
+				l.pos++
+				return operand{kind: operandArray, arr: arr}, "", nil
+			}
+			o, kw, err := l.next()
+			if err != nil {
+				return operand{}, "", err
+			}
+			switch kw {
+			case "":
+				arr = append(arr, o)
+			case "true", "false":
+				arr = append(arr, operand{kind: operandBool, num: boolNum(kw == "true")})
+			case "null":
+				arr = append(arr, operand{kind: operandNull})
+			default:
+				return operand{}, "", fmt.Errorf("unexpected keyword %q inside array", kw)
+			}
+		}
+	case c == '/':
+		return operand{kind: operandName, name: l.readName()}, "", nil
+	case c == '+' || c == '-' || c == '.' || c >= '0' && c <= '9':
+		start := l.pos
+		l.pos++
+		for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		f, err := strconv.ParseFloat(string(l.data[start:l.pos]), 64)
+		if err != nil {
+			// Algunos generadores emiten números como "--5" o "5-"; se tratan como 0.
+			f = 0
+		}
+		return operand{kind: operandNumber, num: f}, "", nil
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return operand{}, "", fmt.Errorf("unexpected delimiter %q at offset %d", c, l.pos-1)
+	default:
+		start := l.pos
+		for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		return operand{}, string(l.data[start:l.pos]), nil
+	}
+}
+
+func (l *contentLexer) readLiteralString() ([]byte, error) {
+	l.pos++ // '('
+	var buf bytes.Buffer
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+			buf.WriteByte(c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return buf.Bytes(), nil
+			}
+			buf.WriteByte(c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				return nil, fmt.Errorf("unterminated string literal")
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				buf.WriteByte('\n')
+			case 'r':
+				buf.WriteByte('\r')
+			case 't':
+				buf.WriteByte('\t')
+			case 'b':
+				buf.WriteByte('\b')
+			case 'f':
+				buf.WriteByte('\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					buf.WriteByte(byte(v))
+				} else {
+					buf.WriteByte(e)
+				}
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return nil, fmt.Errorf("unterminated string literal")
+}
+
+func (l *contentLexer) readHexString() ([]byte, error) {
+	l.pos++ // '<'
+	var digits []byte
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			if len(digits)%2 == 1 {
+				digits = append(digits, '0')
+			}
+			out := make([]byte, len(digits)/2)
+			for i := range out {
+				out[i] = unhex(digits[2*i])<<4 | unhex(digits[2*i+1])
+			}
+			return out, nil
+		}
+		if isPDFSpace(c) {
+			continue
+		}
+		digits = append(digits, c)
+	}
+	return nil, fmt.Errorf("unterminated hex string")
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10
+	}
+	return 0
+}
+
+func (l *contentLexer) readName() string {
+	l.pos++ // '/'
+	var buf bytes.Buffer
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			buf.WriteByte(unhex(l.data[l.pos+1])<<4 | unhex(l.data[l.pos+2]))
+			l.pos += 3
+			continue
+		}
+		buf.WriteByte(c)
+		l.pos++
+	}
+	return buf.String()
+}
+
+// readDict salta un diccionario (posiblemente anidado) y devuelve sus bytes.
+func (l *contentLexer) readDict() ([]byte, error) {
+	start := l.pos
+	depth := 0
+	for l.pos < len(l.data) {
+		switch {
+		case bytes.HasPrefix(l.data[l.pos:], []byte("<<")):
+			depth++
+			l.pos += 2
+		case bytes.HasPrefix(l.data[l.pos:], []byte(">>")):
+			depth--
+			l.pos += 2
+			if depth == 0 {
+				return l.data[start:l.pos], nil
+			}
+		case l.data[l.pos] == '(':
+			if _, err := l.readLiteralString(); err != nil {
+				return nil, err
+			}
+		default:
+			l.pos++
+		}
+	}
+	return nil, fmt.Errorf("unterminated dictionary")
+}
+
+// skipInlineImage avanza hasta después del EI que cierra una imagen inline.
+func (l *contentLexer) skipInlineImage() error {
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return fmt.Errorf("unterminated inline image")
+		}
+		_, kw, err := l.next()
+		if err != nil {
+			return err
+		}
+		if kw == "ID" {
+			break
+		}
+	}
+	// Un único espacio separa ID de los datos.
+	l.pos++
+	for i := l.pos; i+2 <= len(l.data); i++ {
+		if l.data[i] != 'E' || l.data[i+1] != 'I' {
+			continue
+		}
+		if i > 0 && !isPDFSpace(l.data[i-1]) {
+			continue
+		}
+		if i+2 < len(l.data) && !isPDFSpace(l.data[i+2]) && !isPDFDelimiter(l.data[i+2]) {
+			continue
+		}
+		l.pos = i + 2
+		return nil
+	}
+	return fmt.Errorf("inline image without EI")
+}
+
+// num devuelve el i-ésimo operando numérico o 0.
+func (op contentOp) num(i int) float64 {
+	if i < len(op.args) && op.args[i].kind == operandNumber {
+		return op.args[i].num
+	}
+	return 0
+}
+
+// writePDFString serializa un string PDF en notación literal o hex.
+func writePDFString(buf *bytes.Buffer, s []byte, hex bool) {
+	if hex {
+		fmt.Fprintf(buf, "<%X>", s)
+		return
+	}
+	buf.WriteByte('(')
+	for _, c := range s {
+		switch c {
+		case '(', ')', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\n':
+			buf.WriteString(`\n`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte(')')
+}
+
+// formatNumber formatea un número para un content stream sin notación exponencial.
+func formatNumber(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 4, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
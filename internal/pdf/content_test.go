@@ -0,0 +1,72 @@
+package pdf
+
+import (
+	"testing"
+)
+
+func TestParseContent(t *testing.T) {
+	data := []byte("q 1 0 0 1 72 720 cm BT /F1 12 Tf (a\\(b\\)\\101) Tj [<0041> -250 (c)] TJ ET % comment\nBI /W 1 /H 1 ID \x00\xff EI Q")
+
+	ops, err := parseContent(data)
+	if err != nil {
+		t.Fatalf("parseContent failed: %v", err)
+	}
+
+	var names []string
+	for _, op := range ops {
+		names = append(names, op.op)
+	}
+	want := []string{"q", "cm", "BT", "Tf", "Tj", "TJ", "ET", "BI", "Q"}
+	if len(names) != len(want) {
+		t.Fatalf("operators = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("operators = %v, want %v", names, want)
+		}
+	}
+
+	if got := string(ops[4].args[0].str); got != "a(b)A" {
+		t.Errorf("literal string = %q, want %q", got, "a(b)A")
+	}
+	tj := ops[5].args[0]
+	if tj.kind != operandArray || len(tj.arr) != 3 || !tj.arr[0].hex || tj.arr[1].num != -250 {
+		t.Errorf("unexpected TJ array: %+v", tj)
+	}
+	if ops[1].num(5) != 720 {
+		t.Errorf("cm f = %v, want 720", ops[1].num(5))
+	}
+}
+
+func TestParseContentErrors(t *testing.T) {
+	tests := []string{
+		"(unterminated Tj",
+		"[1 2 Tj",
+		"1 2",
+		"BI /W 1 ID xx",
+	}
+	for _, tt := range tests {
+		if _, err := parseContent([]byte(tt)); err == nil {
+			t.Errorf("parseContent(%q) expected error", tt)
+		}
+	}
+}
+
+func TestParseToUnicodeCMap(t *testing.T) {
+	cmap := []byte(`begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar <0003> <0020> <0011> <00660069> endbfchar
+1 beginbfrange <0024> <0026> <0041> endbfrange
+endcmap`)
+
+	m, codeLen := parseToUnicodeCMap(cmap)
+	if codeLen != 2 {
+		t.Errorf("codeLen = %d, want 2", codeLen)
+	}
+	want := map[int]string{0x03: " ", 0x11: "fi", 0x24: "A", 0x25: "B", 0x26: "C"}
+	for code, s := range want {
+		if m[code] != s {
+			t.Errorf("code %#x = %q, want %q", code, m[code], s)
+		}
+	}
+}
@@ -0,0 +1,806 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// maxDiffCells limita la tabla de la subsecuencia común más larga. Por encima,
+// el bloque distinto se informa como eliminado y añadido sin alinear.
+const maxDiffCells = 1 << 20
+
+// maxRemovedNote es el número máximo de líneas eliminadas que se copian en la
+// nota de una página del PDF anotado.
+const maxRemovedNote = 20
+
+// diffPage es el texto y las anotaciones de una página preparados para comparar.
+type diffPage struct {
+	lines  []diffLine
+	key    string // texto normalizado de la página, para alinear páginas
+	annots []diffAnnot
+}
+
+type diffLine struct {
+	text  string
+	rects []rect
+}
+
+type diffAnnot struct {
+	subtype  string
+	contents string
+	rect     rect
+}
+
+func (a diffAnnot) key() string {
+	return fmt.Sprintf("%s|%s|%.0f %.0f %.0f %.0f", a.subtype, a.contents, a.rect.llx, a.rect.lly, a.rect.urx, a.rect.ury)
+}
+
+// attachmentInfo resume el contenido de un archivo adjunto.
+type attachmentInfo struct {
+	size int64
+	sum  string
+}
+
+// Diff compara dos versiones de un documento: número de páginas, texto de cada
+// página (diff por líneas), metadatos, valores de formulario, anotaciones y
+// adjuntos. Las páginas se alinean por su texto, de modo que insertar o quitar
+// una página no marca como distintas todas las siguientes.
+// Con opts.AnnotatedOutputPath escribe una copia de pathB con las líneas
+// añadidas resaltadas y una nota con las eliminadas en cada página modificada.
+func (p *Processor) Diff(ctx context.Context, pathA, pathB string, opts types.DiffOptions) (_ *types.DiffResult, err error) {
+	p.logger.Debug("comparing PDFs",
+		slog.String("a", pathA),
+		slog.String("b", pathB),
+		slog.String("annotated_output", opts.AnnotatedOutputPath))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(pathA); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(pathB); err != nil {
+		return nil, err
+	}
+
+	ctxA, err := p.readContext(pathA)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+	ctxB, err := p.readContext(pathB)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+
+	result := &types.DiffResult{
+		PathA:       pathA,
+		PathB:       pathB,
+		PagesA:      ctxA.PageCount,
+		PagesB:      ctxB.PageCount,
+		Pages:       []types.PageDiff{},
+		Annotations: []types.AnnotationChange{},
+	}
+
+	pagesA, warnings, err := loadDiffPages(ctx, ctxA.XRefTable, "a")
+	if err != nil {
+		return nil, err
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+	pagesB, warnings, err := loadDiffPages(ctx, ctxB.XRefTable, "b")
+	if err != nil {
+		return nil, err
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+	diffDocumentPages(pagesA, pagesB, result)
+	result.ChangedPages = len(result.Pages)
+
+	result.Metadata = diffValues(documentMetadata(ctxA.XRefTable), documentMetadata(ctxB.XRefTable))
+	result.FormFields = diffValues(formFieldValues(ctxA.XRefTable), formFieldValues(ctxB.XRefTable))
+
+	attA, warnings := documentAttachments(ctxA.XRefTable, "a")
+	result.Warnings = append(result.Warnings, warnings...)
+	attB, warnings := documentAttachments(ctxB.XRefTable, "b")
+	result.Warnings = append(result.Warnings, warnings...)
+	result.Attachments = diffAttachments(attA, attB)
+
+	result.Identical = result.PagesA == result.PagesB && len(result.Pages) == 0 && len(result.Metadata) == 0 &&
+		len(result.FormFields) == 0 && len(result.Annotations) == 0 && len(result.Attachments) == 0
+
+	if opts.AnnotatedOutputPath != "" {
+		n, err := annotateDiff(ctxB.XRefTable, result.Pages)
+		if err != nil {
+			p.logger.Error("failed to annotate differences", err)
+			return nil, fmt.Errorf("failed to annotate differences: %w", err)
+		}
+		if err := ensureOutputDir(opts.AnnotatedOutputPath); err != nil {
+			return nil, err
+		}
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+		defer guardOutput(ctx, opts.AnnotatedOutputPath).cleanup(&err)
+		if err := api.WriteContextFile(ctxB, opts.AnnotatedOutputPath); err != nil {
+			p.logger.Error("failed to write annotated PDF", err)
+			return nil, fmt.Errorf("failed to write annotated PDF: %w", err)
+		}
+		result.AnnotatedOutputPath = opts.AnnotatedOutputPath
+		result.Highlights = n
+	}
+
+	p.logger.Debug("PDF comparison complete",
+		slog.Bool("identical", result.Identical),
+		slog.Int("changed_pages", result.ChangedPages))
+
+	return result, nil
+}
+
+// loadDiffPages extrae el texto por líneas y las anotaciones de cada página.
+// Las páginas cuyo texto no se puede extraer se comparan como vacías. Solo
+// devuelve error si ctx se cancela.
+func loadDiffPages(ctx context.Context, xrt *model.XRefTable, label string) ([]diffPage, []string, error) {
+	var warnings []string
+	fonts := newFontCache(xrt)
+	pages := make([]diffPage, xrt.PageCount)
+
+	for pageNr := 1; pageNr <= xrt.PageCount; pageNr++ {
+		if err := checkCancelled(ctx); err != nil {
+			return nil, nil, err
+		}
+		page := &pages[pageNr-1]
+
+		pc, err := loadPageContent(xrt, pageNr, fonts)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s page %d: text could not be extracted: %v", label, pageNr, err))
+		} else {
+			text, spans := pageText(pc.glyphs)
+			keys := []string{}
+			start := 0
+			for _, segment := range strings.SplitAfter(text, "\n") {
+				end := start + len(segment)
+				if line := strings.Join(strings.Fields(segment), " "); line != "" {
+					page.lines = append(page.lines, diffLine{
+						text:  line,
+						rects: lineRects(pc.glyphs, glyphsInRange(spans, start, end)),
+					})
+					keys = append(keys, line)
+				}
+				start = end
+			}
+			page.key = strings.Join(keys, "\n")
+		}
+
+		d, _, _, err := xrt.PageDict(pageNr, false)
+		if err != nil || d == nil {
+			continue
+		}
+		annots, err := xrt.DereferenceArray(d["Annots"])
+		if err != nil {
+			continue
+		}
+		for _, o := range annots {
+			ad, err := xrt.DereferenceDict(o)
+			if err != nil || ad == nil {
+				continue
+			}
+			a := diffAnnot{}
+			if st := ad.Subtype(); st != nil {
+				a.subtype = *st
+			}
+			// Los widgets se comparan como valores de formulario y los popups
+			// dependen de su anotación padre.
+			if a.subtype == "Widget" || a.subtype == "Popup" {
+				continue
+			}
+			if s, err := xrt.DereferenceText(ad["Contents"]); err == nil {
+				a.contents = s
+			}
+			if arr, err := xrt.DereferenceArray(ad["Rect"]); err == nil && len(arr) == 4 {
+				if r, err := xrt.RectForArray(arr); err == nil {
+					a.rect = rect{llx: r.LL.X, lly: r.LL.Y, urx: r.UR.X, ury: r.UR.Y}.normalized()
+				}
+			}
+			page.annots = append(page.annots, a)
+		}
+	}
+	return pages, warnings, nil
+}
+
+// diffDocumentPages alinea las páginas por su texto y añade a result las
+// páginas añadidas, eliminadas o modificadas y las diferencias de anotaciones.
+// Entre dos páginas iguales, cada página distinta de a se empareja en orden con
+// la página de b con más líneas en común; las que quedan sin pareja se informan
+// como eliminadas o añadidas.
+func diffDocumentPages(pagesA, pagesB []diffPage, result *types.DiffResult) {
+	keysA := make([]string, len(pagesA))
+	for i, pg := range pagesA {
+		keysA[i] = pg.key
+	}
+	keysB := make([]string, len(pagesB))
+	for i, pg := range pagesB {
+		keysB[i] = pg.key
+	}
+
+	var removed, added []int
+	flush := func() {
+		pairs := map[int]int{} // página de b -> página de a
+		paired := map[int]bool{}
+		next := 0
+		for _, i := range removed {
+			best, bestCommon := -1, 0
+			for k := next; k < len(added); k++ {
+				if c := commonLines(pagesA[i], pagesB[added[k]]); c > bestCommon {
+					best, bestCommon = k, c
+				}
+			}
+			if best >= 0 {
+				pairs[added[best]] = i
+				paired[i] = true
+				next = best + 1
+			}
+		}
+
+		for _, i := range removed {
+			if !paired[i] {
+				result.Pages = append(result.Pages, diffPageText(pagesA[i], diffPage{}, i+1, 0))
+				result.Annotations = append(result.Annotations, diffAnnotations(pagesA[i].annots, nil, i+1, 0)...)
+			}
+		}
+		for _, j := range added {
+			if i, ok := pairs[j]; ok {
+				result.Pages = append(result.Pages, diffPageText(pagesA[i], pagesB[j], i+1, j+1))
+				result.Annotations = append(result.Annotations, diffAnnotations(pagesA[i].annots, pagesB[j].annots, i+1, j+1)...)
+				continue
+			}
+			result.Pages = append(result.Pages, diffPageText(diffPage{}, pagesB[j], 0, j+1))
+			result.Annotations = append(result.Annotations, diffAnnotations(nil, pagesB[j].annots, 0, j+1)...)
+		}
+		removed, added = removed[:0], added[:0]
+	}
+
+	for _, op := range diffSequences(keysA, keysB) {
+		switch op.kind {
+		case '-':
+			removed = append(removed, op.a)
+		case '+':
+			added = append(added, op.b)
+		default:
+			flush()
+			result.Annotations = append(result.Annotations, diffAnnotations(pagesA[op.a].annots, pagesB[op.b].annots, op.a+1, op.b+1)...)
+		}
+	}
+	flush()
+}
+
+// commonLines cuenta las líneas que comparten dos páginas.
+func commonLines(a, b diffPage) int {
+	counts := map[string]int{}
+	for _, l := range a.lines {
+		counts[l.text]++
+	}
+	n := 0
+	for _, l := range b.lines {
+		if counts[l.text] > 0 {
+			counts[l.text]--
+			n++
+		}
+	}
+	return n
+}
+
+// diffPageText compara las líneas de dos páginas. pageA o pageB es 0 cuando la
+// página solo existe en uno de los PDFs.
+func diffPageText(a, b diffPage, pageA, pageB int) types.PageDiff {
+	pd := types.PageDiff{PageA: pageA, PageB: pageB, Change: types.ChangeModified}
+	switch {
+	case pageA == 0:
+		pd.Change = types.ChangeAdded
+	case pageB == 0:
+		pd.Change = types.ChangeRemoved
+	}
+
+	textA := make([]string, len(a.lines))
+	for i, l := range a.lines {
+		textA[i] = l.text
+	}
+	textB := make([]string, len(b.lines))
+	for i, l := range b.lines {
+		textB[i] = l.text
+	}
+
+	for _, op := range diffSequences(textA, textB) {
+		switch op.kind {
+		case '-':
+			pd.LinesRemoved++
+			pd.Lines = append(pd.Lines, types.LineChange{Change: types.ChangeRemoved, LineA: op.a + 1, Text: textA[op.a]})
+		case '+':
+			pd.LinesAdded++
+			pd.Lines = append(pd.Lines, types.LineChange{
+				Change: types.ChangeAdded,
+				LineB:  op.b + 1,
+				Text:   textB[op.b],
+				Rects:  rectsToTypes(b.lines[op.b].rects),
+			})
+		}
+	}
+	return pd
+}
+
+// diffAnnotations compara las anotaciones de dos páginas como multiconjuntos
+// de (subtipo, contenido, rectángulo redondeado).
+func diffAnnotations(a, b []diffAnnot, pageA, pageB int) []types.AnnotationChange {
+	counts := map[string]int{}
+	for _, an := range b {
+		counts[an.key()]++
+	}
+	var out []types.AnnotationChange
+	for _, an := range a {
+		if k := an.key(); counts[k] > 0 {
+			counts[k]--
+			continue
+		}
+		out = append(out, types.AnnotationChange{
+			Change: types.ChangeRemoved, Page: pageA, Subtype: an.subtype, Contents: an.contents, Rect: an.rect.toTypes(),
+		})
+	}
+
+	counts = map[string]int{}
+	for _, an := range a {
+		counts[an.key()]++
+	}
+	for _, an := range b {
+		if k := an.key(); counts[k] > 0 {
+			counts[k]--
+			continue
+		}
+		out = append(out, types.AnnotationChange{
+			Change: types.ChangeAdded, Page: pageB, Subtype: an.subtype, Contents: an.contents, Rect: an.rect.toTypes(),
+		})
+	}
+	return out
+}
+
+// diffOp es un paso del diff: '=' (igual), '-' (solo en a) o '+' (solo en b).
+type diffOp struct {
+	kind byte
+	a, b int // índices en a y b; -1 si no aplica
+}
+
+// diffSequences calcula un diff mínimo entre dos secuencias con la
+// subsecuencia común más larga, tras descartar el prefijo y el sufijo comunes.
+func diffSequences(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	ops := make([]diffOp, 0, max(len(a), len(b)))
+	for i := 0; i < pre; i++ {
+		ops = append(ops, diffOp{'=', i, i})
+	}
+
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	n, m := len(ma), len(mb)
+	i, j := 0, 0
+	if n > 0 && m > 0 && n*m <= maxDiffCells {
+		// lcs[i][j] es la longitud de la subsecuencia común más larga de ma[i:] y mb[j:].
+		lcs := make([][]int32, n+1)
+		for k := range lcs {
+			lcs[k] = make([]int32, m+1)
+		}
+		for x := n - 1; x >= 0; x-- {
+			for y := m - 1; y >= 0; y-- {
+				if ma[x] == mb[y] {
+					lcs[x][y] = lcs[x+1][y+1] + 1
+				} else {
+					lcs[x][y] = max(lcs[x+1][y], lcs[x][y+1])
+				}
+			}
+		}
+		for i < n && j < m {
+			switch {
+			case ma[i] == mb[j]:
+				ops = append(ops, diffOp{'=', pre + i, pre + j})
+				i++
+				j++
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				ops = append(ops, diffOp{'-', pre + i, -1})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', -1, pre + j})
+				j++
+			}
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', pre + i, -1})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', -1, pre + j})
+	}
+
+	for k := 0; k < suf; k++ {
+		ops = append(ops, diffOp{'=', len(a) - suf + k, len(b) - suf + k})
+	}
+	return ops
+}
+
+// diffValues compara dos conjuntos de valores con nombre, ordenados por nombre.
+func diffValues(a, b map[string]string) []types.ValueChange {
+	names := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		names = append(names, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	out := []types.ValueChange{}
+	for _, name := range names {
+		before, inA := a[name]
+		after, inB := b[name]
+		switch {
+		case !inA:
+			out = append(out, types.ValueChange{Name: name, Change: types.ChangeAdded, After: after})
+		case !inB:
+			out = append(out, types.ValueChange{Name: name, Change: types.ChangeRemoved, Before: before})
+		case before != after:
+			out = append(out, types.ValueChange{Name: name, Change: types.ChangeModified, Before: before, After: after})
+		}
+	}
+	return out
+}
+
+// documentMetadata devuelve las entradas del diccionario Info y un resumen del
+// XMP del catálogo (tamaño y hash), que se compara como un único valor.
+func documentMetadata(xrt *model.XRefTable) map[string]string {
+	meta := map[string]string{}
+	if xrt.Info != nil {
+		if info, err := xrt.DereferenceDict(*xrt.Info); err == nil && info != nil {
+			for k, v := range info {
+				o, err := xrt.Dereference(v)
+				if err != nil || o == nil {
+					continue
+				}
+				if s, err := xrt.DereferenceText(o); err == nil {
+					meta[k] = s
+				} else {
+					meta[k] = o.String()
+				}
+			}
+		}
+	}
+
+	if root, err := xrt.Catalog(); err == nil && root != nil {
+		if sd, _, err := xrt.DereferenceStreamDict(root["Metadata"]); err == nil && sd != nil {
+			if err := sd.Decode(); err == nil {
+				sum := sha256.Sum256(sd.Content)
+				meta["XMP"] = fmt.Sprintf("%d bytes, sha256 %s", len(sd.Content), hex.EncodeToString(sum[:8]))
+			}
+		}
+	}
+	return meta
+}
+
+// formFieldValues devuelve el valor de cada campo terminal del AcroForm por su
+// nombre completo. Los widgets sin nombre propio heredan nombre y valor del padre.
+func formFieldValues(xrt *model.XRefTable) map[string]string {
+	values := map[string]string{}
+	root, err := xrt.Catalog()
+	if err != nil {
+		return values
+	}
+	form, err := xrt.DereferenceDict(root["AcroForm"])
+	if err != nil || form == nil {
+		return values
+	}
+	fields, err := xrt.DereferenceArray(form["Fields"])
+	if err != nil {
+		return values
+	}
+
+	seen := map[int]bool{}
+	var walk func(obj pdftypes.Object, parentName, parentValue string, depth int)
+	walk = func(obj pdftypes.Object, parentName, parentValue string, depth int) {
+		if depth > 32 {
+			return
+		}
+		if ref, ok := obj.(pdftypes.IndirectRef); ok {
+			if seen[ref.ObjectNumber.Value()] {
+				return
+			}
+			seen[ref.ObjectNumber.Value()] = true
+		}
+		d, err := xrt.DereferenceDict(obj)
+		if err != nil || d == nil {
+			return
+		}
+
+		name := parentName
+		if t, err := xrt.DereferenceText(d["T"]); err == nil && t != "" {
+			if name != "" {
+				name += "."
+			}
+			name += t
+		}
+		value := parentValue
+		if v, ok := d["V"]; ok {
+			value = fieldValueText(xrt, v)
+		}
+
+		if kids, err := xrt.DereferenceArray(d["Kids"]); err == nil && len(kids) > 0 {
+			for _, k := range kids {
+				walk(k, name, value, depth+1)
+			}
+			return
+		}
+		if name != "" {
+			values[name] = value
+		}
+	}
+
+	for _, f := range fields {
+		walk(f, "", "", 0)
+	}
+	return values
+}
+
+// fieldValueText convierte el valor /V de un campo en texto: cadenas, nombres
+// (casillas y botones de opción), arrays (listas de selección múltiple) y
+// diccionarios de firma.
+func fieldValueText(xrt *model.XRefTable, v pdftypes.Object) string {
+	o, err := xrt.Dereference(v)
+	if err != nil || o == nil {
+		return ""
+	}
+	switch o := o.(type) {
+	case pdftypes.Name:
+		return string(o)
+	case pdftypes.Array:
+		parts := make([]string, 0, len(o))
+		for _, e := range o {
+			parts = append(parts, fieldValueText(xrt, e))
+		}
+		return strings.Join(parts, ", ")
+	case pdftypes.Dict:
+		if o.Type() != nil && *o.Type() == "Sig" || o["ByteRange"] != nil {
+			return "(signed)"
+		}
+		return o.String()
+	}
+	if s, err := xrt.DereferenceText(o); err == nil {
+		return s
+	}
+	return o.String()
+}
+
+// documentAttachments devuelve el tamaño y el hash de cada archivo del árbol
+// EmbeddedFiles, por su nombre en el árbol.
+func documentAttachments(xrt *model.XRefTable, label string) (map[string]attachmentInfo, []string) {
+	out := map[string]attachmentInfo{}
+	var warnings []string
+
+	root, err := xrt.Catalog()
+	if err != nil {
+		return out, nil
+	}
+	names, err := xrt.DereferenceDict(root["Names"])
+	if err != nil || names == nil {
+		return out, nil
+	}
+	tree, err := xrt.DereferenceDict(names["EmbeddedFiles"])
+	if err != nil || tree == nil {
+		return out, nil
+	}
+
+	for _, e := range nameTreeEntries(xrt, tree, map[int]bool{}) {
+		fs, err := xrt.DereferenceDict(e.value)
+		if err != nil || fs == nil {
+			continue
+		}
+		name := e.key
+		for _, key := range []string{"UF", "F"} {
+			if name != "" {
+				break
+			}
+			name, _ = xrt.DereferenceText(fs[key])
+		}
+
+		info := attachmentInfo{}
+		if ef, err := xrt.DereferenceDict(fs["EF"]); err == nil && ef != nil {
+			for _, key := range []string{"UF", "F"} {
+				sd, _, err := xrt.DereferenceStreamDict(ef[key])
+				if err != nil || sd == nil {
+					continue
+				}
+				if err := sd.Decode(); err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s attachment %q could not be decoded: %v", label, name, err))
+					break
+				}
+				sum := sha256.Sum256(sd.Content)
+				info = attachmentInfo{size: int64(len(sd.Content)), sum: hex.EncodeToString(sum[:])}
+				break
+			}
+		}
+		out[name] = info
+	}
+	return out, warnings
+}
+
+// diffAttachments compara los adjuntos por nombre y contenido.
+func diffAttachments(a, b map[string]attachmentInfo) []types.AttachmentChange {
+	names := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		names = append(names, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	out := []types.AttachmentChange{}
+	for _, name := range names {
+		before, inA := a[name]
+		after, inB := b[name]
+		c := types.AttachmentChange{
+			Name:         name,
+			SizeBefore:   before.size,
+			SizeAfter:    after.size,
+			SHA256Before: before.sum,
+			SHA256After:  after.sum,
+		}
+		switch {
+		case !inA:
+			c.Change = types.ChangeAdded
+		case !inB:
+			c.Change = types.ChangeRemoved
+		case before != after:
+			c.Change = types.ChangeModified
+		default:
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// annotateDiff añade al PDF b un resaltado sobre cada línea añadida y, en las
+// páginas con líneas eliminadas, una nota con su texto. Devuelve el número de
+// anotaciones añadidas.
+func annotateDiff(xrt *model.XRefTable, pages []types.PageDiff) (int, error) {
+	added := 0
+	for _, pd := range pages {
+		if pd.PageB == 0 {
+			continue
+		}
+		page, _, inh, err := xrt.PageDict(pd.PageB, false)
+		if err != nil || page == nil {
+			return added, fmt.Errorf("page %d: %w", pd.PageB, err)
+		}
+
+		var refs pdftypes.Array
+		var removed []string
+		for _, l := range pd.Lines {
+			if l.Change == types.ChangeRemoved {
+				removed = append(removed, l.Text)
+				continue
+			}
+			for _, r := range l.Rects {
+				ref, err := newHighlightAnnot(xrt, rectFromTypes(r))
+				if err != nil {
+					return added, err
+				}
+				refs = append(refs, *ref)
+			}
+		}
+		if len(removed) > 0 {
+			box := rect{llx: 0, lly: 0, urx: 612, ury: 792}
+			if inh != nil && inh.MediaBox != nil {
+				box = rect{llx: inh.MediaBox.LL.X, lly: inh.MediaBox.LL.Y, urx: inh.MediaBox.UR.X, ury: inh.MediaBox.UR.Y}
+			}
+			ref, err := newRemovedNote(xrt, box, removed)
+			if err != nil {
+				return added, err
+			}
+			refs = append(refs, *ref)
+		}
+		if len(refs) == 0 {
+			continue
+		}
+
+		annots, _ := xrt.DereferenceArray(page["Annots"])
+		annots = append(annots, refs...)
+		added += len(refs)
+		if ref, ok := page["Annots"].(pdftypes.IndirectRef); ok {
+			if entry, found := xrt.FindTableEntryForIndRef(&ref); found {
+				entry.Object = annots
+				continue
+			}
+		}
+		page["Annots"] = annots
+	}
+	return added, nil
+}
+
+// newHighlightAnnot crea una anotación Highlight amarilla con su apariencia
+// (relleno en modo Multiply) para que se vea igual en todos los visores.
+func newHighlightAnnot(xrt *model.XRefTable, r rect) (*pdftypes.IndirectRef, error) {
+	w, h := r.width(), r.height()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "/GS0 gs 1 1 0 rg 0 0 %s %s re f\n", formatNumber(w), formatNumber(h))
+	ap, err := xrt.NewStreamDictForBuf(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	ap.Insert("Type", pdftypes.Name("XObject"))
+	ap.Insert("Subtype", pdftypes.Name("Form"))
+	ap.Insert("BBox", pdftypes.NewNumberArray(0, 0, w, h))
+	ap.Insert("Resources", pdftypes.Dict{
+		"ExtGState": pdftypes.Dict{
+			"GS0": pdftypes.Dict{"Type": pdftypes.Name("ExtGState"), "BM": pdftypes.Name("Multiply"), "ca": pdftypes.Float(0.5)},
+		},
+	})
+	if err := ap.Encode(); err != nil {
+		return nil, err
+	}
+	apRef, err := xrt.IndRefForNewObject(*ap)
+	if err != nil {
+		return nil, err
+	}
+
+	return xrt.IndRefForNewObject(pdftypes.Dict{
+		"Type":       pdftypes.Name("Annot"),
+		"Subtype":    pdftypes.Name("Highlight"),
+		"Rect":       pdftypes.NewNumberArray(r.llx, r.lly, r.urx, r.ury),
+		"QuadPoints": pdftypes.NewNumberArray(r.llx, r.ury, r.urx, r.ury, r.llx, r.lly, r.urx, r.lly),
+		"C":          pdftypes.NewNumberArray(1, 1, 0),
+		"CA":         pdftypes.Float(0.5),
+		"F":          pdftypes.Integer(4), // Print
+		"T":          pdfTextString("pdf_diff"),
+		"Contents":   pdfTextString("Added or changed text"),
+		"AP":         pdftypes.Dict{"N": *apRef},
+	})
+}
+
+// newRemovedNote crea una nota (anotación Text) en la esquina superior
+// izquierda de la página con las líneas eliminadas.
+func newRemovedNote(xrt *model.XRefTable, box rect, lines []string) (*pdftypes.IndirectRef, error) {
+	text := "Removed text:\n" + strings.Join(lines[:min(len(lines), maxRemovedNote)], "\n")
+	if len(lines) > maxRemovedNote {
+		text += fmt.Sprintf("\n... and %d more lines", len(lines)-maxRemovedNote)
+	}
+	x, y := box.llx+4, box.ury-24
+	return xrt.IndRefForNewObject(pdftypes.Dict{
+		"Type":     pdftypes.Name("Annot"),
+		"Subtype":  pdftypes.Name("Text"),
+		"Rect":     pdftypes.NewNumberArray(x, y, x+20, y+20),
+		"Name":     pdftypes.Name("Comment"),
+		"C":        pdftypes.NewNumberArray(1, 0, 0),
+		"F":        pdftypes.Integer(4), // Print
+		"T":        pdfTextString("pdf_diff"),
+		"Contents": pdfTextString(text),
+	})
+}
@@ -0,0 +1,153 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// writeFormTestPDF genera una página con un campo de texto, una nota, un
+// adjunto y un diccionario Info con el título indicado.
+func writeFormTestPDF(t *testing.T, dir, name, title, value, note, attachment string) string {
+	t.Helper()
+
+	content := "BT /F1 12 Tf 72 720 Td (Order form) Tj ET\n"
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [5 0 R] >> /Names << /EmbeddedFiles << /Names [(terms.txt) 7 0 R] >> >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 9 0 R /Annots [5 0 R 6 0 R] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Type /Annot /Subtype /Widget /FT /Tx /T (customer) /V (%s) /DA (/Helv 0 Tf 0 g) /Rect [72 600 300 620] /P 3 0 R >>", value),
+		fmt.Sprintf("<< /Type /Annot /Subtype /Text /Rect [400 700 420 720] /Contents (%s) >>", note),
+		"<< /Type /Filespec /F (terms.txt) /EF << /F 8 0 R >> >>",
+		fmt.Sprintf("<< /Type /EmbeddedFile /Length %d >>\nstream\n%s\nendstream", len(attachment), attachment),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		fmt.Sprintf("<< /Title (%s) /Author (Legal) >>", title),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, len(objs), xref)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write test PDF: %v", err)
+	}
+	return path
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	p := newTestProcessor()
+
+	a := writeTestPDF(t, dir, "v1.pdf", []string{
+		"Service agreement\nPrice: 100 EUR\nTerm: 12 months",
+		"Old annex\nTo be removed",
+		"Signatures\nClient and provider",
+	})
+	b := writeTestPDF(t, dir, "v2.pdf", []string{
+		"Cover page",
+		"Service agreement\nPrice: 120 EUR\nTerm: 12 months",
+		"Signatures\nClient and provider",
+	})
+
+	t.Run("identical", func(t *testing.T) {
+		result, err := p.Diff(context.Background(), a, a, types.DiffOptions{})
+		if err != nil {
+			t.Fatalf("Diff failed: %v", err)
+		}
+		if !result.Identical || len(result.Pages) != 0 {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("text changes", func(t *testing.T) {
+		out := filepath.Join(dir, "out", "annotated.pdf")
+		result, err := p.Diff(context.Background(), a, b, types.DiffOptions{AnnotatedOutputPath: out})
+		if err != nil {
+			t.Fatalf("Diff failed: %v", err)
+		}
+		if result.Identical || result.ChangedPages != 3 {
+			t.Fatalf("changed pages = %d: %+v", result.ChangedPages, result.Pages)
+		}
+
+		byChange := map[string]types.PageDiff{}
+		for _, pd := range result.Pages {
+			byChange[pd.Change] = pd
+		}
+		if pd := byChange[types.ChangeRemoved]; pd.PageA != 2 || pd.LinesRemoved != 2 {
+			t.Errorf("removed page = %+v", pd)
+		}
+		if pd := byChange[types.ChangeAdded]; pd.PageB != 1 || pd.LinesAdded != 1 {
+			t.Errorf("added page = %+v", pd)
+		}
+		pd := byChange[types.ChangeModified]
+		if pd.PageA != 1 || pd.PageB != 2 || pd.LinesAdded != 1 || pd.LinesRemoved != 1 {
+			t.Fatalf("modified page = %+v", pd)
+		}
+		for _, l := range pd.Lines {
+			switch l.Change {
+			case types.ChangeRemoved:
+				if l.Text != "Price: 100 EUR" || l.LineA != 2 {
+					t.Errorf("removed line = %+v", l)
+				}
+			case types.ChangeAdded:
+				if l.Text != "Price: 120 EUR" || l.LineB != 2 || len(l.Rects) != 1 {
+					t.Errorf("added line = %+v", l)
+				}
+			}
+		}
+
+		// Un resaltado en la portada y otro en la línea modificada, más la nota con
+		// la línea eliminada.
+		if result.Highlights != 3 {
+			t.Errorf("highlights = %d", result.Highlights)
+		}
+		if err := p.ValidateFile(out); err != nil {
+			t.Errorf("annotated PDF does not validate: %v", err)
+		}
+		if got := extractTestText(t, out, 2); got != extractTestText(t, b, 2) {
+			t.Errorf("annotated page text changed: %q", got)
+		}
+	})
+
+	t.Run("metadata, forms, annotations and attachments", func(t *testing.T) {
+		fa := writeFormTestPDF(t, dir, "form1.pdf", "Order", "ACME", "check totals", "v1 terms")
+		fb := writeFormTestPDF(t, dir, "form2.pdf", "Order (rev)", "ACME Corp", "totals ok", "v2 terms!")
+		result, err := p.Diff(context.Background(), fa, fb, types.DiffOptions{})
+		if err != nil {
+			t.Fatalf("Diff failed: %v", err)
+		}
+		if len(result.Pages) != 0 {
+			t.Errorf("pages = %+v", result.Pages)
+		}
+		if len(result.Metadata) != 1 || result.Metadata[0].Name != "Title" || result.Metadata[0].After != "Order (rev)" {
+			t.Errorf("metadata = %+v", result.Metadata)
+		}
+		if len(result.FormFields) != 1 || result.FormFields[0].Before != "ACME" || result.FormFields[0].After != "ACME Corp" {
+			t.Errorf("form fields = %+v", result.FormFields)
+		}
+		if len(result.Annotations) != 2 || result.Annotations[0].Contents != "check totals" || result.Annotations[1].Contents != "totals ok" {
+			t.Errorf("annotations = %+v", result.Annotations)
+		}
+		if len(result.Attachments) != 1 || result.Attachments[0].Change != types.ChangeModified ||
+			result.Attachments[0].SizeBefore != 8 || result.Attachments[0].SizeAfter != 9 {
+			t.Errorf("attachments = %+v", result.Attachments)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package pdf
+
+import (
+	"math"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// matrix es una matriz de transformación PDF [a b c d e f].
+// Los puntos se transforman como vectores fila: p' = p × M.
+type matrix [6]float64
+
+var identityMatrix = matrix{1, 0, 0, 1, 0, 0}
+
+// mul devuelve m × n (aplicar m y después n).
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m matrix) apply(x, y float64) (float64, float64) {
+	return x*m[0] + y*m[2] + m[4], x*m[1] + y*m[3] + m[5]
+}
+
+// inverse devuelve la inversa de m; ok es false si m es singular.
+func (m matrix) inverse() (matrix, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if math.Abs(det) < 1e-12 {
+		return matrix{}, false
+	}
+	return matrix{
+		m[3] / det,
+		-m[1] / det,
+		-m[2] / det,
+		m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det,
+		(m[1]*m[4] - m[0]*m[5]) / det,
+	}, true
+}
+
+// rect es un rectángulo alineado con los ejes en espacio de usuario.
+type rect struct {
+	llx, lly, urx, ury float64
+}
+
+// transformRect devuelve la caja envolvente de r transformado por m.
+func transformRect(r rect, m matrix) rect {
+	xs := [4]float64{}
+	ys := [4]float64{}
+	xs[0], ys[0] = m.apply(r.llx, r.lly)
+	xs[1], ys[1] = m.apply(r.urx, r.lly)
+	xs[2], ys[2] = m.apply(r.llx, r.ury)
+	xs[3], ys[3] = m.apply(r.urx, r.ury)
+
+	out := rect{xs[0], ys[0], xs[0], ys[0]}
+	for i := 1; i < 4; i++ {
+		out.llx = math.Min(out.llx, xs[i])
+		out.lly = math.Min(out.lly, ys[i])
+		out.urx = math.Max(out.urx, xs[i])
+		out.ury = math.Max(out.ury, ys[i])
+	}
+	return out
+}
+
+func (r rect) width() float64  { return r.urx - r.llx }
+func (r rect) height() float64 { return r.ury - r.lly }
+
+// overlaps indica si r y o se solapan más de tol puntos en ambos ejes.
+func (r rect) overlaps(o rect, tol float64) bool {
+	return math.Min(r.urx, o.urx)-math.Max(r.llx, o.llx) > tol &&
+		math.Min(r.ury, o.ury)-math.Max(r.lly, o.lly) > tol
+}
+
+// contains indica si r contiene completamente a o.
+func (r rect) contains(o rect) bool {
+	return o.llx >= r.llx && o.lly >= r.lly && o.urx <= r.urx && o.ury <= r.ury
+}
+
+func (r rect) union(o rect) rect {
+	return rect{
+		math.Min(r.llx, o.llx),
+		math.Min(r.lly, o.lly),
+		math.Max(r.urx, o.urx),
+		math.Max(r.ury, o.ury),
+	}
+}
+
+// normalized garantiza ll <= ur en ambos ejes.
+func (r rect) normalized() rect {
+	if r.llx > r.urx {
+		r.llx, r.urx = r.urx, r.llx
+	}
+	if r.lly > r.ury {
+		r.lly, r.ury = r.ury, r.lly
+	}
+	return r
+}
+
+func (r rect) toTypes() types.Rect {
+	return types.Rect{
+		LLX: round2(r.llx),
+		LLY: round2(r.lly),
+		URX: round2(r.urx),
+		URY: round2(r.ury),
+	}
+}
+
+func rectFromTypes(r types.Rect) rect {
+	return rect{r.LLX, r.LLY, r.URX, r.URY}.normalized()
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,133 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// srgbProfileDescription identifica el perfil en el OutputIntent.
+const srgbProfileDescription = "sRGB IEC61966-2.1"
+
+// Primarios de sRGB adaptados a D50 (Bradford) y blanco D50 del espacio de conexión.
+var (
+	iccD50      = [3]float64{0.9642, 1.0, 0.8249}
+	iccRedXYZ   = [3]float64{0.4361, 0.2225, 0.0139}
+	iccGreenXYZ = [3]float64{0.3851, 0.7169, 0.0971}
+	iccBlueXYZ  = [3]float64{0.1431, 0.0606, 0.7141}
+)
+
+// srgbICCProfile genera un perfil ICC v2.1 de monitor para sRGB (matriz y curvas
+// de tono), válido como DestOutputProfile de un OutputIntent PDF/A-1, 2 y 3.
+func srgbICCProfile() []byte {
+	type tag struct {
+		sig  string
+		data []byte
+	}
+
+	curve := iccCurve(1024, func(x float64) float64 {
+		if x <= 0.04045 {
+			return x / 12.92
+		}
+		return math.Pow((x+0.055)/1.055, 2.4)
+	})
+	tags := []tag{
+		{"desc", iccDesc(srgbProfileDescription)},
+		{"cprt", iccText("No copyright, use freely")},
+		{"wtpt", iccXYZ(iccD50)},
+		{"rXYZ", iccXYZ(iccRedXYZ)},
+		{"gXYZ", iccXYZ(iccGreenXYZ)},
+		{"bXYZ", iccXYZ(iccBlueXYZ)},
+		{"rTRC", curve},
+		{"gTRC", curve},
+		{"bTRC", curve},
+	}
+
+	// Las tres curvas comparten los mismos datos.
+	offset := 128 + 4 + 12*len(tags)
+	var table, data bytes.Buffer
+	binary.Write(&table, binary.BigEndian, uint32(len(tags)))
+	offsets := map[string]int{}
+	for _, t := range tags {
+		key := string(t.data)
+		off, ok := offsets[key]
+		if !ok {
+			off = offset + data.Len()
+			offsets[key] = off
+			data.Write(t.data)
+			for data.Len()%4 != 0 {
+				data.WriteByte(0)
+			}
+		}
+		table.WriteString(t.sig)
+		binary.Write(&table, binary.BigEndian, uint32(off))
+		binary.Write(&table, binary.BigEndian, uint32(len(t.data)))
+	}
+
+	size := 128 + table.Len() + data.Len()
+	var h bytes.Buffer
+	binary.Write(&h, binary.BigEndian, uint32(size))
+	h.Write(make([]byte, 4))                               // CMM
+	binary.Write(&h, binary.BigEndian, uint32(0x02100000)) // versión 2.1
+	h.WriteString("mntrRGB XYZ ")                          // clase, espacio de color y PCS
+	for _, v := range []uint16{2026, 1, 1, 0, 0, 0} {      // fecha de creación
+		binary.Write(&h, binary.BigEndian, v)
+	}
+	h.WriteString("acsp")
+	h.Write(make([]byte, 4+4+4+4+8+4)) // plataforma, flags, fabricante, modelo, atributos, intent
+	for _, v := range iccD50 {
+		binary.Write(&h, binary.BigEndian, s15Fixed16(v))
+	}
+	h.Write(make([]byte, 128-h.Len())) // creador, ID y reservado
+
+	out := make([]byte, 0, size)
+	out = append(out, h.Bytes()...)
+	out = append(out, table.Bytes()...)
+	return append(out, data.Bytes()...)
+}
+
+func s15Fixed16(v float64) int32 {
+	return int32(math.Round(v * 65536))
+}
+
+func iccXYZ(v [3]float64) []byte {
+	var b bytes.Buffer
+	b.WriteString("XYZ ")
+	b.Write(make([]byte, 4))
+	for _, c := range v {
+		binary.Write(&b, binary.BigEndian, s15Fixed16(c))
+	}
+	return b.Bytes()
+}
+
+func iccCurve(n int, f func(float64) float64) []byte {
+	var b bytes.Buffer
+	b.WriteString("curv")
+	b.Write(make([]byte, 4))
+	binary.Write(&b, binary.BigEndian, uint32(n))
+	for i := 0; i < n; i++ {
+		y := f(float64(i) / float64(n-1))
+		binary.Write(&b, binary.BigEndian, uint16(math.Round(y*65535)))
+	}
+	return b.Bytes()
+}
+
+func iccDesc(s string) []byte {
+	var b bytes.Buffer
+	b.WriteString("desc")
+	b.Write(make([]byte, 4))
+	binary.Write(&b, binary.BigEndian, uint32(len(s)+1))
+	b.WriteString(s)
+	b.WriteByte(0)
+	b.Write(make([]byte, 4+4+2+1+67)) // Unicode y ScriptCode vacíos
+	return b.Bytes()
+}
+
+func iccText(s string) []byte {
+	var b bytes.Buffer
+	b.WriteString("text")
+	b.Write(make([]byte, 4))
+	b.WriteString(s)
+	b.WriteByte(0)
+	return b.Bytes()
+}
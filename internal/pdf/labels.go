@@ -0,0 +1,330 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// Estilos de numeración del diccionario de etiquetas (/S, ISO 32000 12.4.2).
+var labelStyleNames = map[types.PageLabelStyle]string{
+	types.LabelDecimal:      "D",
+	types.LabelRomanUpper:   "R",
+	types.LabelRomanLower:   "r",
+	types.LabelLettersUpper: "A",
+	types.LabelLettersLower: "a",
+}
+
+// GetPageLabels devuelve los tramos de etiquetas de página (/PageLabels del
+// catálogo) y la etiqueta resultante de cada página.
+func (p *Processor) GetPageLabels(ctx context.Context, inputPath string) (*types.PageLabelsResult, error) {
+	p.logger.Debug("reading page labels",
+		slog.String("input", inputPath))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(inputPath); err != nil {
+		return nil, err
+	}
+
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+
+	ranges := pageLabelRanges(pdfCtx.XRefTable)
+	return &types.PageLabelsResult{
+		InputPath:  inputPath,
+		TotalPages: pdfCtx.PageCount,
+		HasLabels:  len(ranges) > 0,
+		Ranges:     ranges,
+		Labels:     formatPageLabels(ranges, pdfCtx.PageCount),
+	}, nil
+}
+
+// SetPageLabels sustituye las etiquetas de página por los tramos indicados.
+// El primer tramo debe empezar en la página 1; sin tramos se eliminan las
+// etiquetas y los visores vuelven a mostrar los números de página.
+func (p *Processor) SetPageLabels(ctx context.Context, inputPath, outputPath string, ranges []types.PageLabelRange) (_ *types.PageLabelsResult, err error) {
+	p.logger.Debug("setting page labels",
+		slog.String("input", inputPath),
+		slog.String("output", outputPath),
+		slog.Int("ranges", len(ranges)))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(inputPath); err != nil {
+		return nil, err
+	}
+
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+
+	ranges, err = normalizePageLabelRanges(ranges, pdfCtx.PageCount)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := pdfCtx.XRefTable.Catalog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	if len(ranges) == 0 {
+		root.Delete("PageLabels")
+	} else {
+		nums := pdftypes.Array{}
+		for _, r := range ranges {
+			d := pdftypes.Dict{"Type": pdftypes.Name("PageLabel")}
+			if s, ok := labelStyleNames[r.Style]; ok {
+				d["S"] = pdftypes.Name(s)
+			}
+			if r.Prefix != "" {
+				d["P"] = pdfTextString(r.Prefix)
+			}
+			if r.StartValue != 1 {
+				d["St"] = pdftypes.Integer(r.StartValue)
+			}
+			nums = append(nums, pdftypes.Integer(r.StartPage-1), d)
+		}
+		ref, err := pdfCtx.XRefTable.IndRefForNewObject(pdftypes.Dict{"Nums": nums})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add page labels: %w", err)
+		}
+		root["PageLabels"] = *ref
+	}
+
+	if err := ensureOutputDir(outputPath); err != nil {
+		p.logger.Error("failed to create output directory", err)
+		return nil, err
+	}
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	if err := api.WriteContextFile(pdfCtx, outputPath); err != nil {
+		p.logger.Error("failed to write PDF", err)
+		return nil, fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return &types.PageLabelsResult{
+		InputPath:  inputPath,
+		OutputPath: outputPath,
+		TotalPages: pdfCtx.PageCount,
+		HasLabels:  len(ranges) > 0,
+		Ranges:     ranges,
+		Labels:     formatPageLabels(ranges, pdfCtx.PageCount),
+	}, nil
+}
+
+// normalizePageLabelRanges valida los tramos, aplica valores por defecto y los
+// ordena por página inicial.
+func normalizePageLabelRanges(ranges []types.PageLabelRange, totalPages int) ([]types.PageLabelRange, error) {
+	out := make([]types.PageLabelRange, len(ranges))
+	copy(out, ranges)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartPage < out[j].StartPage })
+
+	for i := range out {
+		r := &out[i]
+		if r.Style == "" {
+			r.Style = types.LabelDecimal
+		}
+		if !r.Style.IsValid() {
+			return nil, fmt.Errorf("invalid page label style %q", r.Style)
+		}
+		if r.StartPage < 1 || r.StartPage > totalPages {
+			return nil, fmt.Errorf("page label range start %d out of bounds (PDF has %d pages)", r.StartPage, totalPages)
+		}
+		if i > 0 && r.StartPage == out[i-1].StartPage {
+			return nil, fmt.Errorf("duplicate page label range at page %d", r.StartPage)
+		}
+		if r.StartValue < 0 {
+			return nil, fmt.Errorf("invalid page label start value %d", r.StartValue)
+		}
+		if r.StartValue == 0 {
+			r.StartValue = 1
+		}
+	}
+	if len(out) > 0 && out[0].StartPage != 1 {
+		return nil, fmt.Errorf("the first page label range must start at page 1")
+	}
+	return out, nil
+}
+
+// pageLabelRanges lee el árbol de números /PageLabels del catálogo. Devuelve
+// nil si el documento no tiene etiquetas.
+func pageLabelRanges(xrt *model.XRefTable) []types.PageLabelRange {
+	root, err := xrt.Catalog()
+	if err != nil {
+		return nil
+	}
+	tree, err := xrt.DereferenceDict(root["PageLabels"])
+	if err != nil || tree == nil {
+		return nil
+	}
+
+	var ranges []types.PageLabelRange
+	for _, e := range numberTreeEntries(xrt, tree, map[int]bool{}) {
+		d, err := xrt.DereferenceDict(e.value)
+		if err != nil || d == nil || e.key < 0 || e.key >= xrt.PageCount {
+			continue
+		}
+		r := types.PageLabelRange{StartPage: e.key + 1, Style: types.LabelNone, StartValue: 1}
+		if s := d.NameEntry("S"); s != nil {
+			for style, name := range labelStyleNames {
+				if name == *s {
+					r.Style = style
+				}
+			}
+		}
+		if prefix, err := xrt.DereferenceText(d["P"]); err == nil {
+			r.Prefix = prefix
+		}
+		if st, err := xrt.DereferenceInteger(d["St"]); err == nil && st != nil && st.Value() > 0 {
+			r.StartValue = st.Value()
+		}
+		ranges = append(ranges, r)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].StartPage < ranges[j].StartPage })
+	return ranges
+}
+
+// numberTreeEntry es una hoja de un árbol de números.
+type numberTreeEntry struct {
+	key   int
+	value pdftypes.Object
+}
+
+// numberTreeEntries devuelve las hojas (clave y valor) de un árbol de números.
+func numberTreeEntries(xrt *model.XRefTable, node pdftypes.Dict, visited map[int]bool) []numberTreeEntry {
+	var entries []numberTreeEntry
+	if nums, err := xrt.DereferenceArray(node["Nums"]); err == nil {
+		for i := 1; i < len(nums); i += 2 {
+			if key, err := xrt.DereferenceInteger(nums[i-1]); err == nil && key != nil {
+				entries = append(entries, numberTreeEntry{key: key.Value(), value: nums[i]})
+			}
+		}
+	}
+	kids, err := xrt.DereferenceArray(node["Kids"])
+	if err != nil {
+		return entries
+	}
+	for _, k := range kids {
+		if ref, ok := k.(pdftypes.IndirectRef); ok {
+			if visited[ref.ObjectNumber.Value()] {
+				continue
+			}
+			visited[ref.ObjectNumber.Value()] = true
+		}
+		if kid, err := xrt.DereferenceDict(k); err == nil && kid != nil {
+			entries = append(entries, numberTreeEntries(xrt, kid, visited)...)
+		}
+	}
+	return entries
+}
+
+// documentPageLabels devuelve la etiqueta de cada página del documento.
+func documentPageLabels(xrt *model.XRefTable) []string {
+	return formatPageLabels(pageLabelRanges(xrt), xrt.PageCount)
+}
+
+// formatPageLabels calcula la etiqueta de cada página a partir de los tramos
+// ordenados. Sin tramos, la etiqueta es el número de página.
+func formatPageLabels(ranges []types.PageLabelRange, totalPages int) []string {
+	labels := make([]string, totalPages)
+	if len(ranges) == 0 {
+		for i := range labels {
+			labels[i] = strconv.Itoa(i + 1)
+		}
+		return labels
+	}
+
+	for i, r := range ranges {
+		end := totalPages
+		if i+1 < len(ranges) {
+			end = min(ranges[i+1].StartPage-1, totalPages)
+		}
+		for page := r.StartPage; page <= end; page++ {
+			labels[page-1] = r.Prefix + formatLabelNumber(r.Style, r.StartValue+page-r.StartPage)
+		}
+	}
+	return labels
+}
+
+// formatLabelNumber formatea la parte numérica de una etiqueta.
+func formatLabelNumber(style types.PageLabelStyle, n int) string {
+	switch style {
+	case types.LabelDecimal:
+		return strconv.Itoa(n)
+	case types.LabelRomanUpper:
+		return toRoman(n)
+	case types.LabelRomanLower:
+		return strings.ToLower(toRoman(n))
+	case types.LabelLettersUpper:
+		return toLetters(n)
+	case types.LabelLettersLower:
+		return strings.ToLower(toLetters(n))
+	}
+	return ""
+}
+
+var romanNumerals = []struct {
+	value  int
+	symbol string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+	{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+// toRoman convierte n (≥ 1) a números romanos en mayúsculas.
+func toRoman(n int) string {
+	var sb strings.Builder
+	for _, r := range romanNumerals {
+		for n >= r.value {
+			sb.WriteString(r.symbol)
+			n -= r.value
+		}
+	}
+	return sb.String()
+}
+
+// toLetters convierte n (≥ 1) al estilo de letras de PDF: A..Z, AA..ZZ, AAA..
+func toLetters(n int) string {
+	if n < 1 {
+		return ""
+	}
+	return strings.Repeat(string(rune('A'+(n-1)%26)), (n-1)/26+1)
+}
+
+// resolvePageLabel devuelve el número de página con la etiqueta indicada.
+// Las etiquetas repetidas en varias páginas son ambiguas.
+func resolvePageLabel(label string, labels []string) (int, error) {
+	page := 0
+	for i, l := range labels {
+		if l != label {
+			continue
+		}
+		if page != 0 {
+			return 0, fmt.Errorf("page label %q is ambiguous (pages %d and %d)", label, page, i+1)
+		}
+		page = i + 1
+	}
+	if page == 0 {
+		return 0, fmt.Errorf("page label %q not found", label)
+	}
+	return page, nil
+}
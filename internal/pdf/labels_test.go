@@ -0,0 +1,128 @@
+package pdf
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestFormatPageLabels(t *testing.T) {
+	ranges := []types.PageLabelRange{
+		{StartPage: 1, Style: types.LabelRomanLower, StartValue: 1},
+		{StartPage: 4, Style: types.LabelDecimal, StartValue: 1},
+		{StartPage: 6, Style: types.LabelLettersUpper, Prefix: "A-", StartValue: 26},
+		{StartPage: 8, Style: types.LabelNone, Prefix: "Back cover", StartValue: 1},
+	}
+	want := []string{"i", "ii", "iii", "1", "2", "A-Z", "A-AA", "Back cover"}
+	if got := formatPageLabels(ranges, 8); !reflect.DeepEqual(got, want) {
+		t.Errorf("formatPageLabels = %v, want %v", got, want)
+	}
+	if got := formatPageLabels(nil, 3); !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Errorf("formatPageLabels without ranges = %v", got)
+	}
+	if got := toRoman(1994); got != "MCMXCIV" {
+		t.Errorf("toRoman(1994) = %q", got)
+	}
+}
+
+func TestParsePageSelectionLabels(t *testing.T) {
+	labels := []string{"i", "ii", "iii", "iv", "1", "2", "3", "A-1", "A-2", "x", "x"}
+	tests := []struct {
+		name      string
+		selection string
+		want      []int
+		wantErr   string
+		noLabels  bool
+	}{
+		{name: "single label", selection: "label:iii", want: []int{3}},
+		{name: "label range", selection: "label:ii-label:2", want: []int{2, 3, 4, 5, 6}},
+		{name: "mixed with numbers", selection: "1,label:3-9", want: []int{1, 7, 8, 9}},
+		{name: "labels with dashes", selection: "label:A-1-label:A-2", want: []int{8, 9}},
+		{name: "unknown label", selection: "label:v", wantErr: `page label "v" not found`},
+		{name: "unknown end label", selection: "label:i-label:zz", wantErr: `page label "zz" not found`},
+		{name: "ambiguous label", selection: "label:x", wantErr: "ambiguous"},
+		{name: "reversed range", selection: "label:2-label:i", want: []int{1, 2, 3, 4, 5, 6}},
+		{name: "labels unavailable", selection: "label:i", wantErr: "not available", noLabels: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := labels
+			if tt.noLabels {
+				l = nil
+			}
+			got, err := parsePageSelection(tt.selection, len(labels), l)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parsePageSelection(%q) error = %v, want %q", tt.selection, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePageSelection(%q) unexpected error: %v", tt.selection, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePageSelection(%q) = %v, want %v", tt.selection, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageLabels(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestPDF(t, dir, "book.pdf", []string{"title", "preface", "contents", "one", "two", "three"})
+	p := newTestProcessor()
+
+	result, err := p.GetPageLabels(context.Background(), in)
+	if err != nil {
+		t.Fatalf("GetPageLabels failed: %v", err)
+	}
+	if result.HasLabels || len(result.Labels) != 6 || result.Labels[5] != "6" {
+		t.Errorf("unlabelled document: %+v", result)
+	}
+
+	labelled := filepath.Join(dir, "labelled.pdf")
+	_, err = p.SetPageLabels(context.Background(), in, labelled, []types.PageLabelRange{
+		{StartPage: 4, Style: types.LabelDecimal},
+		{StartPage: 1, Style: types.LabelRomanLower},
+	})
+	if err != nil {
+		t.Fatalf("SetPageLabels failed: %v", err)
+	}
+
+	result, err = p.GetPageLabels(context.Background(), labelled)
+	if err != nil {
+		t.Fatalf("GetPageLabels failed: %v", err)
+	}
+	want := []string{"i", "ii", "iii", "1", "2", "3"}
+	if !result.HasLabels || len(result.Ranges) != 2 || !reflect.DeepEqual(result.Labels, want) {
+		t.Errorf("labels = %+v", result)
+	}
+
+	t.Run("remove pages by label", func(t *testing.T) {
+		out := filepath.Join(dir, "body.pdf")
+		removed, err := p.RemovePages(context.Background(), labelled, out, "label:i-label:iii", types.ModeRemove)
+		if err != nil {
+			t.Fatalf("RemovePages failed: %v", err)
+		}
+		if !reflect.DeepEqual(removed.RemovedPages, []int{1, 2, 3}) {
+			t.Errorf("removed pages = %v", removed.RemovedPages)
+		}
+	})
+
+	t.Run("invalid ranges", func(t *testing.T) {
+		for _, ranges := range [][]types.PageLabelRange{
+			{{StartPage: 2}},
+			{{StartPage: 1}, {StartPage: 1}},
+			{{StartPage: 1, Style: "greek"}},
+			{{StartPage: 7}},
+		} {
+			if _, err := p.SetPageLabels(context.Background(), in, filepath.Join(dir, "bad.pdf"), ranges); err == nil {
+				t.Errorf("SetPageLabels(%+v) expected error", ranges)
+			}
+		}
+	})
+}
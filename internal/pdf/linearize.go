@@ -0,0 +1,769 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// pdfcpu no escribe archivos linealizados, así que la salida se genera aquí
+// siguiendo el anexo F de ISO 32000-1:
+//
+//	cabecera, diccionario de linealización, xref y trailer de la primera página,
+//	catálogo y objetos de documento, hint stream, objetos de la primera página,
+//	resto de páginas, objetos compartidos, otros objetos, xref principal.
+//
+// Los objetos de la primera sección se numeran después de los de la xref
+// principal. Los desplazamientos de las hint tables no incluyen el hint stream.
+
+// Linearize reescribe un PDF linealizado ("fast web view") para que un visor
+// pueda mostrar la primera página, y después cada página, sin descargar todo el archivo.
+func (p *Processor) Linearize(ctx context.Context, inputPath, outputPath string) (_ *types.LinearizeResult, err error) {
+	p.logger.Debug("linearizing PDF",
+		slog.String("input", inputPath),
+		slog.String("output", outputPath))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(inputPath); err != nil {
+		return nil, err
+	}
+
+	originalInfo, err := os.Stat(inputPath)
+	if err != nil {
+		p.logger.Error("failed to stat input file", err)
+		return nil, fmt.Errorf("failed to stat input file: %w", err)
+	}
+
+	if err := ensureOutputDir(outputPath); err != nil {
+		p.logger.Error("failed to create output directory", err)
+		return nil, err
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	if err := p.linearizeFile(inputPath, outputPath); err != nil {
+		p.logger.Error("PDF linearization failed", err)
+		return nil, err
+	}
+
+	outputInfo, err := os.Stat(outputPath)
+	if err != nil {
+		p.logger.Error("failed to stat output file", err)
+		return nil, fmt.Errorf("failed to stat output file: %w", err)
+	}
+
+	pages, err := linearizedPageCount(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.LinearizeResult{
+		OutputPath:   outputPath,
+		TotalPages:   pages,
+		OriginalSize: originalInfo.Size(),
+		OutputSize:   outputInfo.Size(),
+		Linearized:   true,
+	}
+
+	p.logger.Debug("PDF linearization complete",
+		slog.Int64("output_size", result.OutputSize))
+
+	return result, nil
+}
+
+// linearizeFile escribe en outputPath la versión linealizada de inputPath.
+// inputPath y outputPath pueden ser el mismo archivo.
+func (p *Processor) linearizeFile(inputPath, outputPath string) error {
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := linearizeContext(pdfCtx)
+	if err != nil {
+		return fmt.Errorf("failed to linearize PDF: %w", err)
+	}
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write linearized PDF: %w", err)
+	}
+	return nil
+}
+
+var (
+	linearizedDictRe = regexp.MustCompile(`^%PDF-\d\.\d[^\n\r]*[\r\n]+(?:%[^\n\r]*[\r\n]+)*\s*\d+\s+\d+\s+obj\s*<<([^>]*)>>`)
+	linearizedKeyRe  = regexp.MustCompile(`/Linearized\s+[\d.]+`)
+	linearizedLenRe  = regexp.MustCompile(`/L\s+(\d+)`)
+)
+
+// IsLinearized indica si el archivo está linealizado: el primer objeto es un
+// diccionario de linealización cuyo /L coincide con el tamaño del archivo (si
+// no coincide, el archivo se ha modificado después y ya no es válido como tal).
+func IsLinearized(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	head := make([]byte, 1024)
+	n, _ := f.Read(head)
+	m := linearizedDictRe.FindSubmatch(head[:n])
+	if m == nil || !linearizedKeyRe.Match(m[1]) {
+		return false, nil
+	}
+	l := linearizedLenRe.FindSubmatch(m[1])
+	if l == nil {
+		return false, nil
+	}
+	size, err := strconv.ParseInt(string(l[1]), 10, 64)
+	return err == nil && size == fi.Size(), nil
+}
+
+func linearizedPageCount(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	pdfCtx, err := api.ReadAndValidate(f, conf)
+	if err != nil {
+		return 0, fmt.Errorf("linearized output is invalid: %w", err)
+	}
+	return pdfCtx.PageCount, nil
+}
+
+// linearizer clasifica los objetos del documento en las partes del anexo F.
+type linearizer struct {
+	xrt      *model.XRefTable
+	pageTree map[int]bool // nodos Page y Pages
+	pages    []int
+	assigned map[int]bool
+
+	part4  []int   // catálogo y objetos de documento
+	part6  []int   // primera página
+	part7  [][]int // resto de páginas, un grupo por página
+	part8  []int   // objetos compartidos
+	part9  []int   // otros objetos
+	shared [][]int // referencias a objetos compartidos de cada página
+}
+
+func linearizeContext(pdfCtx *model.Context) ([]byte, error) {
+	xrt := pdfCtx.XRefTable
+	if xrt.Encrypt != nil {
+		return nil, fmt.Errorf("encrypted PDFs cannot be linearized")
+	}
+	if xrt.Root == nil {
+		return nil, fmt.Errorf("document has no catalog")
+	}
+
+	l := &linearizer{
+		xrt:      xrt,
+		pageTree: make(map[int]bool),
+		assigned: make(map[int]bool),
+	}
+
+	catalogNr := xrt.Root.ObjectNumber.Value()
+	catalog, err := xrt.DereferenceDict(*xrt.Root)
+	if err != nil || catalog == nil {
+		return nil, fmt.Errorf("invalid document catalog")
+	}
+	pagesRef := catalog.IndirectRefEntry("Pages")
+	if pagesRef == nil {
+		return nil, fmt.Errorf("document has no page tree")
+	}
+	if err := l.collectPages(pagesRef.ObjectNumber.Value(), pdftypes.Dict{}, map[int]bool{}); err != nil {
+		return nil, err
+	}
+	if len(l.pages) == 0 {
+		return nil, fmt.Errorf("document has no pages")
+	}
+
+	l.classify(catalogNr, catalog)
+
+	infoNr := 0
+	if xrt.Info != nil {
+		infoNr = xrt.Info.ObjectNumber.Value()
+		l.part9 = append(l.part9, l.collect(infoNr, false)...)
+	}
+
+	return l.write(xrt.VersionString(), catalogNr, infoNr)
+}
+
+// Atributos heredables de los nodos del árbol de páginas (tabla 30 de ISO 32000-1).
+var inheritablePageAttrs = []string{"Resources", "MediaBox", "CropBox", "Rotate"}
+
+// collectPages recorre el árbol de páginas en orden y copia a cada página los
+// atributos heredados, para que cada página sea autosuficiente.
+func (l *linearizer) collectPages(objNr int, inherited pdftypes.Dict, seen map[int]bool) error {
+	if seen[objNr] {
+		return fmt.Errorf("page tree contains a cycle at object %d", objNr)
+	}
+	seen[objNr] = true
+
+	d, err := l.xrt.DereferenceDict(*pdftypes.NewIndirectRef(objNr, 0))
+	if err != nil || d == nil {
+		return fmt.Errorf("invalid page tree node %d", objNr)
+	}
+	l.pageTree[objNr] = true
+
+	if t := d.Type(); t != nil && *t == "Page" {
+		for k, v := range inherited {
+			if _, ok := d[k]; !ok {
+				d[k] = v
+			}
+		}
+		l.pages = append(l.pages, objNr)
+		return nil
+	}
+
+	next := inherited.Clone().(pdftypes.Dict)
+	for _, k := range inheritablePageAttrs {
+		if v, ok := d[k]; ok {
+			next[k] = v
+		}
+	}
+
+	kids, err := l.xrt.DereferenceArray(d["Kids"])
+	if err != nil {
+		return fmt.Errorf("invalid page tree node %d: %w", objNr, err)
+	}
+	for _, k := range kids {
+		ref, ok := k.(pdftypes.IndirectRef)
+		if !ok {
+			return fmt.Errorf("page tree node %d has a direct kid", objNr)
+		}
+		if err := l.collectPages(ref.ObjectNumber.Value(), next, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Entradas del catálogo necesarias para abrir el documento (F.3.4 de ISO 32000-1).
+var documentLevelKeys = []string{"ViewerPreferences", "PageMode", "Threads", "OpenAction", "AcroForm"}
+
+func (l *linearizer) classify(catalogNr int, catalog pdftypes.Dict) {
+	l.assigned[catalogNr] = true
+	l.part4 = []int{catalogNr}
+	keys := documentLevelKeys
+	if pm := catalog.NameEntry("PageMode"); pm != nil && *pm == "UseOutlines" {
+		keys = append(keys, "Outlines")
+	}
+	for _, k := range keys {
+		l.part4 = append(l.part4, l.collectFrom(catalog[k], true, nil)...)
+	}
+
+	// Objetos usados por cada página, sin marcarlos todavía como asignados.
+	used := make([][]int, len(l.pages))
+	for i, pageNr := range l.pages {
+		seen := map[int]bool{}
+		used[i] = append([]int{pageNr}, l.collectFrom(l.object(pageNr), true, seen)...)
+	}
+
+	for _, n := range used[0] {
+		l.assigned[n] = true
+	}
+	l.part6 = used[0]
+	inFirst := make(map[int]int, len(l.part6))
+	for i, n := range l.part6 {
+		inFirst[n] = i
+	}
+
+	users := map[int]int{}
+	for _, objs := range used[1:] {
+		for _, n := range objs {
+			if _, ok := inFirst[n]; !ok {
+				users[n]++
+			}
+		}
+	}
+
+	l.part7 = make([][]int, len(l.pages))
+	l.shared = make([][]int, len(l.pages))
+	sharedIdx := map[int]int{}
+	for i := 1; i < len(l.pages); i++ {
+		for _, n := range used[i] {
+			if idx, ok := inFirst[n]; ok {
+				l.shared[i] = append(l.shared[i], idx)
+				continue
+			}
+			if users[n] == 1 || n == l.pages[i] {
+				l.part7[i] = append(l.part7[i], n)
+				l.assigned[n] = true
+				continue
+			}
+			if _, ok := sharedIdx[n]; !ok {
+				sharedIdx[n] = len(l.part8)
+				l.part8 = append(l.part8, n)
+				l.assigned[n] = true
+			}
+			l.shared[i] = append(l.shared[i], len(l.part6)+sharedIdx[n])
+		}
+	}
+
+	l.part9 = l.collectFrom(catalog, false, nil)
+}
+
+// collect devuelve el objeto objNr y los que referencia que aún no están asignados.
+func (l *linearizer) collect(objNr int, skipPages bool) []int {
+	if l.assigned[objNr] || l.object(objNr) == nil {
+		return nil
+	}
+	l.assigned[objNr] = true
+	return append([]int{objNr}, l.collectFrom(l.object(objNr), skipPages, nil)...)
+}
+
+// collectFrom recorre o en profundidad. Con skipPages no entra en otros nodos
+// del árbol de páginas ni sigue /Parent, para no arrastrar todo el documento.
+// Si seen no es nil se usa en lugar de la marca de asignación global.
+func (l *linearizer) collectFrom(o pdftypes.Object, skipPages bool, seen map[int]bool) []int {
+	var out []int
+	var walk func(o pdftypes.Object)
+	walk = func(o pdftypes.Object) {
+		switch v := o.(type) {
+		case pdftypes.IndirectRef:
+			n := v.ObjectNumber.Value()
+			if skipPages && l.pageTree[n] {
+				return
+			}
+			if seen != nil {
+				if seen[n] || l.assigned[n] {
+					return
+				}
+				seen[n] = true
+			} else {
+				if l.assigned[n] {
+					return
+				}
+				l.assigned[n] = true
+			}
+			obj := l.object(n)
+			if obj == nil {
+				return
+			}
+			out = append(out, n)
+			walk(obj)
+		case pdftypes.Dict:
+			for _, k := range sortedKeys(v) {
+				if skipPages && k == "Parent" {
+					continue
+				}
+				walk(v[k])
+			}
+		case pdftypes.StreamDict:
+			for _, k := range sortedKeys(v.Dict) {
+				if k == "Length" || (skipPages && k == "Parent") {
+					continue
+				}
+				walk(v.Dict[k])
+			}
+		case pdftypes.Array:
+			for _, e := range v {
+				walk(e)
+			}
+		}
+	}
+
+	walk(o)
+	return out
+}
+
+// sortedKeys devuelve las claves de d ordenadas, para que el orden de los
+// objetos en la salida sea reproducible.
+func sortedKeys(d pdftypes.Dict) []string {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (l *linearizer) object(objNr int) pdftypes.Object {
+	e, ok := l.xrt.Find(objNr)
+	if !ok || e == nil || e.Free {
+		return nil
+	}
+	return e.Object
+}
+
+// write serializa los objetos en el orden del anexo F y genera las dos tablas
+// xref, el diccionario de linealización y el hint stream.
+func (l *linearizer) write(version string, catalogNr, infoNr int) ([]byte, error) {
+	// La xref principal cubre 1..m-1 (partes 7, 8 y 9); la de la primera página
+	// m..size-1 (diccionario de linealización, partes 4 y 6 y hint stream).
+	var mainOrder []int
+	for _, g := range l.part7 {
+		mainOrder = append(mainOrder, g...)
+	}
+	mainOrder = append(mainOrder, l.part8...)
+	mainOrder = append(mainOrder, l.part9...)
+
+	renum := make(map[int]int)
+	next := 1
+	for _, n := range mainOrder {
+		renum[n] = next
+		next++
+	}
+	m := next
+	linNr := next
+	next++
+	for _, n := range l.part4 {
+		renum[n] = next
+		next++
+	}
+	for _, n := range l.part6 {
+		renum[n] = next
+		next++
+	}
+	hintNr := next
+	size := next + 1
+
+	objData := make(map[int][]byte, len(renum))
+	for old, num := range renum {
+		data, err := l.serialize(old, num, renum)
+		if err != nil {
+			return nil, err
+		}
+		objData[old] = data
+	}
+
+	header := fmt.Sprintf("%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", version)
+	linObj := func(fileLen, hintOff, hintLen, end, mainXRefT int) string {
+		return fmt.Sprintf("%d 0 obj\n<< /Linearized 1 /L %10d /H [%10d %10d] /O %10d /E %10d /N %10d /T %10d >>\nendobj\n",
+			linNr, fileLen, hintOff, hintLen, renum[l.pages[0]], end, len(l.pages), mainXRefT)
+	}
+	infoRef := ""
+	if n, ok := renum[infoNr]; ok && infoNr != 0 {
+		infoRef = fmt.Sprintf(" /Info %d 0 R", n)
+	}
+	var idBuf bytes.Buffer
+	for _, n := range mainOrder {
+		idBuf.Write(objData[n])
+	}
+	id := fmt.Sprintf("%x", md5.Sum(idBuf.Bytes()))
+	firstTrailer := func(prev int) string {
+		return fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R%s /ID [<%s><%s>] /Prev %10d >>\nstartxref\n0\n%%%%EOF\n",
+			size, renum[catalogNr], infoRef, id, id, prev)
+	}
+	firstXRefHeader := fmt.Sprintf("xref\n%d %d\n", m, size-m)
+
+	// Posiciones antes del hint stream.
+	linOffset := len(header)
+	firstXRefOffset := linOffset + len(linObj(0, 0, 0, 0, 0))
+	pos := firstXRefOffset + len(firstXRefHeader) + 20*(size-m) + len(firstTrailer(0))
+
+	offsets := make(map[int]int, len(renum))
+	for _, n := range l.part4 {
+		offsets[n] = pos
+		pos += len(objData[n])
+	}
+	hintOffset := pos
+
+	// Posiciones sin el hint stream, tal como las usan las hint tables.
+	adj := make(map[int]int, len(renum))
+	for _, n := range append(append([]int{}, l.part6...), mainOrder...) {
+		adj[n] = pos
+		pos += len(objData[n])
+	}
+	adjMainXRef := pos
+
+	hint, err := l.hintStream(hintNr, adj, objData, renum)
+	if err != nil {
+		return nil, err
+	}
+	hintLen := len(hint)
+	for n, off := range adj {
+		offsets[n] = off + hintLen
+	}
+	mainXRef := adjMainXRef + hintLen
+	endFirstPage := offsets[l.part6[len(l.part6)-1]] + len(objData[l.part6[len(l.part6)-1]])
+
+	var mainXRefBuf bytes.Buffer
+	fmt.Fprintf(&mainXRefBuf, "xref\n0 %d", m)
+	mainXRefT := mainXRef + mainXRefBuf.Len()
+	mainXRefBuf.WriteString("\n0000000000 65535 f \n")
+	for _, n := range mainOrder {
+		fmt.Fprintf(&mainXRefBuf, "%010d 00000 n \n", offsets[n])
+	}
+	fmt.Fprintf(&mainXRefBuf, "trailer\n<< /Size %d >>\nstartxref\n%d\n%%%%EOF\n", m, firstXRefOffset)
+	fileLen := mainXRef + mainXRefBuf.Len()
+
+	var out bytes.Buffer
+	out.Grow(fileLen)
+	out.WriteString(header)
+	out.WriteString(linObj(fileLen, hintOffset, hintLen, endFirstPage, mainXRefT))
+	out.WriteString(firstXRefHeader)
+	fmt.Fprintf(&out, "%010d 00000 n \n", linOffset)
+	for _, n := range l.part4 {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offsets[n])
+	}
+	for _, n := range l.part6 {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offsets[n])
+	}
+	fmt.Fprintf(&out, "%010d 00000 n \n", hintOffset)
+	out.WriteString(firstTrailer(mainXRef))
+	for _, n := range l.part4 {
+		out.Write(objData[n])
+	}
+	out.Write(hint)
+	for _, n := range l.part6 {
+		out.Write(objData[n])
+	}
+	for _, n := range mainOrder {
+		out.Write(objData[n])
+	}
+	out.Write(mainXRefBuf.Bytes())
+
+	if out.Len() != fileLen {
+		return nil, fmt.Errorf("internal error: linearized layout is %d bytes, expected %d", out.Len(), fileLen)
+	}
+	return out.Bytes(), nil
+}
+
+// serialize escribe el objeto old con el número num y las referencias renumeradas.
+func (l *linearizer) serialize(old, num int, renum map[int]int) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d 0 obj\n", num)
+
+	switch v := l.object(old).(type) {
+	case pdftypes.StreamDict:
+		d := renumberObject(v.Dict, renum).(pdftypes.Dict)
+		d["Length"] = pdftypes.Integer(len(v.Raw))
+		buf.WriteString(d.PDFString())
+		buf.WriteString("\nstream\n")
+		buf.Write(v.Raw)
+		buf.WriteString("\nendstream")
+	case pdftypes.Dict, pdftypes.Array:
+		buf.WriteString(renumberObject(v, renum).PDFString())
+	case pdftypes.Object:
+		buf.WriteString(v.PDFString())
+	default:
+		return nil, fmt.Errorf("object %d has unsupported type %T", old, v)
+	}
+
+	buf.WriteString("\nendobj\n")
+	return buf.Bytes(), nil
+}
+
+// renumberObject copia o sustituyendo cada referencia por su nuevo número. Las
+// referencias a objetos que no se escriben pasan a null.
+func renumberObject(o pdftypes.Object, renum map[int]int) pdftypes.Object {
+	switch v := o.(type) {
+	case pdftypes.IndirectRef:
+		if n, ok := renum[v.ObjectNumber.Value()]; ok {
+			return *pdftypes.NewIndirectRef(n, 0)
+		}
+		return nil
+	case pdftypes.Dict:
+		d := pdftypes.NewDict()
+		for k, e := range v {
+			d[k] = renumberObject(e, renum)
+		}
+		return d
+	case pdftypes.Array:
+		a := make(pdftypes.Array, len(v))
+		for i, e := range v {
+			a[i] = renumberObject(e, renum)
+		}
+		return a
+	}
+	return o
+}
+
+// hintStream genera el hint stream primario: la tabla de desplazamientos de
+// página (F.4.1) y la tabla de objetos compartidos (F.4.2), con grupos de un objeto.
+func (l *linearizer) hintStream(num int, adj map[int]int, objData map[int][]byte, renum map[int]int) ([]byte, error) {
+	groupEnd := func(g []int) int {
+		last := g[len(g)-1]
+		return adj[last] + len(objData[last])
+	}
+
+	npages := len(l.pages)
+	nobjects := make([]int, npages)
+	lengths := make([]int, npages)
+	for i := range l.pages {
+		g := l.part6
+		if i > 0 {
+			g = l.part7[i]
+		}
+		nobjects[i] = len(g)
+		lengths[i] = groupEnd(g) - adj[g[0]]
+	}
+
+	minObjs, maxObjs := minMax(nobjects)
+	minLen, maxLen := minMax(lengths)
+	maxShared, maxSharedID := 0, 0
+	for _, refs := range l.shared {
+		if len(refs) > maxShared {
+			maxShared = len(refs)
+		}
+		for _, id := range refs {
+			if id > maxSharedID {
+				maxSharedID = id
+			}
+		}
+	}
+
+	var w bitWriter
+	w.write(minObjs, 32)
+	w.write(adj[l.pages[0]], 32)
+	w.write(bitsNeeded(maxObjs-minObjs), 16)
+	w.write(minLen, 32)
+	w.write(bitsNeeded(maxLen-minLen), 16)
+	w.write(0, 32) // desplazamiento mínimo del content stream
+	w.write(0, 16)
+	w.write(minLen, 32) // como qpdf, la longitud del contenido es la de la página
+	w.write(bitsNeeded(maxLen-minLen), 16)
+	w.write(bitsNeeded(maxShared), 16)
+	w.write(bitsNeeded(maxSharedID), 16)
+	w.write(0, 16) // bits del numerador
+	w.write(1, 16) // denominador
+
+	for _, n := range nobjects {
+		w.write(n-minObjs, bitsNeeded(maxObjs-minObjs))
+	}
+	w.flush()
+	for _, n := range lengths {
+		w.write(n-minLen, bitsNeeded(maxLen-minLen))
+	}
+	w.flush()
+	for _, refs := range l.shared {
+		w.write(len(refs), bitsNeeded(maxShared))
+	}
+	w.flush()
+	for _, refs := range l.shared {
+		for _, id := range refs {
+			w.write(id, bitsNeeded(maxSharedID))
+		}
+	}
+	w.flush()
+	w.flush() // numeradores: 0 bits
+	w.flush() // desplazamientos del contenido: 0 bits
+	for _, n := range lengths {
+		w.write(n-minLen, bitsNeeded(maxLen-minLen))
+	}
+	w.flush()
+
+	sharedOffset := w.buf.Len()
+
+	groups := append(append([]int{}, l.part6...), l.part8...)
+	groupLens := make([]int, len(groups))
+	for i, n := range groups {
+		groupLens[i] = len(objData[n])
+	}
+	minGroup, maxGroup := minMax(groupLens)
+	firstShared, firstSharedOffset := 0, 0
+	if len(l.part8) > 0 {
+		firstShared = renum[l.part8[0]]
+		firstSharedOffset = adj[l.part8[0]]
+	}
+
+	w.write(firstShared, 32)
+	w.write(firstSharedOffset, 32)
+	w.write(len(l.part6), 32)
+	w.write(len(groups), 32)
+	w.write(0, 16) // grupos de un objeto
+	w.write(minGroup, 32)
+	w.write(bitsNeeded(maxGroup-minGroup), 16)
+	for _, n := range groupLens {
+		w.write(n-minGroup, bitsNeeded(maxGroup-minGroup))
+	}
+	w.flush()
+	for range groups {
+		w.write(0, 1) // sin firma MD5
+	}
+	w.flush()
+
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write(w.buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to compress hint stream: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress hint stream: %w", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d 0 obj\n<</Filter/FlateDecode/Length %d/S %d>>\nstream\n", num, z.Len(), sharedOffset)
+	buf.Write(z.Bytes())
+	buf.WriteString("\nendstream\nendobj\n")
+	return buf.Bytes(), nil
+}
+
+func minMax(values []int) (int, int) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values[1:] {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	return lo, hi
+}
+
+// bitsNeeded devuelve el número de bits necesario para representar n.
+func bitsNeeded(n int) int {
+	bits := 0
+	for n > 0 {
+		bits++
+		n >>= 1
+	}
+	return bits
+}
+
+// bitWriter escribe enteros de ancho arbitrario, el bit más significativo primero.
+type bitWriter struct {
+	buf   bytes.Buffer
+	cur   byte
+	nbits int
+}
+
+func (w *bitWriter) write(v, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		w.cur = w.cur<<1 | byte(v>>i&1)
+		w.nbits++
+		if w.nbits == 8 {
+			w.buf.WriteByte(w.cur)
+			w.cur, w.nbits = 0, 0
+		}
+	}
+}
+
+// flush completa el byte en curso con ceros.
+func (w *bitWriter) flush() {
+	if w.nbits > 0 {
+		w.write(0, 8-w.nbits)
+	}
+}
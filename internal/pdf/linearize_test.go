@@ -0,0 +1,155 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestLinearize(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestPDF(t, dir, "in.pdf", []string{"page one", "page two", "page three"})
+	out := filepath.Join(dir, "out", "linear.pdf")
+	p := newTestProcessor()
+
+	if lin, err := IsLinearized(in); err != nil || lin {
+		t.Fatalf("IsLinearized(input) = %v, %v; want false", lin, err)
+	}
+
+	result, err := p.Linearize(context.Background(), in, out)
+	if err != nil {
+		t.Fatalf("Linearize failed: %v", err)
+	}
+	if result.TotalPages != 3 || !result.Linearized {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	info, err := p.GetInfo(context.Background(), out)
+	if err != nil {
+		t.Fatalf("GetInfo failed: %v", err)
+	}
+	if !info.Linearized || info.TotalPages != 3 {
+		t.Errorf("GetInfo = %+v, want linearized with 3 pages", info)
+	}
+	if got := extractTestText(t, out, 3); !strings.Contains(got, "page three") {
+		t.Errorf("page 3 text = %q", got)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLinearizedLayout(t, data, 3)
+}
+
+// checkLinearizedLayout comprueba los valores del diccionario de linealización
+// y la tabla de desplazamientos de página del hint stream.
+func checkLinearizedLayout(t *testing.T, data []byte, pages int) {
+	t.Helper()
+
+	param := func(key string) int {
+		m := regexp.MustCompile(`/` + key + `\s+(\d+)`).FindSubmatch(data[:300])
+		if m == nil {
+			t.Fatalf("linearization dictionary has no /%s", key)
+		}
+		v, _ := strconv.Atoi(string(m[1]))
+		return v
+	}
+
+	if l := param("L"); l != len(data) {
+		t.Errorf("/L = %d, file size %d", l, len(data))
+	}
+	if n := param("N"); n != pages {
+		t.Errorf("/N = %d, want %d", n, pages)
+	}
+
+	h := regexp.MustCompile(`/H \[\s*(\d+)\s+(\d+)\]`).FindSubmatch(data)
+	hintOff, _ := strconv.Atoi(string(h[1]))
+	hintLen, _ := strconv.Atoi(string(h[2]))
+	hint := data[hintOff : hintOff+hintLen]
+	if !regexp.MustCompile(`^\d+ 0 obj\n<<[^>]*/S \d+`).Match(hint) || !bytes.HasSuffix(hint, []byte("endobj\n")) {
+		t.Fatalf("/H does not point to the hint stream: %q", hint[:40])
+	}
+
+	pageObj := fmt.Sprintf("%d 0 obj\n<<", param("O"))
+	firstPage := bytes.Index(data, []byte(pageObj))
+	if firstPage < hintOff+hintLen || !bytes.Contains(data[firstPage:firstPage+200], []byte("/Type/Page")) {
+		t.Errorf("/O object not found after the hint stream")
+	}
+	if e := param("E"); e <= firstPage || e > len(data) {
+		t.Errorf("/E = %d out of range", e)
+	}
+
+	tOff := param("T")
+	if !bytes.HasPrefix(data[tOff:], []byte("\n0000000000 65535 f")) {
+		t.Errorf("/T does not point before the first main xref entry: %q", data[tOff:tOff+20])
+	}
+
+	// Tabla de desplazamientos de página: número mínimo de objetos y posición
+	// de la primera página sin contar el hint stream.
+	start := bytes.Index(hint, []byte("stream\n")) + len("stream\n")
+	end := bytes.LastIndex(hint, []byte("\nendstream"))
+	zr, err := zlib.NewReader(bytes.NewReader(hint[start:end]))
+	if err != nil {
+		t.Fatalf("hint stream is not Flate encoded: %v", err)
+	}
+	table, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	be32 := func(b []byte) int { return int(b[0])<<24 | int(b[1])<<16 | int(b[2])<<8 | int(b[3]) }
+	if got := be32(table[4:8]); got != firstPage-hintLen {
+		t.Errorf("hint table first page offset = %d, want %d", got, firstPage-hintLen)
+	}
+	if minObjs := be32(table[0:4]); minObjs < 1 {
+		t.Errorf("hint table least objects per page = %d", minObjs)
+	}
+}
+
+func TestCompressAndMergeLinearize(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestPDF(t, dir, "a.pdf", []string{"a1", "a2"})
+	b := writeTestPDF(t, dir, "b.pdf", []string{"b1"})
+	p := newTestProcessor()
+
+	merged := filepath.Join(dir, "merged.pdf")
+	mr, err := p.Merge(context.Background(), []string{a, b}, merged, types.MergeOptions{Linearize: true})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if lin, _ := IsLinearized(merged); !lin || !mr.Linearized {
+		t.Error("merged output is not linearized")
+	}
+
+	compressed := filepath.Join(dir, "compressed.pdf")
+	cr, err := p.Compress(context.Background(), merged, compressed, types.CompressOptions{Linearize: true})
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	data, err := os.ReadFile(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lin, _ := IsLinearized(compressed); !lin || !cr.Linearized {
+		t.Fatal("compressed output is not linearized")
+	}
+	checkLinearizedLayout(t, data, 3)
+
+	// Añadir bytes al final invalida la linealización.
+	if err := os.WriteFile(compressed, append(data, '\n'), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if lin, _ := IsLinearized(compressed); lin {
+		t.Error("modified file still reported as linearized")
+	}
+}
@@ -0,0 +1,568 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// compressSettings son los parámetros efectivos de una compresión, una vez
+// combinados el perfil y las opciones explícitas.
+type compressSettings struct {
+	profile   types.CompressProfile
+	lossy     bool
+	quality   int
+	maxDPI    int
+	grayscale bool
+}
+
+var compressProfiles = map[types.CompressProfile]compressSettings{
+	types.ProfileLossless: {},
+	types.ProfilePrint:    {lossy: true, quality: 85, maxDPI: 300},
+	types.ProfileEbook:    {lossy: true, quality: 75, maxDPI: 150},
+	types.ProfileScreen:   {lossy: true, quality: 50, maxDPI: 72},
+}
+
+// resolveCompressSettings aplica las opciones explícitas sobre el perfil.
+// Sin perfil se usa lossless; el perfil custom parte de PDF_IMAGE_QUALITY y no
+// reduce la resolución salvo que se indique max_dpi.
+func (p *Processor) resolveCompressSettings(opts types.CompressOptions) (compressSettings, error) {
+	profile := opts.Profile
+	if profile == "" {
+		profile = types.ProfileLossless
+	}
+	if opts.ImageQuality < 0 || opts.ImageQuality > 100 {
+		return compressSettings{}, fmt.Errorf("invalid image quality %d: must be between 1 and 100", opts.ImageQuality)
+	}
+	if opts.MaxDPI < 0 {
+		return compressSettings{}, fmt.Errorf("invalid max DPI %d", opts.MaxDPI)
+	}
+
+	var s compressSettings
+	switch profile {
+	case types.ProfileCustom:
+		s = compressSettings{lossy: true, quality: p.config.ImageQuality}
+		if s.quality <= 0 || s.quality > 100 {
+			s.quality = 75
+		}
+	case types.ProfileLossless:
+		if opts.ImageQuality != 0 || opts.MaxDPI != 0 || opts.Grayscale {
+			return compressSettings{}, fmt.Errorf("the lossless profile does not accept image quality, max DPI or grayscale")
+		}
+	default:
+		base, ok := compressProfiles[profile]
+		if !ok {
+			return compressSettings{}, fmt.Errorf("unknown compression profile %q (valid: lossless, print, ebook, screen, custom)", profile)
+		}
+		s = base
+	}
+
+	if opts.ImageQuality > 0 {
+		s.quality = opts.ImageQuality
+	}
+	if opts.MaxDPI > 0 {
+		s.maxDPI = opts.MaxDPI
+	}
+	s.grayscale = opts.Grayscale
+	s.profile = profile
+	return s, nil
+}
+
+// compressContext lee inputPath, aplica los pasos de compresión propios y la
+// optimización de pdfcpu, y escribe el resultado en w. El avance se cuenta
+// por página analizada y, en los perfiles con pérdida, por imagen recodificada.
+func (p *Processor) compressContext(ctx context.Context, inputPath string, settings compressSettings, result *types.CompressResult, w io.Writer) error {
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		return err
+	}
+	pdfCtx.Cmd = model.OPTIMIZE
+
+	c := &compressor{ctx: ctx, xrt: pdfCtx.XRefTable, settings: settings, result: result}
+	total := pdfCtx.PageCount
+	if settings.lossy {
+		total += len(c.imageObjects())
+	}
+	c.progress = newProgressCounter(ctx, total)
+	if err := c.run(); err != nil {
+		return err
+	}
+	c.progress.finish()
+
+	if err := checkCancelled(ctx); err != nil {
+		return err
+	}
+	if err := api.OptimizeContext(pdfCtx); err != nil {
+		return fmt.Errorf("failed to optimize PDF: %w", err)
+	}
+	if err := api.WriteContext(pdfCtx, w); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return nil
+}
+
+// compressor aplica sobre el contexto en memoria los pasos de compresión que
+// pdfcpu no hace: fuentes sin usar, imágenes duplicadas y recompresión de imágenes.
+type compressor struct {
+	ctx      context.Context
+	xrt      *model.XRefTable
+	settings compressSettings
+	result   *types.CompressResult
+	dropped  map[int]bool // imágenes duplicadas que ya no se referencian
+	progress *progressCounter
+}
+
+// run ejecuta los pasos y anota en el resultado los bytes ahorrados por cada uno.
+// Solo falla si se cancela c.ctx.
+func (c *compressor) run() error {
+	size := reachableSize(c.xrt)
+	measure := func(saved *int64) {
+		next := reachableSize(c.xrt)
+		*saved += size - next
+		size = next
+	}
+
+	c.dropUnusedFonts()
+	measure(&c.result.Savings.UnusedFonts)
+
+	c.dedupeImages()
+	measure(&c.result.Savings.DuplicateImages)
+
+	if c.settings.lossy {
+		if err := c.recompressImages(); err != nil {
+			return err
+		}
+		measure(&c.result.Savings.Images)
+	}
+	return nil
+}
+
+func (c *compressor) warn(format string, args ...interface{}) {
+	c.result.Warnings = append(c.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+// loadPages interpreta el contenido de todas las páginas. Con report cada
+// página cuenta como una unidad de avance.
+func (c *compressor) loadPages(report bool) ([]*pageContent, error) {
+	fonts := newFontCache(c.xrt)
+	pages := make([]*pageContent, 0, c.xrt.PageCount)
+	for i := 1; i <= c.xrt.PageCount; i++ {
+		pc, err := loadPageContent(c.xrt, i, fonts)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i, err)
+		}
+		pages = append(pages, pc)
+		if report {
+			c.progress.step()
+		}
+	}
+	return pages, nil
+}
+
+// dropUnusedFonts elimina de los diccionarios /Font de las páginas y Form XObjects
+// las fuentes que ningún operador Tf selecciona. Un mismo diccionario puede estar
+// compartido por varias páginas, así que solo se elimina una fuente si no la usa
+// ninguna. Si algún contenido no se puede interpretar no se elimina nada.
+func (c *compressor) dropUnusedFonts() {
+	pages, err := c.loadPages(true)
+	if err != nil {
+		c.warn("unused fonts kept: %v", err)
+		return
+	}
+
+	type fontDict struct {
+		dict pdftypes.Dict
+		used map[string]bool
+	}
+	var dicts []*fontDict
+	byID := map[uintptr]*fontDict{}
+
+	for _, pc := range pages {
+		for _, src := range pc.sources {
+			d, err := c.xrt.DereferenceDict(src.resources["Font"])
+			if err != nil || len(d) == 0 {
+				continue
+			}
+			id := reflect.ValueOf(d).Pointer()
+			fd := byID[id]
+			if fd == nil {
+				fd = &fontDict{dict: d, used: map[string]bool{}}
+				byID[id] = fd
+				dicts = append(dicts, fd)
+			}
+			for _, op := range src.ops {
+				if op.op == "Tf" && len(op.args) == 2 && op.args[0].kind == operandName {
+					fd.used[op.args[0].name] = true
+				}
+			}
+		}
+	}
+
+	for _, fd := range dicts {
+		for _, name := range sortedKeys(fd.dict) {
+			if !fd.used[name] {
+				delete(fd.dict, name)
+				c.result.FontsRemoved++
+			}
+		}
+	}
+}
+
+// imageObjects devuelve ordenados los números de objeto de las imágenes.
+func (c *compressor) imageObjects() []int {
+	var nums []int
+	for objNr, e := range c.xrt.Table {
+		if e == nil || e.Free || c.dropped[objNr] {
+			continue
+		}
+		sd, ok := e.Object.(pdftypes.StreamDict)
+		if !ok {
+			continue
+		}
+		if st := sd.Subtype(); st != nil && *st == "Image" {
+			nums = append(nums, objNr)
+		}
+	}
+	sort.Ints(nums)
+	return nums
+}
+
+// dedupeImages sustituye las referencias a imágenes idénticas (mismo diccionario y
+// mismos datos) por la primera copia. Se repite hasta que no quedan duplicados
+// porque fusionar dos /SMask puede hacer idénticas a las imágenes que las usan.
+func (c *compressor) dedupeImages() {
+	if c.dropped == nil {
+		c.dropped = map[int]bool{}
+	}
+	for {
+		first := map[[sha256.Size]byte]pdftypes.IndirectRef{}
+		repl := map[int]pdftypes.IndirectRef{}
+		for _, objNr := range c.imageObjects() {
+			e := c.xrt.Table[objNr]
+			sd := e.Object.(pdftypes.StreamDict)
+
+			h := sha256.New()
+			h.Write([]byte(sd.Dict.PDFString()))
+			h.Write(sd.Raw)
+			var key [sha256.Size]byte
+			copy(key[:], h.Sum(nil))
+
+			if ref, ok := first[key]; ok {
+				repl[objNr] = ref
+				continue
+			}
+			gen := 0
+			if e.Generation != nil {
+				gen = *e.Generation
+			}
+			first[key] = *pdftypes.NewIndirectRef(objNr, gen)
+		}
+		if len(repl) == 0 {
+			return
+		}
+
+		for _, e := range c.xrt.Table {
+			if e != nil && !e.Free {
+				replaceRefs(e.Object, repl)
+			}
+		}
+		for objNr := range repl {
+			c.dropped[objNr] = true
+		}
+		c.result.DuplicateImages += len(repl)
+	}
+}
+
+// replaceRefs sustituye en o, en el sitio, las referencias indicadas en repl.
+func replaceRefs(o pdftypes.Object, repl map[int]pdftypes.IndirectRef) {
+	swap := func(e pdftypes.Object) (pdftypes.Object, bool) {
+		if ir, ok := e.(pdftypes.IndirectRef); ok {
+			if to, ok := repl[ir.ObjectNumber.Value()]; ok {
+				return to, true
+			}
+		}
+		return nil, false
+	}
+
+	switch v := o.(type) {
+	case pdftypes.Dict:
+		for k, e := range v {
+			if to, ok := swap(e); ok {
+				v[k] = to
+				continue
+			}
+			replaceRefs(e, repl)
+		}
+	case pdftypes.Array:
+		for i, e := range v {
+			if to, ok := swap(e); ok {
+				v[i] = to
+				continue
+			}
+			replaceRefs(e, repl)
+		}
+	case pdftypes.StreamDict:
+		replaceRefs(v.Dict, repl)
+	}
+}
+
+// imageResolutions devuelve, para cada imagen dibujada en alguna página, la menor
+// resolución efectiva (en DPI) con la que aparece: reducirla por debajo de ese
+// valor perdería detalle en el uso más grande de la imagen.
+func (c *compressor) imageResolutions(pages []*pageContent) map[int]float64 {
+	res := map[int]float64{}
+	for _, pc := range pages {
+		for _, img := range pc.images {
+			if img.inline || img.ref == nil {
+				continue
+			}
+			objNr := img.ref.ObjectNumber.Value()
+			sd, _, err := c.xrt.DereferenceStreamDict(*img.ref)
+			if err != nil || sd == nil {
+				continue
+			}
+			w, h := sd.IntEntry("Width"), sd.IntEntry("Height")
+			pw, ph := math.Hypot(img.ctm[0], img.ctm[1]), math.Hypot(img.ctm[2], img.ctm[3])
+			if w == nil || h == nil || pw == 0 || ph == 0 {
+				continue
+			}
+			dpi := math.Min(float64(*w)*72/pw, float64(*h)*72/ph)
+			if old, ok := res[objNr]; !ok || dpi < old {
+				res[objNr] = dpi
+			}
+		}
+	}
+	return res
+}
+
+// recompressImages recodifica como JPEG las imágenes de 8 bits en gris o RGB,
+// reduciendo la resolución y pasando a gris según el perfil. Las imágenes usadas
+// como /SMask, las máscaras y las que tienen máscara por color se dejan intactas.
+func (c *compressor) recompressImages() error {
+	var dpi map[int]float64
+	if c.settings.maxDPI > 0 {
+		pages, err := c.loadPages(false)
+		if err != nil {
+			c.warn("images not downsampled: %v", err)
+		} else {
+			dpi = c.imageResolutions(pages)
+		}
+	}
+
+	images := c.imageObjects()
+	masks := map[int]bool{}
+	for _, objNr := range images {
+		sd := c.xrt.Table[objNr].Object.(pdftypes.StreamDict)
+		if ir := sd.IndirectRefEntry("SMask"); ir != nil {
+			masks[ir.ObjectNumber.Value()] = true
+		}
+	}
+
+	for _, objNr := range images {
+		if err := checkCancelled(c.ctx); err != nil {
+			return err
+		}
+		c.progress.step()
+		e := c.xrt.Table[objNr]
+		sd := e.Object.(pdftypes.StreamDict)
+		if masks[objNr] {
+			continue
+		}
+		if _, ok := sd.Find("Mask"); ok {
+			if _, isArray := sd.Dict["Mask"].(pdftypes.Array); isArray {
+				continue
+			}
+		}
+
+		out, downsampled, gray, err := reencodeImage(c.xrt, sd, c.settings, dpi[objNr])
+		if err != nil || out == nil {
+			continue
+		}
+		e.Object = *out
+		c.result.ImagesReencoded++
+		if downsampled {
+			c.result.ImagesDownsampled++
+		}
+		if gray {
+			c.result.ImagesGrayscale++
+		}
+	}
+	return nil
+}
+
+// reencodeImage devuelve la imagen recodificada como JPEG, o nil si la nueva
+// versión no ocupa menos que la original. dpi es la resolución efectiva de la
+// imagen en la página (0 si no se conoce, en cuyo caso no se reduce).
+func reencodeImage(xrt *model.XRefTable, sd pdftypes.StreamDict, s compressSettings, dpi float64) (*pdftypes.StreamDict, bool, bool, error) {
+	pixels, w, h, comps, err := decodeImagePixels(xrt, &sd)
+	if err != nil {
+		return nil, false, false, err
+	}
+	if comps == 4 {
+		return nil, false, false, fmt.Errorf("CMYK images are not re-encoded")
+	}
+
+	gray := false
+	if s.grayscale && comps == 3 {
+		pixels = rgbToGray(pixels)
+		comps = 1
+		gray = true
+	}
+
+	downsampled := false
+	if s.maxDPI > 0 && dpi > float64(s.maxDPI) {
+		f := float64(s.maxDPI) / dpi
+		nw, nh := max(1, int(math.Round(float64(w)*f))), max(1, int(math.Round(float64(h)*f)))
+		if nw < w || nh < h {
+			pixels = downsamplePixels(pixels, w, h, comps, nw, nh)
+			w, h = nw, nh
+			downsampled = true
+		}
+	}
+
+	data, err := encodeJPEG(pixels, w, h, comps, s.quality)
+	if err != nil {
+		return nil, false, false, err
+	}
+	if len(data) >= len(sd.Raw) {
+		return nil, false, false, nil
+	}
+
+	out := pdftypes.StreamDict{
+		Dict:           sd.Dict.Clone().(pdftypes.Dict),
+		Raw:            data,
+		FilterPipeline: []pdftypes.PDFFilter{{Name: filter.DCT}},
+	}
+	l := int64(len(data))
+	out.StreamLength = &l
+	out.Delete("DecodeParms")
+	out.Update("Filter", pdftypes.Name(filter.DCT))
+	out.Update("Length", pdftypes.Integer(l))
+	out.Update("Width", pdftypes.Integer(w))
+	out.Update("Height", pdftypes.Integer(h))
+	if gray {
+		out.Update("ColorSpace", pdftypes.Name("DeviceGray"))
+	}
+	return &out, downsampled, gray, nil
+}
+
+// rgbToGray convierte muestras RGB a gris con los pesos de luminancia de Rec. 601.
+func rgbToGray(pixels []byte) []byte {
+	out := make([]byte, len(pixels)/3)
+	for i := range out {
+		r, g, b := float64(pixels[3*i]), float64(pixels[3*i+1]), float64(pixels[3*i+2])
+		out[i] = byte(math.Round(0.299*r + 0.587*g + 0.114*b))
+	}
+	return out
+}
+
+// downsamplePixels reduce la imagen a nw x nh promediando los píxeles de origen
+// que cubre cada píxel de destino.
+func downsamplePixels(pixels []byte, w, h, comps, nw, nh int) []byte {
+	out := make([]byte, nw*nh*comps)
+	sum := make([]int, comps)
+	for y := 0; y < nh; y++ {
+		y0, y1 := y*h/nh, max((y+1)*h/nh, y*h/nh+1)
+		for x := 0; x < nw; x++ {
+			x0, x1 := x*w/nw, max((x+1)*w/nw, x*w/nw+1)
+			for k := range sum {
+				sum[k] = 0
+			}
+			for sy := y0; sy < y1; sy++ {
+				row := pixels[(sy*w+x0)*comps : (sy*w+x1)*comps]
+				for i, v := range row {
+					sum[i%comps] += int(v)
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			for k := range sum {
+				out[(y*nw+x)*comps+k] = byte((sum[k] + n/2) / n)
+			}
+		}
+	}
+	return out
+}
+
+// encodeJPEG codifica muestras de 8 bits en gris (comps 1) o RGB (comps 3).
+func encodeJPEG(pixels []byte, w, h, comps, quality int) ([]byte, error) {
+	var img image.Image
+	switch comps {
+	case 1:
+		img = &image.Gray{Pix: pixels, Stride: w, Rect: image.Rect(0, 0, w, h)}
+	case 3:
+		rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+		for i := 0; i < w*h; i++ {
+			copy(rgba.Pix[4*i:], pixels[3*i:3*i+3])
+			rgba.Pix[4*i+3] = 255
+		}
+		img = rgba
+	default:
+		return nil, fmt.Errorf("unsupported number of components: %d", comps)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode JPEG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// reachableSize suma el tamaño aproximado (diccionario y datos del stream) de los
+// objetos alcanzables desde el trailer, que son los que se escriben en la salida.
+func reachableSize(xrt *model.XRefTable) int64 {
+	var size int64
+	seen := map[int]bool{}
+
+	var walk func(o pdftypes.Object)
+	walk = func(o pdftypes.Object) {
+		switch v := o.(type) {
+		case pdftypes.IndirectRef:
+			n := v.ObjectNumber.Value()
+			if seen[n] {
+				return
+			}
+			seen[n] = true
+			e, ok := xrt.Find(n)
+			if !ok || e.Free || e.Object == nil {
+				return
+			}
+			if sd, ok := e.Object.(pdftypes.StreamDict); ok {
+				size += int64(len(sd.Raw))
+			}
+			size += int64(len(e.Object.PDFString()))
+			walk(e.Object)
+		case pdftypes.Dict:
+			for _, e := range v {
+				walk(e)
+			}
+		case pdftypes.Array:
+			for _, e := range v {
+				walk(e)
+			}
+		case pdftypes.StreamDict:
+			walk(v.Dict)
+		}
+	}
+
+	if xrt.Root != nil {
+		walk(*xrt.Root)
+	}
+	if xrt.Info != nil {
+		walk(*xrt.Info)
+	}
+	return size
+}
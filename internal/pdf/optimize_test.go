@@ -0,0 +1,182 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// writeCompressTestPDF genera una página con dos copias idénticas de una imagen RGB
+// de 400x400 píxeles dibujadas a 100x100 puntos (288 DPI) y una fuente sin usar.
+func writeCompressTestPDF(t *testing.T, dir string) string {
+	t.Helper()
+
+	pixels := make([]byte, 0, 400*400*3)
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 400; x++ {
+			pixels = append(pixels, byte(x*255/399), byte(y*255/399), byte((x+y)%256))
+		}
+	}
+	var zbuf bytes.Buffer
+	zw := zlib.NewWriter(&zbuf)
+	zw.Write(pixels)
+	zw.Close()
+
+	image := fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width 400 /Height 400 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", zbuf.Len(), zbuf.String())
+	fontFile := strings.Repeat("unused font program ", 200)
+	content := "q 100 0 0 100 100 100 cm /Im1 Do Q q 100 0 0 100 300 100 cm /Im2 Do Q BT /F1 12 Tf 72 720 Td (text) Tj ET\n"
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 7 0 R /F2 8 0 R >> /XObject << /Im1 5 0 R /Im2 6 0 R >> >> /Contents 4 0 R >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content),
+		image,
+		image,
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Unused /FirstChar 32 /LastChar 32 /Widths [250] /FontDescriptor 9 0 R >>",
+		"<< /Type /FontDescriptor /FontName /Unused /Flags 32 /FontBBox [0 0 1000 1000] /ItalicAngle 0 /Ascent 800 /Descent -200 /CapHeight 700 /StemV 80 /FontFile 10 0 R >>",
+		fmt.Sprintf("<< /Length1 %d /Length2 0 /Length3 0 /Length %d >>\nstream\n%s\nendstream", len(fontFile), len(fontFile), fontFile),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+
+	path := filepath.Join(dir, "compress.pdf")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write test PDF: %v", err)
+	}
+	return path
+}
+
+// pageImages devuelve los diccionarios de las imágenes dibujadas en la página 1.
+func pageImages(t *testing.T, p *Processor, path string) []pdftypes.Dict {
+	t.Helper()
+	ctx, err := p.readContext(path)
+	if err != nil {
+		t.Fatalf("readContext failed: %v", err)
+	}
+	pc, err := loadPageContent(ctx.XRefTable, 1, newFontCache(ctx.XRefTable))
+	if err != nil {
+		t.Fatalf("loadPageContent failed: %v", err)
+	}
+	var dicts []pdftypes.Dict
+	for _, img := range pc.images {
+		sd, _, err := ctx.XRefTable.DereferenceStreamDict(*img.ref)
+		if err != nil || sd == nil {
+			t.Fatalf("failed to load image: %v", err)
+		}
+		dicts = append(dicts, sd.Dict)
+	}
+	return dicts
+}
+
+func TestCompressEbookProfile(t *testing.T) {
+	dir := t.TempDir()
+	in := writeCompressTestPDF(t, dir)
+	out := filepath.Join(dir, "ebook.pdf")
+	p := newTestProcessor()
+
+	result, err := p.Compress(context.Background(), in, out, types.CompressOptions{Profile: types.ProfileEbook})
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	if result.Profile != types.ProfileEbook {
+		t.Errorf("Profile = %q", result.Profile)
+	}
+	if result.DuplicateImages != 1 || result.FontsRemoved != 1 {
+		t.Errorf("duplicates = %d, fonts removed = %d; want 1 and 1", result.DuplicateImages, result.FontsRemoved)
+	}
+	if result.ImagesReencoded != 1 || result.ImagesDownsampled != 1 {
+		t.Errorf("reencoded = %d, downsampled = %d; want 1 and 1", result.ImagesReencoded, result.ImagesDownsampled)
+	}
+	s := result.Savings
+	if s.Images <= 0 || s.DuplicateImages <= 0 || s.UnusedFonts <= 0 {
+		t.Errorf("expected savings in every category: %+v", s)
+	}
+	if total := s.Images + s.DuplicateImages + s.UnusedFonts + s.Structure; total != result.OriginalSize-result.CompressedSize {
+		t.Errorf("savings add up to %d, want %d", total, result.OriginalSize-result.CompressedSize)
+	}
+
+	imgs := pageImages(t, p, out)
+	if len(imgs) != 2 {
+		t.Fatalf("expected 2 image draws, got %d", len(imgs))
+	}
+	// 400 px a 288 DPI reducidos a 150 DPI.
+	if w := imgs[0].IntEntry("Width"); w == nil || *w != 208 {
+		t.Errorf("Width = %v, want 208", w)
+	}
+	if f := imgs[0].NameEntry("Filter"); f == nil || *f != "DCTDecode" {
+		t.Errorf("Filter = %v, want DCTDecode", f)
+	}
+	if got := extractTestText(t, out, 1); !strings.Contains(got, "text") {
+		t.Errorf("page text = %q", got)
+	}
+}
+
+func TestCompressLosslessAndGrayscale(t *testing.T) {
+	dir := t.TempDir()
+	in := writeCompressTestPDF(t, dir)
+	p := newTestProcessor()
+
+	lossless := filepath.Join(dir, "lossless.pdf")
+	result, err := p.Compress(context.Background(), in, lossless, types.CompressOptions{})
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	if result.Profile != types.ProfileLossless || result.ImagesReencoded != 0 || result.Savings.Images != 0 {
+		t.Errorf("lossless compression changed images: %+v", result)
+	}
+	if w := pageImages(t, p, lossless)[0].IntEntry("Width"); w == nil || *w != 400 {
+		t.Errorf("Width = %v, want 400", w)
+	}
+
+	gray := filepath.Join(dir, "gray.pdf")
+	result, err = p.Compress(context.Background(), in, gray, types.CompressOptions{Profile: types.ProfileCustom, ImageQuality: 60, Grayscale: true})
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	if result.ImagesGrayscale != 1 || result.ImagesDownsampled != 0 {
+		t.Errorf("grayscale = %d, downsampled = %d; want 1 and 0", result.ImagesGrayscale, result.ImagesDownsampled)
+	}
+	if cs := pageImages(t, p, gray)[0].NameEntry("ColorSpace"); cs == nil || *cs != "DeviceGray" {
+		t.Errorf("ColorSpace = %v, want DeviceGray", cs)
+	}
+}
+
+func TestCompressInvalidOptions(t *testing.T) {
+	dir := t.TempDir()
+	in := writeCompressTestPDF(t, dir)
+	p := newTestProcessor()
+
+	for name, opts := range map[string]types.CompressOptions{
+		"unknown profile":       {Profile: "tiny"},
+		"lossless with quality": {Profile: types.ProfileLossless, ImageQuality: 50},
+		"quality out of range":  {Profile: types.ProfileEbook, ImageQuality: 101},
+		"negative dpi":          {Profile: types.ProfileCustom, MaxDPI: -1},
+	} {
+		if _, err := p.Compress(context.Background(), in, filepath.Join(dir, "out.pdf"), opts); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -90,8 +90,8 @@ func TestParsePageSelection(t *testing.T) {
 			wantErr:    true,
 		},
 		{
-			name:       "negative page",
-			selection:  "-1",
+			name:       "double dash",
+			selection:  "--1",
 			totalPages: 10,
 			wantErr:    true,
 		},
@@ -108,9 +108,15 @@ func TestParsePageSelection(t *testing.T) {
 			wantErr:    true,
 		},
 		{
-			name:       "reversed range",
+			name:       "reversed range sorted",
 			selection:  "8-5",
 			totalPages: 10,
+			want:       []int{5, 6, 7, 8},
+		},
+		{
+			name:       "range starting at zero",
+			selection:  "0-3",
+			totalPages: 10,
 			wantErr:    true,
 		},
 		{
@@ -135,7 +141,7 @@ func TestParsePageSelection(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parsePageSelection(tt.selection, tt.totalPages)
+			got, err := parsePageSelection(tt.selection, tt.totalPages, nil)
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("parsePageSelection(%q, %d) expected error, got %v", tt.selection, tt.totalPages, got)
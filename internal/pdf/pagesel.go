@@ -0,0 +1,336 @@
+package pdf
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// labelSelectorPrefix marca en una selección de páginas un extremo que es una
+// etiqueta de página en lugar de un número ("label:iv-label:xii").
+const labelSelectorPrefix = "label:"
+
+// PageSelectionSyntax resume la sintaxis de las selecciones de páginas para
+// descripciones de herramientas y ayudas de línea de comandos.
+const PageSelectionSyntax = "Comma-separated pages and ranges: '5', '2-8', open ranges '5-' and '-3', 'last' and 'last-2', 'odd', 'even', " +
+	"steps '1-20/3', reverse ranges '8-5', exclusions '1-20,!5' and page labels 'label:iv-label:xii'"
+
+// PageSelection es una expresión de selección de páginas ya analizada. Se
+// resuelve contra un documento concreto con Pages.
+//
+// Gramática (los elementos se separan con comas y se ignoran los espacios):
+//
+//	elemento := ["!"] término ["/" paso]
+//	término  := "odd" | "even" | extremo | [extremo] "-" [extremo]
+//	extremo  := N | "last" | "last-" N | "label:" etiqueta
+//
+// Un rango sin inicio empieza en la página 1 y uno sin final acaba en la
+// última; si el inicio es mayor que el final, el rango se recorre al revés.
+// Los elementos con "!" se excluyen del resultado; si solo hay exclusiones,
+// se parte de todas las páginas.
+type PageSelection struct {
+	expr  string
+	terms []pageTerm
+}
+
+// pageBound es un extremo de un rango.
+type pageBound struct {
+	text   string
+	last   bool   // "last" o "last-N"; n es el desplazamiento
+	label  string // "label:..."; vacío si no es una etiqueta
+	n      int
+	isOpen bool
+}
+
+// pageRangeCandidate es una forma de leer un término como página o rango.
+// Los términos con etiquetas pueden admitir varias, porque las etiquetas
+// pueden contener guiones ("label:A-1-label:A-9").
+type pageRangeCandidate struct {
+	from, to pageBound
+	single   bool
+}
+
+type pageTerm struct {
+	text       string
+	exclude    bool
+	parity     int // 1 impares, 2 pares, 0 rango
+	step       int
+	candidates []pageRangeCandidate
+}
+
+// ParsePageSelection analiza la sintaxis de una selección sin resolverla
+// contra un documento. Los errores son los mismos que devuelve SelectPages,
+// de modo que los front ends pueden validar la selección antes de procesar.
+func ParsePageSelection(expr string) (*PageSelection, error) {
+	sel := &PageSelection{expr: expr}
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		term, err := parsePageTerm(part)
+		if err != nil {
+			return nil, err
+		}
+		sel.terms = append(sel.terms, term)
+	}
+	if len(sel.terms) == 0 {
+		return nil, fmt.Errorf("empty page selection")
+	}
+	return sel, nil
+}
+
+// SelectPages analiza expr y la resuelve contra un documento de totalPages
+// páginas. labels contiene la etiqueta de cada página; con nil, las
+// referencias "label:" producen un error.
+func SelectPages(expr string, totalPages int, labels []string) ([]int, error) {
+	sel, err := ParsePageSelection(expr)
+	if err != nil {
+		return nil, err
+	}
+	return sel.Pages(totalPages, labels)
+}
+
+// parsePageSelection devuelve las páginas de selection ordenadas y sin duplicados.
+func parsePageSelection(selection string, totalPages int, labels []string) ([]int, error) {
+	return SelectPages(selection, totalPages, labels)
+}
+
+// String devuelve la expresión original.
+func (s *PageSelection) String() string {
+	return s.expr
+}
+
+// Pages resuelve la selección contra un documento de totalPages páginas y
+// devuelve las páginas ordenadas y sin duplicados.
+func (s *PageSelection) Pages(totalPages int, labels []string) ([]int, error) {
+	var included []int
+	excluded := map[int]bool{}
+	hasInclusions := false
+
+	for _, t := range s.terms {
+		pages, err := t.resolve(totalPages, labels)
+		if err != nil {
+			return nil, err
+		}
+		if t.exclude {
+			for _, p := range pages {
+				excluded[p] = true
+			}
+			continue
+		}
+		hasInclusions = true
+		included = append(included, pages...)
+	}
+	if !hasInclusions {
+		for p := 1; p <= totalPages; p++ {
+			included = append(included, p)
+		}
+	}
+
+	out := make([]int, 0, len(included))
+	seen := map[int]bool{}
+	for _, p := range included {
+		if excluded[p] || seen[p] {
+			continue
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	sort.Ints(out)
+	if len(out) == 0 {
+		return nil, fmt.Errorf("page selection %q matches no pages", s.expr)
+	}
+	return out, nil
+}
+
+// parsePageTerm analiza un elemento de la selección.
+func parsePageTerm(part string) (pageTerm, error) {
+	t := pageTerm{text: part}
+	body := part
+	if rest, ok := strings.CutPrefix(body, "!"); ok {
+		t.exclude = true
+		body = strings.TrimSpace(rest)
+	}
+
+	isLabel := strings.Contains(body, labelSelectorPrefix)
+	if i := strings.LastIndex(body, "/"); i >= 0 {
+		step := strings.TrimSpace(body[i+1:])
+		switch {
+		case isDigits(step):
+			t.step, _ = strconv.Atoi(step)
+			if t.step < 1 {
+				return t, fmt.Errorf("invalid step in %q: must be at least 1", part)
+			}
+			body = strings.TrimSpace(body[:i])
+		case !isLabel:
+			return t, fmt.Errorf("invalid step %q in %q", step, part)
+		}
+	}
+
+	switch strings.ToLower(body) {
+	case "odd":
+		t.parity = 1
+	case "even":
+		t.parity = 2
+	}
+	if t.parity != 0 {
+		if t.step != 0 {
+			return t, fmt.Errorf("invalid selection %q: steps are only allowed on ranges", part)
+		}
+		return t, nil
+	}
+
+	// Con paso, el término solo puede ser un rango: "last-2/2" va de la última a la 2.
+	if b, ok := parsePageBound(body); ok && !b.isOpen && t.step == 0 {
+		t.candidates = append(t.candidates, pageRangeCandidate{from: b, to: b, single: true})
+	}
+	for i := 0; i < len(body); i++ {
+		if body[i] != '-' {
+			continue
+		}
+		from, okFrom := parsePageBound(body[:i])
+		to, okTo := parsePageBound(body[i+1:])
+		if okFrom && okTo && !(from.isOpen && to.isOpen) {
+			t.candidates = append(t.candidates, pageRangeCandidate{from: from, to: to})
+			if !isLabel {
+				break
+			}
+		}
+	}
+
+	if len(t.candidates) == 0 {
+		if t.step != 0 {
+			return t, fmt.Errorf("invalid selection %q: steps are only allowed on ranges", part)
+		}
+		return t, fmt.Errorf("invalid page selection %q: expected N, N-M, N-, -N, last, last-N, odd, even or label:<label>", part)
+	}
+	return t, nil
+}
+
+// parsePageBound analiza un extremo de rango. Un extremo vacío es un extremo abierto.
+func parsePageBound(s string) (pageBound, bool) {
+	s = strings.TrimSpace(s)
+	b := pageBound{text: s}
+	if s == "" {
+		b.isOpen = true
+		return b, true
+	}
+	if label, ok := strings.CutPrefix(s, labelSelectorPrefix); ok {
+		b.label = strings.TrimSpace(label)
+		return b, b.label != ""
+	}
+	if isDigits(s) {
+		n, err := strconv.Atoi(s)
+		b.n = n
+		return b, err == nil
+	}
+	lower := strings.ToLower(s)
+	if lower == "last" {
+		b.last = true
+		return b, true
+	}
+	if rest, ok := strings.CutPrefix(lower, "last"); ok {
+		if off, ok := strings.CutPrefix(strings.TrimSpace(rest), "-"); ok && isDigits(strings.TrimSpace(off)) {
+			n, err := strconv.Atoi(strings.TrimSpace(off))
+			b.last, b.n = true, n
+			return b, err == nil
+		}
+	}
+	return b, false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve devuelve las páginas del término en el orden de la expresión.
+func (t pageTerm) resolve(totalPages int, labels []string) ([]int, error) {
+	if t.parity != 0 {
+		var pages []int
+		for p := t.parity; p <= totalPages; p += 2 {
+			pages = append(pages, p)
+		}
+		return pages, nil
+	}
+
+	// Se usa la primera lectura cuyos extremos existen. Si ninguna sirve, se
+	// informa preferentemente del extremo final de un rango cuyo inicio sí existe.
+	var firstErr, endErr error
+	for _, c := range t.candidates {
+		start, err := c.from.resolve(totalPages, labels, 1)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		end, err := c.to.resolve(totalPages, labels, totalPages)
+		if err != nil {
+			if endErr == nil && !c.single {
+				endErr = err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		return expandPageRange(t, c, start, end, totalPages)
+	}
+	if endErr != nil {
+		return nil, endErr
+	}
+	return nil, firstErr
+}
+
+// expandPageRange comprueba los límites y enumera las páginas del rango.
+func expandPageRange(t pageTerm, c pageRangeCandidate, start, end, totalPages int) ([]int, error) {
+	if c.single {
+		if start < 1 || start > totalPages {
+			return nil, fmt.Errorf("page %d out of bounds (PDF has %d pages)", start, totalPages)
+		}
+		return []int{start}, nil
+	}
+	if start < 1 || end < 1 || start > totalPages || end > totalPages {
+		return nil, fmt.Errorf("range %d-%d out of bounds (PDF has %d pages)", start, end, totalPages)
+	}
+
+	step := max(t.step, 1)
+	var pages []int
+	if start <= end {
+		for p := start; p <= end; p += step {
+			pages = append(pages, p)
+		}
+	} else {
+		for p := start; p >= end; p -= step {
+			pages = append(pages, p)
+		}
+	}
+	return pages, nil
+}
+
+// resolve convierte el extremo en un número de página; open es el valor de un
+// extremo abierto.
+func (b pageBound) resolve(totalPages int, labels []string, open int) (int, error) {
+	switch {
+	case b.isOpen:
+		return open, nil
+	case b.label != "":
+		if labels == nil {
+			return 0, fmt.Errorf("page labels are not available for %q", b.text)
+		}
+		return resolvePageLabel(b.label, labels)
+	case b.last:
+		return totalPages - b.n, nil
+	}
+	return b.n, nil
+}
@@ -0,0 +1,78 @@
+package pdf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection string
+		want      []int
+		wantErr   string
+	}{
+		{name: "open end", selection: "8-", want: []int{8, 9, 10}},
+		{name: "open start", selection: "-3", want: []int{1, 2, 3}},
+		{name: "last", selection: "last", want: []int{10}},
+		{name: "last offset", selection: "last-2", want: []int{8}},
+		{name: "last offset range", selection: "last-2-last", want: []int{8, 9, 10}},
+		{name: "odd", selection: "odd", want: []int{1, 3, 5, 7, 9}},
+		{name: "even uppercase", selection: "EVEN", want: []int{2, 4, 6, 8, 10}},
+		{name: "step", selection: "1-10/3", want: []int{1, 4, 7, 10}},
+		{name: "step on open range", selection: "2-/4", want: []int{2, 6, 10}},
+		{name: "exclusion", selection: "1-5,!2,!4", want: []int{1, 3, 5}},
+		{name: "only exclusions", selection: "!odd,!10", want: []int{2, 4, 6, 8}},
+		{name: "reverse range", selection: "4-1", want: []int{1, 2, 3, 4}},
+		{name: "reverse step", selection: "last-1/4", want: []int{2, 6, 10}},
+		{name: "duplicates", selection: "3,1,3,1-2", want: []int{1, 2, 3}},
+		{name: "spaces", selection: " last - 2 , ! 9 ", want: []int{8}},
+		{name: "empty", selection: " , ", wantErr: "empty page selection"},
+		{name: "out of bounds", selection: "11", wantErr: "page 11 out of bounds (PDF has 10 pages)"},
+		{name: "last offset out of bounds", selection: "last-10", wantErr: "page 0 out of bounds"},
+		{name: "range out of bounds", selection: "9-12", wantErr: "range 9-12 out of bounds"},
+		{name: "zero step", selection: "1-5/0", wantErr: "must be at least 1"},
+		{name: "step on single page", selection: "5/2", wantErr: "steps are only allowed on ranges"},
+		{name: "step on parity", selection: "odd/2", wantErr: "steps are only allowed on ranges"},
+		{name: "bad step", selection: "1-5/x", wantErr: `invalid step "x"`},
+		{name: "garbage", selection: "first", wantErr: `invalid page selection "first"`},
+		{name: "bare dash", selection: "-", wantErr: `invalid page selection "-"`},
+		{name: "nothing left", selection: "2,!2", wantErr: "matches no pages"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectPages(tt.selection, 10, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SelectPages(%q) error = %v, want %q", tt.selection, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectPages(%q) unexpected error: %v", tt.selection, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SelectPages(%q) = %v, want %v", tt.selection, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePageSelectionSyntaxOnly(t *testing.T) {
+	// La sintaxis se valida sin documento; las etiquetas se resuelven después.
+	sel, err := ParsePageSelection("label:iv-label:x, last")
+	if err != nil {
+		t.Fatalf("ParsePageSelection failed: %v", err)
+	}
+	got, err := sel.Pages(6, []string{"i", "ii", "iii", "iv", "v", "x"})
+	if err != nil {
+		t.Fatalf("Pages failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []int{4, 5, 6}) {
+		t.Errorf("Pages = %v", got)
+	}
+	if _, err := ParsePageSelection("1-3/"); err == nil {
+		t.Error("expected syntax error for empty step")
+	}
+}
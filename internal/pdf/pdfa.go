@@ -0,0 +1,891 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// Reglas PDF/A comprobadas por CheckPDFA.
+const (
+	PDFARuleFileHeader           = "file_header"
+	PDFARuleTrailerID            = "trailer_id"
+	PDFARuleEncryption           = "encryption"
+	PDFARuleOutputIntent         = "output_intent"
+	PDFARuleDeviceColor          = "device_color"
+	PDFARuleFontsEmbedded        = "fonts_embedded"
+	PDFARuleFontUnicode          = "font_unicode"
+	PDFARuleTransparency         = "transparency"
+	PDFARuleBlendMode            = "blend_mode"
+	PDFARuleJavaScript           = "javascript"
+	PDFARuleForbiddenAction      = "forbidden_action"
+	PDFARuleAdditionalActions    = "additional_actions"
+	PDFARuleForbiddenAnnotation  = "forbidden_annotation"
+	PDFARuleAnnotationFlags      = "annotation_flags"
+	PDFARuleAnnotationAppearance = "annotation_appearance"
+	PDFARuleEmbeddedFiles        = "embedded_files"
+	PDFARuleOptionalContent      = "optional_content"
+	PDFARuleForms                = "forms"
+	PDFARuleImages               = "images"
+	PDFARuleStreams              = "streams"
+	PDFARuleContent              = "content"
+	PDFARuleXMPMetadata          = "xmp_metadata"
+	PDFARuleXMPIdentification    = "xmp_pdfa_id"
+	PDFARuleXMPMismatch          = "xmp_mismatch"
+)
+
+// Tipos de acción prohibidos en PDF/A (ISO 19005-2, 6.6.1).
+var pdfaForbiddenActions = map[string]bool{
+	"Launch": true, "Sound": true, "Movie": true, "ResetForm": true, "ImportData": true,
+	"Hide": true, "SetOCGState": true, "Rendition": true, "Trans": true, "GoTo3DView": true,
+}
+
+// Acciones Named permitidas.
+var pdfaNamedActions = map[string]bool{"NextPage": true, "PrevPage": true, "FirstPage": true, "LastPage": true}
+
+// Anotaciones prohibidas en todos los niveles.
+var pdfaForbiddenAnnots = map[string]bool{"Sound": true, "Movie": true, "Screen": true, "3D": true, "RichMedia": true}
+
+// Modos de fusión estándar (ISO 32000-1, tabla 136).
+var pdfaBlendModes = map[string]bool{
+	"Normal": true, "Compatible": true, "Multiply": true, "Screen": true, "Overlay": true,
+	"Darken": true, "Lighten": true, "ColorDodge": true, "ColorBurn": true, "HardLight": true,
+	"SoftLight": true, "Difference": true, "Exclusion": true, "Hue": true, "Saturation": true,
+	"Color": true, "Luminosity": true,
+}
+
+// Flags de anotación (ISO 32000-1, 12.5.3).
+const (
+	annotFlagInvisible    = 1
+	annotFlagHidden       = 2
+	annotFlagPrint        = 4
+	annotFlagNoView       = 32
+	annotFlagToggleNoView = 256
+)
+
+// CheckPDFA comprueba la conformidad del documento con un nivel PDF/A (por
+// defecto 2b). Las violaciones marcadas como fixable las corrige ConvertPDFA.
+func (p *Processor) CheckPDFA(ctx context.Context, inputPath string, level types.PDFALevel) (*types.PDFAReport, error) {
+	p.logger.Debug("checking PDF/A conformance",
+		slog.String("input", inputPath),
+		slog.String("level", string(level)))
+
+	level, ok := types.ParsePDFALevel(string(level))
+	if !ok {
+		return nil, unsupportedPDFALevel(level)
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(inputPath); err != nil {
+		return nil, err
+	}
+
+	header, err := readFileHeader(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF", err)
+		return nil, err
+	}
+
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+
+	c := newPDFAChecker(pdfCtx.XRefTable, level, false)
+	if !hasBinaryComment(header) {
+		c.report(PDFARuleFileHeader, func() {}, "the header is not followed by a comment with binary characters")
+	}
+	if err := c.run(ctx); err != nil {
+		return nil, err
+	}
+
+	report := &types.PDFAReport{
+		InputPath:    inputPath,
+		Level:        level,
+		ClaimedLevel: c.claimed,
+		Conformant:   len(c.found) == 0,
+		Convertible:  true,
+		Violations:   c.found,
+	}
+	for _, v := range c.found {
+		if !v.Fixable {
+			report.Convertible = false
+		}
+	}
+
+	p.logger.Debug("PDF/A check complete",
+		slog.Bool("conformant", report.Conformant),
+		slog.Int("violations", len(report.Violations)))
+
+	return report, nil
+}
+
+func unsupportedPDFALevel(level types.PDFALevel) error {
+	return fmt.Errorf("unsupported PDF/A level %q (supported: 1b, 2b, 2u, 3b, 3u)", level)
+}
+
+// readFileHeader lee el principio del archivo para comprobar la cabecera.
+func readFileHeader(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	defer f.Close()
+
+	buf := make([]byte, 64)
+	n, _ := f.Read(buf)
+	return buf[:n], nil
+}
+
+// hasBinaryComment indica si tras la línea %PDF-x.y hay un comentario con al
+// menos cuatro bytes mayores que 127 (ISO 19005-2, 6.1.2).
+func hasBinaryComment(header []byte) bool {
+	i := bytes.IndexAny(header, "\r\n")
+	if i < 0 {
+		return false
+	}
+	rest := bytes.TrimLeft(header[i:], "\r\n")
+	if len(rest) < 5 || rest[0] != '%' {
+		return false
+	}
+	for _, b := range rest[1:5] {
+		if b < 128 {
+			return false
+		}
+	}
+	return true
+}
+
+// pdfaChecker recorre el documento comprobando las reglas PDF/A. Con fix activo
+// corrige las violaciones corregibles en el contexto en lugar de informarlas.
+type pdfaChecker struct {
+	xrt   *model.XRefTable
+	level types.PDFALevel
+	part  string // "1", "2" o "3"
+	fix   bool
+
+	page int // página que se recorre (0 fuera de las páginas)
+	obj  int // objeto indirecto que se recorre
+
+	seen       map[int]bool
+	fontsSeen  map[int]bool
+	gstateSeen map[int]bool
+	rgbPage    int // primera página que usa DeviceRGB
+	cmykPage   int // primera página que usa DeviceCMYK
+	intentN    int // componentes del perfil del OutputIntent (0 si no hay)
+
+	found   []types.PDFAViolation
+	fixed   []types.PDFAViolation
+	claimed string
+
+	rewriteXMP  bool
+	addIntent   bool
+	hadMetadata bool
+}
+
+func newPDFAChecker(xrt *model.XRefTable, level types.PDFALevel, fix bool) *pdfaChecker {
+	return &pdfaChecker{
+		xrt:        xrt,
+		level:      level,
+		part:       string(level[:1]),
+		fix:        fix,
+		seen:       map[int]bool{},
+		fontsSeen:  map[int]bool{},
+		gstateSeen: map[int]bool{},
+		found:      []types.PDFAViolation{},
+		fixed:      []types.PDFAViolation{},
+	}
+}
+
+// report anota una violación. fix es nil si no se puede corregir; en modo fix
+// las corregibles se corrigen y se anotan en fixed.
+func (c *pdfaChecker) report(rule string, fix func(), format string, args ...interface{}) {
+	v := types.PDFAViolation{
+		Rule:        rule,
+		Description: fmt.Sprintf(format, args...),
+		Page:        c.page,
+		Object:      c.obj,
+		Fixable:     fix != nil,
+	}
+	if c.fix && fix != nil {
+		fix()
+		c.fixed = append(c.fixed, v)
+		return
+	}
+	c.found = append(c.found, v)
+}
+
+// run recorre el documento página a página; solo falla si ctx se cancela.
+func (c *pdfaChecker) run(ctx context.Context) error {
+	xrt := c.xrt
+	if xrt.Encrypt != nil {
+		c.report(PDFARuleEncryption, nil, "the document is encrypted")
+	}
+	if len(xrt.ID) == 0 {
+		c.report(PDFARuleTrailerID, func() {}, "the trailer has no ID")
+	}
+
+	fonts := newFontCache(xrt)
+	for i := 1; i <= xrt.PageCount; i++ {
+		if err := checkCancelled(ctx); err != nil {
+			return err
+		}
+		pd, ref, inh, err := xrt.PageDict(i, false)
+		if err != nil || pd == nil {
+			continue
+		}
+		c.page, c.obj = i, 0
+		if ref != nil {
+			c.obj = ref.ObjectNumber.Value()
+			c.seen[c.obj] = true
+		}
+		c.walkDict(pd)
+		if _, own := pd["Resources"]; !own && inh != nil && inh.Resources != nil {
+			c.walk(inh.Resources)
+		}
+
+		c.obj = 0
+		pc, err := loadPageContent(xrt, i, fonts)
+		if err != nil {
+			c.report(PDFARuleContent, nil, "the page content cannot be parsed: %v", err)
+			continue
+		}
+		c.checkContent(pc)
+	}
+	c.page = 0
+
+	root, err := xrt.Catalog()
+	if err != nil || root == nil {
+		c.report(PDFARuleStreams, nil, "the document has no catalog")
+		return nil
+	}
+	c.obj = xrt.Root.ObjectNumber.Value()
+	c.seen[c.obj] = true
+	c.checkCatalog(root)
+	c.walkDict(root)
+
+	c.obj = xrt.Root.ObjectNumber.Value()
+	c.checkOutputIntent(root)
+	c.checkMetadata(root)
+	return nil
+}
+
+// walk recorre o y todo lo que referencia, sin subir por Parent ni entrar en
+// otras páginas.
+func (c *pdfaChecker) walk(o pdftypes.Object) {
+	if ref, ok := o.(pdftypes.IndirectRef); ok {
+		n := ref.ObjectNumber.Value()
+		if c.seen[n] {
+			return
+		}
+		c.seen[n] = true
+		obj, err := c.xrt.Dereference(ref)
+		if err != nil || obj == nil {
+			return
+		}
+		prev := c.obj
+		c.obj = n
+		c.walk(obj)
+		c.obj = prev
+		return
+	}
+
+	switch v := o.(type) {
+	case pdftypes.Dict:
+		if t := v.Type(); t != nil && (*t == "Page" || *t == "Pages") {
+			return
+		}
+		c.walkDict(v)
+	case pdftypes.StreamDict:
+		c.checkStream(v)
+		c.walkDict(v.Dict)
+	case pdftypes.Array:
+		for _, e := range v {
+			c.walk(e)
+		}
+	}
+}
+
+func (c *pdfaChecker) walkDict(d pdftypes.Dict) {
+	c.checkDict(d)
+	for _, k := range sortedKeys(d) {
+		if k == "Parent" {
+			continue
+		}
+		c.walk(d[k])
+	}
+}
+
+func (c *pdfaChecker) checkDict(d pdftypes.Dict) {
+	if _, ok := d["AA"]; ok {
+		c.report(PDFARuleAdditionalActions, func() { d.Delete("AA") }, "additional actions (AA) are not allowed")
+	}
+	for _, key := range []string{"A", "OpenAction"} {
+		if _, ok := d[key]; ok {
+			c.checkAction(d, key)
+		}
+	}
+
+	if gs, err := c.xrt.DereferenceDict(d["ExtGState"]); err == nil && gs != nil {
+		for _, name := range sortedKeys(gs) {
+			c.checkExtGState(name, gs[name])
+		}
+	}
+
+	if g, err := c.xrt.DereferenceDict(d["Group"]); err == nil && g != nil && c.part == "1" {
+		if s := g.NameEntry("S"); s != nil && *s == "Transparency" {
+			c.report(PDFARuleTransparency, nil, "transparency groups are not allowed in PDF/A-1")
+		}
+	}
+
+	if _, ok := d["Annots"]; ok {
+		c.checkAnnotations(d)
+	}
+
+	if _, ok := d["EF"]; ok {
+		c.checkFileSpec(d)
+	}
+
+	// Diccionario AcroForm.
+	if _, ok := d["Fields"]; ok {
+		if na := d.BooleanEntry("NeedAppearances"); na != nil && *na {
+			c.report(PDFARuleForms, func() { d.Delete("NeedAppearances") }, "NeedAppearances must not be true")
+		}
+		if _, ok := d["XFA"]; ok {
+			c.report(PDFARuleForms, func() { d.Delete("XFA") }, "XFA forms are not allowed")
+		}
+	}
+}
+
+// checkAction comprueba la acción de holder[key] y su cadena Next.
+func (c *pdfaChecker) checkAction(holder pdftypes.Dict, key string) {
+	a, err := c.xrt.DereferenceDict(holder[key])
+	if err != nil || a == nil {
+		return
+	}
+	if rule, desc := pdfaActionViolation(a); rule != "" {
+		c.report(rule, func() { holder.Delete(key) }, "%s (%s)", desc, key)
+		return
+	}
+
+	switch next := a["Next"].(type) {
+	case nil:
+	case pdftypes.Array:
+		kept := pdftypes.Array{}
+		for _, e := range next {
+			na, err := c.xrt.DereferenceDict(e)
+			if err == nil && na != nil {
+				if rule, desc := pdfaActionViolation(na); rule != "" {
+					c.report(rule, func() {}, "%s (Next)", desc)
+					if c.fix {
+						continue
+					}
+				}
+			}
+			kept = append(kept, e)
+		}
+		if c.fix {
+			a["Next"] = kept
+		}
+	default:
+		c.checkAction(a, "Next")
+	}
+}
+
+// pdfaActionViolation devuelve la regla que incumple una acción, si alguna.
+func pdfaActionViolation(a pdftypes.Dict) (rule, desc string) {
+	s := a.NameEntry("S")
+	if s == nil {
+		return "", ""
+	}
+	switch {
+	case *s == "JavaScript":
+		return PDFARuleJavaScript, "JavaScript actions are not allowed"
+	case pdfaForbiddenActions[*s]:
+		return PDFARuleForbiddenAction, fmt.Sprintf("%s actions are not allowed", *s)
+	case *s == "Named":
+		if n := a.NameEntry("N"); n == nil || !pdfaNamedActions[*n] {
+			name := ""
+			if n != nil {
+				name = *n
+			}
+			return PDFARuleForbiddenAction, fmt.Sprintf("named action %q is not allowed", name)
+		}
+	}
+	return "", ""
+}
+
+func (c *pdfaChecker) checkExtGState(name string, o pdftypes.Object) {
+	if ref, ok := o.(pdftypes.IndirectRef); ok {
+		n := ref.ObjectNumber.Value()
+		if c.gstateSeen[n] {
+			return
+		}
+		c.gstateSeen[n] = true
+	}
+	gs, err := c.xrt.DereferenceDict(o)
+	if err != nil || gs == nil {
+		return
+	}
+
+	var bm []string
+	switch v := gs["BM"].(type) {
+	case pdftypes.Name:
+		bm = []string{v.Value()}
+	case pdftypes.Array:
+		for _, e := range v {
+			if n, ok := e.(pdftypes.Name); ok {
+				bm = append(bm, n.Value())
+			}
+		}
+	}
+
+	if c.part == "1" {
+		if sm, ok := gs["SMask"]; ok {
+			if n, isName := sm.(pdftypes.Name); !isName || n.Value() != "None" {
+				c.report(PDFARuleTransparency, nil, "graphics state %s uses a soft mask", name)
+			}
+		}
+		for _, key := range []string{"CA", "ca"} {
+			if v, err := c.xrt.DereferenceNumber(gs[key]); err == nil && gs[key] != nil && v < 1 {
+				c.report(PDFARuleTransparency, nil, "graphics state %s sets %s to %g", name, key, v)
+			}
+		}
+		for _, m := range bm {
+			if m != "Normal" && m != "Compatible" {
+				c.report(PDFARuleTransparency, nil, "graphics state %s uses blend mode %s", name, m)
+			}
+		}
+		return
+	}
+	for _, m := range bm {
+		if !pdfaBlendModes[m] {
+			c.report(PDFARuleBlendMode, nil, "graphics state %s uses non-standard blend mode %s", name, m)
+		}
+	}
+}
+
+func (c *pdfaChecker) checkStream(sd pdftypes.StreamDict) {
+	d := sd.Dict
+	for _, f := range streamFilters(d) {
+		if f == "LZWDecode" {
+			c.report(PDFARuleStreams, nil, "LZWDecode filter is not allowed")
+		}
+	}
+	if _, ok := d["F"]; ok {
+		c.report(PDFARuleStreams, nil, "external streams (F) are not allowed")
+	}
+
+	st := d.Subtype()
+	if st == nil {
+		return
+	}
+	switch *st {
+	case "Image":
+		if b := d.BooleanEntry("Interpolate"); b != nil && *b {
+			c.report(PDFARuleImages, func() { d.Delete("Interpolate") }, "images must not set Interpolate")
+		}
+		for _, key := range []string{"Alternates", "OPI"} {
+			key := key
+			if _, ok := d[key]; ok {
+				c.report(PDFARuleImages, func() { d.Delete(key) }, "image %s entries are not allowed", key)
+			}
+		}
+		if c.part == "1" {
+			if _, ok := d["SMask"]; ok {
+				c.report(PDFARuleTransparency, nil, "images with soft masks are not allowed in PDF/A-1")
+			}
+		}
+	case "Form":
+		for _, key := range []string{"OPI", "PS"} {
+			key := key
+			if _, ok := d[key]; ok {
+				c.report(PDFARuleStreams, func() { d.Delete(key) }, "form XObject %s entries are not allowed", key)
+			}
+		}
+		if st2 := d.NameEntry("Subtype2"); st2 != nil && *st2 == "PS" {
+			c.report(PDFARuleStreams, nil, "PostScript XObjects are not allowed")
+		}
+	case "PS":
+		c.report(PDFARuleStreams, nil, "PostScript XObjects are not allowed")
+	}
+}
+
+// streamFilters devuelve los nombres de los filtros de un stream.
+func streamFilters(d pdftypes.Dict) []string {
+	switch f := d["Filter"].(type) {
+	case pdftypes.Name:
+		return []string{f.Value()}
+	case pdftypes.Array:
+		var names []string
+		for _, e := range f {
+			if n, ok := e.(pdftypes.Name); ok {
+				names = append(names, n.Value())
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// checkAnnotations comprueba las anotaciones de una página; en modo fix quita
+// del array Annots las prohibidas.
+func (c *pdfaChecker) checkAnnotations(page pdftypes.Dict) {
+	annots, err := c.xrt.DereferenceArray(page["Annots"])
+	if err != nil || annots == nil {
+		return
+	}
+
+	kept := pdftypes.Array{}
+	removed := false
+	for _, e := range annots {
+		ad, err := c.xrt.DereferenceDict(e)
+		if err != nil || ad == nil {
+			kept = append(kept, e)
+			continue
+		}
+		prev := c.obj
+		if ref, ok := e.(pdftypes.IndirectRef); ok {
+			c.obj = ref.ObjectNumber.Value()
+		}
+
+		subtype := ""
+		if st := ad.Subtype(); st != nil {
+			subtype = *st
+		}
+		if pdfaForbiddenAnnots[subtype] || (c.part == "1" && subtype == "FileAttachment") {
+			drop := false
+			c.report(PDFARuleForbiddenAnnotation, func() { drop = true }, "%s annotations are not allowed", subtype)
+			if drop {
+				removed = true
+				c.obj = prev
+				continue
+			}
+		}
+
+		if subtype != "Popup" {
+			flags := 0
+			if f := ad.IntEntry("F"); f != nil {
+				flags = *f
+			}
+			bad := annotFlagInvisible | annotFlagHidden | annotFlagNoView | annotFlagToggleNoView
+			if flags&annotFlagPrint == 0 || flags&bad != 0 {
+				fixed := (flags | annotFlagPrint) &^ bad
+				c.report(PDFARuleAnnotationFlags, func() { ad["F"] = pdftypes.Integer(fixed) },
+					"%s annotation must be printable and visible (F=%d)", subtype, flags)
+			}
+		}
+
+		if c.part != "1" && subtype != "Popup" && subtype != "Link" {
+			if ap, err := c.xrt.DereferenceDict(ad["AP"]); err != nil || ap == nil || ap["N"] == nil {
+				c.report(PDFARuleAnnotationAppearance, nil, "%s annotation has no normal appearance stream", subtype)
+			}
+		}
+
+		c.obj = prev
+		kept = append(kept, e)
+	}
+
+	if !removed {
+		return
+	}
+	if ref, ok := page["Annots"].(pdftypes.IndirectRef); ok {
+		if entry, found := c.xrt.FindTableEntryForIndRef(&ref); found {
+			entry.Object = kept
+			return
+		}
+	}
+	page["Annots"] = kept
+}
+
+// checkFileSpec comprueba un file specification con archivos incrustados.
+func (c *pdfaChecker) checkFileSpec(fs pdftypes.Dict) {
+	switch c.part {
+	case "1":
+		c.report(PDFARuleEmbeddedFiles, nil, "embedded files are not allowed in PDF/A-1")
+	case "2":
+		ef, err := c.xrt.DereferenceDict(fs["EF"])
+		if err != nil || ef == nil {
+			return
+		}
+		for _, key := range sortedKeys(ef) {
+			sd, _, err := c.xrt.DereferenceStreamDict(ef[key])
+			if err != nil || sd == nil {
+				continue
+			}
+			if err := sd.Decode(); err != nil || !bytes.HasPrefix(sd.Content, []byte("%PDF-")) {
+				c.report(PDFARuleEmbeddedFiles, nil, "PDF/A-2 only allows embedded PDF/A files")
+				return
+			}
+		}
+	case "3":
+		if fs.NameEntry("AFRelationship") == nil {
+			c.report(PDFARuleEmbeddedFiles, func() { fs.InsertName("AFRelationship", "Unspecified") },
+				"embedded files must declare AFRelationship")
+		}
+	}
+}
+
+// checkContent comprueba las fuentes que usa el contenido de la página y los
+// espacios de color dependientes del dispositivo.
+func (c *pdfaChecker) checkContent(pc *pageContent) {
+	for _, src := range pc.sources {
+		fonts, _ := c.xrt.DereferenceDict(src.resources["Font"])
+		for _, op := range src.ops {
+			switch op.op {
+			case "Tf":
+				if len(op.args) == 2 && op.args[0].kind == operandName && fonts != nil {
+					c.checkFont(op.args[0].name, fonts[op.args[0].name])
+				}
+			case "rg", "RG":
+				c.useColor("DeviceRGB")
+			case "k", "K":
+				c.useColor("DeviceCMYK")
+			case "cs", "CS":
+				if len(op.args) == 1 && op.args[0].kind == operandName {
+					c.useColor(op.args[0].name)
+				}
+			}
+		}
+	}
+	for _, img := range pc.images {
+		if img.ref == nil {
+			continue
+		}
+		if sd, _, err := c.xrt.DereferenceStreamDict(*img.ref); err == nil && sd != nil {
+			if cs, ok := sd.Dict["ColorSpace"].(pdftypes.Name); ok {
+				c.useColor(cs.Value())
+			}
+		}
+	}
+}
+
+func (c *pdfaChecker) useColor(cs string) {
+	switch cs {
+	case "DeviceRGB":
+		if c.rgbPage == 0 {
+			c.rgbPage = c.page
+		}
+	case "DeviceCMYK":
+		if c.cmykPage == 0 {
+			c.cmykPage = c.page
+		}
+	}
+}
+
+func (c *pdfaChecker) checkFont(name string, o pdftypes.Object) {
+	prev := c.obj
+	defer func() { c.obj = prev }()
+	if ref, ok := o.(pdftypes.IndirectRef); ok {
+		n := ref.ObjectNumber.Value()
+		if c.fontsSeen[n] {
+			return
+		}
+		c.fontsSeen[n] = true
+		c.obj = n
+	}
+	fd, err := c.xrt.DereferenceDict(o)
+	if err != nil || fd == nil {
+		return
+	}
+
+	label := name
+	if bf := fd.NameEntry("BaseFont"); bf != nil {
+		label = *bf
+	}
+	subtype := ""
+	if st := fd.Subtype(); st != nil {
+		subtype = *st
+	}
+
+	unicodeRequired := strings.HasSuffix(string(c.level), "u") && (subtype == "Type0" || subtype == "Type3")
+	if _, ok := fd["ToUnicode"]; !ok && unicodeRequired {
+		c.report(PDFARuleFontUnicode, nil, "font %s has no ToUnicode map", label)
+	}
+	if subtype == "Type3" {
+		return
+	}
+
+	descHolder := fd
+	if subtype == "Type0" {
+		if df, err := c.xrt.DereferenceArray(fd["DescendantFonts"]); err == nil && len(df) > 0 {
+			if d, err := c.xrt.DereferenceDict(df[0]); err == nil && d != nil {
+				descHolder = d
+			}
+		}
+	}
+	desc, err := c.xrt.DereferenceDict(descHolder["FontDescriptor"])
+	embedded := false
+	if err == nil && desc != nil {
+		for _, key := range []string{"FontFile", "FontFile2", "FontFile3"} {
+			if _, ok := desc[key]; ok {
+				embedded = true
+			}
+		}
+	}
+	if !embedded {
+		c.report(PDFARuleFontsEmbedded, nil, "font %s is not embedded", label)
+	}
+}
+
+func (c *pdfaChecker) checkCatalog(root pdftypes.Dict) {
+	if names, err := c.xrt.DereferenceDict(root["Names"]); err == nil && names != nil {
+		if _, ok := names["JavaScript"]; ok {
+			c.report(PDFARuleJavaScript, func() { names.Delete("JavaScript") }, "document-level JavaScript is not allowed")
+		}
+	}
+	if _, ok := root["OCProperties"]; ok && c.part == "1" {
+		c.report(PDFARuleOptionalContent, nil, "optional content is not allowed in PDF/A-1")
+	}
+}
+
+// checkOutputIntent busca el OutputIntent GTS_PDFA1 y comprueba que los colores
+// de dispositivo usados son compatibles con su perfil.
+func (c *pdfaChecker) checkOutputIntent(root pdftypes.Dict) {
+	intents, _ := c.xrt.DereferenceArray(root["OutputIntents"])
+	var profiles []int
+	for _, o := range intents {
+		oi, err := c.xrt.DereferenceDict(o)
+		if err != nil || oi == nil {
+			continue
+		}
+		if s := oi.NameEntry("S"); s == nil || *s != "GTS_PDFA1" {
+			continue
+		}
+		ref := oi.IndirectRefEntry("DestOutputProfile")
+		if ref == nil {
+			continue
+		}
+		profiles = append(profiles, ref.ObjectNumber.Value())
+		if sd, _, err := c.xrt.DereferenceStreamDict(*ref); err == nil && sd != nil {
+			if n := sd.IntEntry("N"); n != nil {
+				c.intentN = *n
+			}
+		}
+	}
+
+	for _, n := range profiles[min(1, len(profiles)):] {
+		if n != profiles[0] {
+			c.report(PDFARuleOutputIntent, nil, "PDF/A output intents use different profiles")
+			break
+		}
+	}
+
+	intentN := c.intentN
+	if len(profiles) == 0 {
+		c.report(PDFARuleOutputIntent, func() { c.addIntent = true }, "there is no PDF/A OutputIntent with an ICC profile")
+		intentN = 3 // ConvertPDFA añade un OutputIntent sRGB
+	}
+
+	switch {
+	case c.cmykPage > 0 && intentN != 4:
+		c.page = c.cmykPage
+		c.report(PDFARuleDeviceColor, nil, "DeviceCMYK is used but the output intent is not CMYK")
+	case c.rgbPage > 0 && intentN != 3:
+		c.page = c.rgbPage
+		c.report(PDFARuleDeviceColor, nil, "DeviceRGB is used but the output intent is not RGB")
+	}
+	c.page = 0
+}
+
+// checkMetadata compara el XMP del catálogo con el diccionario Info y con el nivel.
+func (c *pdfaChecker) checkMetadata(root pdftypes.Dict) {
+	rewrite := func() { c.rewriteXMP = true }
+
+	props := map[string]string{}
+	sd, _, err := c.xrt.DereferenceStreamDict(root["Metadata"])
+	switch {
+	case err != nil || sd == nil:
+		c.report(PDFARuleXMPMetadata, rewrite, "the catalog has no XMP metadata stream")
+	default:
+		c.hadMetadata = true
+		if ref := root.IndirectRefEntry("Metadata"); ref != nil {
+			c.obj = ref.ObjectNumber.Value()
+		}
+		if c.part == "1" && len(streamFilters(sd.Dict)) > 0 {
+			c.report(PDFARuleXMPMetadata, rewrite, "the XMP metadata stream must not be compressed")
+		}
+		if err := sd.Decode(); err != nil {
+			c.report(PDFARuleXMPMetadata, rewrite, "the XMP metadata stream cannot be decoded: %v", err)
+		} else if props, err = parseXMP(sd.Content); err != nil {
+			props = map[string]string{}
+			c.report(PDFARuleXMPMetadata, rewrite, "the XMP metadata is not valid: %v", err)
+		}
+	}
+
+	if part := props["pdfaid:part"]; part != "" {
+		c.claimed = part + strings.ToLower(props["pdfaid:conformance"])
+	}
+	if c.claimed != string(c.level) {
+		claimed := c.claimed
+		if claimed == "" {
+			claimed = "none"
+		}
+		c.report(PDFARuleXMPIdentification, rewrite, "XMP PDF/A identification is %s, expected %s", claimed, c.level)
+	}
+
+	c.obj = 0
+	if c.xrt.Info != nil {
+		c.obj = c.xrt.Info.ObjectNumber.Value()
+	}
+	for _, f := range xmpInfoFields {
+		value, ok, valid := infoValue(c.xrt, f.info, f.date)
+		if !ok {
+			continue
+		}
+		if !valid {
+			info, _ := c.xrt.DereferenceDict(*c.xrt.Info)
+			c.report(PDFARuleXMPMismatch, func() { info.Delete(f.info); c.rewriteXMP = true },
+				"Info %s is not a valid date", f.info)
+			continue
+		}
+		if !xmpValueMatches(props[f.xmp], value, f.date) {
+			c.report(PDFARuleXMPMismatch, rewrite, "Info %s %q does not match XMP %s %q", f.info, value, f.xmp, props[f.xmp])
+		}
+	}
+	c.obj = 0
+}
+
+// infoValue devuelve una entrada de texto del diccionario Info. Las fechas se
+// devuelven en formato XMP; valid es false si la fecha no se puede interpretar.
+func infoValue(xrt *model.XRefTable, key string, date bool) (value string, ok, valid bool) {
+	if xrt.Info == nil {
+		return "", false, false
+	}
+	info, err := xrt.DereferenceDict(*xrt.Info)
+	if err != nil || info == nil || info[key] == nil {
+		return "", false, false
+	}
+	s, err := xrt.DereferenceText(info[key])
+	if err != nil {
+		return "", true, false
+	}
+	if !date {
+		return s, true, true
+	}
+	t, ok := pdftypes.DateTime(s, true)
+	if !ok {
+		return s, true, false
+	}
+	return formatXMPDate(t), true, true
+}
+
+func xmpValueMatches(xmpValue, infoValue string, date bool) bool {
+	if !date {
+		return strings.TrimSpace(xmpValue) == strings.TrimSpace(infoValue)
+	}
+	a, ok1 := parseXMPDate(xmpValue)
+	b, ok2 := parseXMPDate(infoValue)
+	return ok1 && ok2 && a.Equal(b)
+}
@@ -0,0 +1,238 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// maxListedViolations limita las violaciones citadas en un mensaje de error.
+const maxListedViolations = 5
+
+// ConvertPDFA intenta convertir el documento al nivel PDF/A indicado (por defecto
+// 2b): incrusta un OutputIntent sRGB, sincroniza el XMP con el diccionario Info y
+// elimina JavaScript, acciones prohibidas y el resto de construcciones
+// corregibles. Si alguna violación no se puede corregir (fuentes sin incrustar,
+// transparencia en PDF/A-1, cifrado...) no escribe nada y devuelve un error que
+// las enumera. La salida se escribe linealizada, sin que pdfcpu reescriba el
+// diccionario Info, y se vuelve a comprobar antes de devolverla.
+func (p *Processor) ConvertPDFA(ctx context.Context, inputPath, outputPath string, level types.PDFALevel) (_ *types.PDFAConvertResult, err error) {
+	p.logger.Debug("converting PDF to PDF/A",
+		slog.String("input", inputPath),
+		slog.String("output", outputPath),
+		slog.String("level", string(level)))
+
+	level, ok := types.ParsePDFALevel(string(level))
+	if !ok {
+		return nil, unsupportedPDFALevel(level)
+	}
+
+	report, err := p.CheckPDFA(ctx, inputPath, level)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Convertible {
+		var blocking []types.PDFAViolation
+		for _, v := range report.Violations {
+			if !v.Fixable {
+				blocking = append(blocking, v)
+			}
+		}
+		err := fmt.Errorf("cannot convert to PDF/A-%s: %d violation(s) cannot be fixed automatically: %s",
+			level, len(blocking), describeViolations(blocking))
+		p.logger.Error("PDF/A conversion refused", err)
+		return nil, err
+	}
+
+	originalInfo, err := os.Stat(inputPath)
+	if err != nil {
+		p.logger.Error("failed to stat input file", err)
+		return nil, fmt.Errorf("failed to stat input file: %w", err)
+	}
+
+	if err := ensureOutputDir(outputPath); err != nil {
+		p.logger.Error("failed to create output directory", err)
+		return nil, err
+	}
+
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+
+	// La cabecera la corrige el escritor; el resto se corrige en el contexto.
+	c := newPDFAChecker(pdfCtx.XRefTable, level, true)
+	for _, v := range report.Violations {
+		if v.Rule == PDFARuleFileHeader {
+			c.fixed = append(c.fixed, v)
+		}
+	}
+	if err := c.run(ctx); err != nil {
+		return nil, err
+	}
+	if len(c.found) > 0 {
+		return nil, fmt.Errorf("cannot convert to PDF/A-%s: %s", level, describeViolations(c.found))
+	}
+
+	result := &types.PDFAConvertResult{
+		OutputPath: outputPath,
+		Level:      level,
+		TotalPages: pdfCtx.PageCount,
+		Fixed:      c.fixed,
+	}
+
+	if c.addIntent {
+		if err := c.addSRGBOutputIntent(); err != nil {
+			p.logger.Error("failed to add output intent", err)
+			return nil, fmt.Errorf("failed to add output intent: %w", err)
+		}
+	}
+	if c.rewriteXMP {
+		if err := c.writeXMP(time.Now()); err != nil {
+			p.logger.Error("failed to write XMP metadata", err)
+			return nil, fmt.Errorf("failed to write XMP metadata: %w", err)
+		}
+		if c.hadMetadata {
+			result.Warnings = append(result.Warnings,
+				"existing XMP metadata was replaced; properties that do not mirror the Info dictionary were dropped")
+		}
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	data, err := linearizeContext(pdfCtx)
+	if err != nil {
+		p.logger.Error("failed to write PDF/A", err)
+		return nil, fmt.Errorf("failed to write PDF/A: %w", err)
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		p.logger.Error("failed to write PDF/A", err)
+		return nil, fmt.Errorf("failed to write PDF/A: %w", err)
+	}
+
+	check, err := p.CheckPDFA(ctx, outputPath, level)
+	if err != nil || !check.Conformant {
+		os.Remove(outputPath)
+		if err == nil {
+			err = fmt.Errorf("conversion did not reach PDF/A-%s: %s", level, describeViolations(check.Violations))
+		}
+		p.logger.Error("PDF/A conversion failed", err)
+		return nil, err
+	}
+
+	result.OriginalSize = originalInfo.Size()
+	result.OutputSize = int64(len(data))
+	result.Warnings = append(result.Warnings, "the output is linearized (fast web view)")
+
+	p.logger.Debug("PDF/A conversion complete",
+		slog.Int("fixed", len(result.Fixed)),
+		slog.Int64("output_size", result.OutputSize))
+
+	return result, nil
+}
+
+// describeViolations resume las primeras violaciones para un mensaje de error.
+func describeViolations(vs []types.PDFAViolation) string {
+	parts := make([]string, 0, maxListedViolations+1)
+	for i, v := range vs {
+		if i == maxListedViolations {
+			parts = append(parts, fmt.Sprintf("and %d more", len(vs)-i))
+			break
+		}
+		where := ""
+		if v.Page > 0 {
+			where = fmt.Sprintf(" on page %d", v.Page)
+		}
+		parts = append(parts, fmt.Sprintf("%s%s: %s", v.Rule, where, v.Description))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// addSRGBOutputIntent añade al catálogo un OutputIntent GTS_PDFA1 con un perfil sRGB.
+func (c *pdfaChecker) addSRGBOutputIntent() error {
+	sd, err := c.xrt.NewStreamDictForBuf(srgbICCProfile())
+	if err != nil {
+		return err
+	}
+	sd.InsertInt("N", 3)
+	if err := sd.Encode(); err != nil {
+		return err
+	}
+	profile, err := c.xrt.IndRefForNewObject(*sd)
+	if err != nil {
+		return err
+	}
+
+	intent := pdftypes.Dict{
+		"Type":                      pdftypes.Name("OutputIntent"),
+		"S":                         pdftypes.Name("GTS_PDFA1"),
+		"OutputConditionIdentifier": pdftypes.StringLiteral(srgbProfileDescription),
+		"Info":                      pdftypes.StringLiteral(srgbProfileDescription),
+		"RegistryName":              pdftypes.StringLiteral("http://www.color.org"),
+		"DestOutputProfile":         *profile,
+	}
+	ref, err := c.xrt.IndRefForNewObject(intent)
+	if err != nil {
+		return err
+	}
+
+	root, err := c.xrt.Catalog()
+	if err != nil {
+		return err
+	}
+	intents, _ := c.xrt.DereferenceArray(root["OutputIntents"])
+	root["OutputIntents"] = append(append(pdftypes.Array{}, intents...), *ref)
+	return nil
+}
+
+// writeXMP sustituye el XMP del catálogo por uno con la identificación PDF/A y
+// los valores del diccionario Info. Las propiedades conocidas del XMP anterior
+// que Info no tiene se conservan.
+func (c *pdfaChecker) writeXMP(now time.Time) error {
+	root, err := c.xrt.Catalog()
+	if err != nil {
+		return err
+	}
+
+	props := map[string]string{}
+	if sd, _, err := c.xrt.DereferenceStreamDict(root["Metadata"]); err == nil && sd != nil {
+		if err := sd.Decode(); err == nil {
+			if old, err := parseXMP(sd.Content); err == nil {
+				props = old
+			}
+		}
+	}
+	for _, f := range xmpInfoFields {
+		if value, ok, valid := infoValue(c.xrt, f.info, f.date); ok && valid {
+			props[f.xmp] = value
+		}
+	}
+
+	conformance := strings.ToUpper(string(c.level[1:]))
+	data := buildXMP(props, c.part, conformance, now)
+	sd := pdftypes.StreamDict{
+		Dict: pdftypes.Dict{
+			"Type":    pdftypes.Name("Metadata"),
+			"Subtype": pdftypes.Name("XML"),
+			"Length":  pdftypes.Integer(len(data)),
+		},
+		Content: data,
+		Raw:     data,
+	}
+	ref, err := c.xrt.IndRefForNewObject(sd)
+	if err != nil {
+		return err
+	}
+	root["Metadata"] = *ref
+	return nil
+}
@@ -0,0 +1,268 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// writePDFATestPDF genera una página con JavaScript, una acción Launch, acciones
+// adicionales, una anotación sin flag de impresión, una imagen con Interpolate y
+// transparencia, sin OutputIntent ni XMP. La fuente solo se incrusta si embedFont.
+func writePDFATestPDF(t *testing.T, dir, name string, embedFont bool) string {
+	t.Helper()
+
+	content := "/GS1 gs 1 0 0 rg BT /F1 12 Tf 72 720 Td (Hello) Tj ET q 10 0 0 10 100 100 cm /Im1 Do Q\n"
+	fontFile := ""
+	if embedFont {
+		fontFile = " /FontFile2 11 0 R"
+	}
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R /OpenAction 9 0 R /Names << /JavaScript 10 0 R >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> /ExtGState << /GS1 << /Type /ExtGState /ca 0.5 >> >> /XObject << /Im1 7 0 R >> >> /Contents 5 0 R /Annots [8 0 R] /AA << /O 9 0 R >> >>",
+		"<< /Type /Font /Subtype /TrueType /BaseFont /TestSans /FirstChar 32 /LastChar 32 /Widths [250] /Encoding /WinAnsiEncoding /FontDescriptor 6 0 R >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content),
+		"<< /Type /FontDescriptor /FontName /TestSans /Flags 32 /FontBBox [0 0 1000 1000] /ItalicAngle 0 /Ascent 800 /Descent -200 /CapHeight 700 /StemV 80" + fontFile + " >>",
+		"<< /Type /XObject /Subtype /Image /Width 2 /Height 1 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Interpolate true /Length 6 >>\nstream\n\xff\x00\x00\x00\xff\x00\nendstream",
+		"<< /Type /Annot /Subtype /Link /Rect [0 0 10 10] /Border [0 0 0] /A << /S /Launch /F (calc.exe) >> >>",
+		"<< /S /JavaScript /JS (app.alert\\(1\\)) >>",
+		"<< /Names [(init) 9 0 R] >>",
+		"<< /Length 16 /Length1 16 >>\nstream\nnot a real font\nendstream",
+		"<< /Title (Archive test) /Author (Tester) /CreationDate (D:20260102030405+01'00') >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 12 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write test PDF: %v", err)
+	}
+	return path
+}
+
+func violationRules(vs []types.PDFAViolation) map[string]int {
+	rules := map[string]int{}
+	for _, v := range vs {
+		rules[v.Rule]++
+	}
+	return rules
+}
+
+func TestCheckPDFA(t *testing.T) {
+	dir := t.TempDir()
+	in := writePDFATestPDF(t, dir, "in.pdf", false)
+	p := newTestProcessor()
+
+	report, err := p.CheckPDFA(context.Background(), in, "")
+	if err != nil {
+		t.Fatalf("CheckPDFA failed: %v", err)
+	}
+	if report.Level != types.PDFA2B || report.Conformant || report.Convertible {
+		t.Errorf("level %q, conformant %v, convertible %v", report.Level, report.Conformant, report.Convertible)
+	}
+
+	rules := violationRules(report.Violations)
+	for rule, want := range map[string]int{
+		PDFARuleFileHeader:        1,
+		PDFARuleTrailerID:         1,
+		PDFARuleFontsEmbedded:     1,
+		PDFARuleOutputIntent:      1,
+		PDFARuleJavaScript:        2, // OpenAction y Names
+		PDFARuleForbiddenAction:   1,
+		PDFARuleAdditionalActions: 1,
+		PDFARuleAnnotationFlags:   1,
+		PDFARuleImages:            1,
+		PDFARuleXMPMetadata:       1,
+		PDFARuleXMPIdentification: 1,
+		PDFARuleXMPMismatch:       3, // Title, Author y CreationDate
+	} {
+		if rules[rule] != want {
+			t.Errorf("%s: %d violations, want %d", rule, rules[rule], want)
+		}
+	}
+	if rules[PDFARuleTransparency] != 0 || rules[PDFARuleDeviceColor] != 0 {
+		t.Errorf("unexpected violations for PDF/A-2b: %v", rules)
+	}
+	for _, v := range report.Violations {
+		if v.Rule == PDFARuleFontsEmbedded && (v.Fixable || v.Page != 1 || !strings.Contains(v.Description, "TestSans")) {
+			t.Errorf("unexpected font violation: %+v", v)
+		}
+	}
+
+	// PDF/A-1 no admite transparencia.
+	report, err = p.CheckPDFA(context.Background(), in, types.PDFA1B)
+	if err != nil {
+		t.Fatalf("CheckPDFA failed: %v", err)
+	}
+	if violationRules(report.Violations)[PDFARuleTransparency] != 1 {
+		t.Errorf("PDF/A-1b: expected a transparency violation, got %v", violationRules(report.Violations))
+	}
+
+	if _, err := p.CheckPDFA(context.Background(), in, "4x"); err == nil {
+		t.Error("expected error for unsupported level")
+	}
+
+	// Las fuentes sin incrustar no se pueden corregir: no se escribe nada.
+	out := filepath.Join(dir, "refused.pdf")
+	_, err = p.ConvertPDFA(context.Background(), in, out, types.PDFA2B)
+	if err == nil || !strings.Contains(err.Error(), "fonts_embedded") || !strings.Contains(err.Error(), "cannot be fixed") {
+		t.Errorf("expected refusal naming the font violation, got %v", err)
+	}
+	if _, statErr := os.Stat(out); !os.IsNotExist(statErr) {
+		t.Error("refused conversion wrote an output file")
+	}
+}
+
+func TestConvertPDFA(t *testing.T) {
+	dir := t.TempDir()
+	in := writePDFATestPDF(t, dir, "in.pdf", true)
+	out := filepath.Join(dir, "out", "archive.pdf")
+	p := newTestProcessor()
+
+	result, err := p.ConvertPDFA(context.Background(), in, out, types.PDFA2B)
+	if err != nil {
+		t.Fatalf("ConvertPDFA failed: %v", err)
+	}
+	if result.TotalPages != 1 || result.Level != types.PDFA2B {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	fixed := violationRules(result.Fixed)
+	for _, rule := range []string{PDFARuleJavaScript, PDFARuleForbiddenAction, PDFARuleAdditionalActions, PDFARuleOutputIntent, PDFARuleXMPMismatch} {
+		if fixed[rule] == 0 {
+			t.Errorf("%s was not reported as fixed: %v", rule, fixed)
+		}
+	}
+
+	report, err := p.CheckPDFA(context.Background(), out, types.PDFA2B)
+	if err != nil {
+		t.Fatalf("CheckPDFA of output failed: %v", err)
+	}
+	if !report.Conformant || report.ClaimedLevel != "2b" {
+		t.Errorf("output not conformant (claimed %q): %+v", report.ClaimedLevel, report.Violations)
+	}
+
+	ctx, err := p.readContext(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xrt := ctx.XRefTable
+	root, _ := xrt.Catalog()
+	if _, ok := root["OpenAction"]; ok {
+		t.Error("OpenAction was kept")
+	}
+	intents, _ := xrt.DereferenceArray(root["OutputIntents"])
+	if len(intents) != 1 {
+		t.Fatalf("expected 1 output intent, got %d", len(intents))
+	}
+	oi, _ := xrt.DereferenceDict(intents[0])
+	profile, _, err := xrt.DereferenceStreamDict(oi["DestOutputProfile"])
+	if err != nil || profile == nil || profile.Decode() != nil {
+		t.Fatalf("invalid output profile: %v", err)
+	}
+	if !bytes.Equal(profile.Content, srgbICCProfile()) {
+		t.Error("output intent does not embed the sRGB profile")
+	}
+
+	metadata, _, _ := xrt.DereferenceStreamDict(root["Metadata"])
+	metadata.Decode()
+	props, err := parseXMP(metadata.Content)
+	if err != nil {
+		t.Fatalf("invalid XMP: %v", err)
+	}
+	if props["dc:title"] != "Archive test" || props["dc:creator"] != "Tester" || props["xmp:CreateDate"] != "2026-01-02T03:04:05+01:00" {
+		t.Errorf("XMP not synced with Info: %v", props)
+	}
+	if title, _, _ := infoValue(xrt, "Title", false); title != "Archive test" {
+		t.Errorf("Info Title = %q", title)
+	}
+
+	pd, _, _, _ := xrt.PageDict(1, false)
+	annot, _ := xrt.DereferenceDict(pd.ArrayEntry("Annots")[0])
+	if f := annot.IntEntry("F"); f == nil || *f != annotFlagPrint {
+		t.Errorf("annotation flags = %v, want %d", f, annotFlagPrint)
+	}
+	if _, ok := annot["A"]; ok {
+		t.Error("Launch action was kept")
+	}
+	if got := extractTestText(t, out, 1); !strings.Contains(got, "Hello") {
+		t.Errorf("page text = %q", got)
+	}
+}
+
+func TestParseXMP(t *testing.T) {
+	data := []byte(`<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+ <rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/" pdfaid:part="1" pdfaid:conformance="B"/>
+ <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:p="http://ns.adobe.com/pdf/1.3/">
+  <dc:title><rdf:Alt><rdf:li xml:lang="es">Titulo</rdf:li><rdf:li xml:lang="x-default">Title</rdf:li></rdf:Alt></dc:title>
+  <dc:creator><rdf:Seq><rdf:li>First</rdf:li><rdf:li>Second</rdf:li></rdf:Seq></dc:creator>
+  <p:Producer> Producer &amp; Co </p:Producer>
+ </rdf:Description>
+</rdf:RDF></x:xmpmeta>
+<?xpacket end="w"?>`)
+
+	props, err := parseXMP(data)
+	if err != nil {
+		t.Fatalf("parseXMP failed: %v", err)
+	}
+	want := map[string]string{
+		"pdfaid:part":        "1",
+		"pdfaid:conformance": "B",
+		"dc:title":           "Title",
+		"dc:creator":         "First",
+		"pdf:Producer":       "Producer & Co",
+	}
+	for k, v := range want {
+		if props[k] != v {
+			t.Errorf("%s = %q, want %q", k, props[k], v)
+		}
+	}
+
+	// Lo que genera buildXMP se vuelve a leer igual.
+	built, err := parseXMP(buildXMP(map[string]string{"dc:title": "A <b>", "xmp:CreateDate": "2026-01-02T03:04:05Z"}, "3", "U", time.Now()))
+	if err != nil {
+		t.Fatalf("parseXMP of generated XMP failed: %v", err)
+	}
+	if built["dc:title"] != "A <b>" || built["pdfaid:part"] != "3" || built["pdfaid:conformance"] != "U" {
+		t.Errorf("round trip = %v", built)
+	}
+}
+
+func TestSRGBICCProfile(t *testing.T) {
+	icc := srgbICCProfile()
+	if size := binary.BigEndian.Uint32(icc); int(size) != len(icc) {
+		t.Errorf("header size %d, profile is %d bytes", size, len(icc))
+	}
+	if string(icc[36:40]) != "acsp" || string(icc[12:24]) != "mntrRGB XYZ " {
+		t.Errorf("invalid header: %q", icc[12:40])
+	}
+	tags := binary.BigEndian.Uint32(icc[128:])
+	for i := 0; i < int(tags); i++ {
+		entry := icc[132+12*i:]
+		off, n := binary.BigEndian.Uint32(entry[4:]), binary.BigEndian.Uint32(entry[8:])
+		if off%4 != 0 || int(off+n) > len(icc) {
+			t.Errorf("tag %q out of range: offset %d, size %d", entry[:4], off, n)
+		}
+	}
+}
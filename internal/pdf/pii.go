@@ -0,0 +1,332 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// Nombres de los detectores de datos personales integrados.
+const (
+	PIIEmail      = "email"
+	PIIPhone      = "phone"
+	PIIIBAN       = "iban"
+	PIICreditCard = "credit_card"
+	PIINationalID = "national_id"
+)
+
+// piiDetector busca un tipo de dato personal. validate permite descartar
+// falsos positivos (checksums) y devolver un detalle del subtipo.
+type piiDetector struct {
+	name     string
+	re       *regexp.Regexp
+	validate func(s string) (detail string, ok bool)
+}
+
+// builtinPIIDetectors en orden de prioridad: si dos detectores coinciden sobre
+// el mismo texto, gana el primero (un IBAN no se informa además como teléfono).
+var builtinPIIDetectors = []piiDetector{
+	{
+		name:     PIIIBAN,
+		re:       regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`),
+		validate: validateIBAN,
+	},
+	{
+		name:     PIICreditCard,
+		re:       regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		validate: validateCreditCard,
+	},
+	{
+		name: PIIEmail,
+		re:   regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+	},
+	{
+		name:     PIINationalID,
+		re:       regexp.MustCompile(`\b(?:\d{8}-?[A-Za-z]|[XYZxyz]-?\d{7}-?[A-Za-z]|\d{3}-\d{2}-\d{4})\b`),
+		validate: validateNationalID,
+	},
+	{
+		name:     PIIPhone,
+		re:       regexp.MustCompile(`(?:\+|\b00)\d{1,3}[ .-]?(?:\(\d{1,4}\)[ .-]?)?\d{2,4}(?:[ .-]?\d{2,4}){1,4}\b|\(?\b\d{2,4}\)?[ .-]?\d{2,4}(?:[ .-]?\d{2,4}){1,3}\b`),
+		validate: validatePhone,
+	},
+}
+
+// PIIDetectorNames devuelve los nombres de los detectores integrados.
+func PIIDetectorNames() []string {
+	names := make([]string, len(builtinPIIDetectors))
+	for i, d := range builtinPIIDetectors {
+		names[i] = d.name
+	}
+	return names
+}
+
+// ScanPII busca datos personales en el texto de todas las páginas y devuelve
+// cada hallazgo con su página y rectángulos. result.Areas tiene el formato de
+// las áreas de Redact para poder redactar los hallazgos directamente.
+func (p *Processor) ScanPII(ctx context.Context, inputPath string, opts types.PIIScanOptions) (*types.PIIScanResult, error) {
+	p.logger.Debug("scanning PDF for personal data",
+		slog.String("input", inputPath),
+		slog.Any("detectors", opts.Detectors),
+		slog.Int("custom", len(opts.Custom)))
+
+	detectors, err := selectPIIDetectors(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(inputPath); err != nil {
+		return nil, err
+	}
+
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+
+	result := &types.PIIScanResult{
+		InputPath:  inputPath,
+		TotalPages: pdfCtx.PageCount,
+		Findings:   []types.PIIFinding{},
+		Counts:     make(map[string]int),
+		Areas:      []types.PageRect{},
+	}
+
+	fonts := newFontCache(pdfCtx.XRefTable)
+	for pageNr := 1; pageNr <= pdfCtx.PageCount; pageNr++ {
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+		pc, err := loadPageContent(pdfCtx.XRefTable, pageNr, fonts)
+		if err != nil {
+			p.logger.Warn("failed to extract page text",
+				slog.Int("page", pageNr),
+				slog.Any("error", err))
+			result.Warnings = append(result.Warnings, fmt.Sprintf("page %d: text could not be extracted: %v", pageNr, err))
+			continue
+		}
+
+		text, spans := pageText(pc.glyphs)
+		var claimed [][2]int
+
+		for _, d := range detectors {
+			for _, m := range findTextMatches(pc.glyphs, text, spans, d.re) {
+				if overlapsClaimed(claimed, m.start, m.end) {
+					continue
+				}
+				detail := ""
+				if d.validate != nil {
+					var ok bool
+					if detail, ok = d.validate(m.text); !ok {
+						continue
+					}
+				}
+				claimed = append(claimed, [2]int{m.start, m.end})
+
+				finding := types.PIIFinding{
+					Type:   d.name,
+					Detail: detail,
+					Page:   pageNr,
+					Text:   m.text,
+					Rects:  rectsToTypes(m.rects),
+				}
+				result.Findings = append(result.Findings, finding)
+				result.Counts[d.name]++
+				for _, r := range finding.Rects {
+					result.Areas = append(result.Areas, types.PageRect{Page: pageNr, Rect: r})
+				}
+			}
+		}
+	}
+
+	// Orden estable por página y posición para que la salida sea legible.
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		a, b := result.Findings[i], result.Findings[j]
+		if a.Page != b.Page {
+			return a.Page < b.Page
+		}
+		return len(a.Rects) > 0 && len(b.Rects) > 0 && a.Rects[0].URY > b.Rects[0].URY
+	})
+
+	p.logger.Debug("PII scan complete",
+		slog.Int("findings", len(result.Findings)))
+
+	return result, nil
+}
+
+func selectPIIDetectors(opts types.PIIScanOptions) ([]piiDetector, error) {
+	var detectors []piiDetector
+	if len(opts.Detectors) == 0 {
+		detectors = append(detectors, builtinPIIDetectors...)
+	} else {
+		wanted := make(map[string]bool, len(opts.Detectors))
+		for _, name := range opts.Detectors {
+			known := false
+			for _, d := range builtinPIIDetectors {
+				if d.name == name {
+					known = true
+				}
+			}
+			if !known {
+				return nil, fmt.Errorf("unknown PII detector %q (valid: %s)", name, strings.Join(PIIDetectorNames(), ", "))
+			}
+			wanted[name] = true
+		}
+		for _, d := range builtinPIIDetectors {
+			if wanted[d.name] {
+				detectors = append(detectors, d)
+			}
+		}
+	}
+
+	for _, c := range opts.Custom {
+		if strings.TrimSpace(c.Name) == "" || c.Pattern == "" {
+			return nil, fmt.Errorf("custom PII detectors require name and pattern")
+		}
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for custom detector %q: %w", c.Name, err)
+		}
+		detectors = append(detectors, piiDetector{name: c.Name, re: re})
+	}
+	return detectors, nil
+}
+
+func overlapsClaimed(claimed [][2]int, start, end int) bool {
+	for _, c := range claimed {
+		if start < c[1] && end > c[0] {
+			return true
+		}
+	}
+	return false
+}
+
+func digitsOnly(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// validateIBAN comprueba el dígito de control ISO 13616 (mod 97).
+func validateIBAN(s string) (string, bool) {
+	iban := strings.ReplaceAll(s, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return "", false
+	}
+
+	var sb strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			fmt.Fprintf(&sb, "%d", r-'A'+10)
+		default:
+			return "", false
+		}
+	}
+
+	n, ok := new(big.Int).SetString(sb.String(), 10)
+	if !ok || new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+		return "", false
+	}
+	return iban[:2], true
+}
+
+// validateCreditCard aplica el algoritmo de Luhn y detecta la marca por prefijo.
+func validateCreditCard(s string) (string, bool) {
+	digits := digitsOnly(s)
+	if len(digits) < 13 || len(digits) > 19 || strings.Count(digits, digits[:1]) == len(digits) {
+		return "", false
+	}
+
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	if sum%10 != 0 {
+		return "", false
+	}
+
+	switch {
+	case digits[0] == '4':
+		return "visa", true
+	case digits[:2] >= "51" && digits[:2] <= "55", digits[:4] >= "2221" && digits[:4] <= "2720":
+		return "mastercard", true
+	case digits[:2] == "34", digits[:2] == "37":
+		return "amex", true
+	case strings.HasPrefix(digits, "6011"), digits[:2] == "65":
+		return "discover", true
+	}
+	return "", true
+}
+
+const dniLetters = "TRWAGMYFPDXBNJZSQVHLCKE"
+
+// validateNationalID valida DNI/NIE españoles (letra de control) y SSN de EE. UU.
+func validateNationalID(s string) (string, bool) {
+	id := strings.ToUpper(strings.ReplaceAll(s, "-", ""))
+
+	letter := len(id) == 9 && id[8] >= 'A' && id[8] <= 'Z'
+
+	switch {
+	case letter && id[0] >= '0' && id[0] <= '9':
+		n := 0
+		for _, c := range id[:8] {
+			n = n*10 + int(c-'0')
+		}
+		return "es_dni", dniLetters[n%23] == id[8]
+	case letter && strings.IndexByte("XYZ", id[0]) >= 0:
+		n := strings.IndexByte("XYZ", id[0])
+		for _, c := range id[1:8] {
+			n = n*10 + int(c-'0')
+		}
+		return "es_nie", dniLetters[n%23] == id[8]
+	case letter:
+		return "", false
+	}
+
+	// SSN: AAA-GG-SSSS sin el guion.
+	digits := digitsOnly(s)
+	if len(digits) != 9 {
+		return "", false
+	}
+	area, group, serial := digits[:3], digits[3:5], digits[5:]
+	if area == "000" || area == "666" || area[0] == '9' || group == "00" || serial == "0000" {
+		return "", false
+	}
+	return "us_ssn", true
+}
+
+// validatePhone acepta números con entre 9 y 15 dígitos (E.164).
+func validatePhone(s string) (string, bool) {
+	n := len(digitsOnly(s))
+	if n < 9 || n > 15 {
+		return "", false
+	}
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "00") {
+		return "international", true
+	}
+	return "", true
+}
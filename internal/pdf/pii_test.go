@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestPIIValidators(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) (string, bool)
+		input    string
+		detail   string
+		ok       bool
+	}{
+		{"valid IBAN", validateIBAN, "ES91 2100 0418 4502 0005 1332", "ES", true},
+		{"bad IBAN checksum", validateIBAN, "ES92 2100 0418 4502 0005 1332", "", false},
+		{"valid card", validateCreditCard, "4111 1111 1111 1111", "visa", true},
+		{"bad Luhn", validateCreditCard, "4111 1111 1111 1112", "", false},
+		{"repeated digits", validateCreditCard, "0000 0000 0000 0000", "", false},
+		{"valid DNI", validateNationalID, "12345678Z", "es_dni", true},
+		{"bad DNI letter", validateNationalID, "12345678A", "es_dni", false},
+		{"valid NIE", validateNationalID, "X1234567L", "es_nie", true},
+		{"valid SSN", validateNationalID, "123-45-6789", "us_ssn", true},
+		{"invalid SSN area", validateNationalID, "666-45-6789", "", false},
+		{"international phone", validatePhone, "+34 612 345 678", "international", true},
+		{"too short phone", validatePhone, "12 34 56", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detail, ok := tt.validate(tt.input)
+			if ok != tt.ok || (ok && detail != tt.detail) {
+				t.Errorf("validate(%q) = (%q, %v), want (%q, %v)", tt.input, detail, ok, tt.detail, tt.ok)
+			}
+		})
+	}
+}
+
+func TestScanPII(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestPDF(t, dir, "in.pdf", []string{
+		"Contact: jane.doe@example.com\nPhone: +34 612 345 678\nIBAN: ES91 2100 0418 4502 0005 1332",
+		"Card: 4111 1111 1111 1111\nDNI: 12345678Z\nRef: ABC-123 Not a card: 4111 1111 1111 1112",
+	})
+
+	result, err := newTestProcessor().ScanPII(context.Background(), in, types.PIIScanOptions{
+		Custom: []types.PIICustomDetector{{Name: "reference", Pattern: `ABC-\d+`}},
+	})
+	if err != nil {
+		t.Fatalf("ScanPII failed: %v", err)
+	}
+
+	want := map[string]int{PIIEmail: 1, PIIPhone: 1, PIIIBAN: 1, PIICreditCard: 1, PIINationalID: 1, "reference": 1}
+	for k, v := range want {
+		if result.Counts[k] != v {
+			t.Errorf("Counts[%s] = %d, want %d (findings: %+v)", k, result.Counts[k], v, result.Findings)
+		}
+	}
+	if len(result.Findings) != 6 {
+		t.Errorf("got %d findings, want 6: %+v", len(result.Findings), result.Findings)
+	}
+	if len(result.Areas) < len(result.Findings) {
+		t.Errorf("expected at least one area per finding, got %d", len(result.Areas))
+	}
+
+	// Las áreas se pueden pasar tal cual a Redact.
+	out := filepath.Join(dir, "out.pdf")
+	red, err := newTestProcessor().Redact(context.Background(), in, out, types.RedactOptions{Areas: result.Areas})
+	if err != nil {
+		t.Fatalf("Redact with PII areas failed: %v", err)
+	}
+	if red.GlyphsRemoved == 0 {
+		t.Errorf("expected glyphs to be removed")
+	}
+	again, err := newTestProcessor().ScanPII(context.Background(), out, types.PIIScanOptions{})
+	if err != nil {
+		t.Fatalf("ScanPII on redacted file failed: %v", err)
+	}
+	if len(again.Findings) != 0 {
+		t.Errorf("redacted file still has findings: %+v", again.Findings)
+	}
+}
+
+func TestScanPIIDetectorSelection(t *testing.T) {
+	if _, err := selectPIIDetectors(types.PIIScanOptions{Detectors: []string{"passport"}}); err == nil {
+		t.Errorf("expected error for unknown detector")
+	}
+	if _, err := selectPIIDetectors(types.PIIScanOptions{Custom: []types.PIICustomDetector{{Name: "x", Pattern: "("}}}); err == nil {
+		t.Errorf("expected error for invalid custom pattern")
+	}
+	ds, err := selectPIIDetectors(types.PIIScanOptions{Detectors: []string{PIIEmail}})
+	if err != nil || len(ds) != 1 || ds[0].name != PIIEmail {
+		t.Errorf("unexpected detectors: %v, %v", ds, err)
+	}
+}
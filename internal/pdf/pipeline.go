@@ -0,0 +1,477 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// pipelineRun ejecuta un paso ya validado sobre los documentos actuales.
+// outputPath está vacío en las operaciones que no escriben un PDF.
+type pipelineRun func(ctx context.Context, p *Processor, inputs []string, outputPath string) (any, error)
+
+// pipelineOperation describe una operación disponible en los pipelines.
+type pipelineOperation struct {
+	// inputs es el número de documentos que consume la operación; 0 significa
+	// cualquier número (merge).
+	inputs int
+	// writes indica que la operación produce un PDF que pasa al paso siguiente.
+	// Las de solo lectura informan sobre el documento actual sin cambiarlo.
+	writes bool
+	// prepare decodifica y valida los argumentos antes de ejecutar nada.
+	prepare func(args json.RawMessage) (pipelineRun, error)
+}
+
+// pipelineOperations son las operaciones de Processor que admite RunPipeline.
+// Los argumentos usan los mismos nombres que las herramientas equivalentes.
+var pipelineOperations = map[string]pipelineOperation{
+	"merge": {inputs: 0, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var args struct {
+			Append    []string `json:"append"`
+			Linearize bool     `json:"linearize"`
+		}
+		if err := decodeStepArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			all := append(append([]string{}, inputs...), args.Append...)
+			if len(all) < 2 {
+				return nil, fmt.Errorf("merge needs at least 2 documents: pass several inputs or args.append")
+			}
+			return p.Merge(ctx, all, out, types.MergeOptions{Linearize: args.Linearize})
+		}, nil
+	}},
+	"collate": {inputs: 2, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var args struct {
+			ReverseBack *bool `json:"reverse_back"` // por defecto true, como pdf_collate
+		}
+		if err := decodeStepArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		reverseBack := args.ReverseBack == nil || *args.ReverseBack
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.Collate(ctx, inputs[0], inputs[1], reverseBack, out)
+		}, nil
+	}},
+	"remove_pages": {inputs: 1, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var args struct {
+			Pages string `json:"pages"`
+			Mode  string `json:"mode"`
+		}
+		if err := decodeStepArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if _, err := ParsePageSelection(args.Pages); err != nil {
+			return nil, err
+		}
+		mode := types.ModeRemove
+		if args.Mode != "" {
+			var ok bool
+			if mode, ok = types.ParseMode(args.Mode); !ok {
+				return nil, fmt.Errorf("invalid mode %q: must be 'remove' or 'keep'", args.Mode)
+			}
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.RemovePages(ctx, inputs[0], out, args.Pages, mode)
+		}, nil
+	}},
+	"rotate": {inputs: 1, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var args struct {
+			Degrees int    `json:"degrees"`
+			Pages   string `json:"pages"`
+		}
+		if err := decodeStepArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if args.Degrees == 0 || args.Degrees%90 != 0 {
+			return nil, fmt.Errorf("invalid rotation %d: must be a non-zero multiple of 90", args.Degrees)
+		}
+		if args.Pages != "" {
+			if _, err := ParsePageSelection(args.Pages); err != nil {
+				return nil, err
+			}
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.Rotate(ctx, inputs[0], out, args.Degrees, args.Pages)
+		}, nil
+	}},
+	"watermark": {inputs: 1, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var opts types.WatermarkOptions
+		if err := decodeStepArgs(raw, &opts); err != nil {
+			return nil, err
+		}
+		if err := validateWatermarkOptions(opts); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.Watermark(ctx, inputs[0], out, opts)
+		}, nil
+	}},
+	"compress": {inputs: 1, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var opts types.CompressOptions
+		if err := decodeStepArgs(raw, &opts); err != nil {
+			return nil, err
+		}
+		if opts.Profile != "" {
+			if _, ok := types.ParseCompressProfile(string(opts.Profile)); !ok {
+				return nil, fmt.Errorf("invalid profile %q: must be lossless, print, ebook, screen or custom", opts.Profile)
+			}
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.Compress(ctx, inputs[0], out, opts)
+		}, nil
+	}},
+	"linearize": {inputs: 1, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.Linearize(ctx, inputs[0], out)
+		}, decodeStepArgs(raw, &struct{}{})
+	}},
+	"repair": {inputs: 1, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.Repair(ctx, inputs[0], out)
+		}, decodeStepArgs(raw, &struct{}{})
+	}},
+	"sanitize": {inputs: 1, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var opts types.SanitizeOptions
+		if err := decodeStepArgs(raw, &opts); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.Sanitize(ctx, inputs[0], out, opts)
+		}, nil
+	}},
+	"redact": {inputs: 1, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var args struct {
+			Areas    []types.PageRect      `json:"areas"`
+			Patterns []types.RedactPattern `json:"patterns"`
+		}
+		if err := decodeStepArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if len(args.Areas) == 0 && len(args.Patterns) == 0 {
+			return nil, fmt.Errorf("redact needs areas or patterns")
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.Redact(ctx, inputs[0], out, types.RedactOptions{Areas: args.Areas, Patterns: args.Patterns})
+		}, nil
+	}},
+	"pdfa_convert": {inputs: 1, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		level, err := decodeLevelArg(raw)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.ConvertPDFA(ctx, inputs[0], out, level)
+		}, nil
+	}},
+	"set_page_labels": {inputs: 1, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var args struct {
+			Ranges []types.PageLabelRange `json:"ranges"`
+		}
+		if err := decodeStepArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.SetPageLabels(ctx, inputs[0], out, args.Ranges)
+		}, nil
+	}},
+	// sign usa siempre la clave configurada (PDF_SIGN_*): las rutas de la
+	// clave y su contraseña no se aceptan como argumentos del paso.
+	"sign": {inputs: 1, writes: true, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var opts types.SignOptions
+		if err := decodeStepArgs(raw, &opts); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, out string) (any, error) {
+			return p.Sign(ctx, inputs[0], out, types.SignKeySource{}, opts)
+		}, nil
+	}},
+	"info": {inputs: 1, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		return func(ctx context.Context, p *Processor, inputs []string, _ string) (any, error) {
+			return p.GetInfo(ctx, inputs[0])
+		}, decodeStepArgs(raw, &struct{}{})
+	}},
+	"validate": {inputs: 1, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		return func(ctx context.Context, p *Processor, inputs []string, _ string) (any, error) {
+			return p.Validate(ctx, inputs[0])
+		}, decodeStepArgs(raw, &struct{}{})
+	}},
+	"security_scan": {inputs: 1, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		return func(ctx context.Context, p *Processor, inputs []string, _ string) (any, error) {
+			return p.SecurityScan(ctx, inputs[0])
+		}, decodeStepArgs(raw, &struct{}{})
+	}},
+	"page_labels": {inputs: 1, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		return func(ctx context.Context, p *Processor, inputs []string, _ string) (any, error) {
+			return p.GetPageLabels(ctx, inputs[0])
+		}, decodeStepArgs(raw, &struct{}{})
+	}},
+	"verify_signatures": {inputs: 1, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		return func(ctx context.Context, p *Processor, inputs []string, _ string) (any, error) {
+			return p.ListSignatures(ctx, inputs[0], "")
+		}, decodeStepArgs(raw, &struct{}{})
+	}},
+	"pdfa_check": {inputs: 1, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		level, err := decodeLevelArg(raw)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, _ string) (any, error) {
+			return p.CheckPDFA(ctx, inputs[0], level)
+		}, nil
+	}},
+	"size_report": {inputs: 1, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var opts types.SizeReportOptions
+		if err := decodeStepArgs(raw, &opts); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, _ string) (any, error) {
+			return p.AnalyzeSize(ctx, inputs[0], opts)
+		}, nil
+	}},
+	"scan_pii": {inputs: 1, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var opts types.PIIScanOptions
+		if err := decodeStepArgs(raw, &opts); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, _ string) (any, error) {
+			return p.ScanPII(ctx, inputs[0], opts)
+		}, nil
+	}},
+	"search": {inputs: 1, prepare: func(raw json.RawMessage) (pipelineRun, error) {
+		var args struct {
+			Query string `json:"query"`
+			types.SearchOptions
+		}
+		if err := decodeStepArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(args.Query) == "" {
+			return nil, fmt.Errorf("search needs a query")
+		}
+		return func(ctx context.Context, p *Processor, inputs []string, _ string) (any, error) {
+			return p.Search(ctx, inputs[0], args.Query, args.SearchOptions)
+		}, nil
+	}},
+}
+
+// PipelineOperations devuelve los nombres de las operaciones admitidas en un
+// pipeline, ordenados.
+func PipelineOperations() []string {
+	names := make([]string, 0, len(pipelineOperations))
+	for name := range pipelineOperations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunPipeline ejecuta los pasos en orden sobre inputPaths. Cada operación que
+// modifica el documento escribe en un directorio temporal propio del pipeline,
+// y su salida es la entrada del paso siguiente; el resultado del último de esos
+// pasos se copia a outputPath. Las operaciones de solo lectura informan sobre
+// el documento actual.
+//
+// Con varias entradas, el primer paso debe ser merge o collate. Los argumentos
+// de todos los pasos se validan antes de ejecutar ninguno, y el primer paso
+// que falla detiene el pipeline: el informe indica qué paso falló, los
+// siguientes quedan como "skipped" y outputPath no se escribe. ctx se
+// comprueba antes de cada paso; si se cancela, el paso en curso falla y el
+// directorio temporal se elimina igualmente. El avance se cuenta por paso.
+func (p *Processor) RunPipeline(ctx context.Context, inputPaths []string, outputPath string, steps []types.PipelineStep) (_ *types.PipelineResult, err error) {
+	p.logger.Debug("running PDF pipeline",
+		slog.Int("inputs", len(inputPaths)),
+		slog.Int("steps", len(steps)),
+		slog.String("output", outputPath))
+
+	if len(inputPaths) == 0 {
+		return nil, fmt.Errorf("pipeline needs at least one input file")
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("pipeline needs at least one step")
+	}
+
+	start := time.Now()
+	result := &types.PipelineResult{
+		InputPaths: inputPaths,
+		Steps:      make([]types.PipelineStepResult, len(steps)),
+	}
+	for i, s := range steps {
+		result.Steps[i] = types.PipelineStepResult{Step: i + 1, Operation: s.Operation, Status: types.StepSkipped}
+	}
+	fail := func(i int, err error) (*types.PipelineResult, error) {
+		result.Steps[i].Status = types.StepFailed
+		result.Steps[i].Error = err.Error()
+		err = fmt.Errorf("step %d (%s) failed: %w", i+1, steps[i].Operation, err)
+		p.logger.Error("pipeline step failed", err)
+		result.FailedStep = i + 1
+		result.Error = err.Error()
+		result.DurationMs = time.Since(start).Milliseconds()
+		return result, err
+	}
+
+	// Validar todos los pasos antes de ejecutar ninguno.
+	runs := make([]pipelineRun, len(steps))
+	documents := len(inputPaths)
+	lastWriter, signedAt := -1, 0
+	for i, s := range steps {
+		op, ok := pipelineOperations[s.Operation]
+		if !ok {
+			return fail(i, fmt.Errorf("unsupported operation %q (supported: %s)", s.Operation, strings.Join(PipelineOperations(), ", ")))
+		}
+		run, err := op.prepare(s.Args)
+		if err != nil {
+			return fail(i, fmt.Errorf("invalid args: %w", err))
+		}
+		if op.inputs != 0 && documents != op.inputs {
+			return fail(i, fmt.Errorf("operation needs %d document(s) but the pipeline has %d; start with merge or collate", op.inputs, documents))
+		}
+		if op.writes {
+			// Modificar un documento firmado invalida la firma.
+			if signedAt != 0 {
+				return fail(i, fmt.Errorf("the document is signed in step %d; sign must be the last step that modifies it", signedAt))
+			}
+			if s.Operation == "sign" {
+				signedAt = i + 1
+			}
+			documents = 1
+			lastWriter = i
+		}
+		runs[i] = run
+	}
+	if lastWriter >= 0 && strings.TrimSpace(outputPath) == "" {
+		return nil, fmt.Errorf("output_path is required when a step modifies the document")
+	}
+
+	tmpDir, err := os.MkdirTemp(p.config.TempDir, "pdf-pipeline-")
+	if err != nil {
+		p.logger.Error("failed to create temp directory", err)
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	progress := newProgressCounter(ctx, len(steps))
+	stepCtx := withoutProgress(ctx)
+	current := inputPaths
+	for i, s := range steps {
+		out := ""
+		if pipelineOperations[s.Operation].writes {
+			out = filepath.Join(tmpDir, fmt.Sprintf("step-%02d-%s.pdf", i+1, s.Operation))
+		}
+
+		if err := checkCancelled(ctx); err != nil {
+			return fail(i, err)
+		}
+		stepStart := time.Now()
+		res, err := runs[i](stepCtx, p, current, out)
+		result.Steps[i].DurationMs = time.Since(stepStart).Milliseconds()
+		if err != nil {
+			return fail(i, err)
+		}
+		result.Steps[i].Status = types.StepOK
+		result.Steps[i].Result = res
+		if out != "" {
+			current = []string{out}
+		}
+		progress.step()
+
+		p.logger.Debug("pipeline step finished",
+			slog.Int("step", i+1),
+			slog.String("operation", s.Operation),
+			slog.Int64("duration_ms", result.Steps[i].DurationMs))
+	}
+
+	if lastWriter >= 0 {
+		if err := ensureOutputDir(outputPath); err != nil {
+			p.logger.Error("failed to create output directory", err)
+			return nil, err
+		}
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+		defer guardOutput(ctx, outputPath).cleanup(&err)
+		if err := copyFile(current[0], outputPath); err != nil {
+			p.logger.Error("failed to write pipeline output", err)
+			return nil, fmt.Errorf("failed to write output: %w", err)
+		}
+		info, err := os.Stat(outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat output file: %w", err)
+		}
+		result.OutputPath = outputPath
+		result.OutputSize = info.Size()
+		setResultOutputPath(result.Steps[lastWriter].Result, outputPath)
+	}
+
+	result.Success = true
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// decodeStepArgs decodifica los argumentos de un paso rechazando los campos
+// desconocidos, para que un error tipográfico no pase desapercibido.
+func decodeStepArgs(raw json.RawMessage, v any) error {
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// decodeLevelArg decodifica el argumento level de los pasos PDF/A.
+func decodeLevelArg(raw json.RawMessage) (types.PDFALevel, error) {
+	var args struct {
+		Level string `json:"level"`
+	}
+	if err := decodeStepArgs(raw, &args); err != nil {
+		return "", err
+	}
+	level, ok := types.ParsePDFALevel(args.Level)
+	if !ok {
+		return "", unsupportedPDFALevel(types.PDFALevel(args.Level))
+	}
+	return level, nil
+}
+
+// setResultOutputPath apunta el campo OutputPath del resultado de un paso a la
+// salida final, en lugar del archivo intermedio que se elimina.
+func setResultOutputPath(result any, path string) {
+	v := reflect.ValueOf(result)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	if f := v.Elem().FieldByName("OutputPath"); f.IsValid() && f.CanSet() && f.Kind() == reflect.String {
+		f.SetString(path)
+	}
+}
+
+// copyFile copia src en dst, sobrescribiéndolo.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,167 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestRunPipeline(t *testing.T) {
+	dir := t.TempDir()
+	p := newTestProcessor()
+	p.config.TempDir = filepath.Join(dir, "tmp")
+	if err := os.MkdirAll(p.config.TempDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	a := writeTestPDF(t, dir, "a.pdf", []string{"cover", "a1", "a2"})
+	b := writeTestPDF(t, dir, "b.pdf", []string{"b1", "b2"})
+
+	step := func(op, args string) types.PipelineStep {
+		s := types.PipelineStep{Operation: op}
+		if args != "" {
+			s.Args = json.RawMessage(args)
+		}
+		return s
+	}
+
+	t.Run("merge, remove pages and compress", func(t *testing.T) {
+		out := filepath.Join(dir, "out", "final.pdf")
+		result, err := p.RunPipeline(context.Background(), []string{a, b}, out, []types.PipelineStep{
+			step("merge", ""),
+			step("remove_pages", `{"pages":"1,last"}`),
+			step("info", ""),
+			step("compress", `{"profile":"lossless"}`),
+		})
+		if err != nil {
+			t.Fatalf("RunPipeline failed: %v", err)
+		}
+		if !result.Success || result.OutputPath != out || result.OutputSize == 0 {
+			t.Errorf("unexpected result: %+v", result)
+		}
+		for _, s := range result.Steps {
+			if s.Status != types.StepOK || s.Result == nil {
+				t.Errorf("step %d: %+v", s.Step, s)
+			}
+		}
+		if info := result.Steps[2].Result.(*types.PDFInfoResult); info.TotalPages != 3 {
+			t.Errorf("info after remove_pages: %d pages", info.TotalPages)
+		}
+		if c := result.Steps[3].Result.(*types.CompressResult); c.OutputPath != out {
+			t.Errorf("final step output path = %q", c.OutputPath)
+		}
+		if got := extractTestText(t, out, 1); !strings.Contains(got, "a1") {
+			t.Errorf("first page text = %q", got)
+		}
+
+		// El directorio temporal del pipeline se elimina al terminar.
+		if entries, _ := os.ReadDir(p.config.TempDir); len(entries) != 0 {
+			t.Errorf("temp dir not cleaned: %v", entries)
+		}
+	})
+
+	t.Run("collate reverses backs by default", func(t *testing.T) {
+		out := filepath.Join(dir, "collated.pdf")
+		if _, err := p.RunPipeline(context.Background(), []string{a, b}, out, []types.PipelineStep{
+			step("collate", ""),
+		}); err != nil {
+			t.Fatalf("RunPipeline failed: %v", err)
+		}
+		// Como pdf_collate: los reversos escaneados al revés se toman del último al primero.
+		for i, want := range []string{"cover", "b2", "a1", "b1", "a2"} {
+			if got := extractTestText(t, out, i+1); !strings.Contains(got, want) {
+				t.Errorf("page %d text = %q, want %q", i+1, got, want)
+			}
+		}
+	})
+
+	t.Run("sanitize, watermark and compress", func(t *testing.T) {
+		form := writeFilledFormTestPDF(t, dir, "form.pdf")
+		out := filepath.Join(dir, "shared.pdf")
+		result, err := p.RunPipeline(context.Background(), []string{form}, out, []types.PipelineStep{
+			step("sanitize", `{"strip_metadata":true,"flatten_forms":true}`),
+			step("watermark", `{"text":"CONFIDENTIAL","on_top":true}`),
+			step("compress", `{"profile":"ebook"}`),
+		})
+		if err != nil {
+			t.Fatalf("RunPipeline failed: %v", err)
+		}
+		if s := result.Steps[0].Result.(*types.SanitizeResult); s.FieldsFlattened != 1 || len(s.MetadataRemoved) == 0 {
+			t.Errorf("sanitize result = %+v", s)
+		}
+		got := extractTestText(t, out, 1)
+		for _, want := range []string{"Application form", "Jane Roe", "CONFIDENTIAL"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("page text = %q, want %q", got, want)
+			}
+		}
+	})
+
+	t.Run("failing step aborts", func(t *testing.T) {
+		out := filepath.Join(dir, "failed.pdf")
+		result, err := p.RunPipeline(context.Background(), []string{a}, out, []types.PipelineStep{
+			step("compress", ""),
+			step("remove_pages", `{"pages":"7"}`),
+			step("linearize", ""),
+		})
+		if err == nil || !strings.Contains(err.Error(), "step 2 (remove_pages) failed") {
+			t.Fatalf("error = %v", err)
+		}
+		if result.Success || result.FailedStep != 2 {
+			t.Errorf("result = %+v", result)
+		}
+		want := []string{types.StepOK, types.StepFailed, types.StepSkipped}
+		for i, s := range result.Steps {
+			if s.Status != want[i] {
+				t.Errorf("step %d status = %q, want %q", i+1, s.Status, want[i])
+			}
+		}
+		if _, err := os.Stat(out); !os.IsNotExist(err) {
+			t.Errorf("output written after a failure: %v", err)
+		}
+	})
+
+	t.Run("validation before running", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			inputs []string
+			steps  []types.PipelineStep
+			want   string
+		}{
+			{"unsupported", []string{a}, []types.PipelineStep{step("compress", ""), step("encrypt", "")}, `step 2 (encrypt) failed: unsupported operation "encrypt"`},
+			{"watermark without text", []string{a}, []types.PipelineStep{step("watermark", `{"opacity":0.5}`)}, "watermark text is required"},
+			{"unknown arg", []string{a}, []types.PipelineStep{step("compress", `{"quality":50}`)}, `unknown field "quality"`},
+			{"bad selection", []string{a}, []types.PipelineStep{step("remove_pages", `{"pages":"x"}`)}, "invalid page selection"},
+			{"needs merge", []string{a, b}, []types.PipelineStep{step("compress", "")}, "start with merge or collate"},
+			{"key in args", []string{a}, []types.PipelineStep{step("sign", `{"pkcs12_path":"/tmp/k.p12"}`)}, `unknown field "pkcs12_path"`},
+			{"write after sign", []string{a}, []types.PipelineStep{step("sign", ""), step("compress", "")}, "sign must be the last step"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				out := filepath.Join(dir, "invalid.pdf")
+				_, err := p.RunPipeline(context.Background(), tt.inputs, out, tt.steps)
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Fatalf("error = %v, want %q", err, tt.want)
+				}
+				if _, err := os.Stat(out); !os.IsNotExist(err) {
+					t.Errorf("output written for an invalid pipeline")
+				}
+			})
+		}
+	})
+
+	t.Run("report only", func(t *testing.T) {
+		result, err := p.RunPipeline(context.Background(), []string{a}, "", []types.PipelineStep{step("validate", ""), step("page_labels", "")})
+		if err != nil {
+			t.Fatalf("RunPipeline failed: %v", err)
+		}
+		if result.OutputPath != "" || len(result.Steps) != 2 {
+			t.Errorf("result = %+v", result)
+		}
+	})
+}
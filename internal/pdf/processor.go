@@ -1,12 +1,15 @@
 package pdf
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 
 	"github.com/scopweb/mcp-go-pdf-tools/internal/config"
@@ -35,23 +38,27 @@ func (p *Processor) ValidateFile(path string) error {
 	}
 
 	// Validar que sea un PDF legible
-	ctx, err := api.ReadContextFile(path)
+	pdfCtx, err := api.ReadContextFile(path)
 	if err != nil {
 		return fmt.Errorf("failed to read PDF: %w", err)
 	}
 
-	if err := api.ValidateContext(ctx); err != nil {
+	if err := api.ValidateContext(pdfCtx); err != nil {
 		return fmt.Errorf("failed to validate PDF: %w", err)
 	}
 
 	return nil
 }
 
-// Split divide un PDF en archivos de una página cada uno.
-func (p *Processor) Split(inputPath string) ([]string, error) {
+// Split divide un PDF en archivos de una página cada uno, devueltos en orden
+// de página. Informa del avance por página (ver WithProgress).
+func (p *Processor) Split(ctx context.Context, inputPath string) ([]string, error) {
 	p.logger.Debug("splitting PDF",
 		slog.String("path", inputPath))
 
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
 	if err := p.ValidateFile(inputPath); err != nil {
 		return nil, err
 	}
@@ -62,25 +69,15 @@ func (p *Processor) Split(inputPath string) ([]string, error) {
 		return nil, fmt.Errorf("failed to create temp dir: %w", err)
 	}
 
-	conf := p.newConfiguration()
-	if err := api.SplitFile(inputPath, tmpDir, 1, conf); err != nil {
-		_ = os.RemoveAll(tmpDir)
-		p.logger.Error("PDF split operation failed", err)
-		return nil, fmt.Errorf("pdfcpu split failed: %w", err)
-	}
-
-	entries, err := os.ReadDir(tmpDir)
+	// Se escribe página a página (como hace api.SplitFile) para poder
+	// comprobar ctx e informar del avance entre páginas.
+	outFiles, err := p.splitPages(ctx, inputPath, tmpDir)
 	if err != nil {
 		_ = os.RemoveAll(tmpDir)
-		p.logger.Error("failed to read temp directory", err)
-		return nil, fmt.Errorf("failed to read temp dir: %w", err)
-	}
-
-	var outFiles []string
-	for _, e := range entries {
-		if !e.IsDir() && filepath.Ext(e.Name()) == ".pdf" {
-			outFiles = append(outFiles, filepath.Join(tmpDir, e.Name()))
+		if ctx.Err() == nil {
+			p.logger.Error("PDF split operation failed", err)
 		}
+		return nil, err
 	}
 
 	p.logger.Debug("PDF split complete",
@@ -89,11 +86,51 @@ func (p *Processor) Split(inputPath string) ([]string, error) {
 	return outFiles, nil
 }
 
+// splitPages escribe cada página de inputPath en outDir con el mismo nombre
+// que usa pdfcpu (<nombre>_<página>.pdf).
+func (p *Processor) splitPages(ctx context.Context, inputPath, outDir string) ([]string, error) {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	conf := p.newConfiguration()
+	conf.Cmd = model.SPLIT
+	pdfCtx, err := api.ReadValidateAndOptimize(f, conf)
+	if err != nil {
+		return nil, fmt.Errorf("pdfcpu split failed: %w", err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(inputPath), ".pdf")
+	outFiles := make([]string, 0, pdfCtx.PageCount)
+	reportProgress(ctx, 0, pdfCtx.PageCount)
+	for i := 1; i <= pdfCtx.PageCount; i++ {
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+		pageCtx, err := pdfcpu.ExtractPages(pdfCtx, []int{i}, false)
+		if err != nil {
+			return nil, fmt.Errorf("pdfcpu split failed on page %d: %w", i, err)
+		}
+		out := filepath.Join(outDir, fmt.Sprintf("%s_%d.pdf", base, i))
+		if err := api.WriteContextFile(pageCtx, out); err != nil {
+			return nil, fmt.Errorf("pdfcpu split failed on page %d: %w", i, err)
+		}
+		outFiles = append(outFiles, out)
+		reportProgress(ctx, i, pdfCtx.PageCount)
+	}
+	return outFiles, nil
+}
+
 // GetInfo retorna información sobre un PDF.
-func (p *Processor) GetInfo(inputPath string) (*types.PDFInfoResult, error) {
+func (p *Processor) GetInfo(ctx context.Context, inputPath string) (*types.PDFInfoResult, error) {
 	p.logger.Debug("reading PDF info",
 		slog.String("path", inputPath))
 
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
 	if err := p.ValidateFile(inputPath); err != nil {
 		return nil, err
 	}
@@ -104,25 +141,42 @@ func (p *Processor) GetInfo(inputPath string) (*types.PDFInfoResult, error) {
 		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
 
-	ctx, err := api.ReadContextFile(inputPath)
+	pdfCtx, err := api.ReadContextFile(inputPath)
 	if err != nil {
 		p.logger.Error("failed to read PDF context", err)
 		return nil, fmt.Errorf("failed to read PDF context: %w", err)
 	}
 
+	linearized, err := IsLinearized(inputPath)
+	if err != nil {
+		p.logger.Warn("failed to check linearization", slog.Any("error", err))
+	}
+
 	return &types.PDFInfoResult{
-		TotalPages: ctx.PageCount,
+		TotalPages: pdfCtx.PageCount,
 		SizeBytes:  fi.Size(),
 		Filename:   filepath.Base(inputPath),
+		Linearized: linearized,
 	}, nil
 }
 
 // Compress comprime un PDF optimizando imágenes y limpiando metadata.
-func (p *Processor) Compress(inputPath, outputPath string) (*types.CompressResult, error) {
+// Con opts.Linearize el resultado se linealiza después de optimizarlo.
+func (p *Processor) Compress(ctx context.Context, inputPath, outputPath string, opts types.CompressOptions) (_ *types.CompressResult, err error) {
 	p.logger.Debug("compressing PDF",
 		slog.String("input", inputPath),
-		slog.String("output", outputPath))
+		slog.String("output", outputPath),
+		slog.String("profile", string(opts.Profile)),
+		slog.Bool("linearize", opts.Linearize))
 
+	settings, err := p.resolveCompressSettings(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
 	if err := p.ValidateFile(inputPath); err != nil {
 		return nil, err
 	}
@@ -141,10 +195,33 @@ func (p *Processor) Compress(inputPath, outputPath string) (*types.CompressResul
 		return nil, err
 	}
 
-	conf := p.newConfiguration()
-	if err := api.OptimizeFile(inputPath, outputPath, conf); err != nil {
+	result := &types.CompressResult{
+		OutputPath: outputPath,
+		Profile:    settings.profile,
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	f, err := os.Create(outputPath)
+	if err != nil {
+		p.logger.Error("failed to create output file", err)
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	err = p.compressContext(ctx, inputPath, settings, result, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
 		p.logger.Error("PDF compression failed", err)
-		return nil, fmt.Errorf("failed to optimize PDF: %w", err)
+		return nil, err
+	}
+
+	if opts.Linearize {
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+		if err := p.linearizeFile(outputPath, outputPath); err != nil {
+			p.logger.Error("PDF linearization failed", err)
+			return nil, err
+		}
 	}
 
 	// Obtener tamaño comprimido
@@ -161,22 +238,30 @@ func (p *Processor) Compress(inputPath, outputPath string) (*types.CompressResul
 		ratio = float64(originalSize-compressedSize) / float64(originalSize)
 	}
 
-	result := &types.CompressResult{
-		OutputPath:       outputPath,
-		OriginalSize:     originalSize,
-		CompressedSize:   compressedSize,
-		CompressionRatio: ratio,
-	}
+	result.OriginalSize = originalSize
+	result.CompressedSize = compressedSize
+	result.CompressionRatio = ratio
+	result.Linearized = opts.Linearize
+
+	// Lo que no explican los pasos propios se debe a la reescritura de pdfcpu
+	// (streams, objetos inalcanzables, tabla xref) y a la linealización.
+	s := &result.Savings
+	s.Structure = originalSize - compressedSize - s.Images - s.DuplicateImages - s.UnusedFonts
 
 	p.logger.Debug("PDF compression complete",
 		slog.Int64("original_size", originalSize),
-		slog.Int64("compressed_size", compressedSize))
+		slog.Int64("compressed_size", compressedSize),
+		slog.Int("images_reencoded", result.ImagesReencoded),
+		slog.Int("fonts_removed", result.FontsRemoved))
 
 	return result, nil
 }
 
-// RemovePages elimina o conserva páginas específicas de un PDF.
-func (p *Processor) RemovePages(inputPath, outputPath, pageSelection string, mode types.PageRemovalMode) (*types.RemovePagesResult, error) {
+// RemovePages elimina o conserva páginas específicas de un PDF. pdfcpu copia
+// las páginas que se conservan en una sola llamada, así que el avance (en
+// páginas del documento) cuenta las eliminadas al resolver la selección y las
+// conservadas al terminar.
+func (p *Processor) RemovePages(ctx context.Context, inputPath, outputPath, pageSelection string, mode types.PageRemovalMode) (_ *types.RemovePagesResult, err error) {
 	p.logger.Debug("removing pages from PDF",
 		slog.String("input", inputPath),
 		slog.String("mode", string(mode)))
@@ -185,26 +270,29 @@ func (p *Processor) RemovePages(inputPath, outputPath, pageSelection string, mod
 		return nil, fmt.Errorf("invalid mode: %s", mode)
 	}
 
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
 	if err := p.ValidateFile(inputPath); err != nil {
 		return nil, err
 	}
 
 	// Leer contexto para obtener página total
-	ctx, err := api.ReadContextFile(inputPath)
+	pdfCtx, err := api.ReadContextFile(inputPath)
 	if err != nil {
 		p.logger.Error("failed to read PDF context", err)
 		return nil, fmt.Errorf("failed to read PDF context: %w", err)
 	}
 
-	if err := api.ValidateContext(ctx); err != nil {
+	if err := api.ValidateContext(pdfCtx); err != nil {
 		p.logger.Error("failed to validate PDF", err)
 		return nil, fmt.Errorf("failed to validate PDF: %w", err)
 	}
 
-	totalPages := ctx.PageCount
+	totalPages := pdfCtx.PageCount
 
 	// Parsear selección de páginas
-	selectedPages, err := parsePageSelection(pageSelection, totalPages)
+	selectedPages, err := parsePageSelection(pageSelection, totalPages, documentPageLabels(pdfCtx.XRefTable))
 	if err != nil {
 		p.logger.Warn("invalid page selection",
 			slog.String("selection", pageSelection),
@@ -249,11 +337,17 @@ func (p *Processor) RemovePages(inputPath, outputPath, pageSelection string, mod
 	// Construir selección de páginas para pdfcpu
 	removeEntries := intsToPageSelectionSlice(pagesToRemove)
 
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	reportProgress(ctx, len(pagesToRemove), totalPages)
 	conf := p.newConfiguration()
 	if err := api.RemovePagesFile(inputPath, outputPath, removeEntries, conf); err != nil {
 		p.logger.Error("failed to remove pages", err)
 		return nil, fmt.Errorf("failed to remove pages: %w", err)
 	}
+	reportProgress(ctx, totalPages, totalPages)
 
 	result := &types.RemovePagesResult{
 		OutputPath:     outputPath,
@@ -272,7 +366,9 @@ func (p *Processor) RemovePages(inputPath, outputPath, pageSelection string, mod
 }
 
 // Merge combina múltiples PDFs en un solo archivo de salida.
-func (p *Processor) Merge(inputPaths []string, outputPath string) (*types.MergeResult, error) {
+// Con opts.Linearize el resultado se linealiza. Informa del avance por
+// archivo de entrada.
+func (p *Processor) Merge(ctx context.Context, inputPaths []string, outputPath string, opts types.MergeOptions) (_ *types.MergeResult, err error) {
 	p.logger.Debug("merging PDFs",
 		slog.Int("input_count", len(inputPaths)),
 		slog.String("output", outputPath))
@@ -283,6 +379,9 @@ func (p *Processor) Merge(inputPaths []string, outputPath string) (*types.MergeR
 
 	// Validar que todos los archivos existan
 	for _, path := range inputPaths {
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
 		if err := p.ValidateFile(path); err != nil {
 			return nil, fmt.Errorf("input file validation failed: %w", err)
 		}
@@ -294,12 +393,28 @@ func (p *Processor) Merge(inputPaths []string, outputPath string) (*types.MergeR
 		return nil, err
 	}
 
-	conf := p.newConfiguration()
-	if err := api.MergeCreateFile(inputPaths, outputPath, false, conf); err != nil {
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	if err := p.mergeFiles(ctx, inputPaths, outputPath); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
 		p.logger.Error("PDF merge failed", err)
 		return nil, fmt.Errorf("merge failed: %w", err)
 	}
 
+	if opts.Linearize {
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+		if err := p.linearizeFile(outputPath, outputPath); err != nil {
+			p.logger.Error("PDF linearization failed", err)
+			return nil, err
+		}
+	}
+
 	// Obtener información del archivo resultante
 	resultInfo, err := os.Stat(outputPath)
 	if err != nil {
@@ -312,6 +427,7 @@ func (p *Processor) Merge(inputPaths []string, outputPath string) (*types.MergeR
 		InputFiles:  inputPaths,
 		InputCount:  len(inputPaths),
 		OutputSize:  resultInfo.Size(),
+		Linearized:  opts.Linearize,
 	}
 
 	p.logger.Debug("PDF merge complete",
@@ -321,6 +437,75 @@ func (p *Processor) Merge(inputPaths []string, outputPath string) (*types.MergeR
 	return result, nil
 }
 
+// mergeFiles hace lo mismo que api.MergeCreateFile, pero archivo a archivo
+// para poder comprobar ctx e informar del avance entre entradas. Si falla,
+// borra la salida.
+func (p *Processor) mergeFiles(ctx context.Context, inputPaths []string, outputPath string) error {
+	conf := p.newConfiguration()
+	conf.Cmd = model.MERGECREATE
+	conf.ValidationMode = model.ValidationRelaxed
+
+	progress := newProgressCounter(ctx, len(inputPaths))
+	var dest *model.Context
+	for _, path := range inputPaths {
+		if err := checkCancelled(ctx); err != nil {
+			return err
+		}
+		src, err := readMergeInput(path, conf)
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(path)
+		if dest == nil {
+			dest = src
+			if conf.CreateBookmarks {
+				if err := pdfcpu.EnsureOutlines(dest, name, false); err != nil {
+					return err
+				}
+			}
+			if dest.XRefTable.Version() < model.V20 {
+				dest.EnsureVersionForWriting()
+			}
+		} else {
+			if dest.XRefTable.Version() < model.V20 && src.XRefTable.Version() == model.V20 {
+				return fmt.Errorf("%s: %w", name, pdfcpu.ErrUnsupportedVersion)
+			}
+			if err := pdfcpu.MergeXRefTables(name, src, dest, false, false); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		progress.step()
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return err
+	}
+	if conf.OptimizeBeforeWriting {
+		if err := api.OptimizeContext(dest); err != nil {
+			return err
+		}
+	}
+	if err := api.WriteContextFile(dest, outputPath); err != nil {
+		_ = os.Remove(outputPath)
+		return err
+	}
+	return nil
+}
+
+// readMergeInput lee y valida una entrada de Merge.
+func readMergeInput(path string, conf *model.Configuration) (*model.Context, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	pdfCtx, err := api.ReadAndValidate(f, conf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return pdfCtx, nil
+}
+
 // newConfiguration crea una configuración pdfcpu con valores del config.
 func (p *Processor) newConfiguration() *model.Configuration {
 	conf := model.NewDefaultConfiguration()
@@ -334,6 +519,22 @@ func (p *Processor) newConfiguration() *model.Configuration {
 	return conf
 }
 
+// readContext lee y valida un PDF con la configuración del procesador,
+// dejando el contexto listo para modificarlo en memoria.
+func (p *Processor) readContext(path string) (*model.Context, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	pdfCtx, err := api.ReadAndValidate(f, p.newConfiguration())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	return pdfCtx, nil
+}
+
 // ensureOutputDir crea el directorio de salida si es necesario.
 func ensureOutputDir(outputPath string) error {
 	outDir := filepath.Dir(outputPath)
@@ -353,3 +554,4 @@ func ensureOutputDir(outputPath string) error {
 
 	return nil
 }
+
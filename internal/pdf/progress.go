@@ -0,0 +1,75 @@
+package pdf
+
+import (
+	"context"
+	"sync"
+)
+
+// ProgressFunc recibe el avance de una operación: done de total unidades.
+// La unidad depende de la operación: páginas en Split y RemovePages, páginas
+// e imágenes en Compress, archivos de entrada en Merge, archivos en Batch,
+// pasos en RunPipeline y perfiles en la estimación de AnalyzeSize. done nunca
+// disminuye dentro de una misma llamada.
+type ProgressFunc func(done, total int)
+
+type progressKey struct{}
+
+// WithProgress devuelve un contexto con el que las operaciones de Processor
+// informan de su avance a fn. El callback va en el contexto y no en el
+// Processor porque un mismo Processor atiende llamadas concurrentes; fn se
+// llama desde la goroutine de la operación (o desde los workers de Batch, de
+// uno en uno) y debe volver rápido. Con fn nil se desactiva el aviso.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// reportProgress avisa al callback de ctx, si lo hay.
+func reportProgress(ctx context.Context, done, total int) {
+	if fn, _ := ctx.Value(progressKey{}).(ProgressFunc); fn != nil {
+		fn(done, total)
+	}
+}
+
+// withoutProgress desactiva el aviso en las operaciones que otra operación
+// ejecuta por dentro (pasos de un pipeline, archivos de un lote), que informa
+// de su propio avance en otra unidad.
+func withoutProgress(ctx context.Context) context.Context {
+	if ctx.Value(progressKey{}) == nil {
+		return ctx
+	}
+	return WithProgress(ctx, nil)
+}
+
+// progressCounter cuenta unidades terminadas desde varias goroutines y avisa
+// en orden creciente.
+type progressCounter struct {
+	ctx   context.Context
+	total int
+
+	mu   sync.Mutex
+	done int
+}
+
+func newProgressCounter(ctx context.Context, total int) *progressCounter {
+	reportProgress(ctx, 0, total)
+	return &progressCounter{ctx: ctx, total: total}
+}
+
+// step anota una unidad terminada.
+func (c *progressCounter) step() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.done++
+	reportProgress(c.ctx, c.done, c.total)
+}
+
+// finish da por terminadas las unidades que falten (las que una operación
+// acaba saltándose) para que el último aviso sea total de total.
+func (c *progressCounter) finish() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done < c.total {
+		c.done = c.total
+		reportProgress(c.ctx, c.done, c.total)
+	}
+}
@@ -0,0 +1,135 @@
+package pdf
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// progressRecorder guarda los avisos de progreso recibidos.
+type progressRecorder struct {
+	mu    sync.Mutex
+	calls [][2]int
+}
+
+func (r *progressRecorder) ctx() context.Context {
+	return WithProgress(context.Background(), func(done, total int) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.calls = append(r.calls, [2]int{done, total})
+	})
+}
+
+// check comprueba que los avisos crecen, no cambian de total y terminan en
+// total de total.
+func (r *progressRecorder) check(t *testing.T, wantTotal int) {
+	t.Helper()
+	if len(r.calls) == 0 {
+		t.Fatal("no progress reported")
+	}
+	prev := -1
+	for _, c := range r.calls {
+		if c[1] != wantTotal {
+			t.Fatalf("total = %d, want %d (calls %v)", c[1], wantTotal, r.calls)
+		}
+		if c[0] < prev || c[0] > c[1] {
+			t.Fatalf("progress not increasing within bounds: %v", r.calls)
+		}
+		prev = c[0]
+	}
+	if last := r.calls[len(r.calls)-1]; last[0] != wantTotal {
+		t.Errorf("last progress = %v, want %d/%d", last, wantTotal, wantTotal)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	dir := t.TempDir()
+	p := newTestProcessor()
+	a := writeTestPDF(t, dir, "a.pdf", []string{"a1", "a2", "a3"})
+	b := writeTestPDF(t, dir, "b.pdf", []string{"b1"})
+	c := writeTestPDF(t, dir, "c.pdf", []string{"c1", "c2"})
+
+	t.Run("split reports every page", func(t *testing.T) {
+		var r progressRecorder
+		parts, err := p.Split(r.ctx(), a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.check(t, 3)
+		if len(r.calls) != 4 {
+			t.Errorf("calls = %v, want 0..3", r.calls)
+		}
+		if len(parts) != 3 || filepath.Base(parts[2]) != "a_3.pdf" {
+			t.Errorf("parts = %v", parts)
+		}
+	})
+
+	t.Run("merge reports every input", func(t *testing.T) {
+		var r progressRecorder
+		out := filepath.Join(dir, "merged.pdf")
+		if _, err := p.Merge(r.ctx(), []string{a, b, c}, out, types.MergeOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		r.check(t, 3)
+		info, err := p.GetInfo(context.Background(), out)
+		if err != nil || info.TotalPages != 6 {
+			t.Errorf("merged pages = %v, %v", info, err)
+		}
+	})
+
+	t.Run("remove pages", func(t *testing.T) {
+		var r progressRecorder
+		out := filepath.Join(dir, "removed.pdf")
+		if _, err := p.RemovePages(r.ctx(), a, out, "2", types.ModeRemove); err != nil {
+			t.Fatal(err)
+		}
+		r.check(t, 3)
+		// Primero la página eliminada y después las conservadas.
+		if want := [][2]int{{1, 3}, {3, 3}}; !reflect.DeepEqual(r.calls, want) {
+			t.Errorf("calls = %v, want %v", r.calls, want)
+		}
+	})
+
+	t.Run("compress counts pages and images", func(t *testing.T) {
+		var r progressRecorder
+		in := writeCompressTestPDF(t, dir)
+		out := filepath.Join(dir, "compressed.pdf")
+		if _, err := p.Compress(r.ctx(), in, out, types.CompressOptions{Profile: types.ProfileScreen}); err != nil {
+			t.Fatal(err)
+		}
+		if len(r.calls) == 0 {
+			t.Fatal("no progress reported")
+		}
+		total := r.calls[0][1]
+		if total <= 1 {
+			t.Errorf("total = %d, want pages plus images", total)
+		}
+		r.check(t, total)
+	})
+
+	t.Run("batch reports files, not their pages", func(t *testing.T) {
+		var r progressRecorder
+		step := types.PipelineStep{Operation: "rotate", Args: []byte(`{"degrees":90}`)}
+		_, err := p.Batch(r.ctx(), []string{a, b, c}, step, types.BatchOptions{OutputDir: filepath.Join(dir, "batch"), Workers: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.check(t, 3)
+	})
+
+	t.Run("pipeline reports steps", func(t *testing.T) {
+		var r progressRecorder
+		steps := []types.PipelineStep{
+			{Operation: "merge"},
+			{Operation: "compress"},
+		}
+		if _, err := p.RunPipeline(r.ctx(), []string{a, c}, filepath.Join(dir, "pipeline.pdf"), steps); err != nil {
+			t.Fatal(err)
+		}
+		r.check(t, 2)
+	})
+}
@@ -0,0 +1,730 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// redactTolerance es el solape mínimo (en puntos) para considerar que un glifo
+// o una imagen cae dentro de un área de redacción.
+const redactTolerance = 0.1
+
+// minMetadataFragment es la longitud mínima de un texto eliminado para buscarlo en los metadatos.
+const minMetadataFragment = 3
+
+// Redact elimina de forma permanente el contenido bajo las áreas indicadas y el texto
+// que coincide con los patrones: los glifos se quitan de los content streams, las
+// imágenes se recortan o eliminan y se dibuja un relleno negro encima. También limpia
+// las anotaciones y los metadatos que contengan el texto eliminado.
+// Con opts.DryRun solo se informa de lo que se eliminaría, sin escribir la salida.
+func (p *Processor) Redact(ctx context.Context, inputPath, outputPath string, opts types.RedactOptions) (_ *types.RedactResult, err error) {
+	p.logger.Debug("redacting PDF",
+		slog.String("input", inputPath),
+		slog.String("output", outputPath),
+		slog.Int("areas", len(opts.Areas)),
+		slog.Int("patterns", len(opts.Patterns)),
+		slog.Bool("dry_run", opts.DryRun))
+
+	if len(opts.Areas) == 0 && len(opts.Patterns) == 0 {
+		return nil, fmt.Errorf("no redaction areas or patterns provided")
+	}
+	if !opts.DryRun && outputPath == "" {
+		return nil, fmt.Errorf("output path is required unless dry_run is set")
+	}
+
+	patterns, err := compileRedactPatterns(opts.Patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(inputPath); err != nil {
+		return nil, err
+	}
+
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+
+	areasByPage := make(map[int][]rect)
+	for _, a := range opts.Areas {
+		if a.Page < 1 || a.Page > pdfCtx.PageCount {
+			return nil, fmt.Errorf("redaction area page %d out of range (1-%d)", a.Page, pdfCtx.PageCount)
+		}
+		r := rectFromTypes(a.Rect)
+		if r.width() <= 0 || r.height() <= 0 {
+			return nil, fmt.Errorf("redaction area on page %d has zero size", a.Page)
+		}
+		areasByPage[a.Page] = append(areasByPage[a.Page], r)
+	}
+
+	result := &types.RedactResult{
+		DryRun:          opts.DryRun,
+		PagesAffected:   []int{},
+		Matches:         []types.RedactMatch{},
+		Areas:           []types.PageRect{},
+		MetadataCleared: []string{},
+	}
+
+	fonts := newFontCache(pdfCtx.XRefTable)
+	var fragments []string
+
+	for pageNr := 1; pageNr <= pdfCtx.PageCount; pageNr++ {
+		if len(areasByPage[pageNr]) == 0 && len(patterns) == 0 {
+			continue
+		}
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+
+		pc, err := loadPageContent(pdfCtx.XRefTable, pageNr, fonts)
+		if err != nil {
+			// Sin interpretar el contenido no se puede garantizar la eliminación.
+			p.logger.Error("failed to parse page content", err)
+			return nil, fmt.Errorf("failed to parse content of page %d: %w", pageNr, err)
+		}
+
+		red := newPageRedaction(pdfCtx.XRefTable, pc)
+		text, spans := pageText(pc.glyphs)
+
+		for i, re := range patterns {
+			for _, m := range findTextMatches(pc.glyphs, text, spans, re) {
+				result.Matches = append(result.Matches, types.RedactMatch{
+					Page:    pageNr,
+					Pattern: opts.Patterns[i].Pattern,
+					Text:    m.text,
+					Rects:   rectsToTypes(m.rects),
+				})
+				red.fills = append(red.fills, m.rects...)
+				for _, g := range m.glyphs {
+					red.removed[g] = true
+				}
+				fragments = append(fragments, m.text)
+			}
+		}
+
+		for _, area := range areasByPage[pageNr] {
+			red.fills = append(red.fills, area)
+			for i, g := range pc.glyphs {
+				if g.bbox.overlaps(area, redactTolerance) {
+					red.removed[i] = true
+				}
+			}
+		}
+
+		if len(red.fills) == 0 {
+			continue
+		}
+
+		for _, r := range red.fills {
+			result.Areas = append(result.Areas, types.PageRect{Page: pageNr, Rect: r.toTypes()})
+		}
+
+		red.redactImages()
+		red.redactAnnotations()
+
+		fragments = append(fragments, red.removedFragments()...)
+		result.GlyphsRemoved += len(red.removed)
+		result.ImagesRemoved += red.imagesRemoved
+		result.ImagesModified += red.imagesModified
+		result.AnnotationsRemoved += red.annotsRemoved
+		result.Warnings = append(result.Warnings, red.warnings...)
+		result.PagesAffected = append(result.PagesAffected, pageNr)
+
+		if !opts.DryRun {
+			if err := red.apply(); err != nil {
+				p.logger.Error("failed to rewrite page content", err)
+				return nil, fmt.Errorf("failed to redact page %d: %w", pageNr, err)
+			}
+		}
+	}
+
+	result.MetadataCleared = clearRedactedMetadata(pdfCtx, fragments, opts.DryRun)
+
+	if opts.DryRun {
+		p.logger.Debug("PDF redaction dry run complete",
+			slog.Int("matches", len(result.Matches)),
+			slog.Int("glyphs", result.GlyphsRemoved))
+		return result, nil
+	}
+
+	// Asegurar directorio de salida
+	if err := ensureOutputDir(outputPath); err != nil {
+		p.logger.Error("failed to create output directory", err)
+		return nil, err
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	if err := api.WriteContextFile(pdfCtx, outputPath); err != nil {
+		p.logger.Error("failed to write redacted PDF", err)
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("failed to write redacted PDF: %w", err)
+	}
+
+	resultInfo, err := os.Stat(outputPath)
+	if err != nil {
+		p.logger.Error("failed to stat output file", err)
+		return nil, fmt.Errorf("failed to stat output file: %w", err)
+	}
+	result.OutputPath = outputPath
+	result.OutputSize = resultInfo.Size()
+
+	p.logger.Debug("PDF redaction complete",
+		slog.Int("pages", len(result.PagesAffected)),
+		slog.Int("glyphs", result.GlyphsRemoved),
+		slog.Int("images_removed", result.ImagesRemoved),
+		slog.Int("images_modified", result.ImagesModified))
+
+	return result, nil
+}
+
+// compileRedactPatterns convierte los patrones en expresiones regulares.
+func compileRedactPatterns(patterns []types.RedactPattern) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pt := range patterns {
+		if pt.Pattern == "" {
+			return nil, fmt.Errorf("empty redaction pattern")
+		}
+		expr := pt.Pattern
+		if !pt.Regex {
+			expr = regexp.QuoteMeta(expr)
+		}
+		if pt.CaseInsensitive {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pt.Pattern, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// pageRedaction acumula los cambios a aplicar sobre una página.
+type pageRedaction struct {
+	xrt     *model.XRefTable
+	pc      *pageContent
+	removed map[int]bool // índices de glifo a eliminar
+	fills   []rect
+
+	dropOps map[*contentSource]map[int]bool
+	renames map[*contentSource]map[int]string
+	newObjs map[*contentSource]map[string]*pdftypes.IndirectRef
+	// images modificadas pendientes de crear como objetos nuevos
+	images map[*contentSource]map[int]*pdftypes.StreamDict
+
+	imagesRemoved  int
+	imagesModified int
+	annotsRemoved  int
+	warnings       []string
+}
+
+func newPageRedaction(xrt *model.XRefTable, pc *pageContent) *pageRedaction {
+	return &pageRedaction{
+		xrt:     xrt,
+		pc:      pc,
+		removed: make(map[int]bool),
+		dropOps: make(map[*contentSource]map[int]bool),
+		renames: make(map[*contentSource]map[int]string),
+		newObjs: make(map[*contentSource]map[string]*pdftypes.IndirectRef),
+		images:  make(map[*contentSource]map[int]*pdftypes.StreamDict),
+	}
+}
+
+func (r *pageRedaction) drop(src *contentSource, op int) {
+	if r.dropOps[src] == nil {
+		r.dropOps[src] = make(map[int]bool)
+	}
+	r.dropOps[src][op] = true
+}
+
+// redactImages elimina las imágenes cubiertas por completo o que no se pueden editar,
+// y borra los píxeles bajo las áreas en el resto.
+func (r *pageRedaction) redactImages() {
+	for _, img := range r.pc.images {
+		var hits []rect
+		covered := false
+		for _, f := range r.fills {
+			if img.bbox.overlaps(f, redactTolerance) {
+				hits = append(hits, f)
+				if f.contains(img.bbox) {
+					covered = true
+				}
+			}
+		}
+		if len(hits) == 0 {
+			continue
+		}
+
+		if !covered && !img.inline && img.ref != nil {
+			sd, err := redactImagePixels(r.xrt, *img.ref, img.ctm, hits)
+			if err == nil {
+				if r.images[img.src] == nil {
+					r.images[img.src] = make(map[int]*pdftypes.StreamDict)
+				}
+				r.images[img.src][img.op] = sd
+				r.imagesModified++
+				continue
+			}
+			r.warnings = append(r.warnings, fmt.Sprintf("page %d: image %s removed entirely (%v)", r.pc.number, img.name, err))
+		}
+
+		r.drop(img.src, img.op)
+		r.imagesRemoved++
+	}
+}
+
+// redactAnnotations elimina las anotaciones que se solapan con un área redactada.
+// Los widgets de formulario se conservan con un aviso para no romper el AcroForm.
+func (r *pageRedaction) redactAnnotations() {
+	annots, err := r.xrt.DereferenceArray(r.pc.dict["Annots"])
+	if err != nil || len(annots) == 0 {
+		return
+	}
+
+	kept := pdftypes.Array{}
+	for _, o := range annots {
+		d, err := r.xrt.DereferenceDict(o)
+		if err != nil || d == nil {
+			kept = append(kept, o)
+			continue
+		}
+		arr, err := r.xrt.DereferenceArray(d["Rect"])
+		if err != nil || len(arr) != 4 {
+			kept = append(kept, o)
+			continue
+		}
+		var v [4]float64
+		for i := range v {
+			v[i], _ = r.xrt.DereferenceNumber(arr[i])
+		}
+		ar := rect{v[0], v[1], v[2], v[3]}.normalized()
+
+		hit := false
+		for _, f := range r.fills {
+			if ar.overlaps(f, redactTolerance) {
+				hit = true
+				break
+			}
+		}
+		if !hit {
+			kept = append(kept, o)
+			continue
+		}
+		if st := d.Subtype(); st != nil && *st == "Widget" {
+			r.warnings = append(r.warnings, fmt.Sprintf("page %d: form field widget overlaps a redaction area and was kept", r.pc.number))
+			kept = append(kept, o)
+			continue
+		}
+		r.annotsRemoved++
+	}
+
+	if r.annotsRemoved > 0 {
+		r.pc.dict["Annots"] = kept
+	}
+}
+
+// removedFragments devuelve los tramos de texto eliminados, en orden de contenido.
+func (r *pageRedaction) removedFragments() []string {
+	var out []string
+	var sb strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(sb.String()); len([]rune(s)) >= minMetadataFragment {
+			out = append(out, s)
+		}
+		sb.Reset()
+	}
+	for i, g := range r.pc.glyphs {
+		if r.removed[i] {
+			sb.WriteString(g.text)
+		} else if sb.Len() > 0 {
+			flush()
+		}
+	}
+	flush()
+	return out
+}
+
+// apply reescribe los content streams afectados y dibuja los rellenos.
+// Los Form XObjects modificados se copian como objetos nuevos para no alterar
+// otras páginas que los compartan.
+func (r *pageRedaction) apply() error {
+	removedBySource := make(map[*contentSource]bool)
+	for i := range r.removed {
+		removedBySource[r.pc.glyphs[i].src] = true
+	}
+
+	// Las imágenes editadas se añaden como objetos nuevos con otro nombre.
+	for src, byOp := range r.images {
+		for op, sd := range byOp {
+			ref, err := r.xrt.IndRefForNewObject(*sd)
+			if err != nil {
+				return err
+			}
+			r.rename(src, op, ref)
+		}
+	}
+
+	// Los hijos se crean después que los padres, así que se recorren al revés.
+	var rootContent []byte
+	for i := len(r.pc.sources) - 1; i >= 0; i-- {
+		src := r.pc.sources[i]
+		changed := removedBySource[src] || len(r.dropOps[src]) > 0 || len(r.renames[src]) > 0
+		if !changed {
+			continue
+		}
+
+		content := r.rebuildContent(src)
+		resources := r.rewriteResources(src)
+
+		if src.parent == nil {
+			rootContent = content
+			if resources != nil {
+				r.pc.dict["Resources"] = resources
+			}
+			continue
+		}
+
+		form := pdftypes.StreamDict{
+			Dict:           src.form.Dict.Clone().(pdftypes.Dict),
+			Content:        content,
+			FilterPipeline: []pdftypes.PDFFilter{{Name: "FlateDecode"}},
+		}
+		form.Delete("DecodeParms")
+		form.Delete("Length")
+		form.Update("Filter", pdftypes.Name("FlateDecode"))
+		if resources != nil {
+			form.Update("Resources", resources)
+		}
+		if err := form.Encode(); err != nil {
+			return err
+		}
+		ref, err := r.xrt.IndRefForNewObject(form)
+		if err != nil {
+			return err
+		}
+		r.rename(src.parent, src.parentOp, ref)
+	}
+
+	if rootContent == nil {
+		rootContent = serializeOps(r.pc.root.ops)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("q\n")
+	buf.Write(rootContent)
+	buf.WriteString("\nQ\nq 0 g\n")
+	for _, f := range r.fills {
+		fmt.Fprintf(&buf, "%s %s %s %s re f\n",
+			formatNumber(f.llx), formatNumber(f.lly), formatNumber(f.width()), formatNumber(f.height()))
+	}
+	buf.WriteString("Q\n")
+
+	sd, err := r.xrt.NewStreamDictForBuf(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := sd.Encode(); err != nil {
+		return err
+	}
+	ref, err := r.xrt.IndRefForNewObject(*sd)
+	if err != nil {
+		return err
+	}
+	r.pc.dict["Contents"] = *ref
+	return nil
+}
+
+// rename hace que el Do op de src apunte a un objeto nuevo.
+func (r *pageRedaction) rename(src *contentSource, op int, ref *pdftypes.IndirectRef) {
+	if r.newObjs[src] == nil {
+		r.newObjs[src] = make(map[string]*pdftypes.IndirectRef)
+	}
+	if r.renames[src] == nil {
+		r.renames[src] = make(map[int]string)
+	}
+	xobjects, _ := r.xrt.DereferenceDict(src.resources["XObject"])
+	name := ""
+	for n := 1; ; n++ {
+		name = "Rd" + strconv.Itoa(n)
+		if _, ok := xobjects[name]; ok {
+			continue
+		}
+		if _, ok := r.newObjs[src][name]; ok {
+			continue
+		}
+		break
+	}
+	r.newObjs[src][name] = ref
+	r.renames[src][op] = name
+}
+
+// rewriteResources devuelve una copia de los recursos de src con los XObjects nuevos
+// y sin los que ya no se usan, o nil si no hay cambios.
+func (r *pageRedaction) rewriteResources(src *contentSource) pdftypes.Dict {
+	if len(r.dropOps[src]) == 0 && len(r.renames[src]) == 0 {
+		return nil
+	}
+
+	used := make(map[string]bool)
+	var stale []string
+	for i, op := range src.ops {
+		if op.op != "Do" || len(op.args) != 1 {
+			continue
+		}
+		name := op.args[0].name
+		if r.dropOps[src][i] {
+			stale = append(stale, name)
+			continue
+		}
+		if n, ok := r.renames[src][i]; ok {
+			stale = append(stale, name)
+			name = n
+		}
+		used[name] = true
+	}
+
+	// Un Form XObject sin recursos propios usa los del padre: no se poda nada.
+	inherited := false
+	for _, s := range r.pc.sources {
+		if s.parent == src && s.form != nil && s.form.Dict["Resources"] == nil {
+			inherited = true
+		}
+	}
+
+	out := pdftypes.NewDict()
+	for k, v := range src.resources {
+		out[k] = v
+	}
+	xobjects := pdftypes.NewDict()
+	if old, _ := r.xrt.DereferenceDict(src.resources["XObject"]); old != nil {
+		for k, v := range old {
+			xobjects[k] = v
+		}
+	}
+	if !inherited {
+		for _, name := range stale {
+			if !used[name] {
+				delete(xobjects, name)
+			}
+		}
+	}
+	for name, ref := range r.newObjs[src] {
+		xobjects[name] = *ref
+	}
+	out["XObject"] = xobjects
+	return out
+}
+
+// rebuildContent serializa los operadores de src aplicando eliminaciones de glifos,
+// imágenes y renombrados de XObjects.
+func (r *pageRedaction) rebuildContent(src *contentSource) []byte {
+	byOp := make(map[int][]int)
+	for i, g := range r.pc.glyphs {
+		if g.src == src {
+			byOp[g.op] = append(byOp[g.op], i)
+		}
+	}
+
+	var buf bytes.Buffer
+	for i, op := range src.ops {
+		if r.dropOps[src][i] {
+			continue
+		}
+		if name, ok := r.renames[src][i]; ok {
+			fmt.Fprintf(&buf, "/%s Do\n", name)
+			continue
+		}
+
+		glyphs := byOp[i]
+		touched := false
+		for _, g := range glyphs {
+			if r.removed[g] {
+				touched = true
+				break
+			}
+		}
+		if !touched {
+			buf.Write(op.raw)
+			buf.WriteByte('\n')
+			continue
+		}
+		r.writeRedactedTextOp(&buf, op, glyphs)
+	}
+	return buf.Bytes()
+}
+
+// writeRedactedTextOp reescribe un operador de texto como TJ sin los glifos eliminados,
+// sustituyéndolos por desplazamientos para que el texto restante no se mueva.
+func (r *pageRedaction) writeRedactedTextOp(buf *bytes.Buffer, op contentOp, glyphs []int) {
+	var elems []operand
+	switch op.op {
+	case "TJ":
+		elems = op.args[0].arr
+	case "Tj", "'":
+		elems = op.args[:1]
+	case "\"":
+		fmt.Fprintf(buf, "%s Tw %s Tc ", formatNumber(op.num(0)), formatNumber(op.num(1)))
+		elems = op.args[2:3]
+	}
+	if op.op == "'" || op.op == "\"" {
+		buf.WriteString("T* ")
+	}
+
+	byElem := make(map[int][]int)
+	for _, g := range glyphs {
+		byElem[r.pc.glyphs[g].elem] = append(byElem[r.pc.glyphs[g].elem], g)
+	}
+
+	type item struct {
+		str   []byte
+		num   float64
+		isNum bool
+	}
+	var items []item
+	addNum := func(n float64) {
+		if k := len(items); k > 0 && items[k-1].isNum {
+			items[k-1].num += n
+			return
+		}
+		items = append(items, item{num: n, isNum: true})
+	}
+
+	hex := false
+	for j, e := range elems {
+		if e.kind == operandNumber {
+			addNum(e.num)
+			continue
+		}
+		if e.kind != operandString {
+			continue
+		}
+		hex = hex || e.hex
+		gs := byElem[j]
+		sort.Slice(gs, func(a, b int) bool { return r.pc.glyphs[gs[a]].off < r.pc.glyphs[gs[b]].off })
+
+		var run []byte
+		for _, g := range gs {
+			tg := r.pc.glyphs[g]
+			end := tg.off + tg.n
+			if end > len(e.str) {
+				end = len(e.str)
+			}
+			if r.removed[g] {
+				if len(run) > 0 {
+					items = append(items, item{str: run})
+					run = nil
+				}
+				addNum(tg.adjust)
+				continue
+			}
+			run = append(run, e.str[tg.off:end]...)
+		}
+		if len(run) > 0 {
+			items = append(items, item{str: run})
+		}
+	}
+
+	buf.WriteByte('[')
+	for k, it := range items {
+		if k > 0 {
+			buf.WriteByte(' ')
+		}
+		if it.isNum {
+			buf.WriteString(formatNumber(it.num))
+		} else {
+			writePDFString(buf, it.str, hex)
+		}
+	}
+	buf.WriteString("] TJ\n")
+}
+
+func serializeOps(ops []contentOp) []byte {
+	var buf bytes.Buffer
+	for _, op := range ops {
+		buf.Write(op.raw)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// clearRedactedMetadata elimina las entradas del diccionario Info y el XMP del
+// catálogo que contengan texto redactado. Devuelve los campos afectados.
+func clearRedactedMetadata(pdfCtx *model.Context, fragments []string, dryRun bool) []string {
+	cleared := []string{}
+	if len(fragments) == 0 {
+		return cleared
+	}
+
+	lower := make([]string, 0, len(fragments))
+	for _, f := range fragments {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			lower = append(lower, f)
+		}
+	}
+	containsAny := func(s string) bool {
+		s = strings.ToLower(s)
+		for _, f := range lower {
+			if strings.Contains(s, f) {
+				return true
+			}
+		}
+		return false
+	}
+
+	xrt := pdfCtx.XRefTable
+	if xrt.Info != nil {
+		if info, err := xrt.DereferenceDict(*xrt.Info); err == nil && info != nil {
+			keys := make([]string, 0, len(info))
+			for k := range info {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				o, _ := xrt.Dereference(info[k])
+				s, err := pdftypes.StringOrHexLiteral(o)
+				if err != nil || s == nil || !containsAny(*s) {
+					continue
+				}
+				cleared = append(cleared, "Info/"+k)
+				if !dryRun {
+					info.Delete(k)
+				}
+			}
+		}
+	}
+
+	if root, err := xrt.Catalog(); err == nil && root != nil {
+		if sd, _, err := xrt.DereferenceStreamDict(root["Metadata"]); err == nil && sd != nil {
+			if err := sd.Decode(); err == nil && containsAny(string(sd.Content)) {
+				cleared = append(cleared, "XMP")
+				if !dryRun {
+					root.Delete("Metadata")
+				}
+			}
+		}
+	}
+
+	return cleared
+}
@@ -0,0 +1,177 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"math"
+
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// redactImagePixels devuelve una copia de la imagen con los píxeles bajo las áreas
+// pintados de negro. La copia se guarda con FlateDecode para no degradar más un JPEG.
+// Solo se admiten imágenes de 8 bits en Gray/RGB/CMYK sin filtros o con un único
+// Flate o DCT; en otro caso se devuelve error y el llamante elimina la imagen.
+func redactImagePixels(xrt *model.XRefTable, ref pdftypes.IndirectRef, ctm matrix, areas []rect) (*pdftypes.StreamDict, error) {
+	sd, _, err := xrt.DereferenceStreamDict(ref)
+	if err != nil || sd == nil {
+		return nil, fmt.Errorf("image object not found")
+	}
+
+	pixels, width, height, comps, err := decodeImagePixels(xrt, sd)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, ok := ctm.inverse()
+	if !ok {
+		return nil, fmt.Errorf("degenerate image transform")
+	}
+
+	black := make([]byte, comps)
+	if comps == 4 {
+		black[3] = 255
+	}
+
+	for _, a := range areas {
+		// Espacio de imagen: cuadrado unidad con la fila 0 arriba.
+		u := transformRect(a, inv)
+		x0 := clampInt(int(math.Floor(u.llx*float64(width))), 0, width)
+		x1 := clampInt(int(math.Ceil(u.urx*float64(width))), 0, width)
+		y0 := clampInt(int(math.Floor((1-u.ury)*float64(height))), 0, height)
+		y1 := clampInt(int(math.Ceil((1-u.lly)*float64(height))), 0, height)
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				copy(pixels[(y*width+x)*comps:], black)
+			}
+		}
+	}
+
+	out := pdftypes.StreamDict{
+		Dict:           sd.Dict.Clone().(pdftypes.Dict),
+		Content:        pixels[:width*height*comps],
+		FilterPipeline: []pdftypes.PDFFilter{{Name: filter.Flate}},
+	}
+	out.Delete("DecodeParms")
+	out.Delete("Length")
+	out.Update("Filter", pdftypes.Name(filter.Flate))
+	if err := out.Encode(); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// decodeImagePixels devuelve las muestras de 8 bits de una imagen Gray/RGB/CMYK
+// sin filtros o con un único Flate o DCT, junto con sus dimensiones y componentes.
+func decodeImagePixels(xrt *model.XRefTable, sd *pdftypes.StreamDict) ([]byte, int, int, int, error) {
+	w, h := sd.IntEntry("Width"), sd.IntEntry("Height")
+	if w == nil || h == nil || *w <= 0 || *h <= 0 {
+		return nil, 0, 0, 0, fmt.Errorf("invalid image dimensions")
+	}
+	if bpc := sd.IntEntry("BitsPerComponent"); bpc == nil || *bpc != 8 {
+		return nil, 0, 0, 0, fmt.Errorf("unsupported bits per component")
+	}
+	if mask := sd.BooleanEntry("ImageMask"); mask != nil && *mask {
+		return nil, 0, 0, 0, fmt.Errorf("image masks are not supported")
+	}
+	if _, ok := sd.Find("Decode"); ok {
+		return nil, 0, 0, 0, fmt.Errorf("images with a Decode array are not supported")
+	}
+
+	comps, err := imageComponents(xrt, sd.Dict["ColorSpace"])
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	var pixels []byte
+	switch {
+	case len(sd.FilterPipeline) == 0, len(sd.FilterPipeline) == 1 && sd.FilterPipeline[0].Name == filter.Flate:
+		if err := sd.Decode(); err != nil {
+			return nil, 0, 0, 0, fmt.Errorf("failed to decode image: %w", err)
+		}
+		pixels = append([]byte(nil), sd.Content...)
+	case len(sd.FilterPipeline) == 1 && sd.FilterPipeline[0].Name == filter.DCT:
+		pixels, err = decodeJPEGPixels(sd.Raw, comps)
+		if err != nil {
+			return nil, 0, 0, 0, err
+		}
+	default:
+		return nil, 0, 0, 0, fmt.Errorf("unsupported image filter")
+	}
+
+	if len(pixels) < *w**h*comps {
+		return nil, 0, 0, 0, fmt.Errorf("image data too short")
+	}
+	return pixels[:*w**h*comps], *w, *h, comps, nil
+}
+
+func imageComponents(xrt *model.XRefTable, o pdftypes.Object) (int, error) {
+	o, _ = xrt.Dereference(o)
+	switch cs := o.(type) {
+	case pdftypes.Name:
+		switch cs.Value() {
+		case "DeviceGray", "CalGray":
+			return 1, nil
+		case "DeviceRGB", "CalRGB":
+			return 3, nil
+		case "DeviceCMYK":
+			return 4, nil
+		}
+	case pdftypes.Array:
+		if len(cs) == 2 {
+			if n, ok := cs[0].(pdftypes.Name); ok && n.Value() == "ICCBased" {
+				if icc, _, err := xrt.DereferenceStreamDict(cs[1]); err == nil && icc != nil {
+					if c := icc.IntEntry("N"); c != nil && (*c == 1 || *c == 3 || *c == 4) {
+						return *c, nil
+					}
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("unsupported color space")
+}
+
+// decodeJPEGPixels decodifica un JPEG a muestras de 8 bits con comps componentes.
+func decodeJPEGPixels(data []byte, comps int) ([]byte, error) {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JPEG: %w", err)
+	}
+	b := img.Bounds()
+	out := make([]byte, 0, b.Dx()*b.Dy()*comps)
+
+	switch comps {
+	case 1:
+		g, ok := img.(*image.Gray)
+		if !ok {
+			return nil, fmt.Errorf("unexpected JPEG color model")
+		}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			out = append(out, g.Pix[(y-b.Min.Y)*g.Stride:(y-b.Min.Y)*g.Stride+b.Dx()]...)
+		}
+	case 3:
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				r, g, bl, _ := img.At(x, y).RGBA()
+				out = append(out, byte(r>>8), byte(g>>8), byte(bl>>8))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("CMYK JPEG images are not supported")
+	}
+	return out, nil
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
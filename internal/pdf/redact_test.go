@@ -0,0 +1,277 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// extractTestText devuelve el texto de una página tal como lo ve el extractor interno.
+func extractTestText(t *testing.T, path string, pageNr int) string {
+	t.Helper()
+	ctx, err := newTestProcessor().readContext(path)
+	if err != nil {
+		t.Fatalf("readContext failed: %v", err)
+	}
+	pc, err := loadPageContent(ctx.XRefTable, pageNr, newFontCache(ctx.XRefTable))
+	if err != nil {
+		t.Fatalf("loadPageContent failed: %v", err)
+	}
+	text, _ := pageText(pc.glyphs)
+	return text
+}
+
+func TestRedactPattern(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestPDF(t, dir, "in.pdf", []string{"Name: John Smith\nEmail: john@example.com", "Nothing here"})
+	out := filepath.Join(dir, "out.pdf")
+
+	result, err := newTestProcessor().Redact(context.Background(), in, out, types.RedactOptions{
+		Patterns: []types.RedactPattern{
+			{Pattern: "john smith", CaseInsensitive: true},
+			{Pattern: `[a-z]+@[a-z.]+`, Regex: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+
+	if len(result.Matches) != 2 {
+		t.Fatalf("matches = %+v, want 2", result.Matches)
+	}
+	if result.Matches[0].Text != "John Smith" || len(result.Matches[0].Rects) != 1 {
+		t.Errorf("unexpected first match: %+v", result.Matches[0])
+	}
+	r := result.Matches[0].Rects[0]
+	if r.LLX <= 72 || r.URY < 720 || r.LLY > 720 {
+		t.Errorf("unexpected match rect: %+v", r)
+	}
+	if len(result.PagesAffected) != 1 || result.PagesAffected[0] != 1 {
+		t.Errorf("pages affected = %v, want [1]", result.PagesAffected)
+	}
+
+	text := extractTestText(t, out, 1)
+	if strings.Contains(text, "John") || strings.Contains(text, "example") {
+		t.Errorf("redacted text still present: %q", text)
+	}
+	if !strings.Contains(text, "Name:") || !strings.Contains(text, "Email:") {
+		t.Errorf("unredacted text missing: %q", text)
+	}
+
+	// El texto restante conserva su posición.
+	ctx, err := newTestProcessor().readContext(out)
+	if err != nil {
+		t.Fatalf("readContext failed: %v", err)
+	}
+	pc, err := loadPageContent(ctx.XRefTable, 1, newFontCache(ctx.XRefTable))
+	if err != nil {
+		t.Fatalf("loadPageContent failed: %v", err)
+	}
+	if len(pc.glyphs) == 0 || pc.glyphs[0].x0 != 72 {
+		t.Errorf("first glyph moved: %+v", pc.glyphs[0])
+	}
+}
+
+func TestRedactArea(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestPDF(t, dir, "in.pdf", []string{"keep this line\nsecret line\nkeep too"})
+	out := filepath.Join(dir, "out.pdf")
+
+	// La segunda línea tiene la línea base en y=706.
+	result, err := newTestProcessor().Redact(context.Background(), in, out, types.RedactOptions{
+		Areas: []types.PageRect{{Page: 1, Rect: types.Rect{LLX: 60, LLY: 703, URX: 300, URY: 713}}},
+	})
+	if err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+	if result.GlyphsRemoved != len("secret line") {
+		t.Errorf("GlyphsRemoved = %d, want %d", result.GlyphsRemoved, len("secret line"))
+	}
+
+	text := extractTestText(t, out, 1)
+	if strings.Contains(text, "secret") {
+		t.Errorf("redacted text still present: %q", text)
+	}
+	if !strings.Contains(text, "keep this line") || !strings.Contains(text, "keep too") {
+		t.Errorf("unredacted text missing: %q", text)
+	}
+}
+
+func TestRedactDryRunAndMetadata(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTestPDF(t, dir, "base.pdf", []string{"Patient: Jane Roe"})
+	in := filepath.Join(dir, "in.pdf")
+	if err := api.AddPropertiesFile(base, in, map[string]string{"Subject": "Record of Jane Roe", "Project": "demo"}, nil); err != nil {
+		t.Fatalf("AddPropertiesFile failed: %v", err)
+	}
+
+	opts := types.RedactOptions{
+		Patterns: []types.RedactPattern{{Pattern: "Jane Roe"}},
+		DryRun:   true,
+	}
+	out := filepath.Join(dir, "out.pdf")
+
+	result, err := newTestProcessor().Redact(context.Background(), in, out, opts)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if !result.DryRun || len(result.Matches) != 1 || result.OutputPath != "" {
+		t.Errorf("unexpected dry run result: %+v", result)
+	}
+	if len(result.MetadataCleared) != 1 || result.MetadataCleared[0] != "Info/Subject" {
+		t.Errorf("MetadataCleared = %v, want [Info/Subject]", result.MetadataCleared)
+	}
+	if _, err := api.PageCountFile(out); err == nil {
+		t.Errorf("dry run must not write the output file")
+	}
+
+	opts.DryRun = false
+	if _, err := newTestProcessor().Redact(context.Background(), in, out, opts); err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+	ctx, err := newTestProcessor().readContext(out)
+	if err != nil {
+		t.Fatalf("readContext failed: %v", err)
+	}
+	info, err := ctx.XRefTable.DereferenceDict(*ctx.XRefTable.Info)
+	if err != nil {
+		t.Fatalf("failed to read Info dict: %v", err)
+	}
+	if _, ok := info["Subject"]; ok {
+		t.Errorf("Subject metadata should have been cleared: %v", info)
+	}
+	if _, ok := info["Project"]; !ok {
+		t.Errorf("unrelated metadata removed: %v", info)
+	}
+}
+
+func TestRedactValidation(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestPDF(t, dir, "in.pdf", []string{"text"})
+	p := newTestProcessor()
+
+	tests := []struct {
+		name string
+		opts types.RedactOptions
+	}{
+		{"nothing to redact", types.RedactOptions{}},
+		{"page out of range", types.RedactOptions{Areas: []types.PageRect{{Page: 2, Rect: types.Rect{URX: 10, URY: 10}}}}},
+		{"empty area", types.RedactOptions{Areas: []types.PageRect{{Page: 1}}}},
+		{"invalid regex", types.RedactOptions{Patterns: []types.RedactPattern{{Pattern: "(", Regex: true}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := p.Redact(context.Background(), in, filepath.Join(dir, "out.pdf"), tt.opts); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+// writeImageTestPDF genera una página con una imagen RGB blanca de 4x4 píxeles
+// dibujada en el cuadrado (100,100)-(200,200).
+func writeImageTestPDF(t *testing.T, dir string) string {
+	t.Helper()
+
+	pixels := strings.Repeat("\xff", 4*4*3)
+	content := "q 100 0 0 100 100 100 cm /Im1 Do Q\n"
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /XObject << /Im1 5 0 R >> >> /Contents 4 0 R >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content),
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width 4 /Height 4 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Length %d >>\nstream\n%s\nendstream", len(pixels), pixels),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+
+	path := filepath.Join(dir, "image.pdf")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write test PDF: %v", err)
+	}
+	return path
+}
+
+func TestRedactImage(t *testing.T) {
+	dir := t.TempDir()
+	in := writeImageTestPDF(t, dir)
+	p := newTestProcessor()
+
+	// Mitad izquierda: la imagen se edita.
+	out := filepath.Join(dir, "partial.pdf")
+	result, err := p.Redact(context.Background(), in, out, types.RedactOptions{
+		Areas: []types.PageRect{{Page: 1, Rect: types.Rect{LLX: 90, LLY: 90, URX: 150, URY: 210}}},
+	})
+	if err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+	if result.ImagesModified != 1 || result.ImagesRemoved != 0 {
+		t.Fatalf("unexpected image counts: %+v", result)
+	}
+
+	ctx, err := p.readContext(out)
+	if err != nil {
+		t.Fatalf("readContext failed: %v", err)
+	}
+	pc, err := loadPageContent(ctx.XRefTable, 1, newFontCache(ctx.XRefTable))
+	if err != nil {
+		t.Fatalf("loadPageContent failed: %v", err)
+	}
+	if len(pc.images) != 1 || pc.images[0].ref == nil {
+		t.Fatalf("expected one image on the redacted page, got %+v", pc.images)
+	}
+	if pc.images[0].name == "Im1" {
+		t.Errorf("original image must no longer be referenced by the page")
+	}
+	sd, _, err := ctx.XRefTable.DereferenceStreamDict(*pc.images[0].ref)
+	if err != nil || sd == nil {
+		t.Fatalf("failed to load redacted image: %v", err)
+	}
+	if err := sd.Decode(); err != nil {
+		t.Fatalf("failed to decode redacted image: %v", err)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			got := sd.Content[(y*4+x)*3]
+			want := byte(0xff)
+			if x < 2 {
+				want = 0
+			}
+			if got != want {
+				t.Errorf("pixel (%d,%d) = %#x, want %#x", x, y, got, want)
+			}
+		}
+	}
+
+	// Cubierta por completo: la imagen se elimina.
+	out = filepath.Join(dir, "full.pdf")
+	result, err = p.Redact(context.Background(), in, out, types.RedactOptions{
+		Areas: []types.PageRect{{Page: 1, Rect: types.Rect{LLX: 50, LLY: 50, URX: 250, URY: 250}}},
+	})
+	if err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+	if result.ImagesRemoved != 1 {
+		t.Errorf("ImagesRemoved = %d, want 1", result.ImagesRemoved)
+	}
+}
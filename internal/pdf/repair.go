@@ -0,0 +1,595 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// Acciones registradas en el informe de Repair.
+const (
+	RepairStreamLength   = "stream_length_fixed"
+	RepairCorrupt        = "corrupt_dropped"
+	RepairUnreachable    = "unreachable_dropped"
+	RepairSuperseded     = "superseded"
+	RepairObjectStream   = "object_stream_expanded"
+	RepairRootReplaced   = "root_replaced"
+	RepairPageTree       = "page_tree_fixed"
+	RepairMissingObjects = "missing_reference"
+)
+
+// recoveredObject es un objeto recuperado del archivo original.
+type recoveredObject struct {
+	num, gen int
+	obj      pdftypes.Object
+	stream   []byte // datos crudos del stream; nil si no es un stream
+	isStream bool
+}
+
+// repairer reconstruye un PDF a partir de los objetos encontrados al escanearlo.
+type repairer struct {
+	data     []byte
+	objects  map[int]*recoveredObject
+	trailer  pdftypes.Dict
+	root     int
+	info     int
+	inlined  map[int]bool // objetos /Length incorporados directamente al stream
+	missing  map[int]bool
+	result   *types.RepairResult
+	catalogs []int
+}
+
+// Repair reconstruye un PDF dañado: escanea los objetos sin usar la tabla xref,
+// corrige las longitudes de los streams, descarta los objetos corruptos o
+// inalcanzables y escribe un archivo nuevo con una xref reconstruida.
+func (p *Processor) Repair(ctx context.Context, inputPath, outputPath string) (_ *types.RepairResult, err error) {
+	p.logger.Debug("repairing PDF",
+		slog.String("input", inputPath),
+		slog.String("output", outputPath))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	raw := scanRawStructure(data)
+	switch {
+	case raw.encrypted:
+		return nil, fmt.Errorf("cannot repair encrypted PDF")
+	case raw.headerVersion == "":
+		return nil, fmt.Errorf("cannot repair: no PDF header found")
+	case len(raw.objects) == 0:
+		return nil, fmt.Errorf("cannot repair: no objects found")
+	}
+
+	r := &repairer{
+		data:    data,
+		objects: make(map[int]*recoveredObject),
+		inlined: make(map[int]bool),
+		missing: make(map[int]bool),
+		result: &types.RepairResult{
+			InputPath:    inputPath,
+			OutputPath:   outputPath,
+			ObjectsFound: len(raw.objects),
+			XRefProblems: xrefHealth(raw).Problems,
+			Changes:      []types.RepairChange{},
+		},
+	}
+
+	r.recover(raw)
+	if err := r.chooseRoot(); err != nil {
+		return nil, err
+	}
+	r.fixStreamLengths()
+	if err := r.fixPageTree(); err != nil {
+		return nil, err
+	}
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	reachable := r.reachable()
+	rebuilt := r.write(raw.headerVersion, reachable)
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+
+	pdfCtx, err := api.ReadContext(bytes.NewReader(rebuilt), conf)
+	if err != nil {
+		p.logger.Error("failed to read rebuilt PDF", err)
+		return nil, fmt.Errorf("repaired PDF could not be read: %w", err)
+	}
+	if err := api.ValidateContext(pdfCtx); err != nil {
+		p.logger.Error("rebuilt PDF is still invalid", err)
+		return nil, fmt.Errorf("repaired PDF is still invalid: %w", err)
+	}
+
+	if err := ensureOutputDir(outputPath); err != nil {
+		p.logger.Error("failed to create output directory", err)
+		return nil, err
+	}
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	if err := api.WriteContextFile(pdfCtx, outputPath); err != nil {
+		_ = os.Remove(outputPath)
+		p.logger.Error("failed to write repaired PDF", err)
+		return nil, fmt.Errorf("failed to write repaired PDF: %w", err)
+	}
+
+	result := r.result
+	result.PDFVersion = pdfCtx.XRefTable.VersionString()
+	result.PageCount = pdfCtx.PageCount
+	result.ObjectsWritten = len(reachable)
+	for _, c := range result.Changes {
+		switch c.Action {
+		case RepairStreamLength:
+			result.StreamLengthsFixed++
+		case RepairCorrupt:
+			result.CorruptDropped++
+		case RepairUnreachable:
+			result.UnreachableDropped++
+		}
+	}
+
+	p.logger.Debug("PDF repair complete",
+		slog.Int("objects_written", result.ObjectsWritten),
+		slog.Int("changes", len(result.Changes)))
+
+	return result, nil
+}
+
+// RepairToTemp repara inputPath en un directorio temporal conservando el nombre
+// del archivo. cleanup elimina el directorio temporal.
+func (p *Processor) RepairToTemp(ctx context.Context, inputPath string) (string, *types.RepairResult, func(), error) {
+	tmpDir, err := os.MkdirTemp(p.config.TempDir, "pdf-repair-")
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(tmpDir) }
+
+	outputPath := filepath.Join(tmpDir, filepath.Base(inputPath))
+	result, err := p.Repair(ctx, inputPath, outputPath)
+	if err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+	return outputPath, result, cleanup, nil
+}
+
+func (r *repairer) change(objNr int, action, detail string) {
+	r.result.Changes = append(r.result.Changes, types.RepairChange{Object: objNr, Action: action, Detail: detail})
+}
+
+// recover analiza cada objeto encontrado. Si un número de objeto aparece varias
+// veces (actualizaciones incrementales) prevalece la última definición.
+func (r *repairer) recover(raw rawStructure) {
+	for _, o := range raw.objects {
+		obj, err := r.parseRawObject(o)
+		if err != nil {
+			r.change(o.num, RepairCorrupt, err.Error())
+			continue
+		}
+
+		if d, ok := obj.obj.(pdftypes.Dict); ok && obj.isStream {
+			switch t := d.Type(); {
+			case t != nil && *t == "XRef":
+				// Las xref streams se sustituyen por la tabla reconstruida.
+				r.trailer = d
+				continue
+			case t != nil && *t == "ObjStm":
+				n, err := r.expandObjectStream(d, obj.stream)
+				if err != nil {
+					r.change(o.num, RepairCorrupt, fmt.Sprintf("object stream: %v", err))
+				} else {
+					r.change(o.num, RepairObjectStream, fmt.Sprintf("%d objects extracted", n))
+				}
+				continue
+			}
+		}
+		r.add(obj)
+	}
+
+	// El trailer clásico más reciente tiene prioridad sobre las xref streams anteriores.
+	if i := bytes.LastIndex(r.data, []byte("trailer")); i >= 0 {
+		s := string(r.data[i+len("trailer"):])
+		if obj, err := model.ParseObject(&s); err == nil {
+			if d, ok := obj.(pdftypes.Dict); ok && (r.trailer == nil || d["Root"] != nil) {
+				r.trailer = d
+			}
+		}
+	}
+}
+
+func (r *repairer) add(obj *recoveredObject) {
+	if prev, ok := r.objects[obj.num]; ok {
+		r.change(prev.num, RepairSuperseded, "replaced by a later definition")
+	}
+	r.objects[obj.num] = obj
+	if d, ok := obj.obj.(pdftypes.Dict); ok {
+		if t := d.Type(); t != nil && *t == "Catalog" {
+			r.catalogs = append(r.catalogs, obj.num)
+		}
+	}
+}
+
+// parseRawObject analiza el cuerpo de un objeto escaneado.
+func (r *repairer) parseRawObject(o rawObject) (*recoveredObject, error) {
+	end := o.end
+	if o.complete {
+		end -= len("endobj")
+	}
+	if o.streamKw >= 0 {
+		end = o.streamKw
+	}
+
+	s := string(r.data[o.bodyStart:end])
+	obj, err := model.ParseObject(&s)
+	if err != nil {
+		return nil, fmt.Errorf("unparsable object: %v", err)
+	}
+
+	ro := &recoveredObject{num: o.num, gen: o.gen, obj: obj}
+	if o.streamKw < 0 {
+		return ro, nil
+	}
+
+	if _, ok := obj.(pdftypes.Dict); !ok {
+		return nil, fmt.Errorf("stream without dictionary")
+	}
+	if !bytes.HasPrefix(r.data[o.streamEnd:], []byte("endstream")) {
+		return nil, fmt.Errorf("stream is truncated (no endstream)")
+	}
+	ro.isStream = true
+	ro.stream = r.data[o.streamStart:o.streamEnd]
+	return ro, nil
+}
+
+// expandObjectStream extrae los objetos comprimidos de un object stream.
+func (r *repairer) expandObjectStream(d pdftypes.Dict, raw []byte) (int, error) {
+	sd := pdftypes.NewStreamDict(d, 0, nil, nil, filterPipeline(d))
+	sd.Raw = trimStreamEOL(raw)
+	if l := d.IntEntry("Length"); l != nil && *l >= 0 && *l <= len(raw) {
+		sd.Raw = raw[:*l]
+	}
+	if err := sd.Decode(); err != nil {
+		return 0, err
+	}
+
+	n, first := d.IntEntry("N"), d.IntEntry("First")
+	if n == nil || first == nil || *first > len(sd.Content) {
+		return 0, fmt.Errorf("missing /N or /First")
+	}
+
+	header := strings.Fields(string(sd.Content[:*first]))
+	count := 0
+	for i := 0; i+1 < len(header) && i/2 < *n; i += 2 {
+		num, err1 := strconv.Atoi(header[i])
+		off, err2 := strconv.Atoi(header[i+1])
+		if err1 != nil || err2 != nil || *first+off > len(sd.Content) {
+			break
+		}
+		end := len(sd.Content)
+		if i+3 < len(header) {
+			if next, err := strconv.Atoi(header[i+3]); err == nil && *first+next <= end && next >= off {
+				end = *first + next
+			}
+		}
+		s := string(sd.Content[*first+off : end])
+		obj, err := model.ParseObject(&s)
+		if err != nil {
+			r.change(num, RepairCorrupt, fmt.Sprintf("unparsable object in object stream: %v", err))
+			continue
+		}
+		r.add(&recoveredObject{num: num, obj: obj})
+		count++
+	}
+	return count, nil
+}
+
+func filterPipeline(d pdftypes.Dict) []pdftypes.PDFFilter {
+	var names []string
+	switch f := d["Filter"].(type) {
+	case pdftypes.Name:
+		names = []string{string(f)}
+	case pdftypes.Array:
+		for _, o := range f {
+			if n, ok := o.(pdftypes.Name); ok {
+				names = append(names, string(n))
+			}
+		}
+	}
+
+	var parms []pdftypes.Dict
+	switch dp := d["DecodeParms"].(type) {
+	case pdftypes.Dict:
+		parms = []pdftypes.Dict{dp}
+	case pdftypes.Array:
+		for _, o := range dp {
+			pd, _ := o.(pdftypes.Dict)
+			parms = append(parms, pd)
+		}
+	}
+
+	pipeline := make([]pdftypes.PDFFilter, len(names))
+	for i, name := range names {
+		pipeline[i].Name = name
+		if i < len(parms) {
+			pipeline[i].DecodeParms = parms[i]
+		}
+	}
+	return pipeline
+}
+
+// trimStreamEOL quita el fin de línea que precede a endstream.
+func trimStreamEOL(b []byte) []byte {
+	switch {
+	case bytes.HasSuffix(b, []byte("\r\n")):
+		return b[:len(b)-2]
+	case bytes.HasSuffix(b, []byte("\n")), bytes.HasSuffix(b, []byte("\r")):
+		return b[:len(b)-1]
+	}
+	return b
+}
+
+// chooseRoot elige el catálogo del trailer o, si no es utilizable, el último
+// catálogo encontrado en el archivo.
+func (r *repairer) chooseRoot() error {
+	if r.trailer != nil {
+		if ref := r.trailer.IndirectRefEntry("Root"); ref != nil {
+			if ro, ok := r.objects[ref.ObjectNumber.Value()]; ok {
+				if d, ok := ro.obj.(pdftypes.Dict); ok && d["Pages"] != nil {
+					r.root = ro.num
+				}
+			}
+		}
+		if ref := r.trailer.IndirectRefEntry("Info"); ref != nil {
+			if ro, ok := r.objects[ref.ObjectNumber.Value()]; ok {
+				if _, ok := ro.obj.(pdftypes.Dict); ok {
+					r.info = ro.num
+				}
+			}
+		}
+	}
+	if r.root != 0 {
+		return nil
+	}
+
+	for i := len(r.catalogs) - 1; i >= 0; i-- {
+		ro := r.objects[r.catalogs[i]]
+		if ro == nil {
+			continue
+		}
+		if d, ok := ro.obj.(pdftypes.Dict); ok && d["Pages"] != nil {
+			r.root = ro.num
+			r.change(ro.num, RepairRootReplaced, "trailer /Root missing or unusable; using the last document catalog")
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot repair: no usable document catalog found")
+}
+
+// fixStreamLengths sustituye /Length por la longitud real de los datos cuando
+// la declarada no coincide con la posición de endstream.
+func (r *repairer) fixStreamLengths() {
+	for _, ro := range r.objects {
+		if !ro.isStream {
+			continue
+		}
+		d := ro.obj.(pdftypes.Dict)
+
+		declared := -1
+		switch l := d["Length"].(type) {
+		case pdftypes.Integer:
+			declared = l.Value()
+		case pdftypes.IndirectRef:
+			if lo, ok := r.objects[l.ObjectNumber.Value()]; ok {
+				if i, ok := lo.obj.(pdftypes.Integer); ok {
+					declared = i.Value()
+				}
+				r.inlined[l.ObjectNumber.Value()] = true
+			}
+		}
+
+		// Una longitud declarada es correcta si tras ella solo hay espacio antes de endstream.
+		if declared >= 0 && declared <= len(ro.stream) && len(bytes.TrimSpace(ro.stream[declared:])) == 0 {
+			ro.stream = ro.stream[:declared]
+		} else {
+			actual := trimStreamEOL(ro.stream)
+			detail := fmt.Sprintf("length %d corrected to %d", declared, len(actual))
+			if declared < 0 {
+				detail = fmt.Sprintf("missing or unresolvable length set to %d", len(actual))
+			}
+			r.change(ro.num, RepairStreamLength, detail)
+			ro.stream = actual
+		}
+		d["Length"] = pdftypes.Integer(len(ro.stream))
+	}
+}
+
+// fixPageTree elimina de /Kids las referencias a páginas perdidas y corrige /Count.
+func (r *repairer) fixPageTree() error {
+	catalog := r.objects[r.root].obj.(pdftypes.Dict)
+	ref := catalog.IndirectRefEntry("Pages")
+	if ref == nil {
+		return fmt.Errorf("cannot repair: document catalog has no page tree")
+	}
+	pages := r.fixPageNode(ref.ObjectNumber.Value(), map[int]bool{})
+	if pages == 0 {
+		return fmt.Errorf("cannot repair: no pages could be recovered")
+	}
+	return nil
+}
+
+func (r *repairer) fixPageNode(objNr int, seen map[int]bool) int {
+	if seen[objNr] {
+		return 0
+	}
+	seen[objNr] = true
+
+	ro, ok := r.objects[objNr]
+	if !ok {
+		return 0
+	}
+	d, ok := ro.obj.(pdftypes.Dict)
+	if !ok {
+		return 0
+	}
+	if t := d.Type(); t == nil || *t != "Pages" {
+		if _, hasKids := d["Kids"]; !hasKids {
+			return 1
+		}
+	}
+
+	kids, _ := d["Kids"].(pdftypes.Array)
+	var kept pdftypes.Array
+	count := 0
+	for _, k := range kids {
+		kr, ok := k.(pdftypes.IndirectRef)
+		if !ok {
+			continue
+		}
+		n := r.fixPageNode(kr.ObjectNumber.Value(), seen)
+		if n == 0 {
+			continue
+		}
+		kept = append(kept, kr)
+		count += n
+	}
+
+	if removed := len(kids) - len(kept); removed > 0 {
+		r.change(objNr, RepairPageTree, fmt.Sprintf("%d missing or invalid kids removed", removed))
+		d["Kids"] = kept
+	}
+	if c := d.IntEntry("Count"); c == nil || *c != count {
+		if c != nil && len(kids) == len(kept) {
+			r.change(objNr, RepairPageTree, fmt.Sprintf("count %d corrected to %d", *c, count))
+		}
+		d["Count"] = pdftypes.Integer(count)
+	}
+	return count
+}
+
+// reachable devuelve los objetos alcanzables desde /Root e /Info y registra
+// como descartados los demás.
+func (r *repairer) reachable() map[int]bool {
+	seen := make(map[int]bool)
+	r.walk(pdftypes.IndirectRef{ObjectNumber: pdftypes.Integer(r.root)}, seen)
+	if r.info != 0 {
+		r.walk(pdftypes.IndirectRef{ObjectNumber: pdftypes.Integer(r.info)}, seen)
+	}
+
+	nums := make([]int, 0, len(r.objects))
+	for n := range r.objects {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	for _, n := range nums {
+		if !seen[n] && !r.inlined[n] {
+			r.change(n, RepairUnreachable, "not referenced from the document catalog")
+		}
+	}
+
+	missing := make([]int, 0, len(r.missing))
+	for n := range r.missing {
+		missing = append(missing, n)
+	}
+	sort.Ints(missing)
+	for _, n := range missing {
+		r.change(n, RepairMissingObjects, "referenced object not found; treated as null")
+	}
+	return seen
+}
+
+func (r *repairer) walk(o pdftypes.Object, seen map[int]bool) {
+	switch v := o.(type) {
+	case pdftypes.IndirectRef:
+		n := v.ObjectNumber.Value()
+		if seen[n] {
+			return
+		}
+		ro, ok := r.objects[n]
+		if !ok {
+			r.missing[n] = true
+			return
+		}
+		seen[n] = true
+		r.walk(ro.obj, seen)
+	case pdftypes.Dict:
+		for _, e := range v {
+			r.walk(e, seen)
+		}
+	case pdftypes.Array:
+		for _, e := range v {
+			r.walk(e, seen)
+		}
+	}
+}
+
+// write serializa los objetos alcanzables con una tabla xref nueva.
+func (r *repairer) write(version string, reachable map[int]bool) []byte {
+	nums := make([]int, 0, len(reachable))
+	for n := range reachable {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", version)
+
+	offsets := make(map[int]int, len(nums))
+	gens := make(map[int]int, len(nums))
+	for _, n := range nums {
+		ro := r.objects[n]
+		offsets[n] = buf.Len()
+		gens[n] = ro.gen
+
+		body := "null"
+		if ro.obj != nil {
+			body = ro.obj.PDFString()
+		}
+		fmt.Fprintf(&buf, "%d %d obj\n%s\n", n, ro.gen, body)
+		if ro.isStream {
+			buf.WriteString("stream\n")
+			buf.Write(ro.stream)
+			buf.WriteString("\nendstream\n")
+		}
+		buf.WriteString("endobj\n")
+	}
+
+	size := 1
+	if len(nums) > 0 {
+		size = nums[len(nums)-1] + 1
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for n := 1; n < size; n++ {
+		if off, ok := offsets[n]; ok {
+			fmt.Fprintf(&buf, "%010d %05d n \n", off, gens[n])
+		} else {
+			buf.WriteString("0000000000 65535 f \n")
+		}
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d %d R", size, r.root, gens[r.root])
+	if r.info != 0 {
+		fmt.Fprintf(&buf, " /Info %d %d R", r.info, gens[r.info])
+	}
+	fmt.Fprintf(&buf, " >>\nstartxref\n%d\n%%%%EOF\n", xref)
+	return buf.Bytes()
+}
@@ -0,0 +1,146 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// damagePDF aplica edit al contenido de src y lo guarda como name.
+func damagePDF(t *testing.T, src, name string, edit func([]byte) []byte) string {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(filepath.Dir(src), name)
+	if err := os.WriteFile(path, edit(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRepairRebuildsXRefAndLengths(t *testing.T) {
+	dir := t.TempDir()
+	src := writeTestPDF(t, dir, "src.pdf", []string{"first page", "second page"})
+
+	// Truncado justo antes de la tabla xref y con una longitud de stream incorrecta.
+	in := damagePDF(t, src, "damaged.pdf", func(b []byte) []byte {
+		b = b[:bytes.Index(b, []byte("xref\n"))]
+		return regexp.MustCompile(`/Length \d+`).ReplaceAll(b, []byte("/Length 5"))
+	})
+
+	p := newTestProcessor()
+	out := filepath.Join(dir, "out", "repaired.pdf")
+	result, err := p.Repair(context.Background(), in, out)
+	if err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+
+	if result.PageCount != 2 {
+		t.Errorf("PageCount = %d, want 2", result.PageCount)
+	}
+	if result.StreamLengthsFixed != 2 {
+		t.Errorf("StreamLengthsFixed = %d, want 2", result.StreamLengthsFixed)
+	}
+	if len(result.XRefProblems) == 0 {
+		t.Error("expected xref problems to be reported")
+	}
+	if err := p.ValidateFile(out); err != nil {
+		t.Fatalf("repaired file is invalid: %v", err)
+	}
+	if got := extractTestText(t, out, 2); !strings.Contains(got, "second page") {
+		t.Errorf("page 2 text = %q", got)
+	}
+}
+
+func TestRepairDropsCorruptAndUnreachable(t *testing.T) {
+	dir := t.TempDir()
+	src := writeTestPDF(t, dir, "src.pdf", []string{"one", "two"})
+
+	in := damagePDF(t, src, "damaged.pdf", func(b []byte) []byte {
+		i := bytes.Index(b, []byte("xref\n"))
+		extra := "20 0 obj\n<< /Orphan true >>\nendobj\n" +
+			"21 0 obj\n<< /Broken [1 2 >>\nendobj\n"
+		b = append(b[:i:i], append([]byte(extra), b[i:]...)...)
+		// La página 2 (objeto 6) desaparece: su referencia queda colgando.
+		return regexp.MustCompile(`(?s)6 0 obj\n.*?endobj\n`).ReplaceAll(b, nil)
+	})
+
+	out := filepath.Join(dir, "repaired.pdf")
+	result, err := newTestProcessor().Repair(context.Background(), in, out)
+	if err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+
+	actions := map[string][]int{}
+	for _, c := range result.Changes {
+		actions[c.Action] = append(actions[c.Action], c.Object)
+	}
+
+	if got := actions[RepairCorrupt]; len(got) != 1 || got[0] != 21 {
+		t.Errorf("corrupt objects = %v, want [21]", got)
+	}
+	if !containsInt(actions[RepairUnreachable], 20) {
+		t.Errorf("unreachable objects = %v, want to include 20", actions[RepairUnreachable])
+	}
+	if !containsInt(actions[RepairPageTree], 2) {
+		t.Errorf("page tree changes = %v, want object 2", actions[RepairPageTree])
+	}
+	if result.PageCount != 1 {
+		t.Errorf("PageCount = %d, want 1", result.PageCount)
+	}
+}
+
+func TestRepairRefusesWithoutCatalog(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "junk.pdf")
+	if err := os.WriteFile(in, []byte("%PDF-1.4\n1 0 obj\n<< /A 1 >>\nendobj\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestProcessor().Repair(context.Background(), in, filepath.Join(dir, "out.pdf")); err == nil {
+		t.Fatal("expected error for file without catalog")
+	}
+}
+
+func containsInt(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRepairExpandsObjectStreams(t *testing.T) {
+	dir := t.TempDir()
+	src := writeTestPDF(t, dir, "src.pdf", []string{"alpha", "beta"})
+	p := newTestProcessor()
+
+	// La salida de pdfcpu usa xref stream y object streams.
+	first := filepath.Join(dir, "first.pdf")
+	if _, err := p.Repair(context.Background(), src, first); err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+
+	result, err := p.Repair(context.Background(), first, filepath.Join(dir, "second.pdf"))
+	if err != nil {
+		t.Fatalf("Repair of object stream file failed: %v", err)
+	}
+	expanded := false
+	for _, c := range result.Changes {
+		if c.Action == RepairObjectStream {
+			expanded = true
+		}
+	}
+	if !expanded {
+		t.Errorf("expected object streams to be expanded, changes: %+v", result.Changes)
+	}
+	if result.PageCount != 2 {
+		t.Errorf("PageCount = %d, want 2", result.PageCount)
+	}
+}
@@ -0,0 +1,71 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// Rotate gira en el sentido de las agujas del reloj las páginas seleccionadas
+// (todas si pageSelection está vacío). degrees debe ser múltiplo de 90; los
+// valores negativos giran en sentido contrario.
+func (p *Processor) Rotate(ctx context.Context, inputPath, outputPath string, degrees int, pageSelection string) (_ *types.RotateResult, err error) {
+	p.logger.Debug("rotating PDF pages",
+		slog.String("input", inputPath),
+		slog.String("output", outputPath),
+		slog.Int("degrees", degrees),
+		slog.String("pages", pageSelection))
+
+	if degrees == 0 || degrees%90 != 0 {
+		return nil, fmt.Errorf("invalid rotation %d: must be a non-zero multiple of 90", degrees)
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(inputPath); err != nil {
+		return nil, err
+	}
+
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+
+	var pages []int
+	if pageSelection == "" {
+		for i := 1; i <= pdfCtx.PageCount; i++ {
+			pages = append(pages, i)
+		}
+	} else {
+		pages, err = parsePageSelection(pageSelection, pdfCtx.PageCount, documentPageLabels(pdfCtx.XRefTable))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ensureOutputDir(outputPath); err != nil {
+		p.logger.Error("failed to create output directory", err)
+		return nil, err
+	}
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	if err := api.RotateFile(inputPath, outputPath, degrees, intsToPageSelectionSlice(pages), p.newConfiguration()); err != nil {
+		p.logger.Error("failed to rotate pages", err)
+		return nil, fmt.Errorf("failed to rotate pages: %w", err)
+	}
+
+	return &types.RotateResult{
+		OutputPath:   outputPath,
+		TotalPages:   pdfCtx.PageCount,
+		Degrees:      degrees,
+		RotatedPages: pages,
+	}, nil
+}
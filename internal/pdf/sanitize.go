@@ -0,0 +1,273 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// metadataKeys son las entradas con datos de la aplicación o del autor que
+// StripMetadata elimina de cualquier objeto.
+var metadataKeys = []string{"Metadata", "PieceInfo"}
+
+// stripMetadata vacía el diccionario Info y elimina el XMP (Metadata) y los
+// datos privados de aplicaciones (PieceInfo) del catálogo, las páginas y el
+// resto de objetos. Al escribir, pdfcpu crea un Info nuevo que solo contiene
+// Producer y las fechas de la copia. Devuelve lo eliminado ("Info/Author",
+// "XMP", "XMP (object 12)").
+func stripMetadata(xrt *model.XRefTable) []string {
+	removed := []string{}
+	if xrt.Info != nil {
+		if info, err := xrt.DereferenceDict(*xrt.Info); err == nil && info != nil {
+			for _, k := range sortedKeys(info) {
+				removed = append(removed, "Info/"+k)
+			}
+		}
+		xrt.Info = nil
+	}
+
+	root := 0
+	if xrt.Root != nil {
+		root = xrt.Root.ObjectNumber.Value()
+	}
+	nrs := make([]int, 0, len(xrt.Table))
+	for nr := range xrt.Table {
+		nrs = append(nrs, nr)
+	}
+	sort.Ints(nrs)
+
+	for _, nr := range nrs {
+		entry := xrt.Table[nr]
+		if entry == nil || entry.Free || entry.Object == nil {
+			continue
+		}
+		var d pdftypes.Dict
+		switch o := entry.Object.(type) {
+		case pdftypes.Dict:
+			d = o
+		case pdftypes.StreamDict:
+			d = o.Dict
+		default:
+			continue
+		}
+		for _, k := range metadataKeys {
+			if _, ok := d[k]; !ok {
+				continue
+			}
+			name := k
+			if k == "Metadata" {
+				name = "XMP"
+			}
+			if nr != root {
+				name += " (object " + strconv.Itoa(nr) + ")"
+			}
+			removed = append(removed, name)
+			d.Delete(k)
+		}
+	}
+	return removed
+}
+
+// flattenForms dibuja la apariencia de cada widget visible en el contenido de
+// su página, como un Form XObject colocado en el Rect del widget, y elimina
+// los widgets y el AcroForm: los valores quedan como contenido estático. Los
+// widgets sin apariencia se eliminan sin dibujar y se informan en warnings.
+// Devuelve el número de widgets dibujados.
+func flattenForms(xrt *model.XRefTable) (int, []string, error) {
+	flattened := 0
+	var warnings []string
+	for i := 1; i <= xrt.PageCount; i++ {
+		pd, _, inh, err := xrt.PageDict(i, false)
+		if err != nil || pd == nil {
+			continue
+		}
+		annots, err := xrt.DereferenceArray(pd["Annots"])
+		if err != nil || len(annots) == 0 {
+			continue
+		}
+
+		var draw bytes.Buffer
+		var xobjects pdftypes.Dict
+		kept := pdftypes.Array{}
+		for _, o := range annots {
+			a, err := xrt.DereferenceDict(o)
+			if err != nil || a == nil || !isSubtype(a, "Widget") {
+				kept = append(kept, o)
+				continue
+			}
+			if f := a.IntEntry("F"); f != nil && *f&(annotFlagHidden|annotFlagNoView) != 0 {
+				continue
+			}
+			ref, place, ok := widgetAppearance(xrt, a)
+			if !ok {
+				name, _ := xrt.DereferenceText(a["T"])
+				warnings = append(warnings, fmt.Sprintf("page %d: form field %q has no appearance and was removed without drawing its value", i, name))
+				continue
+			}
+			if xobjects == nil {
+				if xobjects, err = pageXObjects(xrt, pd, inh); err != nil {
+					return 0, nil, fmt.Errorf("failed to flatten form fields on page %d: %w", i, err)
+				}
+			}
+			name := ""
+			for n := 1; name == "" || xobjects[name] != nil; n++ {
+				name = "Fl" + strconv.Itoa(n)
+			}
+			xobjects[name] = ref
+			fmt.Fprintf(&draw, "q %s %s %s %s %s %s cm /%s Do Q\n",
+				formatNumber(place[0]), formatNumber(place[1]), formatNumber(place[2]),
+				formatNumber(place[3]), formatNumber(place[4]), formatNumber(place[5]), name)
+			flattened++
+		}
+		if len(kept) == len(annots) {
+			continue
+		}
+		if len(kept) == 0 {
+			pd.Delete("Annots")
+		} else {
+			pd["Annots"] = kept
+		}
+		if draw.Len() == 0 {
+			continue
+		}
+		if err := appendPageContent(xrt, pd, draw.Bytes()); err != nil {
+			return 0, nil, fmt.Errorf("failed to flatten form fields on page %d: %w", i, err)
+		}
+	}
+
+	if root, err := xrt.Catalog(); err == nil && root != nil {
+		root.Delete("AcroForm")
+	}
+	return flattened, warnings, nil
+}
+
+// widgetAppearance devuelve la apariencia normal del widget (la del estado AS
+// si hay varias) y la matriz que lleva su BBox, transformada por su Matrix,
+// al Rect del widget (PDF 32000-1, 12.5.5).
+func widgetAppearance(xrt *model.XRefTable, a pdftypes.Dict) (pdftypes.IndirectRef, matrix, bool) {
+	ap, err := xrt.DereferenceDict(a["AP"])
+	if err != nil || ap == nil {
+		return pdftypes.IndirectRef{}, matrix{}, false
+	}
+	n := ap["N"]
+	if states, err := xrt.DereferenceDict(n); err == nil && states != nil {
+		state, _ := xrt.DereferenceName(a["AS"], model.V10, nil)
+		n = states[state.Value()]
+	}
+	ref, ok := n.(pdftypes.IndirectRef)
+	if !ok {
+		return pdftypes.IndirectRef{}, matrix{}, false
+	}
+	sd, _, err := xrt.DereferenceStreamDict(ref)
+	if err != nil || sd == nil {
+		return pdftypes.IndirectRef{}, matrix{}, false
+	}
+
+	bbox, ok := rectEntry(xrt, sd.Dict, "BBox")
+	if !ok {
+		return pdftypes.IndirectRef{}, matrix{}, false
+	}
+	r, ok := rectEntry(xrt, a, "Rect")
+	if !ok {
+		return pdftypes.IndirectRef{}, matrix{}, false
+	}
+	fm := identityMatrix
+	if arr, err := xrt.DereferenceArray(sd.Dict["Matrix"]); err == nil && len(arr) == 6 {
+		for k := range fm {
+			fm[k], _ = xrt.DereferenceNumber(arr[k])
+		}
+	}
+	box := transformRect(bbox, fm)
+	if box.width() == 0 || box.height() == 0 {
+		return pdftypes.IndirectRef{}, matrix{}, false
+	}
+
+	sx, sy := r.width()/box.width(), r.height()/box.height()
+	sd.Dict["Type"] = pdftypes.Name("XObject")
+	sd.Dict["Subtype"] = pdftypes.Name("Form")
+	return ref, matrix{sx, 0, 0, sy, r.llx - box.llx*sx, r.lly - box.lly*sy}, true
+}
+
+// rectEntry lee d[key] como rectángulo normalizado.
+func rectEntry(xrt *model.XRefTable, d pdftypes.Dict, key string) (rect, bool) {
+	arr, err := xrt.DereferenceArray(d[key])
+	if err != nil || len(arr) != 4 {
+		return rect{}, false
+	}
+	var v [4]float64
+	for i := range v {
+		if v[i], err = xrt.DereferenceNumber(arr[i]); err != nil {
+			return rect{}, false
+		}
+	}
+	return rect{v[0], v[1], v[2], v[3]}.normalized(), true
+}
+
+// pageXObjects devuelve el diccionario XObject de los recursos de la página
+// para añadirle entradas. Si los recursos son heredados se copian antes a la
+// página para no cambiar los de sus hermanas.
+func pageXObjects(xrt *model.XRefTable, pd pdftypes.Dict, inh *model.InheritedPageAttrs) (pdftypes.Dict, error) {
+	resources, err := xrt.DereferenceDict(pd["Resources"])
+	if err != nil {
+		return nil, err
+	}
+	if resources == nil {
+		resources = pdftypes.NewDict()
+		if inh != nil && inh.Resources != nil {
+			resources = inh.Resources.Clone().(pdftypes.Dict)
+		}
+		pd["Resources"] = resources
+	}
+	xobjects, err := xrt.DereferenceDict(resources["XObject"])
+	if err != nil {
+		return nil, err
+	}
+	if xobjects == nil {
+		xobjects = pdftypes.NewDict()
+		resources["XObject"] = xobjects
+	}
+	return xobjects, nil
+}
+
+// appendPageContent añade content al final de la página, con el contenido
+// original entre q/Q para que su estado gráfico no le afecte.
+func appendPageContent(xrt *model.XRefTable, pd pdftypes.Dict, content []byte) error {
+	contents := pdftypes.Array{}
+	if o, err := xrt.Dereference(pd["Contents"]); err == nil {
+		switch c := o.(type) {
+		case pdftypes.StreamDict:
+			contents = append(contents, pd["Contents"])
+		case pdftypes.Array:
+			contents = append(contents, c...)
+		}
+	}
+
+	newStream := func(data []byte) (pdftypes.Object, error) {
+		sd, err := xrt.NewStreamDictForBuf(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := sd.Encode(); err != nil {
+			return nil, err
+		}
+		ref, err := xrt.IndRefForNewObject(*sd)
+		if err != nil {
+			return nil, err
+		}
+		return *ref, nil
+	}
+	opening, err := newStream([]byte("q\n"))
+	if err != nil {
+		return err
+	}
+	closing, err := newStream(append([]byte("\nQ\n"), content...))
+	if err != nil {
+		return err
+	}
+	pd["Contents"] = append(append(pdftypes.Array{opening}, contents...), closing)
+	return nil
+}
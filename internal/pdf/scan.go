@@ -0,0 +1,188 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+)
+
+// rawObject es un objeto localizado escaneando los bytes del archivo,
+// sin depender de la tabla xref.
+type rawObject struct {
+	num, gen    int
+	offset      int // inicio de "N G obj"
+	bodyStart   int // inicio del cuerpo, tras "obj"
+	streamKw    int // posición de la palabra "stream", -1 si no es un stream
+	end         int // fin de "endobj" (o del archivo si falta)
+	streamStart int // inicio de los datos del stream, -1 si no es un stream
+	streamEnd   int // fin de los datos del stream (antes de "endstream")
+	complete    bool
+}
+
+// rawStructure describe la estructura física de un PDF obtenida por escaneo.
+type rawStructure struct {
+	headerVersion  string
+	startXRef      int64 // -1 si no hay startxref
+	startXRefValid bool
+	xrefStream     bool
+	trailerFound   bool
+	trailerRoot    bool
+	encrypted      bool
+	eofMarkers     int
+	truncated      bool
+	objects        []rawObject
+	catalogs       []int // índices en objects con /Type /Catalog
+}
+
+var (
+	rawHeaderRe     = regexp.MustCompile(`%PDF-(\d\.\d)`)
+	rawObjHeaderRe  = regexp.MustCompile(`(\d+)[ \t\r\n\f\x00]+(\d+)[ \t\r\n\f\x00]+obj\b`)
+	rawStartXRefRe  = regexp.MustCompile(`startxref[ \t\r\n]+(\d+)`)
+	rawCatalogRe    = regexp.MustCompile(`/Type\s*/Catalog\b`)
+	rawXRefTypeRe   = regexp.MustCompile(`/Type\s*/XRef\b`)
+	rawLinearizedRe = regexp.MustCompile(`/Linearized\s`)
+	rawRootRe       = regexp.MustCompile(`/Root\s+\d+\s+\d+\s+R`)
+	rawStreamKwRe   = regexp.MustCompile(`\bstream(\r\n|\n|\r)`)
+)
+
+// scanRawStructure recorre el archivo buscando cabecera, objetos, trailer y startxref.
+func scanRawStructure(data []byte) rawStructure {
+	rs := rawStructure{startXRef: -1}
+
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if m := rawHeaderRe.FindSubmatch(head); m != nil {
+		rs.headerVersion = string(m[1])
+	}
+
+	rs.objects = scanRawObjects(data)
+	for i, o := range rs.objects {
+		body := data[o.offset:o.end]
+		if o.streamStart >= 0 {
+			body = data[o.offset:o.streamStart]
+		}
+		if rawCatalogRe.Match(body) {
+			rs.catalogs = append(rs.catalogs, i)
+		}
+		if rawXRefTypeRe.Match(body) {
+			rs.xrefStream = true
+			if rawRootRe.Match(body) {
+				rs.trailerRoot = true
+			}
+			if bytes.Contains(body, []byte("/Encrypt")) {
+				rs.encrypted = true
+			}
+		}
+	}
+
+	if i := bytes.LastIndex(data, []byte("trailer")); i >= 0 {
+		rs.trailerFound = true
+		tail := data[i:]
+		if rawRootRe.Match(tail) {
+			rs.trailerRoot = true
+		}
+		if bytes.Contains(tail, []byte("/Encrypt")) {
+			rs.encrypted = true
+		}
+	}
+	if rs.xrefStream {
+		rs.trailerFound = true
+	}
+
+	if ms := rawStartXRefRe.FindAllSubmatch(data, -1); len(ms) > 0 {
+		last := ms[len(ms)-1]
+		if off, err := strconv.ParseInt(string(last[1]), 10, 64); err == nil {
+			rs.startXRef = off
+			rs.startXRefValid = validXRefOffset(data, off)
+		}
+	}
+
+	rs.eofMarkers = bytes.Count(data, []byte("%%EOF"))
+	trimmed := bytes.TrimRight(data, " \t\r\n\x00")
+	rs.truncated = !bytes.HasSuffix(trimmed, []byte("%%EOF"))
+
+	return rs
+}
+
+// validXRefOffset indica si off apunta a una sección xref clásica o a un objeto (xref stream).
+func validXRefOffset(data []byte, off int64) bool {
+	if off < 0 || off >= int64(len(data)) {
+		return false
+	}
+	rest := bytes.TrimLeft(data[off:], " \t\r\n")
+	if bytes.HasPrefix(rest, []byte("xref")) {
+		return true
+	}
+	if len(rest) > 32 {
+		rest = rest[:32]
+	}
+	loc := rawObjHeaderRe.FindIndex(rest)
+	return loc != nil && loc[0] == 0
+}
+
+// scanRawObjects localiza objetos "N G obj ... endobj" saltando el contenido de
+// los streams. Un objeto sin endobj se marca como incompleto y termina donde
+// empieza el siguiente.
+func scanRawObjects(data []byte) []rawObject {
+	var objs []rawObject
+	pos := 0
+	for pos < len(data) {
+		loc := rawObjHeaderRe.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		start := pos + loc[0]
+		// El número debe empezar al principio de un token.
+		if start > 0 && !isPDFSpace(data[start-1]) && !isPDFDelimiter(data[start-1]) {
+			pos = pos + loc[1]
+			continue
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		gen, _ := strconv.Atoi(string(data[pos+loc[4] : pos+loc[5]]))
+		bodyStart := pos + loc[1]
+
+		o := rawObject{num: num, gen: gen, offset: start, bodyStart: bodyStart, streamKw: -1, streamStart: -1}
+
+		next := len(data)
+		if nl := rawObjHeaderRe.FindIndex(data[bodyStart:]); nl != nil {
+			next = bodyStart + nl[0]
+		}
+		endobj := bytes.Index(data[bodyStart:], []byte("endobj"))
+		if endobj >= 0 {
+			endobj += bodyStart
+		}
+
+		sk := rawStreamKwRe.FindIndex(data[bodyStart:])
+		if sk != nil && bodyStart+sk[0] < next && (endobj < 0 || bodyStart+sk[0] < endobj) {
+			o.streamKw = bodyStart + sk[0]
+			o.streamStart = bodyStart + sk[1]
+			es := bytes.Index(data[o.streamStart:], []byte("endstream"))
+			if es >= 0 {
+				o.streamEnd = o.streamStart + es
+				endobj = bytes.Index(data[o.streamEnd:], []byte("endobj"))
+				if endobj >= 0 {
+					endobj += o.streamEnd
+				}
+				if nl := rawObjHeaderRe.FindIndex(data[o.streamEnd:]); nl != nil {
+					next = o.streamEnd + nl[0]
+				} else {
+					next = len(data)
+				}
+			} else {
+				o.streamEnd = next
+			}
+		}
+
+		if endobj >= 0 && endobj < next {
+			o.end = endobj + len("endobj")
+			o.complete = true
+		} else {
+			o.end = next
+		}
+		objs = append(objs, o)
+		pos = o.end
+	}
+	return objs
+}
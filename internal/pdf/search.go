@@ -0,0 +1,210 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+const (
+	defaultSearchContext    = 40
+	defaultSearchMaxResults = 100
+)
+
+// Search busca query en el texto de cada página y devuelve página, fragmento
+// de contexto y rectángulos de cada coincidencia.
+func (p *Processor) Search(ctx context.Context, inputPath, query string, opts types.SearchOptions) (*types.SearchResult, error) {
+	p.logger.Debug("searching PDF",
+		slog.String("input", inputPath),
+		slog.String("mode", string(opts.Mode)))
+
+	re, opts, err := compileSearch(query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(inputPath); err != nil {
+		return nil, err
+	}
+
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+
+	result := &types.SearchResult{
+		InputPath:  inputPath,
+		Query:      query,
+		Mode:       opts.Mode,
+		TotalPages: pdfCtx.PageCount,
+		Hits:       []types.SearchHit{},
+	}
+
+	fonts := newFontCache(pdfCtx.XRefTable)
+	for pageNr := 1; pageNr <= pdfCtx.PageCount; pageNr++ {
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+		pc, err := loadPageContent(pdfCtx.XRefTable, pageNr, fonts)
+		if err != nil {
+			p.logger.Warn("failed to extract page text",
+				slog.Int("page", pageNr),
+				slog.Any("error", err))
+			result.Warnings = append(result.Warnings, fmt.Sprintf("page %d: text could not be extracted: %v", pageNr, err))
+			continue
+		}
+
+		text, spans := pageText(pc.glyphs)
+		for _, m := range findTextMatches(pc.glyphs, text, spans, re) {
+			result.TotalHits++
+			if len(result.Hits) >= opts.MaxResults {
+				result.Truncated = true
+				continue
+			}
+			result.Hits = append(result.Hits, types.SearchHit{
+				Page:    pageNr,
+				Text:    m.text,
+				Snippet: snippet(text, m.start, m.end, opts.ContextChars),
+				Rects:   rectsToTypes(m.rects),
+			})
+		}
+	}
+
+	p.logger.Debug("PDF search complete",
+		slog.Int("hits", result.TotalHits))
+
+	return result, nil
+}
+
+// SearchFiles ejecuta Search sobre varios PDFs. Los errores de un archivo no
+// detienen la búsqueda: se informan en Errors, salvo la cancelación de ctx, que
+// la interrumpe. Solo se incluyen en Files los archivos con coincidencias.
+func (p *Processor) SearchFiles(ctx context.Context, inputPaths []string, query string, opts types.SearchOptions) (*types.MultiSearchResult, error) {
+	if len(inputPaths) == 0 {
+		return nil, fmt.Errorf("no input files provided")
+	}
+	_, opts, err := compileSearch(query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.MultiSearchResult{
+		Query: query,
+		Mode:  opts.Mode,
+		Files: []types.SearchResult{},
+	}
+
+	for _, path := range inputPaths {
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+		result.FilesSearched++
+		r, err := p.Search(ctx, path, query, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, checkCancelled(ctx)
+			}
+			result.Errors = append(result.Errors, types.FileError{Path: path, Error: err.Error()})
+			continue
+		}
+		if r.TotalHits == 0 {
+			continue
+		}
+		result.FilesWithHits++
+		result.TotalHits += r.TotalHits
+		result.Files = append(result.Files, *r)
+	}
+
+	return result, nil
+}
+
+// compileSearch valida la consulta, aplica valores por defecto y devuelve la expresión regular.
+func compileSearch(query string, opts types.SearchOptions) (*regexp.Regexp, types.SearchOptions, error) {
+	if query == "" {
+		return nil, opts, fmt.Errorf("empty search query")
+	}
+	if opts.Mode == "" {
+		opts.Mode = types.SearchLiteral
+	}
+	if opts.ContextChars <= 0 {
+		opts.ContextChars = defaultSearchContext
+	}
+	if opts.MaxResults <= 0 {
+		opts.MaxResults = defaultSearchMaxResults
+	}
+
+	var expr string
+	switch opts.Mode {
+	case types.SearchLiteral:
+		expr = regexp.QuoteMeta(query)
+	case types.SearchCaseInsensitive:
+		expr = "(?i)" + regexp.QuoteMeta(query)
+	case types.SearchRegex:
+		expr = query
+	default:
+		return nil, opts, fmt.Errorf("invalid search mode: %s", opts.Mode)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, opts, fmt.Errorf("invalid search pattern: %w", err)
+	}
+	return re, opts, nil
+}
+
+// snippet devuelve el texto de la coincidencia con hasta n caracteres de
+// contexto a cada lado, en una sola línea.
+func snippet(text string, start, end, n int) string {
+	before := []rune(text[:start])
+	after := []rune(text[end:])
+
+	prefix, suffix := "", ""
+	if len(before) > n {
+		before = before[len(before)-n:]
+		prefix = "…"
+	}
+	if len(after) > n {
+		after = after[:n]
+		suffix = "…"
+	}
+
+	s := prefix + string(before) + text[start:end] + string(after) + suffix
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// FindPDFFiles devuelve los archivos .pdf de dir ordenados por ruta.
+// Si recursive es true también recorre los subdirectorios.
+func FindPDFFiles(dir string, recursive bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.EqualFold(filepath.Ext(path), ".pdf") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PDF files in %s: %w", dir, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
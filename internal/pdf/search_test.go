@@ -0,0 +1,117 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestSearchModes(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestPDF(t, dir, "contract.pdf", []string{
+		"1. Scope\n7.1 Payment terms apply.",
+		"7.2 Termination: either party may terminate.\nSee clause 7.2 above.",
+	})
+	p := newTestProcessor()
+
+	tests := []struct {
+		name  string
+		query string
+		mode  types.SearchMode
+		hits  int
+		pages []int
+	}{
+		{"literal", "7.2", types.SearchLiteral, 2, []int{2, 2}},
+		{"literal is case sensitive", "termination", types.SearchLiteral, 0, nil},
+		{"case insensitive", "termination", types.SearchCaseInsensitive, 1, []int{2}},
+		{"regex", `7\.\d`, types.SearchRegex, 3, []int{1, 2, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := p.Search(context.Background(), in, tt.query, types.SearchOptions{Mode: tt.mode})
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if result.TotalHits != tt.hits {
+				t.Fatalf("TotalHits = %d, want %d", result.TotalHits, tt.hits)
+			}
+			for i, h := range result.Hits {
+				if h.Page != tt.pages[i] {
+					t.Errorf("hit %d on page %d, want %d", i, h.Page, tt.pages[i])
+				}
+				if len(h.Rects) == 0 {
+					t.Errorf("hit %d has no rects", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchSnippetAndLimit(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestPDF(t, dir, "doc.pdf", []string{"alpha beta gamma delta epsilon\nbeta again"})
+
+	result, err := newTestProcessor().Search(context.Background(), in, "gamma", types.SearchOptions{ContextChars: 6})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(result.Hits) != 1 || result.Hits[0].Snippet != "… beta gamma delta…" {
+		t.Errorf("unexpected snippet: %+v", result.Hits)
+	}
+
+	result, err = newTestProcessor().Search(context.Background(), in, "beta", types.SearchOptions{MaxResults: 1})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if result.TotalHits != 2 || len(result.Hits) != 1 || !result.Truncated {
+		t.Errorf("expected truncated result with 2 total hits, got %+v", result)
+	}
+
+	if _, err := newTestProcessor().Search(context.Background(), in, "(", types.SearchOptions{Mode: types.SearchRegex}); err == nil {
+		t.Errorf("expected error for invalid regex")
+	}
+	if _, err := newTestProcessor().Search(context.Background(), in, "x", types.SearchOptions{Mode: "fuzzy"}); err == nil {
+		t.Errorf("expected error for invalid mode")
+	}
+}
+
+func TestSearchFiles(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestPDF(t, dir, "a.pdf", []string{"invoice 42"})
+	writeTestPDF(t, dir, "b.PDF", []string{"nothing"})
+	writeTestPDF(t, sub, "c.pdf", []string{"invoice 43"})
+	if err := os.WriteFile(filepath.Join(dir, "broken.pdf"), []byte("not a pdf"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := FindPDFFiles(dir, false)
+	if err != nil {
+		t.Fatalf("FindPDFFiles failed: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("FindPDFFiles non-recursive = %v, want 3 files", files)
+	}
+	files, err = FindPDFFiles(dir, true)
+	if err != nil || len(files) != 4 {
+		t.Fatalf("FindPDFFiles recursive = %v, %v", files, err)
+	}
+
+	result, err := newTestProcessor().SearchFiles(context.Background(), files, "invoice", types.SearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchFiles failed: %v", err)
+	}
+	if result.FilesSearched != 4 || result.FilesWithHits != 2 || result.TotalHits != 2 {
+		t.Errorf("unexpected totals: %+v", result)
+	}
+	if len(result.Errors) != 1 || filepath.Base(result.Errors[0].Path) != "broken.pdf" {
+		t.Errorf("expected one error for broken.pdf, got %+v", result.Errors)
+	}
+}
@@ -0,0 +1,1123 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// Categorías de hallazgos de SecurityScan.
+const (
+	SecurityJavaScript         = "javascript"
+	SecurityLaunchAction       = "launch_action"
+	SecurityURIAction          = "uri_action"
+	SecuritySubmitFormAction   = "submit_form_action"
+	SecurityImportDataAction   = "import_data_action"
+	SecurityRemoteGoTo         = "remote_goto"
+	SecurityOpenAction         = "open_action"
+	SecurityEmbeddedFile       = "embedded_file"
+	SecurityEmbeddedExecutable = "embedded_executable"
+	SecurityRichMedia          = "rich_media"
+	SecurityXFA                = "xfa"
+	SecurityObjectStreams      = "object_streams"
+	SecurityFilterChain        = "filter_chain"
+)
+
+// securityWeights es la contribución de cada categoría a la puntuación de
+// riesgo; la gravedad de un hallazgo se deriva de ella.
+var securityWeights = map[string]int{
+	SecurityLaunchAction:       40,
+	SecurityEmbeddedExecutable: 40,
+	SecurityJavaScript:         30,
+	SecurityRichMedia:          20,
+	SecuritySubmitFormAction:   15,
+	SecurityImportDataAction:   15,
+	SecurityXFA:                15,
+	SecurityRemoteGoTo:         10,
+	SecurityFilterChain:        10,
+	SecurityObjectStreams:      10,
+	SecurityOpenAction:         5,
+	SecurityEmbeddedFile:       5,
+	SecurityURIAction:          3,
+}
+
+// Anotaciones que reproducen contenido multimedia o interactivo.
+var securityMediaAnnots = map[string]bool{"RichMedia": true, "Screen": true, "Movie": true, "Sound": true, "3D": true}
+
+// Extensiones y tipos MIME de archivos ejecutables o de script.
+var (
+	executableExtensions = map[string]bool{
+		".exe": true, ".dll": true, ".scr": true, ".com": true, ".bat": true, ".cmd": true, ".pif": true,
+		".msi": true, ".cpl": true, ".vbs": true, ".vbe": true, ".js": true, ".jse": true, ".wsf": true,
+		".wsh": true, ".ps1": true, ".hta": true, ".jar": true, ".lnk": true, ".reg": true, ".sh": true,
+		".app": true, ".elf": true,
+	}
+	executableMagics = [][]byte{
+		[]byte("MZ"),
+		[]byte("\x7fELF"),
+		[]byte("#!"),
+		{0xfe, 0xed, 0xfa, 0xce}, {0xfe, 0xed, 0xfa, 0xcf},
+		{0xce, 0xfa, 0xed, 0xfe}, {0xcf, 0xfa, 0xed, 0xfe},
+		{0xca, 0xfe, 0xba, 0xbe},
+	}
+)
+
+// Filtros estándar de streams (ISO 32000-1, 7.4).
+var standardFilters = map[string]bool{
+	filter.ASCIIHex: true, filter.ASCII85: true, filter.LZW: true, filter.Flate: true, filter.RunLength: true,
+	filter.CCITTFax: true, filter.JBIG2: true, filter.DCT: true, filter.JPX: true, "Crypt": true,
+}
+
+// Filtros que Sanitize puede decodificar para recodificar el stream con Flate.
+var reencodableFilters = map[string]bool{
+	filter.ASCIIHex: true, filter.ASCII85: true, filter.LZW: true, filter.Flate: true, filter.RunLength: true,
+}
+
+const (
+	// securitySnippetLen limita los extractos de código y URIs de los hallazgos.
+	securitySnippetLen = 80
+	// Umbrales de streams de objetos excesivos: muchos streams con pocos objetos
+	// cada uno es una técnica de ofuscación habitual.
+	minSuspiciousObjectStreams = 10
+	minObjectsPerObjectStream  = 4
+	maxObjectStreams           = 500
+)
+
+// SecurityScan inspecciona un PDF en busca de contenido activo o sospechoso:
+// JavaScript de documento, página o campo, acciones Launch/URI/SubmitForm/
+// ImportData/GoToR, OpenAction, archivos incrustados (señalando los
+// ejecutables), RichMedia y otras anotaciones multimedia, formularios XFA,
+// streams de objetos excesivos y cadenas de filtros sospechosas. Devuelve los
+// hallazgos y una puntuación de riesgo de 0 a 100. Si pdfcpu no puede leer el
+// archivo, los hallazgos salen de un escaneo de palabras clave sobre los bytes.
+func (p *Processor) SecurityScan(ctx context.Context, inputPath string) (*types.SecurityReport, error) {
+	p.logger.Debug("scanning PDF for active content", slog.String("input", inputPath))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read input file", err)
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	report := &types.SecurityReport{
+		InputPath: inputPath,
+		FileSize:  int64(len(data)),
+	}
+
+	pdfCtx, err := p.readScanContext(data)
+	if err != nil {
+		p.logger.Warn("falling back to raw security scan", slog.Any("error", err))
+		report.Findings, report.ObjectStreams = rawSecurityScan(data)
+		report.Warnings = append(report.Warnings,
+			fmt.Sprintf("the PDF could not be parsed (%v); findings come from a raw keyword scan", err))
+	} else {
+		s := newSecurityScanner(pdfCtx, types.SanitizeOptions{}, false)
+		if err := s.run(ctx); err != nil {
+			return nil, err
+		}
+		report.TotalPages = pdfCtx.PageCount
+		report.Encrypted = pdfCtx.Encrypt != nil
+		report.ObjectStreams = s.objectStreams
+		report.Findings = s.found
+		report.Warnings = s.warnings
+	}
+
+	report.Counts = securityCounts(report.Findings)
+	report.RiskScore = securityRiskScore(report.Findings)
+	report.RiskLevel = securityRiskLevel(report.RiskScore)
+
+	p.logger.Debug("security scan complete",
+		slog.Int("findings", len(report.Findings)),
+		slog.Int("risk_score", report.RiskScore))
+
+	return report, nil
+}
+
+// Sanitize escribe una copia del PDF sin contenido activo: elimina JavaScript
+// (incluido el árbol de nombres del documento), OpenAction, acciones Launch,
+// SubmitForm, ImportData y GoToR/GoToE, anotaciones multimedia, formularios
+// XFA y archivos incrustados, y recodifica con Flate los streams con cadenas de
+// filtros sospechosas. Los objetos no referenciados se descartan al escribir.
+// opts permite conservar los enlaces URI y los adjuntos que no son ejecutables,
+// y además quitar los metadatos (StripMetadata) y aplanar los formularios
+// (FlattenForms). La copia se vuelve a escanear; lo que siga presente se
+// devuelve en Remaining.
+func (p *Processor) Sanitize(ctx context.Context, inputPath, outputPath string, opts types.SanitizeOptions) (_ *types.SanitizeResult, err error) {
+	p.logger.Debug("sanitizing PDF",
+		slog.String("input", inputPath),
+		slog.String("output", outputPath),
+		slog.Bool("keep_links", opts.KeepLinks),
+		slog.Bool("keep_attachments", opts.KeepAttachments),
+		slog.Bool("strip_metadata", opts.StripMetadata),
+		slog.Bool("flatten_forms", opts.FlattenForms))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read input file", err)
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	pdfCtx, err := p.readScanContext(data)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+	if pdfCtx.Encrypt != nil {
+		return nil, fmt.Errorf("encrypted PDFs cannot be sanitized; decrypt the file first")
+	}
+
+	s := newSecurityScanner(pdfCtx, opts, false)
+	if err := s.run(ctx); err != nil {
+		return nil, err
+	}
+	before := securityRiskScore(s.found)
+
+	s = newSecurityScanner(pdfCtx, opts, true)
+	if err := s.run(ctx); err != nil {
+		return nil, err
+	}
+
+	// Se aplana después de limpiar, así que las acciones de los campos ya no
+	// están y las apariencias se dibujan sin ellas.
+	var metadataRemoved []string
+	fieldsFlattened := 0
+	if opts.StripMetadata {
+		metadataRemoved = stripMetadata(pdfCtx.XRefTable)
+	}
+	if opts.FlattenForms {
+		var warnings []string
+		if fieldsFlattened, warnings, err = flattenForms(pdfCtx.XRefTable); err != nil {
+			p.logger.Error("failed to flatten form fields", err)
+			return nil, err
+		}
+		s.warnings = append(s.warnings, warnings...)
+	}
+
+	if err := ensureOutputDir(outputPath); err != nil {
+		p.logger.Error("failed to create output directory", err)
+		return nil, err
+	}
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	if err := api.WriteContextFile(pdfCtx, outputPath); err != nil {
+		p.logger.Error("failed to write sanitized PDF", err)
+		return nil, fmt.Errorf("failed to write sanitized PDF: %w", err)
+	}
+
+	after, err := p.SecurityScan(ctx, outputPath)
+	if err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+
+	result := &types.SanitizeResult{
+		OutputPath:      outputPath,
+		TotalPages:      pdfCtx.PageCount,
+		OriginalSize:    int64(len(data)),
+		OutputSize:      after.FileSize,
+		RiskScoreBefore: before,
+		RiskScoreAfter:  after.RiskScore,
+		RiskLevelAfter:  after.RiskLevel,
+		Removed:         s.fixed,
+		Remaining:       after.Findings,
+		MetadataRemoved: metadataRemoved,
+		FieldsFlattened: fieldsFlattened,
+		Warnings:        append(s.warnings, after.Warnings...),
+	}
+
+	p.logger.Debug("sanitization complete",
+		slog.Int("removed", len(result.Removed)),
+		slog.Int("remaining", len(result.Remaining)),
+		slog.Int("risk_score_after", result.RiskScoreAfter))
+
+	return result, nil
+}
+
+// readScanContext lee el PDF sin validarlo: los archivos hostiles suelen
+// incumplir la especificación y la validación los rechazaría sin inspeccionarlos.
+func (p *Processor) readScanContext(data []byte) (pdfCtx *model.Context, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pdfCtx, err = nil, fmt.Errorf("parser failure: %v", r)
+		}
+	}()
+
+	pdfCtx, err = api.ReadContext(bytes.NewReader(data), p.newConfiguration())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if err := pdfCtx.EnsurePageCount(); err != nil {
+		return nil, fmt.Errorf("failed to read page tree: %w", err)
+	}
+	return pdfCtx, nil
+}
+
+// securitySeverity deriva la gravedad de un hallazgo del peso de su categoría.
+func securitySeverity(category string) string {
+	switch w := securityWeights[category]; {
+	case w >= 40:
+		return types.RiskCritical
+	case w >= 20:
+		return types.RiskHigh
+	case w >= 10:
+		return types.RiskMedium
+	default:
+		return types.RiskLow
+	}
+}
+
+// securityRiskScore suma el peso de cada categoría presente; cada hallazgo
+// adicional de la misma categoría suma un cuarto del peso, hasta el doble.
+func securityRiskScore(findings []types.SecurityFinding) int {
+	score := 0
+	for category, n := range securityCounts(findings) {
+		w := securityWeights[category]
+		score += min(w+(n-1)*w/4, 2*w)
+	}
+	return min(score, 100)
+}
+
+func securityRiskLevel(score int) string {
+	switch {
+	case score == 0:
+		return types.RiskNone
+	case score < 15:
+		return types.RiskLow
+	case score < 30:
+		return types.RiskMedium
+	case score < 60:
+		return types.RiskHigh
+	default:
+		return types.RiskCritical
+	}
+}
+
+func securityCounts(findings []types.SecurityFinding) map[string]int {
+	counts := map[string]int{}
+	for _, f := range findings {
+		counts[f.Category]++
+	}
+	return counts
+}
+
+// securityScanner recorre el documento como pdfaChecker, desde las páginas y el
+// catálogo, y después los objetos no referenciados. Con fix activo elimina lo
+// que encuentra (salvo lo que opts conserva) en lugar de informarlo.
+type securityScanner struct {
+	xrt  *model.XRefTable
+	read *model.ReadContext
+	opts types.SanitizeOptions
+	fix  bool
+
+	page     int    // página que se recorre (0 fuera de las páginas)
+	obj      int    // objeto indirecto que se recorre
+	location string // document, page, field, annotation, outline, attachment o unreferenced
+
+	seen          map[int]bool
+	objectStreams int
+	found         []types.SecurityFinding
+	fixed         []types.SecurityFinding
+	warnings      []string
+}
+
+func newSecurityScanner(pdfCtx *model.Context, opts types.SanitizeOptions, fix bool) *securityScanner {
+	return &securityScanner{
+		xrt:   pdfCtx.XRefTable,
+		read:  pdfCtx.Read,
+		opts:  opts,
+		fix:   fix,
+		seen:  map[int]bool{},
+		found: []types.SecurityFinding{},
+		fixed: []types.SecurityFinding{},
+	}
+}
+
+// report anota un hallazgo. fix es nil si Sanitize no puede eliminarlo; en modo
+// fix se aplica (salvo que opts conserve la categoría) y devuelve true.
+func (s *securityScanner) report(category string, fix func(), detail, format string, args ...interface{}) bool {
+	f := types.SecurityFinding{
+		Category:    category,
+		Severity:    securitySeverity(category),
+		Description: fmt.Sprintf(format, args...),
+		Location:    s.location,
+		Page:        s.page,
+		Object:      s.obj,
+		Detail:      detail,
+		Removable:   fix != nil,
+	}
+	if s.fix && fix != nil && !s.keeps(category) {
+		fix()
+		s.fixed = append(s.fixed, f)
+		return true
+	}
+	s.found = append(s.found, f)
+	return false
+}
+
+func (s *securityScanner) keeps(category string) bool {
+	switch category {
+	case SecurityURIAction:
+		return s.opts.KeepLinks
+	case SecurityEmbeddedFile:
+		return s.opts.KeepAttachments
+	}
+	return false
+}
+
+// run recorre el documento página a página; solo falla si ctx se cancela.
+func (s *securityScanner) run(ctx context.Context) error {
+	xrt := s.xrt
+	for i := 1; i <= xrt.PageCount; i++ {
+		if err := checkCancelled(ctx); err != nil {
+			return err
+		}
+		pd, ref, _, err := xrt.PageDict(i, false)
+		if err != nil || pd == nil {
+			continue
+		}
+		s.page, s.obj, s.location = i, 0, "page"
+		if ref != nil {
+			s.obj = ref.ObjectNumber.Value()
+			s.seen[s.obj] = true
+		}
+		s.walkDict(pd)
+	}
+	s.page = 0
+
+	if root, err := xrt.Catalog(); err == nil && root != nil {
+		s.obj, s.location = xrt.Root.ObjectNumber.Value(), "document"
+		s.seen[s.obj] = true
+		if names, err := xrt.DereferenceDict(root["Names"]); err == nil && names != nil {
+			s.checkDocumentJavaScript(names)
+		}
+		s.walkDict(root)
+	}
+
+	s.location = "unreferenced"
+	s.checkUnreferenced()
+	s.location = "document"
+	s.obj = 0
+	s.checkObjectStreams()
+	return nil
+}
+
+// walk recorre o y todo lo que referencia, sin subir por Parent ni entrar en
+// otras páginas.
+func (s *securityScanner) walk(o pdftypes.Object) {
+	if ref, ok := o.(pdftypes.IndirectRef); ok {
+		n := ref.ObjectNumber.Value()
+		if s.seen[n] {
+			return
+		}
+		s.seen[n] = true
+		obj, err := s.xrt.Dereference(ref)
+		if err != nil || obj == nil {
+			return
+		}
+		prev := s.obj
+		s.obj = n
+		s.walk(obj)
+		s.obj = prev
+		return
+	}
+
+	switch v := o.(type) {
+	case pdftypes.Dict:
+		if t := v.Type(); t != nil && (*t == "Page" || *t == "Pages") {
+			return
+		}
+		prev := s.location
+		if _, ok := v["FT"]; ok || isSubtype(v, "Widget") {
+			s.location = "field"
+		} else if _, ok := v["Rect"]; ok && v.Subtype() != nil {
+			s.location = "annotation"
+		}
+		s.walkDict(v)
+		s.location = prev
+	case pdftypes.StreamDict:
+		s.checkStream(v)
+		s.walkDict(v.Dict)
+	case pdftypes.Array:
+		for _, e := range v {
+			s.walk(e)
+		}
+	}
+}
+
+// keyLocations cambia la ubicación de los hallazgos al entrar en estas claves.
+var keyLocations = map[string]string{
+	"AcroForm":      "field",
+	"Outlines":      "outline",
+	"EmbeddedFiles": "attachment",
+	"EF":            "attachment",
+}
+
+func (s *securityScanner) walkDict(d pdftypes.Dict) {
+	s.checkDict(d)
+	for _, k := range sortedKeys(d) {
+		if k == "Parent" {
+			continue
+		}
+		prev := s.location
+		if loc, ok := keyLocations[k]; ok {
+			s.location = loc
+		}
+		s.walk(d[k])
+		s.location = prev
+	}
+}
+
+func (s *securityScanner) checkDict(d pdftypes.Dict) {
+	for _, key := range []string{"OpenAction", "A"} {
+		if _, ok := d[key]; ok {
+			s.checkAction(d, key)
+		}
+	}
+	if aa, err := s.xrt.DereferenceDict(d["AA"]); err == nil && aa != nil {
+		for _, trigger := range sortedKeys(aa) {
+			s.checkAction(aa, trigger)
+		}
+	}
+
+	if _, ok := d["Annots"]; ok {
+		s.checkAnnotations(d)
+	}
+	if _, ok := d["EF"]; ok {
+		s.checkFileSpec(d)
+	}
+
+	// Diccionario AcroForm.
+	if _, ok := d["Fields"]; ok {
+		if _, ok := d["XFA"]; ok {
+			prev := s.location
+			s.location = "field"
+			s.report(SecurityXFA, s.drop(d, "XFA"), "", "XFA form (its scripts run in XFA-capable viewers)")
+			s.location = prev
+		}
+	}
+}
+
+// checkAction comprueba la acción de holder[key] y su cadena Next.
+func (s *securityScanner) checkAction(holder pdftypes.Dict, key string) {
+	a, err := s.xrt.DereferenceDict(holder[key])
+	if err != nil || a == nil {
+		return
+	}
+	prev := s.obj
+	defer func() { s.obj = prev }()
+	if ref, ok := holder[key].(pdftypes.IndirectRef); ok {
+		s.obj = ref.ObjectNumber.Value()
+	}
+
+	removed := false
+	if key == "OpenAction" {
+		action := ""
+		if st := a.NameEntry("S"); st != nil {
+			action = *st
+		}
+		removed = s.report(SecurityOpenAction, s.drop(holder, key), action,
+			"the document runs a %s action when it is opened", action)
+	}
+	if category, desc, detail := s.actionThreat(a); category != "" {
+		fix := s.drop(holder, key)
+		if removed {
+			fix = func() {}
+		}
+		removed = s.report(category, fix, detail, "%s (%s)", desc, key) || removed
+	}
+	if removed {
+		return
+	}
+
+	switch next := a["Next"].(type) {
+	case nil:
+	case pdftypes.Array:
+		kept := pdftypes.Array{}
+		for _, e := range next {
+			na, err := s.xrt.DereferenceDict(e)
+			if err == nil && na != nil {
+				if category, desc, detail := s.actionThreat(na); category != "" {
+					if s.report(category, func() { s.markSeen(e) }, detail, "%s (Next)", desc) {
+						continue
+					}
+				}
+			}
+			kept = append(kept, e)
+		}
+		if s.fix {
+			a["Next"] = kept
+		}
+	default:
+		s.checkAction(a, "Next")
+	}
+}
+
+// actionThreat clasifica una acción; category es "" si no es sospechosa.
+func (s *securityScanner) actionThreat(a pdftypes.Dict) (category, desc, detail string) {
+	st := a.NameEntry("S")
+	if st == nil {
+		return "", "", ""
+	}
+	switch *st {
+	case "JavaScript":
+		return SecurityJavaScript, "JavaScript action", s.scriptText(a["JS"])
+	case "Launch":
+		return SecurityLaunchAction, "Launch action starts an external application", s.fileSpecName(launchTarget(a))
+	case "URI":
+		uri, _ := s.xrt.DereferenceText(a["URI"])
+		return SecurityURIAction, "URI action opens an external link", shortText(uri)
+	case "SubmitForm":
+		return SecuritySubmitFormAction, "SubmitForm action sends form data", s.fileSpecName(a["F"])
+	case "ImportData":
+		return SecurityImportDataAction, "ImportData action loads form data from a file", s.fileSpecName(a["F"])
+	case "GoToR", "GoToE":
+		return SecurityRemoteGoTo, fmt.Sprintf("%s action opens another document", *st), s.fileSpecName(a["F"])
+	case "RichMediaExecute":
+		return SecurityRichMedia, "RichMediaExecute action runs rich media content", ""
+	case "Rendition":
+		if _, ok := a["JS"]; ok {
+			return SecurityJavaScript, "Rendition action with JavaScript", s.scriptText(a["JS"])
+		}
+	}
+	return "", "", ""
+}
+
+// launchTarget devuelve la aplicación de una acción Launch (F o Win/F).
+func launchTarget(a pdftypes.Dict) pdftypes.Object {
+	if f, ok := a["F"]; ok {
+		return f
+	}
+	if win, ok := a["Win"].(pdftypes.Dict); ok {
+		return win["F"]
+	}
+	return nil
+}
+
+// checkDocumentJavaScript informa cada script del árbol de nombres JavaScript.
+func (s *securityScanner) checkDocumentJavaScript(names pdftypes.Dict) {
+	tree, err := s.xrt.DereferenceDict(names["JavaScript"])
+	if err != nil || tree == nil {
+		return
+	}
+	prev := s.location
+	s.location = "document"
+	defer func() { s.location = prev }()
+
+	for _, v := range nameTreeValues(s.xrt, tree, map[int]bool{}) {
+		a, err := s.xrt.DereferenceDict(v)
+		if err != nil || a == nil {
+			continue
+		}
+		s.report(SecurityJavaScript, s.drop(names, "JavaScript"), s.scriptText(a["JS"]),
+			"document-level JavaScript (runs when the document is opened)")
+	}
+}
+
+// nameTreeValues devuelve los valores de las hojas de un árbol de nombres.
+func nameTreeValues(xrt *model.XRefTable, node pdftypes.Dict, visited map[int]bool) []pdftypes.Object {
+	entries := nameTreeEntries(xrt, node, visited)
+	values := make([]pdftypes.Object, len(entries))
+	for i, e := range entries {
+		values[i] = e.value
+	}
+	return values
+}
+
+// nameTreeEntry es una hoja de un árbol de nombres.
+type nameTreeEntry struct {
+	key   string
+	value pdftypes.Object
+}
+
+// nameTreeEntries devuelve las hojas (clave y valor) de un árbol de nombres.
+func nameTreeEntries(xrt *model.XRefTable, node pdftypes.Dict, visited map[int]bool) []nameTreeEntry {
+	var entries []nameTreeEntry
+	if names, err := xrt.DereferenceArray(node["Names"]); err == nil {
+		for i := 1; i < len(names); i += 2 {
+			key, _ := xrt.DereferenceText(names[i-1])
+			entries = append(entries, nameTreeEntry{key: key, value: names[i]})
+		}
+	}
+	kids, err := xrt.DereferenceArray(node["Kids"])
+	if err != nil {
+		return entries
+	}
+	for _, k := range kids {
+		if ref, ok := k.(pdftypes.IndirectRef); ok {
+			if visited[ref.ObjectNumber.Value()] {
+				continue
+			}
+			visited[ref.ObjectNumber.Value()] = true
+		}
+		if kid, err := xrt.DereferenceDict(k); err == nil && kid != nil {
+			entries = append(entries, nameTreeEntries(xrt, kid, visited)...)
+		}
+	}
+	return entries
+}
+
+// checkAnnotations informa las anotaciones multimedia de una página; en modo
+// fix las quita del array Annots.
+func (s *securityScanner) checkAnnotations(page pdftypes.Dict) {
+	annots, err := s.xrt.DereferenceArray(page["Annots"])
+	if err != nil || annots == nil {
+		return
+	}
+
+	kept := pdftypes.Array{}
+	removed := false
+	prevLoc := s.location
+	s.location = "annotation"
+	for _, e := range annots {
+		ad, err := s.xrt.DereferenceDict(e)
+		if err != nil || ad == nil {
+			kept = append(kept, e)
+			continue
+		}
+		subtype := ""
+		if st := ad.Subtype(); st != nil {
+			subtype = *st
+		}
+		if securityMediaAnnots[subtype] {
+			prev := s.obj
+			if ref, ok := e.(pdftypes.IndirectRef); ok {
+				s.obj = ref.ObjectNumber.Value()
+			}
+			drop := s.report(SecurityRichMedia, func() { s.markSeen(e) }, subtype,
+				"%s annotation embeds multimedia or interactive content", subtype)
+			s.obj = prev
+			if drop {
+				removed = true
+				continue
+			}
+		}
+		kept = append(kept, e)
+	}
+	s.location = prevLoc
+
+	if !removed {
+		return
+	}
+	if ref, ok := page["Annots"].(pdftypes.IndirectRef); ok {
+		if entry, found := s.xrt.FindTableEntryForIndRef(&ref); found {
+			entry.Object = kept
+			return
+		}
+	}
+	page["Annots"] = kept
+}
+
+// checkFileSpec informa los archivos incrustados de un file specification,
+// distinguiendo los ejecutables. En modo fix quita EF: el file specification
+// queda sin contenido y el stream deja de estar referenciado.
+func (s *securityScanner) checkFileSpec(fs pdftypes.Dict) {
+	ef, err := s.xrt.DereferenceDict(fs["EF"])
+	if err != nil || ef == nil {
+		return
+	}
+	name := s.fileSpecName(fs)
+
+	var sd *pdftypes.StreamDict
+	for _, key := range []string{"UF", "F"} {
+		if sd, _, err = s.xrt.DereferenceStreamDict(ef[key]); err == nil && sd != nil {
+			break
+		}
+	}
+	var head []byte
+	mime := ""
+	if sd != nil {
+		if st := sd.Subtype(); st != nil {
+			mime = *st
+		}
+		if head, err = streamHead(sd, 16); err != nil {
+			s.warnings = append(s.warnings, fmt.Sprintf("embedded file %q could not be decoded: %v", name, err))
+		}
+	}
+
+	prev := s.location
+	s.location = "attachment"
+	defer func() { s.location = prev }()
+	if isExecutable(name, mime, head) {
+		s.report(SecurityEmbeddedExecutable, s.drop(fs, "EF"), name, "embedded executable or script file")
+		return
+	}
+	s.report(SecurityEmbeddedFile, s.drop(fs, "EF"), name, "embedded file")
+}
+
+// isExecutable reconoce ejecutables y scripts por extensión, tipo MIME o firma.
+func isExecutable(name, mime string, head []byte) bool {
+	if executableExtensions[strings.ToLower(filepath.Ext(name))] {
+		return true
+	}
+	mime = strings.ToLower(mime)
+	if strings.Contains(mime, "msdownload") || strings.Contains(mime, "executable") || strings.Contains(mime, "x-sh") {
+		return true
+	}
+	for _, magic := range executableMagics {
+		if bytes.HasPrefix(head, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileSpecName devuelve el nombre de archivo de un file specification (cadena o
+// diccionario con UF, F o Win/F).
+func (s *securityScanner) fileSpecName(o pdftypes.Object) string {
+	if o == nil {
+		return ""
+	}
+	if name, err := s.xrt.DereferenceText(o); err == nil {
+		return shortText(name)
+	}
+	d, err := s.xrt.DereferenceDict(o)
+	if err != nil || d == nil {
+		return ""
+	}
+	for _, key := range []string{"UF", "F"} {
+		if name, err := s.xrt.DereferenceText(d[key]); err == nil && name != "" {
+			return shortText(name)
+		}
+	}
+	if win, ok := d["Win"].(pdftypes.Dict); ok {
+		return s.fileSpecName(win["F"])
+	}
+	return ""
+}
+
+// scriptText devuelve un extracto del código de un script (cadena o stream).
+func (s *securityScanner) scriptText(o pdftypes.Object) string {
+	if o == nil {
+		return ""
+	}
+	if sd, _, err := s.xrt.DereferenceStreamDict(o); err == nil && sd != nil {
+		code, err := streamHead(sd, 4*securitySnippetLen)
+		if err != nil {
+			return ""
+		}
+		return shortText(string(code))
+	}
+	code, err := s.xrt.DereferenceText(o)
+	if err != nil {
+		return ""
+	}
+	return shortText(code)
+}
+
+// shortText normaliza los espacios y recorta el texto a securitySnippetLen runas.
+func shortText(text string) string {
+	text = strings.Join(strings.Fields(strings.ToValidUTF8(text, "")), " ")
+	if utf8.RuneCountInString(text) > securitySnippetLen {
+		text = string([]rune(text)[:securitySnippetLen]) + "..."
+	}
+	return text
+}
+
+// streamHead decodifica al menos los primeros n bytes de un stream. DecodeLength
+// de pdfcpu falla con streams más cortos que n; en ese caso se decodifica entero.
+func streamHead(sd *pdftypes.StreamDict, n int) (head []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if err = sd.Decode(); err == nil {
+				head = sd.Content
+			}
+		}
+	}()
+	return sd.DecodeLength(int64(n))
+}
+
+// checkStream informa las cadenas de filtros sospechosas. En modo fix el stream
+// se decodifica y se recodifica con un único Flate si todos los filtros se
+// pueden decodificar.
+func (s *securityScanner) checkStream(sd pdftypes.StreamDict) {
+	filters := streamFilters(sd.Dict)
+	reason := suspiciousFilterChain(filters)
+	if reason == "" {
+		return
+	}
+
+	var fix func()
+	nr := s.obj
+	if nr > 0 && allReencodable(filters) {
+		if !s.fix {
+			fix = func() {}
+		} else if out, err := reencodeFlate(sd); err == nil {
+			fix = func() {
+				if entry, ok := s.xrt.Find(nr); ok {
+					entry.Object = *out
+				}
+			}
+		} else {
+			s.warnings = append(s.warnings, fmt.Sprintf("object %d could not be re-encoded: %v", nr, err))
+		}
+	}
+	s.report(SecurityFilterChain, fix, strings.Join(filters, " > "), "suspicious filter chain: %s", reason)
+}
+
+// suspiciousFilterChain devuelve por qué una cadena de filtros es sospechosa, o
+// "" si no lo es. Las cadenas largas, repetidas o con codificaciones ASCII
+// superfluas se usan para ocultar contenido a los escáneres.
+func suspiciousFilterChain(filters []string) string {
+	if len(filters) >= 3 {
+		return fmt.Sprintf("%d chained filters", len(filters))
+	}
+	seen := map[string]bool{}
+	for i, f := range filters {
+		switch {
+		case !standardFilters[f]:
+			return fmt.Sprintf("non-standard filter %s", f)
+		case seen[f]:
+			return fmt.Sprintf("%s applied more than once", f)
+		case len(filters) > 1 && (f == filter.ASCIIHex || f == filter.ASCII85):
+			return fmt.Sprintf("%s combined with other filters", f)
+		case i < len(filters)-1 && (f == filter.DCT || f == filter.JPX || f == filter.JBIG2 || f == filter.CCITTFax):
+			return fmt.Sprintf("image filter %s is not the last filter", f)
+		}
+		seen[f] = true
+	}
+	return ""
+}
+
+func allReencodable(filters []string) bool {
+	for _, f := range filters {
+		if !reencodableFilters[f] {
+			return false
+		}
+	}
+	return true
+}
+
+// reencodeFlate decodifica un stream y lo vuelve a codificar con Flate.
+func reencodeFlate(sd pdftypes.StreamDict) (*pdftypes.StreamDict, error) {
+	if err := sd.Decode(); err != nil {
+		return nil, err
+	}
+	out := pdftypes.StreamDict{
+		Dict:           sd.Dict.Clone().(pdftypes.Dict),
+		Content:        sd.Content,
+		FilterPipeline: []pdftypes.PDFFilter{{Name: filter.Flate}},
+	}
+	out.Delete("DecodeParms")
+	out.Delete("Length")
+	out.Update("Filter", pdftypes.Name(filter.Flate))
+	if err := out.Encode(); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// checkUnreferenced informa las acciones, ejecutables y cadenas de filtros de
+// objetos a los que no llega el recorrido. El escritor descarta los objetos no
+// referenciados, así que Sanitize los elimina sin tocarlos.
+func (s *securityScanner) checkUnreferenced() {
+	nrs := make([]int, 0, len(s.xrt.Table))
+	for nr := range s.xrt.Table {
+		nrs = append(nrs, nr)
+	}
+	sort.Ints(nrs)
+
+	for _, nr := range nrs {
+		entry := s.xrt.Table[nr]
+		if nr == 0 || s.seen[nr] || entry == nil || entry.Free || entry.Object == nil {
+			continue
+		}
+		s.obj = nr
+		switch o := entry.Object.(type) {
+		case pdftypes.Dict:
+			if category, desc, detail := s.actionThreat(o); category != "" {
+				s.report(category, func() {}, detail, "unreferenced %s", desc)
+			}
+		case pdftypes.StreamDict:
+			if t := o.Type(); t != nil && *t == "EmbeddedFile" {
+				mime := ""
+				if st := o.Subtype(); st != nil {
+					mime = *st
+				}
+				if head, err := streamHead(&o, 16); err == nil && isExecutable("", mime, head) {
+					s.report(SecurityEmbeddedExecutable, func() {}, "", "unreferenced embedded executable")
+				}
+			}
+			if reason := suspiciousFilterChain(streamFilters(o.Dict)); reason != "" {
+				s.report(SecurityFilterChain, func() {}, strings.Join(streamFilters(o.Dict), " > "),
+					"suspicious filter chain: %s", reason)
+			}
+		}
+	}
+	s.obj = 0
+}
+
+// checkObjectStreams informa un número excesivo de streams de objetos. Al
+// escribir la copia limpia pdfcpu genera los suyos, así que Sanitize lo corrige.
+func (s *securityScanner) checkObjectStreams() {
+	objects := 0
+	for nr := range s.read.ObjectStreams {
+		entry, ok := s.xrt.Find(nr)
+		if !ok || entry.Object == nil {
+			continue
+		}
+		s.objectStreams++
+		if osd, ok := entry.Object.(pdftypes.ObjectStreamDict); ok {
+			objects += osd.ObjCount
+		}
+	}
+	n := s.objectStreams
+	if n < minSuspiciousObjectStreams {
+		return
+	}
+	if n > maxObjectStreams || objects < n*minObjectsPerObjectStream {
+		s.report(SecurityObjectStreams, func() {}, strconv.Itoa(n),
+			"%d object streams holding %d objects (objects are spread thinly to hide content)", n, objects)
+	}
+}
+
+// rawSecurityKeywords asocia nombres PDF con categorías para el escaneo de
+// bytes que se usa cuando el archivo no se puede leer.
+var rawSecurityKeywords = map[string]string{
+	"JS":           SecurityJavaScript,
+	"JavaScript":   SecurityJavaScript,
+	"Launch":       SecurityLaunchAction,
+	"URI":          SecurityURIAction,
+	"SubmitForm":   SecuritySubmitFormAction,
+	"ImportData":   SecurityImportDataAction,
+	"GoToR":        SecurityRemoteGoTo,
+	"GoToE":        SecurityRemoteGoTo,
+	"OpenAction":   SecurityOpenAction,
+	"EmbeddedFile": SecurityEmbeddedFile,
+	"RichMedia":    SecurityRichMedia,
+	"XFA":          SecurityXFA,
+}
+
+var rawNameRe = regexp.MustCompile(`/[A-Za-z0-9#]+`)
+
+// rawSecurityScan cuenta los nombres sospechosos en los bytes del archivo,
+// decodificando los escapes #xx que se usan para ocultarlos. Devuelve un
+// hallazgo por categoría y el número de streams de objetos.
+func rawSecurityScan(data []byte) ([]types.SecurityFinding, int) {
+	counts := map[string]int{}
+	obfuscated := map[string]bool{}
+	objectStreams := 0
+	for _, m := range rawNameRe.FindAll(data, -1) {
+		name := string(m[1:])
+		escaped := strings.Contains(name, "#")
+		if escaped {
+			name = decodeNameEscapes(name)
+		}
+		if name == "ObjStm" {
+			objectStreams++
+			continue
+		}
+		category, ok := rawSecurityKeywords[name]
+		if !ok {
+			continue
+		}
+		counts[category]++
+		if escaped {
+			obfuscated[category] = true
+		}
+	}
+
+	categories := make([]string, 0, len(counts))
+	for c := range counts {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+
+	findings := []types.SecurityFinding{}
+	for _, c := range categories {
+		desc := fmt.Sprintf("%d occurrence(s) of %s keywords in the raw file", counts[c], c)
+		if obfuscated[c] {
+			desc += " (some hidden with #xx escapes)"
+		}
+		findings = append(findings, types.SecurityFinding{
+			Category:    c,
+			Severity:    securitySeverity(c),
+			Description: desc,
+			Location:    "document",
+		})
+	}
+	return findings, objectStreams
+}
+
+// decodeNameEscapes decodifica las secuencias #xx de un nombre PDF.
+func decodeNameEscapes(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '#' && i+2 < len(name) {
+			if v, err := strconv.ParseUint(name[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
+
+// drop devuelve la corrección que elimina holder[key]. Los objetos que colgaban
+// de la entrada se marcan como vistos para no informarlos después como no
+// referenciados.
+func (s *securityScanner) drop(holder pdftypes.Dict, key string) func() {
+	return func() {
+		s.markSeen(holder[key])
+		holder.Delete(key)
+	}
+}
+
+// markSeen marca como vistos o y los objetos que referencia, sin entrar en páginas.
+func (s *securityScanner) markSeen(o pdftypes.Object) {
+	switch v := o.(type) {
+	case pdftypes.IndirectRef:
+		n := v.ObjectNumber.Value()
+		if s.seen[n] {
+			return
+		}
+		s.seen[n] = true
+		if obj, err := s.xrt.Dereference(v); err == nil {
+			s.markSeen(obj)
+		}
+	case pdftypes.Dict:
+		if t := v.Type(); t != nil && (*t == "Page" || *t == "Pages") {
+			return
+		}
+		for k, e := range v {
+			if k != "Parent" && k != "P" {
+				s.markSeen(e)
+			}
+		}
+	case pdftypes.StreamDict:
+		s.markSeen(v.Dict)
+	case pdftypes.Array:
+		for _, e := range v {
+			s.markSeen(e)
+		}
+	}
+}
+
+func isSubtype(d pdftypes.Dict, subtype string) bool {
+	st := d.Subtype()
+	return st != nil && *st == subtype
+}
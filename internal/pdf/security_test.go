@@ -0,0 +1,327 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// writeThreatTestPDF genera una página con JavaScript de documento, de apertura
+// y de campo, enlaces URI y Launch, una anotación RichMedia, un formulario XFA,
+// un ejecutable y un texto incrustados, un contenido con filtros
+// ASCIIHex+Flate y una acción Launch no referenciada.
+func writeThreatTestPDF(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	var flated bytes.Buffer
+	zw := zlib.NewWriter(&flated)
+	zw.Write([]byte("BT /F1 12 Tf 72 720 Td (Quarterly report) Tj ET\n"))
+	zw.Close()
+	content := hex.EncodeToString(flated.Bytes()) + ">"
+
+	exe := "MZ\x90\x00fake executable"
+	notes := "meeting notes"
+	xfa := "<xdp:xdp xmlns:xdp=\"http://ns.adobe.com/xdp/\"/>"
+
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R /OpenAction 9 0 R /Names << /JavaScript 10 0 R /EmbeddedFiles 11 0 R >> /AcroForm << /Fields [8 0 R] /XFA 16 0 R >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R /Annots [6 0 R 7 0 R 8 0 R 15 0 R] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d /Filter [/ASCIIHexDecode /FlateDecode] >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Annot /Subtype /Link /Rect [72 600 200 620] /Border [0 0 0] /A << /S /URI /URI (https://example.com/track) >> >>",
+		"<< /Type /Annot /Subtype /Link /Rect [72 560 200 580] /Border [0 0 0] /A << /S /Launch /F (cmd.exe) >> >>",
+		"<< /Type /Annot /Subtype /Widget /FT /Tx /T (amount) /DA (/Helv 0 Tf 0 g) /Rect [72 500 200 520] /P 3 0 R /AA << /K << /S /JavaScript /JS (event.rc = true;) >> >> >>",
+		"<< /S /JavaScript /JS (app.alert\\(1\\)) >>",
+		"<< /Names [(init) 17 0 R] >>",
+		"<< /Names [(notes.txt) 13 0 R (setup.exe) 12 0 R] >>",
+		"<< /Type /Filespec /F (setup.exe) /UF (setup.exe) /EF << /F 14 0 R >> >>",
+		"<< /Type /Filespec /F (notes.txt) /EF << /F 19 0 R >> >>",
+		fmt.Sprintf("<< /Type /EmbeddedFile /Length %d >>\nstream\n%s\nendstream", len(exe), exe),
+		"<< /Type /Annot /Subtype /RichMedia /Rect [300 500 500 700] /RichMediaContent << >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(xfa), xfa),
+		"<< /S /JavaScript /JS (this.print\\(\\);) >>",
+		"<< /S /Launch /F (evil.sh) >>",
+		fmt.Sprintf("<< /Type /EmbeddedFile /Length %d >>\nstream\n%s\nendstream", len(notes), notes),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write test PDF: %v", err)
+	}
+	return path
+}
+
+func TestSecurityScan(t *testing.T) {
+	dir := t.TempDir()
+	in := writeThreatTestPDF(t, dir, "threats.pdf")
+	p := newTestProcessor()
+
+	report, err := p.SecurityScan(context.Background(), in)
+	if err != nil {
+		t.Fatalf("SecurityScan failed: %v", err)
+	}
+	if len(report.Warnings) > 0 {
+		t.Logf("warnings: %v", report.Warnings)
+	}
+
+	want := map[string]int{
+		SecurityJavaScript:         3,
+		SecurityOpenAction:         1,
+		SecurityURIAction:          1,
+		SecurityLaunchAction:       2,
+		SecurityEmbeddedExecutable: 1,
+		SecurityEmbeddedFile:       1,
+		SecurityRichMedia:          1,
+		SecurityXFA:                1,
+		SecurityFilterChain:        1,
+	}
+	for category, n := range want {
+		if report.Counts[category] != n {
+			t.Errorf("%s: got %d findings, want %d", category, report.Counts[category], n)
+		}
+	}
+	if report.RiskScore != 100 || report.RiskLevel != types.RiskCritical {
+		t.Errorf("risk = %d (%s)", report.RiskScore, report.RiskLevel)
+	}
+
+	locations := map[string]bool{}
+	for _, f := range report.Findings {
+		locations[f.Category+"@"+f.Location] = true
+		if f.Category == SecurityEmbeddedExecutable && f.Detail != "setup.exe" {
+			t.Errorf("executable detail = %q", f.Detail)
+		}
+		if f.Category == SecurityFilterChain && (f.Page != 1 || f.Object != 5) {
+			t.Errorf("filter chain at page %d, object %d", f.Page, f.Object)
+		}
+	}
+	for _, key := range []string{"javascript@document", "javascript@field", "launch_action@annotation",
+		"launch_action@unreferenced", "embedded_executable@attachment", "xfa@field"} {
+		if !locations[key] {
+			t.Errorf("missing finding %s in %v", key, locations)
+		}
+	}
+
+	t.Run("clean file", func(t *testing.T) {
+		clean := writeTestPDF(t, dir, "clean.pdf", []string{"nothing to see"})
+		report, err := p.SecurityScan(context.Background(), clean)
+		if err != nil {
+			t.Fatalf("SecurityScan failed: %v", err)
+		}
+		if len(report.Findings) != 0 || report.RiskScore != 0 || report.RiskLevel != types.RiskNone {
+			t.Errorf("unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("unparseable file", func(t *testing.T) {
+		broken := filepath.Join(dir, "broken.pdf")
+		data := "%PDF-1.7\n1 0 obj\n<< /S /#4A#61vaScript /JS (x) >>\nendobj\ngarbage"
+		if err := os.WriteFile(broken, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		report, err := p.SecurityScan(context.Background(), broken)
+		if err != nil {
+			t.Fatalf("SecurityScan failed: %v", err)
+		}
+		if report.Counts[SecurityJavaScript] != 1 || len(report.Warnings) == 0 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		if !strings.Contains(report.Findings[0].Description, "#xx") {
+			t.Errorf("obfuscation not reported: %q", report.Findings[0].Description)
+		}
+	})
+}
+
+func TestSanitize(t *testing.T) {
+	dir := t.TempDir()
+	in := writeThreatTestPDF(t, dir, "threats.pdf")
+	p := newTestProcessor()
+
+	t.Run("default", func(t *testing.T) {
+		out := filepath.Join(dir, "out", "clean.pdf")
+		result, err := p.Sanitize(context.Background(), in, out, types.SanitizeOptions{})
+		if err != nil {
+			t.Fatalf("Sanitize failed: %v", err)
+		}
+		if result.RiskScoreBefore != 100 || result.RiskScoreAfter != 0 || len(result.Remaining) != 0 {
+			t.Errorf("before = %d, after = %d, remaining = %+v", result.RiskScoreBefore, result.RiskScoreAfter, result.Remaining)
+		}
+		if len(result.Removed) != 12 {
+			t.Errorf("removed %d findings: %+v", len(result.Removed), result.Removed)
+		}
+		if err := p.ValidateFile(out); err != nil {
+			t.Errorf("sanitized PDF does not validate: %v", err)
+		}
+		if got := extractTestText(t, out, 1); !strings.Contains(got, "Quarterly report") {
+			t.Errorf("page text = %q", got)
+		}
+		data, _ := os.ReadFile(out)
+		for _, s := range []string{"JavaScript", "Launch", "MZ\x90", "RichMedia", "XFA"} {
+			if bytes.Contains(data, []byte(s)) {
+				t.Errorf("output still contains %q", s)
+			}
+		}
+	})
+
+	t.Run("keep links and attachments", func(t *testing.T) {
+		out := filepath.Join(dir, "keep.pdf")
+		result, err := p.Sanitize(context.Background(), in, out, types.SanitizeOptions{KeepLinks: true, KeepAttachments: true})
+		if err != nil {
+			t.Fatalf("Sanitize failed: %v", err)
+		}
+		remaining := map[string]int{}
+		for _, f := range result.Remaining {
+			remaining[f.Category]++
+		}
+		if len(remaining) != 2 || remaining[SecurityURIAction] != 1 || remaining[SecurityEmbeddedFile] != 1 {
+			t.Errorf("remaining = %+v", result.Remaining)
+		}
+	})
+}
+
+// writeFilledFormTestPDF genera una página con un campo de texto relleno (con su
+// apariencia), metadatos XMP en el catálogo y autor y título en Info.
+func writeFilledFormTestPDF(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	content := "BT /F1 12 Tf 72 720 Td (Application form) Tj ET"
+	xmp := "<x:xmpmeta xmlns:x=\"adobe:ns:meta/\"><dc:creator>Jane Roe</dc:creator></x:xmpmeta>"
+	appearance := "/Tx BMC BT /F1 12 Tf 2 5 Td (Jane Roe) Tj ET EMC"
+
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Metadata 7 0 R /AcroForm << /Fields [5 0 R] >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 6 0 R /Annots [5 0 R] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Annot /Subtype /Widget /FT /Tx /T (name) /V (Jane Roe) /DA (/Helv 0 Tf 0 g) /Rect [100 600 300 620] /P 3 0 R /AP << /N 8 0 R >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(xmp), xmp),
+		fmt.Sprintf("<< /BBox [0 0 200 20] /Resources << /Font << /F1 4 0 R >> >> /Length %d >>\nstream\n%s\nendstream", len(appearance), appearance),
+		"<< /Author (Jane Roe) /Title (Application) >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 9 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write test PDF: %v", err)
+	}
+	return path
+}
+
+func TestSanitizeMetadataAndForms(t *testing.T) {
+	dir := t.TempDir()
+	in := writeFilledFormTestPDF(t, dir, "form.pdf")
+	p := newTestProcessor()
+
+	t.Run("untouched by default", func(t *testing.T) {
+		out := filepath.Join(dir, "default.pdf")
+		result, err := p.Sanitize(context.Background(), in, out, types.SanitizeOptions{})
+		if err != nil {
+			t.Fatalf("Sanitize failed: %v", err)
+		}
+		if len(result.MetadataRemoved) != 0 || result.FieldsFlattened != 0 {
+			t.Errorf("metadata removed = %v, fields flattened = %d", result.MetadataRemoved, result.FieldsFlattened)
+		}
+		ctx, err := api.ReadContextFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, _ := ctx.Catalog()
+		if root["AcroForm"] == nil || root["Metadata"] == nil {
+			t.Errorf("catalog = %v, want AcroForm and Metadata kept", root)
+		}
+	})
+
+	t.Run("strip and flatten", func(t *testing.T) {
+		out := filepath.Join(dir, "flat.pdf")
+		result, err := p.Sanitize(context.Background(), in, out, types.SanitizeOptions{StripMetadata: true, FlattenForms: true})
+		if err != nil {
+			t.Fatalf("Sanitize failed: %v", err)
+		}
+		if want := []string{"Info/Author", "Info/Title", "XMP"}; !reflect.DeepEqual(result.MetadataRemoved, want) {
+			t.Errorf("metadata removed = %v, want %v", result.MetadataRemoved, want)
+		}
+		if result.FieldsFlattened != 1 {
+			t.Errorf("fields flattened = %d, want 1", result.FieldsFlattened)
+		}
+		if err := p.ValidateFile(out); err != nil {
+			t.Fatalf("flattened PDF does not validate: %v", err)
+		}
+
+		ctx, err := api.ReadContextFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, _ := ctx.Catalog()
+		if root["AcroForm"] != nil || root["Metadata"] != nil {
+			t.Errorf("catalog = %v, want no AcroForm or Metadata", root)
+		}
+		if ctx.Info != nil {
+			info, _ := ctx.DereferenceDict(*ctx.Info)
+			if info["Author"] != nil || info["Title"] != nil {
+				t.Errorf("info = %v", info)
+			}
+		}
+		pd, _, _, _ := ctx.PageDict(1, false)
+		if pd["Annots"] != nil {
+			t.Errorf("page still has annotations: %v", pd["Annots"])
+		}
+		data, _ := os.ReadFile(out)
+		if bytes.Contains(data, []byte("xmpmeta")) {
+			t.Error("output still contains the XMP packet")
+		}
+
+		// El valor del campo es ahora texto de la página, dentro del Rect del widget.
+		pc, err := loadPageContent(ctx.XRefTable, 1, newFontCache(ctx.XRefTable))
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, _ := pageText(pc.glyphs)
+		if !strings.Contains(text, "Application form") || !strings.Contains(text, "Jane Roe") {
+			t.Errorf("page text = %q", text)
+		}
+		widget := rect{100, 600, 300, 620}
+		for _, g := range pc.glyphs {
+			if g.y0 < 700 && !widget.contains(g.bbox) {
+				t.Errorf("glyph %q at %+v is outside the field", g.text, g.bbox)
+			}
+		}
+	})
+}
@@ -0,0 +1,701 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+	"golang.org/x/crypto/pkcs12"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// signSubFilter es el formato de las firmas PAdES baseline.
+const signSubFilter = "ETSI.CAdES.detached"
+
+// signContentsPadding es el margen reservado en /Contents sobre el tamaño de una
+// firma de prueba (las firmas ECDSA varían unos bytes).
+const signContentsPadding = 64
+
+// byteRangePlaceholder reserva sitio para /ByteRange; se sustituye al final.
+var byteRangePlaceholder = "[0 0000000000 0000000000 0000000000]"
+
+// Sign firma el documento con una firma PAdES-B-B (ETSI.CAdES.detached,
+// SHA-256) añadida como actualización incremental, de modo que las firmas
+// anteriores siguen siendo válidas. La clave se carga de keySource o, si está
+// vacío, de PDF_SIGN_PKCS12 / PDF_SIGN_CERT y PDF_SIGN_KEY; la contraseña solo
+// se lee de la configuración. Con opts.Appearance la firma es visible en esa
+// página y rectángulo.
+func (p *Processor) Sign(ctx context.Context, inputPath, outputPath string, keySource types.SignKeySource, opts types.SignOptions) (*types.SignResult, error) {
+	if keySource == (types.SignKeySource{}) {
+		keySource = types.SignKeySource{
+			PKCS12Path: p.config.SignPKCS12Path,
+			CertPath:   p.config.SignCertPath,
+			KeyPath:    p.config.SignKeyPath,
+		}
+	}
+	p.logger.Debug("signing PDF",
+		slog.String("input", inputPath),
+		slog.String("output", outputPath),
+		slog.String("pkcs12", keySource.PKCS12Path),
+		slog.String("cert", keySource.CertPath),
+		slog.String("key", keySource.KeyPath))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	signer, chain, err := loadSigningKey(keySource, p.config.SignKeyPassword)
+	if err != nil {
+		p.logger.Error("failed to load signing key", err)
+		return nil, err
+	}
+	cert := chain[0]
+	now := time.Now()
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, fmt.Errorf("signing certificate is not valid now (valid from %s to %s)",
+			cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+	}
+	if cert.KeyUsage != 0 && cert.KeyUsage&(x509.KeyUsageDigitalSignature|x509.KeyUsageContentCommitment) == 0 {
+		return nil, errors.New("signing certificate does not allow digital signatures (key usage)")
+	}
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read input file", err)
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+	if pdfCtx.Encrypt != nil {
+		return nil, errors.New("cannot sign an encrypted PDF")
+	}
+
+	page := 1
+	var rect pdftypes.Array
+	if a := opts.Appearance; a != nil {
+		if a.Page < 1 || a.Page > pdfCtx.PageCount {
+			return nil, fmt.Errorf("appearance page %d out of range (document has %d pages)", a.Page, pdfCtx.PageCount)
+		}
+		if a.URX <= a.LLX || a.URY <= a.LLY {
+			return nil, errors.New("appearance rectangle must have llx < urx and lly < ury")
+		}
+		page = a.Page
+		rect = pdftypes.Array{pdftypes.Float(a.LLX), pdftypes.Float(a.LLY), pdftypes.Float(a.URX), pdftypes.Float(a.URY)}
+	} else {
+		rect = pdftypes.Array{pdftypes.Integer(0), pdftypes.Integer(0), pdftypes.Integer(0), pdftypes.Integer(0)}
+	}
+
+	u, err := newIncrementalUpdate(pdfCtx.XRefTable, data)
+	if err != nil {
+		p.logger.Error("failed to prepare incremental update", err)
+		return nil, err
+	}
+
+	fieldName := opts.FieldName
+	if fieldName == "" {
+		fieldName = u.freeFieldName("Signature")
+	} else if u.fieldNames()[fieldName] {
+		return nil, fmt.Errorf("form field %q already exists", fieldName)
+	}
+
+	// Tamaño de /Contents: una firma de prueba más un margen.
+	probe, err := buildCAdES(signer, chain, nil)
+	if err != nil {
+		p.logger.Error("failed to build signature", err)
+		return nil, err
+	}
+	contentsSize := len(probe) + signContentsPadding
+
+	sigDict := pdftypes.Dict{
+		"Type":      pdftypes.Name("Sig"),
+		"Filter":    pdftypes.Name("Adobe.PPKLite"),
+		"SubFilter": pdftypes.Name(signSubFilter),
+		"M":         pdftypes.StringLiteral(pdftypes.DateString(now)),
+	}
+	if cn := cert.Subject.CommonName; cn != "" {
+		sigDict["Name"] = pdfTextString(cn)
+	}
+	for key, value := range map[string]string{"Reason": opts.Reason, "Location": opts.Location, "ContactInfo": opts.ContactInfo} {
+		if value != "" {
+			sigDict[key] = pdfTextString(value)
+		}
+	}
+	sigNr := u.newObject(nil)
+	u.raw[sigNr] = []byte(strings.TrimSuffix(sigDict.PDFString(), ">>") +
+		"/ByteRange " + byteRangePlaceholder +
+		"/Contents <" + strings.Repeat("0", 2*contentsSize) + ">>>")
+
+	pageDict, pageRef, _, err := pdfCtx.PageDict(page, false)
+	if err != nil || pageDict == nil || pageRef == nil {
+		return nil, fmt.Errorf("failed to read page %d", page)
+	}
+
+	widget := pdftypes.Dict{
+		"Type":    pdftypes.Name("Annot"),
+		"Subtype": pdftypes.Name("Widget"),
+		"FT":      pdftypes.Name("Sig"),
+		"T":       pdfTextString(fieldName),
+		"V":       *pdftypes.NewIndirectRef(sigNr, 0),
+		"P":       *pageRef,
+		"Rect":    rect,
+		"F":       pdftypes.Integer(132), // Print | Locked
+	}
+	if opts.Appearance != nil {
+		lines := signatureAppearanceLines(cert, now, opts)
+		ap := signatureAppearance(opts.Appearance.URX-opts.Appearance.LLX, opts.Appearance.URY-opts.Appearance.LLY, lines)
+		apNr := u.newObject(ap)
+		widget["AP"] = pdftypes.Dict{"N": *pdftypes.NewIndirectRef(apNr, 0)}
+	}
+	widgetNr := u.newObject(widget)
+	widgetRef := *pdftypes.NewIndirectRef(widgetNr, 0)
+
+	if err := u.appendToArray(pageRef.ObjectNumber.Value(), pageDict, "Annots", widgetRef); err != nil {
+		return nil, fmt.Errorf("failed to add signature widget: %w", err)
+	}
+	if err := u.addFormField(widgetRef); err != nil {
+		return nil, fmt.Errorf("failed to add signature field: %w", err)
+	}
+
+	out, err := u.write()
+	if err != nil {
+		p.logger.Error("failed to write incremental update", err)
+		return nil, err
+	}
+
+	// Sustituir /ByteRange y firmar todo salvo el valor de /Contents.
+	sigStart := u.offsets[sigNr]
+	brAt := sigStart + bytes.Index(out[sigStart:], []byte(byteRangePlaceholder))
+	contentsAt := sigStart + bytes.Index(out[sigStart:], []byte("/Contents <")) + len("/Contents ")
+	contentsEnd := contentsAt + 2 + 2*contentsSize
+	byteRange := []int64{0, int64(contentsAt), int64(contentsEnd), int64(len(out) - contentsEnd)}
+	br := fmt.Sprintf("[%d %d %d %d]", byteRange[0], byteRange[1], byteRange[2], byteRange[3])
+	copy(out[brAt:], br+strings.Repeat(" ", len(byteRangePlaceholder)-len(br)))
+
+	signedData := make([]byte, 0, len(out)-(contentsEnd-contentsAt))
+	signedData = append(append(signedData, out[:contentsAt]...), out[contentsEnd:]...)
+	cms, err := buildCAdES(signer, chain, signedData)
+	if err != nil {
+		p.logger.Error("failed to build signature", err)
+		return nil, err
+	}
+	if len(cms) > contentsSize {
+		return nil, fmt.Errorf("signature needs %d bytes but only %d were reserved", len(cms), contentsSize)
+	}
+	copy(out[contentsAt+1:], hex.EncodeToString(cms))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := ensureOutputDir(outputPath); err != nil {
+		p.logger.Error("failed to create output directory", err)
+		return nil, err
+	}
+	if err := os.WriteFile(outputPath, out, 0644); err != nil {
+		p.logger.Error("failed to write signed PDF", err)
+		return nil, fmt.Errorf("failed to write signed PDF: %w", err)
+	}
+
+	result := &types.SignResult{
+		OutputPath:    outputPath,
+		FieldName:     fieldName,
+		SubFilter:     signSubFilter,
+		PAdESLevel:    "B-B",
+		SignerSubject: cert.Subject.String(),
+		SignerIssuer:  cert.Issuer.String(),
+		SigningTime:   now.Format(time.RFC3339),
+		Visible:       opts.Appearance != nil,
+		Page:          page,
+		ByteRange:     byteRange,
+		OriginalSize:  int64(len(data)),
+		OutputSize:    int64(len(out)),
+	}
+	if opts.Appearance != nil {
+		r := opts.Appearance.Rect
+		result.Rect = &r
+	}
+
+	p.logger.Debug("PDF signed",
+		slog.String("field", fieldName),
+		slog.String("signer", result.SignerSubject),
+		slog.Int64("output_size", result.OutputSize))
+
+	return result, nil
+}
+
+// loadSigningKey carga la clave privada y la cadena de certificados (empezando
+// por el del firmante). Los errores nunca incluyen la contraseña.
+func loadSigningKey(src types.SignKeySource, password string) (crypto.Signer, []*x509.Certificate, error) {
+	var blocks []*pem.Block
+	switch {
+	case src.PKCS12Path != "":
+		raw, err := os.ReadFile(src.PKCS12Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read PKCS#12 file: %w", err)
+		}
+		blocks, err = pkcs12.ToPEM(raw, password)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode PKCS#12 file (only legacy 3DES/RC2 encryption is supported): %w", err)
+		}
+	case src.CertPath != "":
+		for _, path := range []string{src.CertPath, src.KeyPath} {
+			if path == "" {
+				continue
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			for {
+				var block *pem.Block
+				if block, raw = pem.Decode(raw); block == nil {
+					break
+				}
+				blocks = append(blocks, block)
+			}
+		}
+	default:
+		return nil, nil, errors.New("no signing key configured (set pkcs12_path or cert_path/key_path, or PDF_SIGN_PKCS12 / PDF_SIGN_CERT and PDF_SIGN_KEY)")
+	}
+
+	var (
+		signer crypto.Signer
+		certs  []*x509.Certificate
+	)
+	for _, block := range blocks {
+		if block.Type == "CERTIFICATE" {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid certificate: %w", err)
+			}
+			certs = append(certs, cert)
+			continue
+		}
+		if signer != nil || !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			continue
+		}
+		key, err := parsePrivateKey(block, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		signer = key
+	}
+	if signer == nil {
+		return nil, nil, errors.New("no private key found")
+	}
+	if len(certs) == 0 {
+		return nil, nil, errors.New("no certificate found")
+	}
+
+	// El certificado del firmante es el que corresponde a la clave; va primero.
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	for i, c := range certs {
+		if ok && pub.Equal(c.PublicKey) {
+			certs[0], certs[i] = certs[i], certs[0]
+			return signer, certs, nil
+		}
+	}
+	return nil, nil, errors.New("no certificate matches the private key")
+}
+
+// parsePrivateKey interpreta una clave PEM en PKCS#8, PKCS#1 o SEC 1, cifrada o
+// no con el cifrado PEM tradicional.
+func parsePrivateKey(block *pem.Block, password string) (crypto.Signer, error) {
+	der := block.Bytes
+	// El cifrado PEM tradicional es el único que descifra la biblioteca estándar.
+	if x509.IsEncryptedPEMBlock(block) {
+		var err error
+		if der, err = x509.DecryptPEMBlock(block, []byte(password)); err != nil {
+			return nil, errors.New("failed to decrypt private key: incorrect password")
+		}
+	}
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		return nil, errors.New("encrypted PKCS#8 keys are not supported; use a PKCS#12 file or a traditional encrypted PEM key")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case *ecdsa.PrivateKey:
+			return k, nil
+		default:
+			return nil, fmt.Errorf("unsupported private key type %T (use RSA or ECDSA)", key)
+		}
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported or invalid private key (%s)", block.Type)
+}
+
+// pdfTextString codifica un texto como cadena PDF: literal si es ASCII imprimible
+// y UTF-16BE en hexadecimal en otro caso.
+func pdfTextString(s string) pdftypes.Object {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return pdftypes.NewHexLiteral([]byte(pdftypes.EncodeUTF16String(s)))
+		}
+	}
+	escaped, _ := pdftypes.Escape(s)
+	return pdftypes.StringLiteral(*escaped)
+}
+
+// signatureAppearanceLines compone el texto de la apariencia visible.
+func signatureAppearanceLines(cert *x509.Certificate, now time.Time, opts types.SignOptions) []string {
+	name := cert.Subject.CommonName
+	if name == "" {
+		name = cert.Subject.String()
+	}
+	lines := []string{
+		"Digitally signed by " + name,
+		"Date: " + now.Format("2006-01-02 15:04:05 -07:00"),
+	}
+	if opts.Reason != "" {
+		lines = append(lines, "Reason: "+opts.Reason)
+	}
+	if opts.Location != "" {
+		lines = append(lines, "Location: "+opts.Location)
+	}
+	if opts.ContactInfo != "" {
+		lines = append(lines, "Contact: "+opts.ContactInfo)
+	}
+	return lines
+}
+
+// signatureAppearance genera el Form XObject de la apariencia: un marco y las
+// líneas de texto en Helvetica, con el tamaño de letra ajustado al rectángulo.
+func signatureAppearance(w, h float64, lines []string) pdftypes.StreamDict {
+	const margin = 4.0
+
+	encoded := make([]string, len(lines))
+	widest := 0.0
+	for i, l := range lines {
+		encoded[i] = pdftypes.UTF8ToCP1252(l)
+		width := 0
+		for _, r := range l {
+			width += font.CharWidth("Helvetica", r)
+		}
+		widest = max(widest, float64(width)/1000)
+	}
+	size := 10.0
+	if widest > 0 {
+		size = min(size, (w-2*margin)/widest)
+	}
+	size = max(min(size, (h-2*margin)/(1.2*float64(len(lines)))), 1)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "q 0.2 0.3 0.6 RG 1 w 0.5 0.5 %.2f %.2f re S Q\n", w-1, h-1)
+	fmt.Fprintf(&b, "BT 0.1 0.1 0.3 rg /Helv %.2f Tf %.2f TL %.2f %.2f Td\n", size, size*1.2, margin, h-margin-size)
+	for i, l := range encoded {
+		if i > 0 {
+			b.WriteString("T* ")
+		}
+		writePDFString(&b, []byte(l), false)
+		b.WriteString(" Tj\n")
+	}
+	b.WriteString("ET\n")
+
+	content := b.Bytes()
+	return pdftypes.StreamDict{
+		Dict: pdftypes.Dict{
+			"Type":    pdftypes.Name("XObject"),
+			"Subtype": pdftypes.Name("Form"),
+			"BBox":    pdftypes.Array{pdftypes.Integer(0), pdftypes.Integer(0), pdftypes.Float(w), pdftypes.Float(h)},
+			"Length":  pdftypes.Integer(len(content)),
+			"Resources": pdftypes.Dict{"Font": pdftypes.Dict{"Helv": pdftypes.Dict{
+				"Type":     pdftypes.Name("Font"),
+				"Subtype":  pdftypes.Name("Type1"),
+				"BaseFont": pdftypes.Name("Helvetica"),
+				"Encoding": pdftypes.Name("WinAnsiEncoding"),
+			}}},
+		},
+		Content: content,
+		Raw:     content,
+	}
+}
+
+// incrementalUpdate acumula objetos nuevos o modificados y los escribe tras el
+// archivo original con una sección xref que enlaza con la anterior (/Prev).
+type incrementalUpdate struct {
+	xrt        *model.XRefTable
+	original   []byte
+	prevXRef   int64
+	xrefStream bool
+	nextNr     int
+	objects    map[int]pdftypes.Object
+	gens       map[int]int
+	raw        map[int][]byte // cuerpos ya serializados
+	offsets    map[int]int    // posición de cada objeto en el archivo final
+}
+
+func newIncrementalUpdate(xrt *model.XRefTable, original []byte) (*incrementalUpdate, error) {
+	ms := rawStartXRefRe.FindAllSubmatch(original, -1)
+	if len(ms) == 0 {
+		return nil, errors.New("startxref not found; repair the file before signing")
+	}
+	prev, err := strconv.ParseInt(string(ms[len(ms)-1][1]), 10, 64)
+	if err != nil || !validXRefOffset(original, prev) {
+		return nil, errors.New("invalid startxref; repair the file before signing")
+	}
+	if xrt.Size == nil {
+		return nil, errors.New("trailer /Size missing")
+	}
+	rest := bytes.TrimLeft(original[prev:], " \t\r\n")
+	return &incrementalUpdate{
+		xrt:        xrt,
+		original:   original,
+		prevXRef:   prev,
+		xrefStream: !bytes.HasPrefix(rest, []byte("xref")),
+		nextNr:     *xrt.Size,
+		objects:    map[int]pdftypes.Object{},
+		gens:       map[int]int{},
+		raw:        map[int][]byte{},
+		offsets:    map[int]int{},
+	}, nil
+}
+
+// newObject reserva un número de objeto nuevo para o (nil si se serializa aparte).
+func (u *incrementalUpdate) newObject(o pdftypes.Object) int {
+	nr := u.nextNr
+	u.nextNr++
+	if o != nil {
+		u.objects[nr] = o
+	}
+	return nr
+}
+
+// update sustituye el objeto nr, conservando su generación.
+func (u *incrementalUpdate) update(nr int, o pdftypes.Object) {
+	if e, ok := u.xrt.Find(nr); ok && e.Generation != nil {
+		u.gens[nr] = *e.Generation
+	}
+	u.objects[nr] = o
+}
+
+// appendToArray añade ref al array d[key] del objeto nr. Si el array es un
+// objeto indirecto se actualiza ese objeto; si no, el propio diccionario.
+func (u *incrementalUpdate) appendToArray(nr int, d pdftypes.Dict, key string, ref pdftypes.IndirectRef) error {
+	if ar, ok := d[key].(pdftypes.IndirectRef); ok {
+		arr, err := u.xrt.DereferenceArray(ar)
+		if err != nil {
+			return err
+		}
+		u.update(ar.ObjectNumber.Value(), append(append(pdftypes.Array{}, arr...), ref))
+		return nil
+	}
+	arr, err := u.xrt.DereferenceArray(d[key])
+	if err != nil {
+		return err
+	}
+	nd := d.Clone().(pdftypes.Dict)
+	nd[key] = append(append(pdftypes.Array{}, arr...), ref)
+	u.update(nr, nd)
+	return nil
+}
+
+// addFormField añade el campo al AcroForm (creándolo si falta) con SigFlags 3.
+func (u *incrementalUpdate) addFormField(ref pdftypes.IndirectRef) error {
+	root, err := u.xrt.Catalog()
+	if err != nil {
+		return err
+	}
+	rootNr := u.xrt.Root.ObjectNumber.Value()
+
+	if fr, ok := root["AcroForm"].(pdftypes.IndirectRef); ok {
+		form, err := u.xrt.DereferenceDict(fr)
+		if err != nil || form == nil {
+			return fmt.Errorf("invalid AcroForm: %v", err)
+		}
+		nf := form.Clone().(pdftypes.Dict)
+		nf["SigFlags"] = pdftypes.Integer(3)
+		if err := u.appendToArray(fr.ObjectNumber.Value(), nf, "Fields", ref); err != nil {
+			return err
+		}
+		if _, updated := u.objects[fr.ObjectNumber.Value()]; !updated {
+			u.update(fr.ObjectNumber.Value(), nf)
+		}
+		return nil
+	}
+
+	nroot := root.Clone().(pdftypes.Dict)
+	form := pdftypes.Dict{}
+	if d, err := u.xrt.DereferenceDict(root["AcroForm"]); err == nil && d != nil {
+		form = d.Clone().(pdftypes.Dict)
+	}
+	form["SigFlags"] = pdftypes.Integer(3)
+	nroot["AcroForm"] = form
+	if fa, ok := form["Fields"].(pdftypes.IndirectRef); ok {
+		arr, err := u.xrt.DereferenceArray(fa)
+		if err != nil {
+			return err
+		}
+		u.update(fa.ObjectNumber.Value(), append(append(pdftypes.Array{}, arr...), ref))
+	} else {
+		arr, _ := u.xrt.DereferenceArray(form["Fields"])
+		form["Fields"] = append(append(pdftypes.Array{}, arr...), ref)
+	}
+	u.update(rootNr, nroot)
+	return nil
+}
+
+// fieldNames devuelve los nombres de los campos de primer nivel del formulario.
+func (u *incrementalUpdate) fieldNames() map[string]bool {
+	names := map[string]bool{}
+	root, err := u.xrt.Catalog()
+	if err != nil {
+		return names
+	}
+	form, err := u.xrt.DereferenceDict(root["AcroForm"])
+	if err != nil || form == nil {
+		return names
+	}
+	fields, _ := u.xrt.DereferenceArray(form["Fields"])
+	for _, f := range fields {
+		if d, err := u.xrt.DereferenceDict(f); err == nil && d != nil {
+			if t, err := u.xrt.DereferenceText(d["T"]); err == nil {
+				names[t] = true
+			}
+		}
+	}
+	return names
+}
+
+// freeFieldName devuelve prefix1, prefix2... el primero que no exista.
+func (u *incrementalUpdate) freeFieldName(prefix string) string {
+	names := u.fieldNames()
+	for i := 1; ; i++ {
+		if name := fmt.Sprintf("%s%d", prefix, i); !names[name] {
+			return name
+		}
+	}
+}
+
+// write devuelve el archivo original seguido de la actualización.
+func (u *incrementalUpdate) write() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(u.original)
+	if n := len(u.original); n > 0 && u.original[n-1] != '\n' && u.original[n-1] != '\r' {
+		buf.WriteByte('\n')
+	}
+
+	nrs := make([]int, 0, len(u.objects)+len(u.raw))
+	for nr := range u.objects {
+		nrs = append(nrs, nr)
+	}
+	for nr := range u.raw {
+		nrs = append(nrs, nr)
+	}
+	sort.Ints(nrs)
+
+	for _, nr := range nrs {
+		u.offsets[nr] = buf.Len()
+		fmt.Fprintf(&buf, "%d %d obj\n", nr, u.gens[nr])
+		if raw, ok := u.raw[nr]; ok {
+			buf.Write(raw)
+		} else if sd, ok := u.objects[nr].(pdftypes.StreamDict); ok {
+			buf.WriteString(sd.Dict.PDFString())
+			buf.WriteString("\nstream\n")
+			buf.Write(sd.Raw)
+			buf.WriteString("\nendstream")
+		} else {
+			buf.WriteString(u.objects[nr].PDFString())
+		}
+		buf.WriteString("\nendobj\n")
+	}
+
+	trailer := pdftypes.Dict{
+		"Size": pdftypes.Integer(u.nextNr),
+		"Root": *u.xrt.Root,
+		"Prev": pdftypes.Integer(u.prevXRef),
+	}
+	if u.xrt.Info != nil {
+		trailer["Info"] = *u.xrt.Info
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	first := pdftypes.Object(pdftypes.NewHexLiteral(id))
+	if len(u.xrt.ID) > 0 {
+		first = u.xrt.ID[0]
+	}
+	trailer["ID"] = pdftypes.Array{first, pdftypes.NewHexLiteral(id)}
+
+	xrefAt := buf.Len()
+	if u.xrefStream {
+		// La propia xref stream ocupa el siguiente número libre.
+		xrefNr := u.nextNr
+		u.offsets[xrefNr] = xrefAt
+		nrs = append(nrs, xrefNr)
+		trailer["Size"] = pdftypes.Integer(xrefNr + 1)
+
+		var entries bytes.Buffer
+		var index pdftypes.Array
+		for _, sec := range xrefSubsections(nrs) {
+			index = append(index, pdftypes.Integer(sec[0]), pdftypes.Integer(len(sec)))
+			for _, nr := range sec {
+				entries.WriteByte(1)
+				binary.Write(&entries, binary.BigEndian, uint32(u.offsets[nr]))
+				binary.Write(&entries, binary.BigEndian, uint16(u.gens[nr]))
+			}
+		}
+		trailer["Type"] = pdftypes.Name("XRef")
+		trailer["W"] = pdftypes.Array{pdftypes.Integer(1), pdftypes.Integer(4), pdftypes.Integer(2)}
+		trailer["Index"] = index
+		trailer["Length"] = pdftypes.Integer(entries.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nstream\n", xrefNr, trailer.PDFString())
+		buf.Write(entries.Bytes())
+		buf.WriteString("\nendstream\nendobj\n")
+	} else {
+		buf.WriteString("xref\n")
+		for _, sec := range xrefSubsections(nrs) {
+			fmt.Fprintf(&buf, "%d %d\n", sec[0], len(sec))
+			for _, nr := range sec {
+				fmt.Fprintf(&buf, "%010d %05d n\r\n", u.offsets[nr], u.gens[nr])
+			}
+		}
+		fmt.Fprintf(&buf, "trailer\n%s\n", trailer.PDFString())
+	}
+	fmt.Fprintf(&buf, "startxref\n%d\n%%%%EOF\n", xrefAt)
+	return buf.Bytes(), nil
+}
+
+// xrefSubsections agrupa números de objeto ordenados en tramos consecutivos.
+func xrefSubsections(nrs []int) [][]int {
+	var secs [][]int
+	for i, nr := range nrs {
+		if i == 0 || nr != nrs[i-1]+1 {
+			secs = append(secs, nil)
+		}
+		secs[len(secs)-1] = append(secs[len(secs)-1], nr)
+	}
+	return secs
+}
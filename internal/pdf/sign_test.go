@@ -0,0 +1,204 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/config"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestSign(t *testing.T) {
+	dir := t.TempDir()
+	cert, key := newTestCertificate(t, "Report Signer")
+	certPath, keyPath := writeTestKeyPair(t, dir, cert, key.(*ecdsa.PrivateKey), "")
+	trust := filepath.Dir(certPath)
+	src := types.SignKeySource{CertPath: certPath, KeyPath: keyPath}
+
+	in := writeTestPDF(t, dir, "report.pdf", []string{"quarterly report", "appendix"})
+	p := newTestProcessor()
+
+	t.Run("visible", func(t *testing.T) {
+		out := filepath.Join(dir, "out", "signed.pdf")
+		opts := types.SignOptions{
+			Reason:      "Approved",
+			Location:    "Madrid",
+			ContactInfo: "reports@example.com",
+			Appearance:  &types.PageRect{Page: 2, Rect: types.Rect{LLX: 300, LLY: 50, URX: 550, URY: 120}},
+		}
+		result, err := p.Sign(context.Background(), in, out, src, opts)
+		if err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		if result.FieldName != "Signature1" || result.PAdESLevel != "B-B" || !result.Visible || result.Page != 2 {
+			t.Errorf("unexpected result: %+v", result)
+		}
+
+		original, _ := os.ReadFile(in)
+		signed, _ := os.ReadFile(out)
+		if !bytes.HasPrefix(signed, original) {
+			t.Error("signature was not appended as an incremental update")
+		}
+		if err := p.ValidateFile(out); err != nil {
+			t.Errorf("signed PDF does not validate: %v", err)
+		}
+		if got := extractTestText(t, out, 1); !strings.Contains(got, "quarterly report") {
+			t.Errorf("page 1 text = %q", got)
+		}
+
+		report, err := p.ListSignatures(context.Background(), out, trust)
+		if err != nil {
+			t.Fatalf("ListSignatures failed: %v", err)
+		}
+		if len(report.Signatures) != 1 {
+			t.Fatalf("got %d signatures", len(report.Signatures))
+		}
+		s := report.Signatures[0]
+		if s.Status != types.SignatureValid || !s.CoversWholeDocument || s.ModifiedAfterSigning {
+			t.Errorf("status = %s, problems = %v", s.Status, s.Problems)
+		}
+		if s.SubFilter != "ETSI.CAdES.detached" || s.Page != 2 || !s.Visible || s.SigningTimeSource != "signature_dict" {
+			t.Errorf("signature = %+v", s)
+		}
+		if s.Reason != "Approved" || s.Location != "Madrid" || s.ContactInfo != "reports@example.com" {
+			t.Errorf("reason = %q, location = %q, contact = %q", s.Reason, s.Location, s.ContactInfo)
+		}
+		if !strings.Contains(s.SignerSubject, "CN=Report Signer") {
+			t.Errorf("signer = %q", s.SignerSubject)
+		}
+		if !bytes.Contains(signed, []byte("(Digitally signed by Report Signer) Tj")) {
+			t.Error("appearance stream not found")
+		}
+
+		// Una segunda firma invisible conserva la primera.
+		out2 := filepath.Join(dir, "out", "signed-twice.pdf")
+		if _, err := p.Sign(context.Background(), out, out2, src, types.SignOptions{Reason: "Reviewed"}); err != nil {
+			t.Fatalf("second Sign failed: %v", err)
+		}
+		report, err = p.ListSignatures(context.Background(), out2, trust)
+		if err != nil {
+			t.Fatalf("ListSignatures failed: %v", err)
+		}
+		if len(report.Signatures) != 2 {
+			t.Fatalf("got %d signatures", len(report.Signatures))
+		}
+		first, second := report.Signatures[0], report.Signatures[1]
+		if !first.IntegrityValid || !first.ModifiedAfterSigning || first.Status != types.SignatureValid {
+			t.Errorf("first signature = %+v", first)
+		}
+		if second.FieldName != "Signature2" || second.Visible || second.Status != types.SignatureValid || !second.CoversWholeDocument {
+			t.Errorf("second signature = %+v", second)
+		}
+	})
+
+	t.Run("xref stream", func(t *testing.T) {
+		streamed := filepath.Join(dir, "streamed.pdf")
+		conf := model.NewDefaultConfiguration()
+		conf.WriteXRefStream = true
+		conf.WriteObjectStream = true
+		if err := api.OptimizeFile(in, streamed, conf); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := os.ReadFile(streamed)
+		if !bytes.Contains(data, []byte("/XRef")) {
+			t.Skip("pdfcpu did not write an xref stream")
+		}
+
+		out := filepath.Join(dir, "streamed-signed.pdf")
+		if _, err := p.Sign(context.Background(), streamed, out, src, types.SignOptions{}); err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		if err := p.ValidateFile(out); err != nil {
+			t.Errorf("signed PDF does not validate: %v", err)
+		}
+		report, err := p.ListSignatures(context.Background(), out, trust)
+		if err != nil {
+			t.Fatalf("ListSignatures failed: %v", err)
+		}
+		if len(report.Signatures) != 1 || report.Signatures[0].Status != types.SignatureValid {
+			t.Errorf("unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("encrypted key from config", func(t *testing.T) {
+		const password = "s3cret-passphrase"
+		keyDir := filepath.Join(dir, "encrypted")
+		encCert, encKey := writeTestKeyPair(t, keyDir, cert, key.(*ecdsa.PrivateKey), password)
+
+		cfg := config.PDFConfig{ValidationMode: "relaxed", SignCertPath: encCert, SignKeyPath: encKey}
+		out := filepath.Join(dir, "config-signed.pdf")
+		_, err := NewProcessor(cfg, logging.New("error")).Sign(context.Background(), in, out, types.SignKeySource{}, types.SignOptions{})
+		if err == nil || strings.Contains(err.Error(), password) {
+			t.Fatalf("Sign without password: err = %v", err)
+		}
+
+		cfg.SignKeyPassword = password
+		if _, err := NewProcessor(cfg, logging.New("error")).Sign(context.Background(), in, out, types.SignKeySource{}, types.SignOptions{}); err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		out := filepath.Join(dir, "bad.pdf")
+		if _, err := p.Sign(context.Background(), in, out, types.SignKeySource{}, types.SignOptions{}); err == nil {
+			t.Error("expected an error without a key")
+		}
+		badRect := &types.PageRect{Page: 1, Rect: types.Rect{LLX: 100, LLY: 100, URX: 50, URY: 150}}
+		if _, err := p.Sign(context.Background(), in, out, src, types.SignOptions{Appearance: badRect}); err == nil {
+			t.Error("expected an error for an empty rectangle")
+		}
+		badPage := &types.PageRect{Page: 9, Rect: types.Rect{LLX: 0, LLY: 0, URX: 50, URY: 50}}
+		if _, err := p.Sign(context.Background(), in, out, src, types.SignOptions{Appearance: badPage}); err == nil {
+			t.Error("expected an error for a page out of range")
+		}
+		if _, err := p.Sign(context.Background(), in, out, types.SignKeySource{PKCS12Path: certPath}, types.SignOptions{}); err == nil {
+			t.Error("expected an error for an invalid PKCS#12 file")
+		}
+		if _, err := os.Stat(out); !os.IsNotExist(err) {
+			t.Error("output written despite errors")
+		}
+	})
+}
+
+// writeTestKeyPair escribe el certificado y la clave en PEM; con password la
+// clave se cifra con el cifrado PEM tradicional.
+func writeTestKeyPair(t *testing.T, dir string, cert *x509.Certificate, key *ecdsa.PrivateKey, password string) (string, string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	if password != "" {
+		//lint:ignore SA1019 solo para generar una clave cifrada de prueba
+		block, err = x509.EncryptPEMBlock(rand.Reader, block.Type, der, []byte(password), x509.PEMCipherAES256)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	certPath := filepath.Join(dir, "signer.pem")
+	keyPath := filepath.Join(dir, "signer.key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
@@ -0,0 +1,592 @@
+package pdf
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hhrutter/pkcs7"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// Tipos de firma devueltos en SignatureInfo.Type.
+const (
+	sigTypeApproval      = "approval"
+	sigTypeCertification = "certification"
+	sigTypeTimestamp     = "document_timestamp"
+)
+
+// tstInfo es la parte de un TSTInfo RFC 3161 que se necesita para verificar un
+// sello de tiempo de documento; los campos opcionales posteriores se ignoran.
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint struct {
+		HashAlgorithm pkix.AlgorithmIdentifier
+		HashedMessage []byte
+	}
+	SerialNumber *big.Int
+	GenTime      time.Time `asn1:"generalized"`
+}
+
+// ListSignatures enumera las firmas digitales del documento y las verifica sin
+// acceso a la red: integridad de los bytes firmados, firma criptográfica y
+// cadena del certificado contra los certificados PEM de trustStoreDir (o de
+// PDF_TRUST_STORE_DIR si está vacío). Sin almacén de confianza las firmas
+// correctas se informan como untrusted.
+func (p *Processor) ListSignatures(ctx context.Context, inputPath, trustStoreDir string) (*types.SignatureReport, error) {
+	if trustStoreDir == "" {
+		trustStoreDir = p.config.TrustStoreDir
+	}
+	p.logger.Debug("verifying PDF signatures",
+		slog.String("input", inputPath),
+		slog.String("trust_store", trustStoreDir))
+
+	report := &types.SignatureReport{
+		InputPath:     inputPath,
+		TrustStoreDir: trustStoreDir,
+		Signatures:    []types.SignatureInfo{},
+	}
+
+	roots := x509.NewCertPool()
+	if trustStoreDir != "" {
+		certs, warnings, err := loadTrustStore(trustStoreDir)
+		if err != nil {
+			p.logger.Error("failed to load trust store", err)
+			return nil, err
+		}
+		for _, c := range certs {
+			roots.AddCert(c)
+		}
+		report.TrustedRoots = len(certs)
+		report.Warnings = append(report.Warnings, warnings...)
+	}
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read input file", err)
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	report.FileSize = int64(len(data))
+
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+
+	fields, err := signatureFields(pdfCtx.XRefTable)
+	if err != nil {
+		p.logger.Error("failed to read signature fields", err)
+		return nil, fmt.Errorf("failed to read signature fields: %w", err)
+	}
+	certified := certificationSigObjNr(pdfCtx.XRefTable)
+
+	for _, f := range fields {
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+		info := verifySignatureField(pdfCtx.XRefTable, f, data, roots, report.TrustedRoots > 0, certified)
+		report.Signatures = append(report.Signatures, info)
+	}
+
+	report.Signed = len(report.Signatures) > 0
+	report.AllValid = report.Signed
+	for _, s := range report.Signatures {
+		if s.Status != types.SignatureValid {
+			report.AllValid = false
+		}
+	}
+
+	p.logger.Debug("signature verification complete",
+		slog.Int("signatures", len(report.Signatures)),
+		slog.Bool("all_valid", report.AllValid))
+
+	return report, nil
+}
+
+// loadTrustStore lee todos los certificados PEM (.pem, .crt, .cer) del
+// directorio. Los archivos ilegibles se omiten con un aviso.
+func loadTrustStore(dir string) ([]*x509.Certificate, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read trust store: %w", err)
+	}
+
+	var (
+		certs    []*x509.Certificate
+		warnings []string
+	)
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".pem" && ext != ".crt" && ext != ".cer") {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("trust store: skipped %s: %v", e.Name(), err))
+			continue
+		}
+		found := 0
+		for {
+			var block *pem.Block
+			block, raw = pem.Decode(raw)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("trust store: skipped invalid certificate in %s: %v", e.Name(), err))
+				continue
+			}
+			certs = append(certs, cert)
+			found++
+		}
+		if found == 0 {
+			warnings = append(warnings, fmt.Sprintf("trust store: no PEM certificates in %s", e.Name()))
+		}
+	}
+	return certs, warnings, nil
+}
+
+// sigField es un campo de firma con valor, junto con su widget.
+type sigField struct {
+	name    string
+	objNr   int // diccionario de firma (/V)
+	sigDict pdftypes.Dict
+	page    int
+	visible bool
+}
+
+// signatureFields recorre el árbol del AcroForm y devuelve los campos de firma
+// firmados, en el orden del formulario.
+func signatureFields(xrt *model.XRefTable) ([]sigField, error) {
+	root, err := xrt.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	form, err := xrt.DereferenceDict(root["AcroForm"])
+	if err != nil || form == nil {
+		return nil, nil
+	}
+	fields, err := xrt.DereferenceArray(form["Fields"])
+	if err != nil {
+		return nil, err
+	}
+
+	pages := widgetPages(xrt)
+	var out []sigField
+	seen := map[int]bool{}
+
+	var walk func(obj pdftypes.Object, parentName, parentFT string, depth int)
+	walk = func(obj pdftypes.Object, parentName, parentFT string, depth int) {
+		if depth > 32 {
+			return
+		}
+		ref, isRef := obj.(pdftypes.IndirectRef)
+		if isRef {
+			if seen[ref.ObjectNumber.Value()] {
+				return
+			}
+			seen[ref.ObjectNumber.Value()] = true
+		}
+		d, err := xrt.DereferenceDict(obj)
+		if err != nil || d == nil {
+			return
+		}
+
+		name := parentName
+		if t, err := xrt.DereferenceText(d["T"]); err == nil && t != "" {
+			if name != "" {
+				name += "."
+			}
+			name += t
+		}
+		ft := parentFT
+		if n := d.NameEntry("FT"); n != nil {
+			ft = *n
+		}
+
+		if kids, err := xrt.DereferenceArray(d["Kids"]); err == nil && len(kids) > 0 {
+			for _, k := range kids {
+				walk(k, name, ft, depth+1)
+			}
+			if ft != "Sig" || d["V"] == nil {
+				return
+			}
+		}
+		if ft != "Sig" {
+			return
+		}
+
+		vref, ok := d["V"].(pdftypes.IndirectRef)
+		if !ok {
+			return
+		}
+		sd, err := xrt.DereferenceDict(vref)
+		if err != nil || sd == nil {
+			return
+		}
+
+		f := sigField{name: name, objNr: vref.ObjectNumber.Value(), sigDict: sd}
+		if isRef {
+			f.page = pages[ref.ObjectNumber.Value()]
+		}
+		if rect, err := xrt.DereferenceArray(d["Rect"]); err == nil && len(rect) == 4 {
+			if r, err := xrt.RectForArray(rect); err == nil && r.Width() > 0 && r.Height() > 0 {
+				flags, _ := xrt.DereferenceInteger(d["F"])
+				f.visible = flags == nil || flags.Value()&2 == 0
+			}
+		}
+		out = append(out, f)
+	}
+
+	for _, f := range fields {
+		walk(f, "", "", 0)
+	}
+	return out, nil
+}
+
+// widgetPages relaciona cada anotación referenciada desde una página con su número.
+func widgetPages(xrt *model.XRefTable) map[int]int {
+	pages := map[int]int{}
+	for i := 1; i <= xrt.PageCount; i++ {
+		pd, _, _, err := xrt.PageDict(i, false)
+		if err != nil || pd == nil {
+			continue
+		}
+		annots, err := xrt.DereferenceArray(pd["Annots"])
+		if err != nil {
+			continue
+		}
+		for _, a := range annots {
+			if ref, ok := a.(pdftypes.IndirectRef); ok {
+				pages[ref.ObjectNumber.Value()] = i
+			}
+		}
+	}
+	return pages
+}
+
+// certificationSigObjNr devuelve el objeto del diccionario de firma de
+// certificación (/Perms /DocMDP del catálogo), o 0.
+func certificationSigObjNr(xrt *model.XRefTable) int {
+	root, err := xrt.Catalog()
+	if err != nil {
+		return 0
+	}
+	perms, err := xrt.DereferenceDict(root["Perms"])
+	if err != nil || perms == nil {
+		return 0
+	}
+	if ref, ok := perms["DocMDP"].(pdftypes.IndirectRef); ok {
+		return ref.ObjectNumber.Value()
+	}
+	return 0
+}
+
+// verifySignatureField analiza y verifica una firma.
+func verifySignatureField(xrt *model.XRefTable, f sigField, data []byte, roots *x509.CertPool, haveRoots bool, certified int) types.SignatureInfo {
+	sd := f.sigDict
+	info := types.SignatureInfo{
+		FieldName: f.name,
+		Type:      sigTypeApproval,
+		Page:      f.page,
+		Visible:   f.visible,
+		Status:    types.SignatureUnknown,
+		ByteRange: []int64{},
+	}
+	if n := sd.NameEntry("SubFilter"); n != nil {
+		info.SubFilter = *n
+	}
+	switch {
+	case info.SubFilter == "ETSI.RFC3161" || (sd.Type() != nil && *sd.Type() == "DocTimeStamp"):
+		info.Type = sigTypeTimestamp
+	case f.objNr == certified:
+		info.Type = sigTypeCertification
+	}
+	info.Reason, _ = xrt.DereferenceText(sd["Reason"])
+	info.Location, _ = xrt.DereferenceText(sd["Location"])
+	info.ContactInfo, _ = xrt.DereferenceText(sd["ContactInfo"])
+
+	signed, err := signedBytes(xrt, sd, data, &info)
+	if err != nil {
+		info.Problems = append(info.Problems, err.Error())
+		return info
+	}
+
+	contents, err := signatureContents(sd)
+	if err != nil {
+		info.Problems = append(info.Problems, err.Error())
+		return info
+	}
+
+	var (
+		signer   *x509.Certificate
+		chain    []*x509.Certificate
+		signTime time.Time
+	)
+	switch info.SubFilter {
+	case "adbe.pkcs7.detached", "ETSI.CAdES.detached", "adbe.pkcs7.sha1", "ETSI.RFC3161":
+		signer, chain, signTime = verifyPKCS7(contents, signed, &info)
+	case "adbe.x509.rsa_sha1":
+		signer, chain = verifyRSASHA1(xrt, sd, contents, signed, &info)
+	default:
+		info.Problems = append(info.Problems, fmt.Sprintf("unsupported signature sub-filter %q", info.SubFilter))
+		return info
+	}
+
+	if signTime.IsZero() {
+		if m, err := xrt.DereferenceText(sd["M"]); err == nil && m != "" {
+			if t, ok := pdftypes.DateTime(m, true); ok {
+				signTime = t
+				info.SigningTimeSource = "signature_dict"
+			}
+		}
+	}
+	if !signTime.IsZero() {
+		info.SigningTime = signTime.Format(time.RFC3339)
+	}
+
+	if signer != nil {
+		info.SignerSubject = signer.Subject.String()
+		info.SignerIssuer = signer.Issuer.String()
+		info.SerialNumber = signer.SerialNumber.Text(16)
+
+		switch {
+		case !haveRoots:
+			info.Problems = append(info.Problems, "no trust store configured; certificate chain not checked")
+		default:
+			at := signTime
+			if at.IsZero() {
+				at = time.Now()
+			}
+			if _, err := pkcs7.VerifyCertChain(signer, chain, roots, at); err != nil {
+				info.Problems = append(info.Problems, fmt.Sprintf("certificate not trusted: %v", err))
+			} else {
+				info.Trusted = true
+			}
+		}
+	}
+
+	if info.IntegrityValid && info.SignatureValid && signer != nil {
+		info.Status = types.SignatureUntrusted
+		if info.Trusted {
+			info.Status = types.SignatureValid
+		}
+	} else if signer != nil || !info.IntegrityValid {
+		info.Status = types.SignatureInvalid
+	}
+	info.ModifiedAfterSigning = info.ModifiedAfterSigning || !info.IntegrityValid
+	return info
+}
+
+// signedBytes valida /ByteRange, rellena la cobertura de info y devuelve los
+// bytes firmados. ModifiedAfterSigning queda a true si hay bytes posteriores al
+// rango firmado (actualizaciones incrementales).
+func signedBytes(xrt *model.XRefTable, sd pdftypes.Dict, data []byte, info *types.SignatureInfo) ([]byte, error) {
+	arr, err := xrt.DereferenceArray(sd["ByteRange"])
+	if err != nil || len(arr) == 0 || len(arr)%2 != 0 {
+		return nil, errors.New("missing or invalid /ByteRange")
+	}
+
+	size := int64(len(data))
+	var (
+		signed []byte
+		end    int64
+	)
+	for i := 0; i < len(arr); i += 2 {
+		off, err1 := xrt.DereferenceInteger(arr[i])
+		n, err2 := xrt.DereferenceInteger(arr[i+1])
+		if err1 != nil || err2 != nil || off == nil || n == nil {
+			return nil, errors.New("invalid /ByteRange entry")
+		}
+		o, l := int64(off.Value()), int64(n.Value())
+		info.ByteRange = append(info.ByteRange, o, l)
+		if o < end || l < 0 || o+l > size {
+			return nil, fmt.Errorf("/ByteRange %v is out of bounds for a %d byte file", info.ByteRange, size)
+		}
+		signed = append(signed, data[o:o+l]...)
+		info.CoveredBytes += l
+		end = o + l
+	}
+
+	br := info.ByteRange
+	// La firma cubre el documento completo si el único hueco es el propio /Contents.
+	info.CoversWholeDocument = len(br) == 4 && br[0] == 0 && br[2]+br[3] == size &&
+		data[br[1]] == '<' && data[br[2]-1] == '>'
+	info.ModifiedAfterSigning = end < size
+	if !info.CoversWholeDocument && end == size {
+		info.Problems = append(info.Problems, "byte range leaves bytes other than /Contents unsigned")
+	}
+	return signed, nil
+}
+
+// signatureContents devuelve los bytes de /Contents.
+func signatureContents(sd pdftypes.Dict) ([]byte, error) {
+	switch c := sd["Contents"].(type) {
+	case pdftypes.HexLiteral:
+		b, err := c.Bytes()
+		if err != nil {
+			return nil, fmt.Errorf("invalid /Contents: %w", err)
+		}
+		return b, nil
+	case pdftypes.StringLiteral:
+		b, err := pdftypes.Unescape(c.Value())
+		if err != nil {
+			return nil, fmt.Errorf("invalid /Contents: %w", err)
+		}
+		return b, nil
+	}
+	return nil, errors.New("missing /Contents")
+}
+
+// verifyPKCS7 verifica una firma CMS (detached, sha1 o sello de tiempo RFC 3161)
+// y devuelve el certificado firmante, los certificados incluidos y la hora de firma.
+func verifyPKCS7(contents, signed []byte, info *types.SignatureInfo) (*x509.Certificate, []*x509.Certificate, time.Time) {
+	p7, err := pkcs7.Parse(contents)
+	if err != nil {
+		info.Problems = append(info.Problems, fmt.Sprintf("invalid PKCS#7 signature: %v", err))
+		return nil, nil, time.Time{}
+	}
+	if len(p7.Signers) == 0 {
+		info.Problems = append(info.Problems, "PKCS#7 signature has no signers")
+		return nil, nil, time.Time{}
+	}
+	if len(p7.Signers) > 1 {
+		info.Problems = append(info.Problems, fmt.Sprintf("PKCS#7 signature has %d signers; only the first is verified", len(p7.Signers)))
+	}
+
+	si := p7.Signers[0]
+	cert := pkcs7.GetCertFromCertsByIssuerAndSerial(p7.Certificates, si.IssuerAndSerialNumber)
+	if cert == nil {
+		info.Problems = append(info.Problems, "signer certificate is not embedded in the signature")
+		return nil, nil, time.Time{}
+	}
+
+	var (
+		signTime  time.Time
+		integrity error
+		sigErr    error
+	)
+	switch info.SubFilter {
+	case "adbe.pkcs7.detached", "ETSI.CAdES.detached":
+		if len(si.AuthenticatedAttributes) == 0 {
+			// Sin atributos firmados la firma se calcula directamente sobre el documento.
+			integrity = pkcs7.CheckSignature(cert, si, signed)
+			sigErr = integrity
+		} else {
+			integrity = pkcs7.VerifyMessageDigestDetached(si, signed)
+			sigErr = pkcs7.CheckSignature(cert, si, nil)
+		}
+	case "adbe.pkcs7.sha1":
+		integrity = pkcs7.VerifyMessageDigestEmbedded(p7.Content, signed)
+		sigErr = p7.Verify()
+	case "ETSI.RFC3161":
+		var tst tstInfo
+		if _, err := asn1.Unmarshal(p7.Content, &tst); err != nil {
+			integrity = fmt.Errorf("invalid timestamp token: %w", err)
+		} else {
+			integrity = pkcs7.VerifyMessageDigestTSToken(tst.MessageImprint.HashAlgorithm.Algorithm,
+				tst.MessageImprint.HashedMessage, signed)
+			signTime = tst.GenTime
+			info.SigningTimeSource = "timestamp"
+		}
+		sigErr = p7.Verify()
+	}
+
+	info.IntegrityValid = integrity == nil
+	if integrity != nil {
+		var mismatch *pkcs7.MessageDigestMismatchError
+		if errors.As(integrity, &mismatch) {
+			info.Problems = append(info.Problems, "document digest does not match the signed digest; the signed bytes were modified")
+		} else {
+			info.Problems = append(info.Problems, fmt.Sprintf("integrity check failed: %v", integrity))
+		}
+	}
+	info.SignatureValid = sigErr == nil
+	if sigErr != nil && sigErr != integrity {
+		info.Problems = append(info.Problems, fmt.Sprintf("signature verification failed: %v", sigErr))
+	}
+
+	if signTime.IsZero() {
+		var t time.Time
+		if err := p7.UnmarshalSignedAttribute(pkcs7.OIDAttributeSigningTime, &t); err == nil {
+			signTime = t
+			info.SigningTimeSource = "cms"
+		}
+	}
+	return cert, p7.Certificates, signTime
+}
+
+// verifyRSASHA1 verifica una firma adbe.x509.rsa_sha1 (PKCS#1 sobre el SHA-1 de
+// los bytes firmados, con el certificado en /Cert).
+func verifyRSASHA1(xrt *model.XRefTable, sd pdftypes.Dict, contents, signed []byte, info *types.SignatureInfo) (*x509.Certificate, []*x509.Certificate) {
+	var certObjs pdftypes.Array
+	if arr, err := xrt.DereferenceArray(sd["Cert"]); err == nil && arr != nil {
+		certObjs = arr
+	} else if sd["Cert"] != nil {
+		certObjs = pdftypes.Array{sd["Cert"]}
+	}
+
+	var certs []*x509.Certificate
+	for _, o := range certObjs {
+		var raw []byte
+		switch c := o.(type) {
+		case pdftypes.HexLiteral:
+			raw, _ = c.Bytes()
+		case pdftypes.StringLiteral:
+			raw, _ = pdftypes.Unescape(c.Value())
+		}
+		if cert, err := x509.ParseCertificate(raw); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+	if len(certs) == 0 {
+		info.Problems = append(info.Problems, "missing or invalid /Cert")
+		return nil, nil
+	}
+
+	pub, ok := certs[0].PublicKey.(*rsa.PublicKey)
+	if !ok {
+		info.Problems = append(info.Problems, "signer certificate does not hold an RSA key")
+		return certs[0], certs
+	}
+	var sig []byte
+	if _, err := asn1.Unmarshal(contents, &sig); err != nil {
+		info.Problems = append(info.Problems, fmt.Sprintf("invalid PKCS#1 signature: %v", err))
+		return certs[0], certs
+	}
+
+	h := crypto.SHA1.New()
+	h.Write(signed)
+	// PKCS#1 no separa digest y firma: un fallo puede ser alteración o falsificación.
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA1, h.Sum(nil), sig); err != nil {
+		info.Problems = append(info.Problems, "signature does not match the signed bytes")
+		return certs[0], certs
+	}
+	info.IntegrityValid = true
+	info.SignatureValid = true
+	return certs[0], certs
+}
@@ -0,0 +1,260 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhrutter/pkcs7"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestListSignatures(t *testing.T) {
+	dir := t.TempDir()
+	cert, key := newTestCertificate(t, "Test Signer")
+	signed := writeSignedTestPDF(t, dir, "signed.pdf", cert, key)
+
+	trust := filepath.Join(dir, "trust")
+	if err := os.MkdirAll(trust, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := os.WriteFile(filepath.Join(trust, "signer.pem"), pemData, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newTestProcessor()
+
+	t.Run("trusted", func(t *testing.T) {
+		report, err := p.ListSignatures(context.Background(), signed, trust)
+		if err != nil {
+			t.Fatalf("ListSignatures failed: %v", err)
+		}
+		if !report.Signed || !report.AllValid || report.TrustedRoots != 1 || len(report.Signatures) != 1 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		s := report.Signatures[0]
+		if s.Status != types.SignatureValid || !s.Trusted || !s.IntegrityValid || !s.SignatureValid {
+			t.Errorf("status = %s, problems = %v", s.Status, s.Problems)
+		}
+		if !s.CoversWholeDocument || s.ModifiedAfterSigning {
+			t.Errorf("coverage = %+v", s)
+		}
+		if s.FieldName != "Signature1" || s.Type != "approval" || s.SubFilter != "adbe.pkcs7.detached" {
+			t.Errorf("field = %q, type = %q, sub-filter = %q", s.FieldName, s.Type, s.SubFilter)
+		}
+		if !strings.Contains(s.SignerSubject, "CN=Test Signer") || s.Reason != "Approved" || s.Location != "Madrid" {
+			t.Errorf("signer = %q, reason = %q, location = %q", s.SignerSubject, s.Reason, s.Location)
+		}
+		if s.SigningTime == "" || s.SigningTimeSource != "cms" {
+			t.Errorf("signing time = %q (%s)", s.SigningTime, s.SigningTimeSource)
+		}
+		if s.Page != 1 || !s.Visible {
+			t.Errorf("page = %d, visible = %v", s.Page, s.Visible)
+		}
+		if s.CoveredBytes != report.FileSize-int64(2+2*signatureContentsSize) {
+			t.Errorf("covered %d of %d bytes", s.CoveredBytes, report.FileSize)
+		}
+	})
+
+	t.Run("no trust store", func(t *testing.T) {
+		report, err := p.ListSignatures(context.Background(), signed, "")
+		if err != nil {
+			t.Fatalf("ListSignatures failed: %v", err)
+		}
+		s := report.Signatures[0]
+		if report.AllValid || s.Status != types.SignatureUntrusted || s.Trusted || !s.SignatureValid {
+			t.Errorf("status = %s, problems = %v", s.Status, s.Problems)
+		}
+	})
+
+	t.Run("incremental update", func(t *testing.T) {
+		updated := filepath.Join(dir, "updated.pdf")
+		copyTestFile(t, signed, updated)
+		appendTestIncrementalUpdate(t, updated)
+
+		report, err := p.ListSignatures(context.Background(), updated, trust)
+		if err != nil {
+			t.Fatalf("ListSignatures failed: %v", err)
+		}
+		s := report.Signatures[0]
+		if !s.ModifiedAfterSigning || s.CoversWholeDocument || !s.IntegrityValid {
+			t.Errorf("modified = %v, whole = %v, integrity = %v", s.ModifiedAfterSigning, s.CoversWholeDocument, s.IntegrityValid)
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		data, err := os.ReadFile(signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tampered := filepath.Join(dir, "tampered.pdf")
+		data = bytes.Replace(data, []byte("(Madrid)"), []byte("(Malaga)"), 1)
+		if err := os.WriteFile(tampered, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := p.ListSignatures(context.Background(), tampered, trust)
+		if err != nil {
+			t.Fatalf("ListSignatures failed: %v", err)
+		}
+		s := report.Signatures[0]
+		if s.Status != types.SignatureInvalid || s.IntegrityValid || !s.ModifiedAfterSigning {
+			t.Errorf("status = %s, integrity = %v, problems = %v", s.Status, s.IntegrityValid, s.Problems)
+		}
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		in := writeTestPDF(t, dir, "plain.pdf", []string{"no signatures"})
+		report, err := p.ListSignatures(context.Background(), in, "")
+		if err != nil {
+			t.Fatalf("ListSignatures failed: %v", err)
+		}
+		if report.Signed || len(report.Signatures) != 0 {
+			t.Errorf("unexpected report: %+v", report)
+		}
+	})
+}
+
+// signatureContentsSize es el tamaño reservado para /Contents en los PDF de prueba.
+const signatureContentsSize = 4096
+
+// newTestCertificate genera un certificado autofirmado ECDSA P-256.
+func newTestCertificate(t *testing.T, cn string) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"mcp-go-pdf-tools"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// writeSignedTestPDF escribe un PDF de una página con un campo de firma visible
+// firmado (adbe.pkcs7.detached, SHA-256) que cubre todo el archivo.
+func writeSignedTestPDF(t *testing.T, dir, name string, cert *x509.Certificate, key crypto.Signer) string {
+	t.Helper()
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R] /SigFlags 3 >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [4 0 R] >>",
+		"<< /Type /Annot /Subtype /Widget /FT /Sig /T (Signature1) /Rect [72 72 272 122] /F 4 /P 3 0 R /V 5 0 R >>",
+		"<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /adbe.pkcs7.detached " +
+			"/ByteRange [0 0000000000 0000000000 0000000000] " +
+			"/Contents <" + strings.Repeat("0", 2*signatureContentsSize) + "> " +
+			"/M (D:20260102030405Z) /Reason (Approved) /Location (Madrid) >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	data := buf.Bytes()
+
+	start := bytes.Index(data, []byte("/Contents <")) + len("/Contents ")
+	end := start + 2 + 2*signatureContentsSize
+	br := fmt.Sprintf("[0 %010d %010d %010d]", start, end, len(data)-end)
+	brAt := bytes.Index(data, []byte("[0 0000000000"))
+	copy(data[brAt:], br)
+
+	sd, err := pkcs7.NewSignedData(append(append([]byte{}, data[:start]...), data[end:]...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := sd.AddSigner(cert, key, pkcs7.SignerInfoConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	sd.Detach()
+	sig, err := sd.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(data[start+1:], hex.EncodeToString(sig))
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// appendTestIncrementalUpdate añade al archivo una actualización incremental
+// con un diccionario Info nuevo.
+func appendTestIncrementalUpdate(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("startxref not found")
+	}
+	prev, _ := strconv.Atoi(string(m[1]))
+
+	var buf bytes.Buffer
+	buf.Write(data)
+	obj := buf.Len()
+	buf.WriteString("6 0 obj\n<< /Title (edited after signing) >>\nendobj\n")
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 1\n0000000000 65535 f \n6 1\n%010d 00000 n \n", obj)
+	fmt.Fprintf(&buf, "trailer\n<< /Size 7 /Root 1 0 R /Info 6 0 R /Prev %d >>\nstartxref\n%d\n%%%%EOF\n", prev, xref)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func copyTestFile(t *testing.T, src, dst string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,500 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// Categorías de AnalyzeSize.
+const (
+	SizeImages         = "images"
+	SizeFonts          = "fonts"
+	SizeContentStreams = "content_streams" // Contenido de páginas y Form XObjects
+	SizeMetadata       = "metadata"        // Diccionario Info y streams XMP
+	SizeAttachments    = "attachments"
+	SizeUnused         = "unused_objects" // Objetos inalcanzables y definiciones sustituidas
+	SizeOther          = "other"          // Árbol de páginas, anotaciones, formularios y demás objetos
+	SizeStructure      = "structure"      // Cabecera, tablas xref, trailers y datos de linealización
+)
+
+var sizeCategoryOrder = []string{
+	SizeImages, SizeFonts, SizeContentStreams, SizeMetadata,
+	SizeAttachments, SizeUnused, SizeOther, SizeStructure,
+}
+
+// defaultTopObjects es el número de objetos listados si no se indica TopN.
+const defaultTopObjects = 10
+
+// AnalyzeSize informa de en qué se van los bytes de un PDF: bytes por categoría,
+// los objetos más grandes con las páginas que los usan y, salvo que se pida lo
+// contrario, el tamaño que tendría el archivo con cada perfil de compresión.
+func (p *Processor) AnalyzeSize(ctx context.Context, inputPath string, opts types.SizeReportOptions) (*types.SizeReport, error) {
+	p.logger.Debug("analyzing PDF size",
+		slog.String("input", inputPath),
+		slog.Int("top_n", opts.TopN))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF", err)
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+	xrt := pdfCtx.XRefTable
+
+	topN := opts.TopN
+	if topN <= 0 {
+		topN = defaultTopObjects
+	}
+
+	report := &types.SizeReport{
+		InputPath:  inputPath,
+		FileSize:   int64(len(data)),
+		TotalPages: xrt.PageCount,
+	}
+
+	cats := classifyObjects(xrt)
+	sizes, unassigned := objectFileSizes(xrt, data)
+
+	catBytes := map[string]int64{}
+	counts := map[string]int{}
+	for cat, n := range unassigned {
+		catBytes[cat] += n
+	}
+	objNrs := make([]int, 0, len(sizes))
+	for objNr, n := range sizes {
+		cat, ok := cats[objNr]
+		if !ok {
+			cat = SizeUnused
+			cats[objNr] = cat
+		}
+		catBytes[cat] += n
+		counts[cat]++
+		objNrs = append(objNrs, objNr)
+	}
+
+	var assigned int64
+	for _, n := range catBytes {
+		assigned += n
+	}
+	catBytes[SizeStructure] += report.FileSize - assigned
+
+	for _, cat := range sizeCategoryOrder {
+		c := types.SizeCategory{Category: cat, Bytes: catBytes[cat], Objects: counts[cat]}
+		if report.FileSize > 0 {
+			c.Percent = round2(float64(c.Bytes) * 100 / float64(report.FileSize))
+		}
+		report.Categories = append(report.Categories, c)
+	}
+
+	sort.Slice(objNrs, func(i, j int) bool {
+		if sizes[objNrs[i]] != sizes[objNrs[j]] {
+			return sizes[objNrs[i]] > sizes[objNrs[j]]
+		}
+		return objNrs[i] < objNrs[j]
+	})
+	if len(objNrs) > topN {
+		objNrs = objNrs[:topN]
+	}
+	users := pageUsers(xrt)
+	for _, objNr := range objNrs {
+		e, _ := xrt.Find(objNr)
+		report.TopObjects = append(report.TopObjects, types.SizeObject{
+			Object:      objNr,
+			Category:    cats[objNr],
+			Description: describeObject(xrt, e.Object),
+			Bytes:       sizes[objNr],
+			Pages:       strings.Join(intsToPageSelectionSlice(users[objNr]), ","),
+		})
+	}
+
+	if !opts.SkipEstimates {
+		if report.Estimates, err = p.estimateProfiles(ctx, inputPath, report.FileSize); err != nil {
+			return nil, err
+		}
+	}
+
+	p.logger.Debug("PDF size analysis complete",
+		slog.Int64("file_size", report.FileSize),
+		slog.Int("objects", len(sizes)))
+
+	return report, nil
+}
+
+// estimateProfiles comprime el archivo en memoria con cada perfil y mide el
+// resultado. El avance se cuenta por perfil. Solo devuelve error si ctx se
+// cancela.
+func (p *Processor) estimateProfiles(ctx context.Context, inputPath string, fileSize int64) ([]types.ProfileEstimate, error) {
+	profiles := []types.CompressProfile{
+		types.ProfileLossless, types.ProfilePrint, types.ProfileEbook,
+		types.ProfileScreen, types.ProfileCustom,
+	}
+
+	progress := newProgressCounter(ctx, len(profiles))
+	compressCtx := withoutProgress(ctx)
+	var estimates []types.ProfileEstimate
+	for _, profile := range profiles {
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+		est := types.ProfileEstimate{Profile: profile}
+		settings, err := p.resolveCompressSettings(types.CompressOptions{Profile: profile})
+		if err != nil {
+			est.Error = err.Error()
+			estimates = append(estimates, est)
+			continue
+		}
+
+		result := &types.CompressResult{Profile: profile}
+		var w countingWriter
+		err = p.compressContext(compressCtx, inputPath, settings, result, &w)
+		progress.step()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, checkCancelled(ctx)
+			}
+			p.logger.Warn("profile estimate failed", slog.String("profile", string(profile)), slog.Any("error", err))
+			est.Error = err.Error()
+			estimates = append(estimates, est)
+			continue
+		}
+
+		est.EstimatedSize = w.n
+		est.EstimatedSavings = fileSize - w.n
+		if fileSize > 0 {
+			est.SavingsRatio = float64(est.EstimatedSavings) / float64(fileSize)
+		}
+		est.Savings = result.Savings
+		est.Savings.Structure = est.EstimatedSavings - est.Savings.Images - est.Savings.DuplicateImages - est.Savings.UnusedFonts
+		estimates = append(estimates, est)
+	}
+	return estimates, nil
+}
+
+// countingWriter descarta lo que se escribe y cuenta los bytes.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	w.n += int64(len(b))
+	return len(b), nil
+}
+
+// objectFileSizes reparte los bytes del archivo entre los objetos vigentes de la
+// tabla xref. Los bytes de un object stream se reparten entre los objetos que
+// contiene en proporción a su tamaño sin comprimir. Las definiciones sustituidas
+// por actualizaciones incrementales, las tablas xref en stream y el diccionario
+// de linealización se devuelven aparte, por categoría.
+func objectFileSizes(xrt *model.XRefTable, data []byte) (map[int]int64, map[string]int64) {
+	raw := scanRawObjects(data)
+	defs := map[int]int{}
+	for _, o := range raw {
+		defs[o.num]++
+	}
+
+	// pdfcpu descomprime los object streams al leer y limpia Compressed, pero
+	// conserva en ObjectStream el stream de origen.
+	members := map[int][]int{}
+	for objNr, e := range xrt.Table {
+		if e != nil && !e.Free && e.ObjectStream != nil {
+			members[*e.ObjectStream] = append(members[*e.ObjectStream], objNr)
+		}
+	}
+
+	sizes := map[int]int64{}
+	unassigned := map[string]int64{}
+	for _, o := range raw {
+		span := int64(o.end - o.offset)
+		e, ok := xrt.Find(o.num)
+		current := ok && !e.Free && !e.Compressed &&
+			((e.Offset != nil && *e.Offset == int64(o.offset)) || defs[o.num] == 1)
+
+		dictEnd := o.end
+		if o.streamKw >= 0 {
+			dictEnd = o.streamKw
+		}
+		head := data[o.bodyStart:dictEnd]
+
+		switch {
+		case rawXRefTypeRe.Match(head), rawLinearizedRe.Match(head):
+			unassigned[SizeStructure] += span
+		case len(members[o.num]) > 0:
+			distributeObjectStream(xrt, members[o.num], span, sizes)
+			delete(members, o.num)
+		case !current:
+			unassigned[SizeUnused] += span
+		default:
+			sizes[o.num] += span
+		}
+	}
+	return sizes, unassigned
+}
+
+// distributeObjectStream reparte span bytes entre los objetos de un object stream.
+func distributeObjectStream(xrt *model.XRefTable, objNrs []int, span int64, sizes map[int]int64) {
+	sort.Ints(objNrs)
+	weights := make([]int64, len(objNrs))
+	var total int64
+	for i, objNr := range objNrs {
+		if e, ok := xrt.Find(objNr); ok && e.Object != nil {
+			weights[i] = int64(len(e.Object.PDFString())) + 1
+		} else {
+			weights[i] = 1
+		}
+		total += weights[i]
+	}
+
+	var given int64
+	for i, objNr := range objNrs {
+		share := span * weights[i] / total
+		if i == len(objNrs)-1 {
+			share = span - given
+		}
+		sizes[objNr] += share
+		given += share
+	}
+}
+
+// classifyObjects asigna una categoría a cada objeto alcanzable desde el trailer.
+// Un objeto se clasifica por su tipo o, si no lo tiene, por la clave desde la que
+// se referencia o la categoría del objeto que lo referencia.
+func classifyObjects(xrt *model.XRefTable) map[int]string {
+	c := &sizeClassifier{xrt: xrt, cats: map[int]string{}}
+	if xrt.Encrypt != nil {
+		c.walk(*xrt.Encrypt, SizeStructure)
+	}
+	if xrt.Info != nil {
+		c.walk(*xrt.Info, SizeMetadata)
+	}
+	if xrt.Root != nil {
+		c.walk(*xrt.Root, SizeOther)
+	}
+	return c.cats
+}
+
+type sizeClassifier struct {
+	xrt  *model.XRefTable
+	cats map[int]string
+}
+
+func (c *sizeClassifier) walk(o pdftypes.Object, cat string) {
+	switch v := o.(type) {
+	case pdftypes.IndirectRef:
+		n := v.ObjectNumber.Value()
+		if _, seen := c.cats[n]; seen {
+			return
+		}
+		e, ok := c.xrt.Find(n)
+		if !ok || e.Free || e.Object == nil {
+			return
+		}
+		cat = objectCategory(e.Object, cat)
+		c.cats[n] = cat
+		c.walk(e.Object, cat)
+	case pdftypes.Dict:
+		for _, k := range sortedKeys(v) {
+			c.walk(v[k], keyCategory(k, cat))
+		}
+	case pdftypes.Array:
+		for _, e := range v {
+			c.walk(e, cat)
+		}
+	case pdftypes.StreamDict:
+		c.walk(v.Dict, cat)
+	}
+}
+
+// keyCategory devuelve la categoría de lo referenciado desde la clave key.
+func keyCategory(key, cat string) string {
+	switch key {
+	case "Contents":
+		if cat == SizeOther {
+			return SizeContentStreams
+		}
+	case "FontDescriptor", "FontFile", "FontFile2", "FontFile3", "DescendantFonts", "ToUnicode", "CIDToGIDMap", "CIDSet":
+		return SizeFonts
+	case "Metadata":
+		return SizeMetadata
+	case "EF", "EmbeddedFiles":
+		return SizeAttachments
+	case "Parent", "Resources", "Annots", "P":
+		return SizeOther
+	}
+	return cat
+}
+
+// objectCategory clasifica un objeto por su /Type o /Subtype.
+func objectCategory(o pdftypes.Object, inherited string) string {
+	var d pdftypes.Dict
+	switch v := o.(type) {
+	case pdftypes.Dict:
+		d = v
+	case pdftypes.StreamDict:
+		d = v.Dict
+	default:
+		return inherited
+	}
+
+	if st := d.Subtype(); st != nil {
+		switch *st {
+		case "Image":
+			return SizeImages
+		case "Form":
+			return SizeContentStreams
+		}
+	}
+	if t := d.Type(); t != nil {
+		switch *t {
+		case "Font", "FontDescriptor":
+			return SizeFonts
+		case "Metadata":
+			return SizeMetadata
+		case "EmbeddedFile", "Filespec":
+			return SizeAttachments
+		case "Catalog", "Pages", "Page", "Annot":
+			return SizeOther
+		case "XRef", "ObjStm":
+			return SizeStructure
+		}
+	}
+	return inherited
+}
+
+// pageUsers devuelve, para cada objeto, las páginas que lo usan directa o
+// indirectamente (contenido, recursos heredados, anotaciones...).
+func pageUsers(xrt *model.XRefTable) map[int][]int {
+	users := map[int][]int{}
+	for i := 1; i <= xrt.PageCount; i++ {
+		d, ref, inh, err := xrt.PageDict(i, false)
+		if err != nil || d == nil {
+			continue
+		}
+		seen := map[int]bool{}
+		if ref != nil {
+			seen[ref.ObjectNumber.Value()] = true
+		}
+		walkPageObjects(xrt, d, seen)
+		if inh != nil {
+			walkPageObjects(xrt, inh.Resources, seen)
+		}
+		for objNr := range seen {
+			users[objNr] = append(users[objNr], i)
+		}
+	}
+	return users
+}
+
+// walkPageObjects marca en seen los objetos alcanzables desde o sin subir por
+// /Parent ni entrar en otras páginas (enlaces, destinos, /P de anotaciones).
+func walkPageObjects(xrt *model.XRefTable, o pdftypes.Object, seen map[int]bool) {
+	switch v := o.(type) {
+	case pdftypes.IndirectRef:
+		n := v.ObjectNumber.Value()
+		if seen[n] {
+			return
+		}
+		e, ok := xrt.Find(n)
+		if !ok || e.Free || e.Object == nil {
+			return
+		}
+		if d, ok := e.Object.(pdftypes.Dict); ok {
+			if t := d.Type(); t != nil && (*t == "Page" || *t == "Pages") {
+				return
+			}
+		}
+		seen[n] = true
+		walkPageObjects(xrt, e.Object, seen)
+	case pdftypes.Dict:
+		for k, e := range v {
+			if k != "Parent" {
+				walkPageObjects(xrt, e, seen)
+			}
+		}
+	case pdftypes.Array:
+		for _, e := range v {
+			walkPageObjects(xrt, e, seen)
+		}
+	case pdftypes.StreamDict:
+		walkPageObjects(xrt, v.Dict, seen)
+	}
+}
+
+// describeObject resume un objeto para el informe: tipo, dimensiones y filtros.
+func describeObject(xrt *model.XRefTable, o pdftypes.Object) string {
+	var d pdftypes.Dict
+	kind := "Dictionary"
+	switch v := o.(type) {
+	case pdftypes.StreamDict:
+		d, kind = v.Dict, "Stream"
+	case pdftypes.Dict:
+		d = v
+	case pdftypes.Array:
+		return fmt.Sprintf("Array (%d elements)", len(v))
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", o)
+	}
+
+	var parts []string
+	if st := d.Subtype(); st != nil && *st == "Image" {
+		parts = append(parts, "Image")
+		if w, h := d.IntEntry("Width"), d.IntEntry("Height"); w != nil && h != nil {
+			parts = append(parts, fmt.Sprintf("%dx%d", *w, *h))
+		}
+		if cs, err := xrt.Dereference(d["ColorSpace"]); err == nil {
+			switch c := cs.(type) {
+			case pdftypes.Name:
+				parts = append(parts, c.Value())
+			case pdftypes.Array:
+				if len(c) > 0 {
+					if n, ok := c[0].(pdftypes.Name); ok {
+						parts = append(parts, n.Value())
+					}
+				}
+			}
+		}
+	} else {
+		parts = append(parts, kind)
+		if t := d.Type(); t != nil {
+			parts = append(parts, *t)
+		}
+		if st := d.Subtype(); st != nil {
+			parts = append(parts, *st)
+		}
+		if bf := d.NameEntry("BaseFont"); bf != nil {
+			parts = append(parts, *bf)
+		}
+	}
+
+	if f, err := xrt.Dereference(d["Filter"]); err == nil {
+		switch v := f.(type) {
+		case pdftypes.Name:
+			parts = append(parts, v.Value())
+		case pdftypes.Array:
+			for _, e := range v {
+				if n, ok := e.(pdftypes.Name); ok {
+					parts = append(parts, n.Value())
+				}
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
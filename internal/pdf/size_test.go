@@ -0,0 +1,101 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestAnalyzeSize(t *testing.T) {
+	dir := t.TempDir()
+	src := writeCompressTestPDF(t, dir)
+	in := damagePDF(t, src, "orphan.pdf", func(b []byte) []byte {
+		i := bytes.Index(b, []byte("xref\n"))
+		orphan := []byte("20 0 obj\n<< /Orphan (" + string(bytes.Repeat([]byte("x"), 500)) + ") >>\nendobj\n")
+		return append(b[:i:i], append(orphan, b[i:]...)...)
+	})
+	p := newTestProcessor()
+
+	report, err := p.AnalyzeSize(context.Background(), in, types.SizeReportOptions{TopN: 3})
+	if err != nil {
+		t.Fatalf("AnalyzeSize failed: %v", err)
+	}
+
+	byCat := map[string]types.SizeCategory{}
+	var total int64
+	for _, c := range report.Categories {
+		byCat[c.Category] = c
+		total += c.Bytes
+	}
+	if total != report.FileSize {
+		t.Errorf("categories add up to %d, file size %d", total, report.FileSize)
+	}
+	if c := byCat[SizeImages]; c.Objects != 2 || c.Bytes < report.FileSize*9/10 {
+		t.Errorf("images = %+v", c)
+	}
+	if c := byCat[SizeFonts]; c.Objects != 4 {
+		t.Errorf("fonts = %+v, want 4 objects", c)
+	}
+	if c := byCat[SizeContentStreams]; c.Objects != 1 {
+		t.Errorf("content streams = %+v, want 1 object", c)
+	}
+	if c := byCat[SizeUnused]; c.Bytes < 500 {
+		t.Errorf("unused objects = %+v, want the orphan object", c)
+	}
+
+	if len(report.TopObjects) != 3 {
+		t.Fatalf("expected 3 top objects, got %d", len(report.TopObjects))
+	}
+	top := report.TopObjects[0]
+	if top.Object != 5 || top.Category != SizeImages || top.Pages != "1" || top.Description != "Image 400x400 DeviceRGB FlateDecode" {
+		t.Errorf("unexpected top object: %+v", top)
+	}
+
+	if len(report.Estimates) != 5 {
+		t.Fatalf("expected 5 profile estimates, got %d", len(report.Estimates))
+	}
+	est := map[types.CompressProfile]types.ProfileEstimate{}
+	for _, e := range report.Estimates {
+		if e.Error != "" {
+			t.Errorf("%s estimate failed: %s", e.Profile, e.Error)
+		}
+		est[e.Profile] = e
+	}
+	if est[types.ProfileScreen].EstimatedSize >= est[types.ProfileLossless].EstimatedSize {
+		t.Errorf("screen estimate %d not smaller than lossless %d",
+			est[types.ProfileScreen].EstimatedSize, est[types.ProfileLossless].EstimatedSize)
+	}
+
+	// La estimación coincide con la compresión real.
+	out := filepath.Join(dir, "ebook.pdf")
+	result, err := p.Compress(context.Background(), in, out, types.CompressOptions{Profile: types.ProfileEbook})
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	if result.CompressedSize != est[types.ProfileEbook].EstimatedSize {
+		t.Errorf("ebook estimate %d, actual %d", est[types.ProfileEbook].EstimatedSize, result.CompressedSize)
+	}
+
+	// Archivo escrito por pdfcpu, con object streams.
+	report, err = p.AnalyzeSize(context.Background(), out, types.SizeReportOptions{SkipEstimates: true})
+	if err != nil {
+		t.Fatalf("AnalyzeSize of compressed file failed: %v", err)
+	}
+	total = 0
+	for _, c := range report.Categories {
+		total += c.Bytes
+		if c.Category == SizeImages && c.Objects != 1 {
+			t.Errorf("compressed images = %+v, want 1 object", c)
+		}
+		// Los object streams se reparten entre sus miembros.
+		if c.Category == SizeUnused && c.Bytes != 0 {
+			t.Errorf("compressed file has unused bytes: %+v", c)
+		}
+	}
+	if total != report.FileSize || len(report.Estimates) != 0 {
+		t.Errorf("categories add up to %d (file %d), estimates %d", total, report.FileSize, len(report.Estimates))
+	}
+}
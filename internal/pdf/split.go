@@ -1,6 +1,8 @@
 package pdf
 
 import (
+	"context"
+
 	"github.com/scopweb/mcp-go-pdf-tools/internal/config"
 	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
 )
@@ -12,7 +14,7 @@ func SplitPDFFile(inputPath string) ([]string, error) {
 		ValidationMode: "relaxed",
 	}
 	processor := NewProcessor(defaultConfig, logging.New("info"))
-	return processor.Split(inputPath)
+	return processor.Split(context.Background(), inputPath)
 }
 
 // GetPDFInfo is a backward-compatible wrapper for getting PDF information.
@@ -23,7 +25,7 @@ func GetPDFInfo(inputPath string) (map[string]interface{}, error) {
 	}
 	processor := NewProcessor(defaultConfig, logging.New("info"))
 
-	info, err := processor.GetInfo(inputPath)
+	info, err := processor.GetInfo(context.Background(), inputPath)
 	if err != nil {
 		return nil, err
 	}
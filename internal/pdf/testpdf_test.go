@@ -0,0 +1,75 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/config"
+	"github.com/scopweb/mcp-go-pdf-tools/internal/logging"
+)
+
+// writeTestPDF genera un PDF mínimo con una página por elemento de pages.
+// Cada línea del texto de una página se dibuja con Helvetica 12pt empezando en (72, 720).
+func writeTestPDF(t *testing.T, dir, name string, pages []string) string {
+	t.Helper()
+
+	var objs []string
+	fontObj := 3
+	firstPageObj := 4
+
+	kids := make([]string, 0, len(pages))
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPageObj+2*i))
+	}
+
+	objs = append(objs, "<< /Type /Catalog /Pages 2 0 R >>")
+	objs = append(objs, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	objs = append(objs, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+
+	for i, text := range pages {
+		var content bytes.Buffer
+		content.WriteString("BT /F1 12 Tf 14 TL 72 720 Td\n")
+		for _, line := range strings.Split(text, "\n") {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", testPDFEscaper.Replace(line))
+		}
+		content.WriteString("ET\n")
+
+		contentObj := firstPageObj + 2*i + 1
+		objs = append(objs, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			fontObj, contentObj))
+		objs = append(objs, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write test PDF: %v", err)
+	}
+	return path
+}
+
+var testPDFEscaper = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+
+// newTestProcessor crea un Processor con configuración relajada para tests.
+func newTestProcessor() *Processor {
+	return NewProcessor(config.PDFConfig{ValidationMode: "relaxed"}, logging.New("error"))
+}
@@ -0,0 +1,466 @@
+package pdf
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// maxFormDepth limita la recursión en Form XObjects anidados.
+const maxFormDepth = 8
+
+// contentSource es un content stream interpretado: el de la página o el de un Form XObject.
+type contentSource struct {
+	ops       []contentOp
+	resources pdftypes.Dict
+	parent    *contentSource
+	parentOp  int                   // índice del operador Do en parent
+	formRef   *pdftypes.IndirectRef // nil para el contenido de la página
+	form      *pdftypes.StreamDict
+}
+
+// textGlyph es un glifo dibujado con su posición en espacio de usuario de la página.
+type textGlyph struct {
+	text   string
+	bbox   rect
+	x0, y0 float64 // origen
+	x1, y1 float64 // origen + avance
+	size   float64 // tamaño efectivo en puntos
+	src    *contentSource
+	op     int     // índice del operador que lo dibuja
+	elem   int     // índice del elemento en el array de TJ (0 para Tj, ' y ")
+	off    int     // offset en bytes dentro del string
+	n      int     // bytes que ocupa el código
+	adjust float64 // avance expresado en unidades de TJ (para compensar al eliminarlo)
+}
+
+// imageDraw es una imagen dibujada en la página (XObject o inline).
+type imageDraw struct {
+	src    *contentSource
+	op     int
+	name   string
+	ref    *pdftypes.IndirectRef
+	inline bool
+	ctm    matrix
+	bbox   rect
+}
+
+// pageContent es el resultado de interpretar el contenido de una página.
+type pageContent struct {
+	number  int
+	dict    pdftypes.Dict
+	root    *contentSource
+	sources []*contentSource
+	glyphs  []textGlyph
+	images  []imageDraw
+}
+
+type graphicsState struct {
+	ctm       matrix
+	font      *textFont
+	fontSize  float64
+	charSpace float64
+	wordSpace float64
+	hScale    float64
+	leading   float64
+	rise      float64
+}
+
+type contentInterpreter struct {
+	xrt   *model.XRefTable
+	fonts *fontCache
+	page  *pageContent
+}
+
+// loadPageContent interpreta el contenido de la página pageNr y recopila glifos e imágenes.
+func loadPageContent(xrt *model.XRefTable, pageNr int, fonts *fontCache) (*pageContent, error) {
+	d, _, inh, err := xrt.PageDict(pageNr, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources pdftypes.Dict
+	if inh != nil {
+		resources = inh.Resources
+	}
+
+	data, err := pageContentBytes(xrt, d)
+	if err != nil {
+		return nil, err
+	}
+
+	ops, err := parseContent(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := &pageContent{number: pageNr, dict: d}
+	pc.root = &contentSource{ops: ops, resources: resources, parentOp: -1}
+	pc.sources = append(pc.sources, pc.root)
+
+	in := &contentInterpreter{xrt: xrt, fonts: fonts, page: pc}
+	in.run(pc.root, identityMatrix, 0)
+	return pc, nil
+}
+
+// pageContentBytes concatena los content streams de la página separándolos con
+// un salto de línea para que los tokens de streams contiguos no se fusionen.
+func pageContentBytes(xrt *model.XRefTable, d pdftypes.Dict) ([]byte, error) {
+	o, err := xrt.Dereference(d["Contents"])
+	if err != nil || o == nil {
+		return nil, err
+	}
+
+	var refs []pdftypes.Object
+	switch c := o.(type) {
+	case pdftypes.StreamDict:
+		refs = []pdftypes.Object{c}
+	case pdftypes.Array:
+		refs = c
+	default:
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	for _, ref := range refs {
+		sd, _, err := xrt.DereferenceStreamDict(ref)
+		if err != nil {
+			return nil, err
+		}
+		if sd == nil {
+			continue
+		}
+		if err := sd.Decode(); err != nil {
+			return nil, err
+		}
+		buf.Write(sd.Content)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func (in *contentInterpreter) resource(res pdftypes.Dict, category, name string) pdftypes.Object {
+	if res == nil {
+		return nil
+	}
+	sub, err := in.xrt.DereferenceDict(res[category])
+	if err != nil || sub == nil {
+		return nil
+	}
+	return sub[name]
+}
+
+func (in *contentInterpreter) run(src *contentSource, ctm matrix, depth int) {
+	gs := graphicsState{ctm: ctm, font: fallbackFont, hScale: 1}
+	var stack []graphicsState
+	tm, tlm := identityMatrix, identityMatrix
+
+	for i, op := range src.ops {
+		switch op.op {
+		case "q":
+			stack = append(stack, gs)
+		case "Q":
+			if len(stack) > 0 {
+				gs = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(op.args) == 6 {
+				gs.ctm = opMatrix(op).mul(gs.ctm)
+			}
+		case "BT":
+			tm, tlm = identityMatrix, identityMatrix
+		case "Tf":
+			if len(op.args) == 2 && op.args[0].kind == operandName {
+				gs.font = fallbackFont
+				if o := in.resource(src.resources, "Font", op.args[0].name); o != nil {
+					gs.font = in.fonts.load(o)
+				}
+				gs.fontSize = op.num(1)
+			}
+		case "Tc":
+			gs.charSpace = op.num(0)
+		case "Tw":
+			gs.wordSpace = op.num(0)
+		case "Tz":
+			gs.hScale = op.num(0) / 100
+		case "TL":
+			gs.leading = op.num(0)
+		case "Ts":
+			gs.rise = op.num(0)
+		case "Td":
+			tlm = matrix{1, 0, 0, 1, op.num(0), op.num(1)}.mul(tlm)
+			tm = tlm
+		case "TD":
+			gs.leading = -op.num(1)
+			tlm = matrix{1, 0, 0, 1, op.num(0), op.num(1)}.mul(tlm)
+			tm = tlm
+		case "Tm":
+			if len(op.args) == 6 {
+				tlm = opMatrix(op)
+				tm = tlm
+			}
+		case "T*":
+			tlm = matrix{1, 0, 0, 1, 0, -gs.leading}.mul(tlm)
+			tm = tlm
+		case "Tj":
+			if len(op.args) == 1 {
+				tm = in.show(src, i, 0, op.args[0].str, &gs, tm)
+			}
+		case "'":
+			tlm = matrix{1, 0, 0, 1, 0, -gs.leading}.mul(tlm)
+			tm = tlm
+			if len(op.args) == 1 {
+				tm = in.show(src, i, 0, op.args[0].str, &gs, tm)
+			}
+		case "\"":
+			if len(op.args) == 3 {
+				gs.wordSpace = op.num(0)
+				gs.charSpace = op.num(1)
+				tlm = matrix{1, 0, 0, 1, 0, -gs.leading}.mul(tlm)
+				tm = tlm
+				tm = in.show(src, i, 0, op.args[2].str, &gs, tm)
+			}
+		case "TJ":
+			if len(op.args) == 1 && op.args[0].kind == operandArray {
+				for j, e := range op.args[0].arr {
+					switch e.kind {
+					case operandString:
+						tm = in.show(src, i, j, e.str, &gs, tm)
+					case operandNumber:
+						tx := -e.num / 1000 * gs.fontSize * gs.hScale
+						tm = matrix{1, 0, 0, 1, tx, 0}.mul(tm)
+					}
+				}
+			}
+		case "Do":
+			if len(op.args) == 1 && op.args[0].kind == operandName {
+				in.doXObject(src, i, op.args[0].name, gs.ctm, depth)
+			}
+		case "BI":
+			in.page.images = append(in.page.images, imageDraw{
+				src:    src,
+				op:     i,
+				inline: true,
+				ctm:    gs.ctm,
+				bbox:   transformRect(rect{0, 0, 1, 1}, gs.ctm),
+			})
+		}
+	}
+}
+
+func opMatrix(op contentOp) matrix {
+	return matrix{op.num(0), op.num(1), op.num(2), op.num(3), op.num(4), op.num(5)}
+}
+
+// show registra los glifos de s y devuelve la matriz de texto tras el avance.
+func (in *contentInterpreter) show(src *contentSource, opIdx, elem int, s []byte, gs *graphicsState, tm matrix) matrix {
+	f := gs.font
+	off := 0
+	for _, code := range f.codes(s) {
+		w0 := f.width(code) / 1000
+		tx := w0*gs.fontSize + gs.charSpace
+		if f.codeLen == 1 && code == ' ' {
+			tx += gs.wordSpace
+		}
+		tx *= gs.hScale
+
+		trm := matrix{gs.fontSize * gs.hScale, 0, 0, gs.fontSize, 0, gs.rise}.mul(tm).mul(gs.ctm)
+		g := textGlyph{
+			text: f.text(code),
+			bbox: transformRect(rect{0, f.descent / 1000, w0, f.ascent / 1000}, trm),
+			size: math.Hypot(trm[2], trm[3]),
+			src:  src,
+			op:   opIdx,
+			elem: elem,
+			off:  off,
+			n:    f.codeLen,
+		}
+		g.x0, g.y0 = trm.apply(0, 0)
+		g.x1, g.y1 = trm.apply(w0, 0)
+		if d := gs.fontSize * gs.hScale; d != 0 {
+			g.adjust = -tx / d * 1000
+		}
+		in.page.glyphs = append(in.page.glyphs, g)
+
+		tm = matrix{1, 0, 0, 1, tx, 0}.mul(tm)
+		off += f.codeLen
+	}
+	return tm
+}
+
+func (in *contentInterpreter) doXObject(src *contentSource, opIdx int, name string, ctm matrix, depth int) {
+	o := in.resource(src.resources, "XObject", name)
+	if o == nil {
+		return
+	}
+	var ref *pdftypes.IndirectRef
+	if ir, ok := o.(pdftypes.IndirectRef); ok {
+		ref = &ir
+	}
+	sd, _, err := in.xrt.DereferenceStreamDict(o)
+	if err != nil || sd == nil {
+		return
+	}
+
+	switch st := sd.Subtype(); {
+	case st != nil && *st == "Image":
+		in.page.images = append(in.page.images, imageDraw{
+			src:  src,
+			op:   opIdx,
+			name: name,
+			ref:  ref,
+			ctm:  ctm,
+			bbox: transformRect(rect{0, 0, 1, 1}, ctm),
+		})
+	case st != nil && *st == "Form":
+		if depth >= maxFormDepth {
+			return
+		}
+		if err := sd.Decode(); err != nil {
+			return
+		}
+		ops, err := parseContent(sd.Content)
+		if err != nil {
+			return
+		}
+		resources, _ := in.xrt.DereferenceDict(sd.Dict["Resources"])
+		if resources == nil {
+			resources = src.resources
+		}
+		fm := identityMatrix
+		if arr, err := in.xrt.DereferenceArray(sd.Dict["Matrix"]); err == nil && len(arr) == 6 {
+			for k := range fm {
+				fm[k], _ = in.xrt.DereferenceNumber(arr[k])
+			}
+		}
+
+		child := &contentSource{
+			ops:       ops,
+			resources: resources,
+			parent:    src,
+			parentOp:  opIdx,
+			formRef:   ref,
+			form:      sd,
+		}
+		in.page.sources = append(in.page.sources, child)
+		in.run(child, fm.mul(ctm), depth+1)
+	}
+}
+
+// glyphSpan relaciona un tramo del texto de la página con el glifo que lo produce.
+// glyph es -1 para espacios y saltos de línea inferidos.
+type glyphSpan struct {
+	start, end int
+	glyph      int
+}
+
+// pageText reconstruye el texto de la página en orden de contenido, infiriendo
+// espacios y saltos de línea a partir de la geometría de los glifos.
+func pageText(glyphs []textGlyph) (string, []glyphSpan) {
+	var sb strings.Builder
+	var spans []glyphSpan
+	prev := -1
+
+	for i, g := range glyphs {
+		if g.text == "" {
+			continue
+		}
+		if prev >= 0 {
+			p := glyphs[prev]
+			size := math.Max(p.size, g.size)
+			lastSpace := strings.HasSuffix(p.text, " ")
+			switch {
+			case math.Abs(g.y0-p.y1) > size*0.5:
+				spans = append(spans, glyphSpan{sb.Len(), sb.Len() + 1, -1})
+				sb.WriteByte('\n')
+			case !lastSpace && !startsWithSpace(g.text) && g.x0-p.x1 > size*0.2:
+				spans = append(spans, glyphSpan{sb.Len(), sb.Len() + 1, -1})
+				sb.WriteByte(' ')
+			}
+		}
+		spans = append(spans, glyphSpan{sb.Len(), sb.Len() + len(g.text), i})
+		sb.WriteString(g.text)
+		prev = i
+	}
+	return sb.String(), spans
+}
+
+func startsWithSpace(s string) bool {
+	for _, r := range s {
+		return unicode.IsSpace(r)
+	}
+	return false
+}
+
+// glyphsInRange devuelve los índices de glifo que producen texto dentro de [start, end).
+func glyphsInRange(spans []glyphSpan, start, end int) []int {
+	var out []int
+	for _, s := range spans {
+		if s.glyph >= 0 && s.start < end && s.end > start {
+			out = append(out, s.glyph)
+		}
+	}
+	return out
+}
+
+// lineRects agrupa las cajas de los glifos indicados en un rectángulo por línea.
+func lineRects(glyphs []textGlyph, idx []int) []rect {
+	var out []rect
+	for _, i := range idx {
+		b := glyphs[i].bbox
+		if n := len(out); n > 0 {
+			last := out[n-1]
+			overlapY := math.Min(last.ury, b.ury) - math.Max(last.lly, b.lly)
+			if overlapY > 0.5*math.Min(last.height(), b.height()) {
+				out[n-1] = last.union(b)
+				continue
+			}
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// textMatch es una coincidencia sobre el texto reconstruido de una página.
+type textMatch struct {
+	start, end int
+	text       string
+	glyphs     []int
+	rects      []rect
+}
+
+// findTextMatches busca re en el texto de la página y resuelve los glifos y
+// rectángulos (uno por línea) de cada coincidencia.
+func findTextMatches(glyphs []textGlyph, text string, spans []glyphSpan, re *regexp.Regexp) []textMatch {
+	var out []textMatch
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		idx := glyphsInRange(spans, loc[0], loc[1])
+		if len(idx) == 0 {
+			continue
+		}
+		out = append(out, textMatch{
+			start:  loc[0],
+			end:    loc[1],
+			text:   text[loc[0]:loc[1]],
+			glyphs: idx,
+			rects:  lineRects(glyphs, idx),
+		})
+	}
+	return out
+}
+
+func rectsToTypes(rs []rect) []types.Rect {
+	out := make([]types.Rect, len(rs))
+	for i, r := range rs {
+		out[i] = r.toTypes()
+	}
+	return out
+}
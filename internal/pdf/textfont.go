@@ -0,0 +1,537 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// textFont contiene lo necesario para convertir códigos de un string PDF
+// en texto Unicode y en anchos de glifo.
+type textFont struct {
+	name         string
+	composite    bool
+	codeLen      int
+	widths       map[int]float64 // unidades de glifo (1/1000 em)
+	defaultWidth float64
+	widthScale   float64 // Type3: FontMatrix convertido a 1/1000
+	toUnicode    map[int]string
+	encoding     *[256]rune
+	ucs2         bool // CMap predefinido con códigos UCS-2
+	coreName     string
+	ascent       float64
+	descent      float64
+}
+
+// fontCache evita recargar el mismo diccionario de fuente en cada página.
+type fontCache struct {
+	xrt   *model.XRefTable
+	fonts map[int]*textFont
+}
+
+func newFontCache(xrt *model.XRefTable) *fontCache {
+	return &fontCache{xrt: xrt, fonts: make(map[int]*textFont)}
+}
+
+// fallbackFont se usa cuando un Tf referencia una fuente inexistente.
+var fallbackFont = &textFont{name: "unknown", codeLen: 1, defaultWidth: 500, widthScale: 1, ascent: 800, descent: -200, encoding: &winAnsiRunes}
+
+// load devuelve la fuente para el objeto o (normalmente una referencia indirecta).
+func (fc *fontCache) load(o pdftypes.Object) *textFont {
+	objNr := -1
+	if ir, ok := o.(pdftypes.IndirectRef); ok {
+		objNr = ir.ObjectNumber.Value()
+		if f, ok := fc.fonts[objNr]; ok {
+			return f
+		}
+	}
+
+	d, err := fc.xrt.DereferenceDict(o)
+	if err != nil || d == nil {
+		return fallbackFont
+	}
+
+	f := fc.parseFont(d)
+	if objNr >= 0 {
+		fc.fonts[objNr] = f
+	}
+	return f
+}
+
+func (fc *fontCache) parseFont(d pdftypes.Dict) *textFont {
+	f := &textFont{
+		codeLen:    1,
+		widths:     make(map[int]float64),
+		widthScale: 1,
+		ascent:     800,
+		descent:    -200,
+	}
+
+	if bf := d.NameEntry("BaseFont"); bf != nil {
+		f.name = *bf
+	}
+	subtype := ""
+	if st := d.NameEntry("Subtype"); st != nil {
+		subtype = *st
+	}
+
+	var descriptor pdftypes.Dict
+
+	if subtype == "Type0" {
+		f.composite = true
+		f.codeLen = 2
+		f.defaultWidth = 1000
+		if enc := d.NameEntry("Encoding"); enc != nil && (strings.Contains(*enc, "UCS2") || strings.Contains(*enc, "UTF16")) {
+			f.ucs2 = true
+		}
+		if arr, err := fc.xrt.DereferenceArray(d["DescendantFonts"]); err == nil && len(arr) > 0 {
+			if cid, err := fc.xrt.DereferenceDict(arr[0]); err == nil && cid != nil {
+				if dw, err := fc.xrt.DereferenceNumber(cid["DW"]); err == nil && cid["DW"] != nil {
+					f.defaultWidth = dw
+				}
+				fc.parseCIDWidths(cid["W"], f.widths)
+				descriptor, _ = fc.xrt.DereferenceDict(cid["FontDescriptor"])
+			}
+		}
+	} else {
+		first := 0
+		if fcObj, ok := d.Find("FirstChar"); ok {
+			if n, err := fc.xrt.DereferenceNumber(fcObj); err == nil {
+				first = int(n)
+			}
+		}
+		if arr, err := fc.xrt.DereferenceArray(d["Widths"]); err == nil {
+			for i, o := range arr {
+				if w, err := fc.xrt.DereferenceNumber(o); err == nil {
+					f.widths[first+i] = w
+				}
+			}
+		}
+		descriptor, _ = fc.xrt.DereferenceDict(d["FontDescriptor"])
+		if descriptor != nil {
+			if mw, err := fc.xrt.DereferenceNumber(descriptor["MissingWidth"]); err == nil && descriptor["MissingWidth"] != nil {
+				f.defaultWidth = mw
+			}
+		}
+
+		core := coreFontName(f.name)
+		if len(f.widths) == 0 && core != "" {
+			f.coreName = core
+		}
+
+		if subtype == "Type3" {
+			if fm, err := fc.xrt.DereferenceArray(d["FontMatrix"]); err == nil && len(fm) == 6 {
+				if a, err := fc.xrt.DereferenceNumber(fm[0]); err == nil && a != 0 {
+					f.widthScale = a * 1000
+				}
+			}
+		}
+
+		f.encoding = fc.parseSimpleEncoding(d, core)
+		if f.defaultWidth == 0 && len(f.widths) == 0 && f.coreName == "" {
+			f.defaultWidth = 500
+		}
+	}
+
+	if descriptor != nil {
+		if a, err := fc.xrt.DereferenceNumber(descriptor["Ascent"]); err == nil && a > 0 {
+			f.ascent = a
+		}
+		if dsc, err := fc.xrt.DereferenceNumber(descriptor["Descent"]); err == nil && dsc < 0 {
+			f.descent = dsc
+		}
+	} else if m, ok := coreFontAscent[strings.SplitN(f.coreName, "-", 2)[0]]; ok {
+		// La FontBBox de las fuentes estándar es mucho más alta que el texto real;
+		// se usan Ascender/Descender de los AFM para no invadir líneas vecinas.
+		f.ascent, f.descent = m[0], m[1]
+	}
+
+	if tu, _, err := fc.xrt.DereferenceStreamDict(d["ToUnicode"]); err == nil && tu != nil {
+		if err := tu.Decode(); err == nil {
+			m, codeLen := parseToUnicodeCMap(tu.Content)
+			f.toUnicode = m
+			if f.composite && codeLen > 0 {
+				f.codeLen = codeLen
+			}
+		}
+	}
+
+	return f
+}
+
+// parseCIDWidths interpreta el array W de una fuente CID:
+// [c [w1 w2 ...]] o [cfirst clast w].
+func (fc *fontCache) parseCIDWidths(o pdftypes.Object, widths map[int]float64) {
+	arr, err := fc.xrt.DereferenceArray(o)
+	if err != nil || arr == nil {
+		return
+	}
+	for i := 0; i < len(arr); {
+		start, err := fc.xrt.DereferenceNumber(arr[i])
+		if err != nil || i+1 >= len(arr) {
+			return
+		}
+		next, _ := fc.xrt.Dereference(arr[i+1])
+		if ws, ok := next.(pdftypes.Array); ok {
+			for j, wo := range ws {
+				if w, err := fc.xrt.DereferenceNumber(wo); err == nil {
+					widths[int(start)+j] = w
+				}
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(arr) {
+			return
+		}
+		end, err1 := fc.xrt.DereferenceNumber(arr[i+1])
+		w, err2 := fc.xrt.DereferenceNumber(arr[i+2])
+		if err1 != nil || err2 != nil {
+			return
+		}
+		for c := int(start); c <= int(end) && c-int(start) < 65536; c++ {
+			widths[c] = w
+		}
+		i += 3
+	}
+}
+
+func (fc *fontCache) parseSimpleEncoding(d pdftypes.Dict, core string) *[256]rune {
+	base := &standardRunes
+	if core == "Symbol" || core == "ZapfDingbats" {
+		base = &latin1Runes
+	}
+
+	o, _ := fc.xrt.Dereference(d["Encoding"])
+	var differences pdftypes.Array
+
+	switch enc := o.(type) {
+	case pdftypes.Name:
+		base = baseEncoding(enc.Value(), base)
+	case pdftypes.Dict:
+		if be := enc.NameEntry("BaseEncoding"); be != nil {
+			base = baseEncoding(*be, base)
+		}
+		differences, _ = fc.xrt.DereferenceArray(enc["Differences"])
+	}
+
+	if len(differences) == 0 {
+		return base
+	}
+
+	table := *base
+	code := 0
+	for _, item := range differences {
+		item, _ = fc.xrt.Dereference(item)
+		switch v := item.(type) {
+		case pdftypes.Integer:
+			code = v.Value()
+		case pdftypes.Float:
+			code = int(v.Value())
+		case pdftypes.Name:
+			if code >= 0 && code < 256 {
+				table[code] = glyphNameToRune(v.Value())
+			}
+			code++
+		}
+	}
+	return &table
+}
+
+func baseEncoding(name string, def *[256]rune) *[256]rune {
+	switch name {
+	case "WinAnsiEncoding":
+		return &winAnsiRunes
+	case "MacRomanEncoding":
+		return &macRomanRunes
+	case "StandardEncoding":
+		return &standardRunes
+	}
+	return def
+}
+
+// codes divide un string PDF en códigos de carácter.
+func (f *textFont) codes(s []byte) []int {
+	if f.codeLen == 1 {
+		out := make([]int, len(s))
+		for i, c := range s {
+			out[i] = int(c)
+		}
+		return out
+	}
+	out := make([]int, 0, len(s)/2+1)
+	for i := 0; i < len(s); i += f.codeLen {
+		c := 0
+		for j := 0; j < f.codeLen; j++ {
+			c <<= 8
+			if i+j < len(s) {
+				c |= int(s[i+j])
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// width devuelve el ancho del código en unidades de glifo (1/1000 em).
+func (f *textFont) width(code int) float64 {
+	if w, ok := f.widths[code]; ok {
+		return w * f.widthScale
+	}
+	if f.coreName != "" && code < 256 {
+		return float64(font.CharWidth(f.coreName, rune(code)))
+	}
+	return f.defaultWidth * f.widthScale
+}
+
+// text devuelve el texto Unicode del código.
+func (f *textFont) text(code int) string {
+	if s, ok := f.toUnicode[code]; ok {
+		return s
+	}
+	if f.ucs2 {
+		return string(rune(code))
+	}
+	if !f.composite && f.encoding != nil && code < 256 {
+		if r := f.encoding[code]; r != 0 {
+			return string(r)
+		}
+	}
+	return ""
+}
+
+// coreFontAscent contiene Ascender y Descender de los AFM de las familias estándar.
+var coreFontAscent = map[string][2]float64{
+	"Helvetica": {718, -207},
+	"Times":     {683, -217},
+	"Courier":   {629, -157},
+}
+
+// coreFontName normaliza BaseFont a uno de los 14 tipos estándar si es posible.
+func coreFontName(baseFont string) string {
+	name := baseFont
+	if i := strings.IndexByte(name, '+'); i == 6 {
+		name = name[i+1:]
+	}
+	if font.IsCoreFont(name) {
+		return name
+	}
+
+	lower := strings.ToLower(strings.ReplaceAll(name, " ", ""))
+	bold := strings.Contains(lower, "bold")
+	italic := strings.Contains(lower, "italic") || strings.Contains(lower, "oblique")
+
+	switch {
+	case strings.HasPrefix(lower, "arial"), strings.HasPrefix(lower, "helvetica"):
+		return coreVariant("Helvetica", bold, italic, "Oblique")
+	case strings.HasPrefix(lower, "times"):
+		if !bold && !italic {
+			return "Times-Roman"
+		}
+		return coreVariant("Times", bold, italic, "Italic")
+	case strings.HasPrefix(lower, "courier"):
+		return coreVariant("Courier", bold, italic, "Oblique")
+	case strings.HasPrefix(lower, "symbol"):
+		return "Symbol"
+	case strings.HasPrefix(lower, "zapfdingbats"):
+		return "ZapfDingbats"
+	}
+	return ""
+}
+
+func coreVariant(family string, bold, italic bool, slant string) string {
+	switch {
+	case bold && italic:
+		return family + "-Bold" + slant
+	case bold:
+		return family + "-Bold"
+	case italic:
+		return family + "-" + slant
+	}
+	return family
+}
+
+// parseToUnicodeCMap interpreta las secciones bfchar/bfrange de un CMap ToUnicode.
+// Devuelve el mapa código -> texto y la longitud de código del codespace (0 si no consta).
+func parseToUnicodeCMap(data []byte) (map[int]string, int) {
+	m := make(map[int]string)
+	codeLen := 0
+
+	ops, err := parseContent(data)
+	if err != nil {
+		return m, 0
+	}
+
+	for _, op := range ops {
+		switch op.op {
+		case "endcodespacerange":
+			if len(op.args) >= 1 && op.args[0].kind == operandString {
+				codeLen = len(op.args[0].str)
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(op.args); i += 2 {
+				src, dst := op.args[i], op.args[i+1]
+				if src.kind != operandString {
+					continue
+				}
+				if dst.kind == operandString {
+					m[bytesToCode(src.str)] = utf16BEToString(dst.str)
+				} else if dst.kind == operandName {
+					m[bytesToCode(src.str)] = string(glyphNameToRune(dst.name))
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(op.args); i += 3 {
+				lo, hi, dst := op.args[i], op.args[i+1], op.args[i+2]
+				if lo.kind != operandString || hi.kind != operandString {
+					continue
+				}
+				from, to := bytesToCode(lo.str), bytesToCode(hi.str)
+				if to < from || to-from > 65535 {
+					continue
+				}
+				switch dst.kind {
+				case operandString:
+					base := []rune(utf16BEToString(dst.str))
+					if len(base) == 0 {
+						continue
+					}
+					for c := from; c <= to; c++ {
+						r := append([]rune{}, base...)
+						r[len(r)-1] += rune(c - from)
+						m[c] = string(r)
+					}
+				case operandArray:
+					for j, item := range dst.arr {
+						if from+j > to {
+							break
+						}
+						if item.kind == operandString {
+							m[from+j] = utf16BEToString(item.str)
+						}
+					}
+				}
+			}
+		}
+	}
+	return m, codeLen
+}
+
+func bytesToCode(b []byte) int {
+	c := 0
+	for _, x := range b {
+		c = c<<8 | int(x)
+	}
+	return c
+}
+
+func utf16BEToString(b []byte) string {
+	if len(b)%2 == 1 {
+		b = append(b, 0)
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return string(utf16.Decode(u))
+}
+
+// glyphNameToRune traduce un nombre de glifo Adobe a su carácter Unicode.
+func glyphNameToRune(name string) rune {
+	if r, ok := glyphNames[name]; ok {
+		return r
+	}
+	if len(name) == 1 {
+		return rune(name[0])
+	}
+	if strings.HasPrefix(name, "uni") && len(name) >= 7 {
+		if v, err := strconv.ParseUint(name[3:7], 16, 32); err == nil {
+			return rune(v)
+		}
+	}
+	if strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7 {
+		if v, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return rune(v)
+		}
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		return glyphNameToRune(name[:i])
+	}
+	return 0
+}
+
+// winAnsiGlyphNames son los nombres de glifo de WinAnsiEncoding desde el código 32.
+var winAnsiGlyphNames = strings.Fields(`space exclam quotedbl numbersign dollar percent ampersand quotesingle
+parenleft parenright asterisk plus comma hyphen period slash zero one two three four five six seven eight nine
+colon semicolon less equal greater question at A B C D E F G H I J K L M N O P Q R S T U V W X Y Z
+bracketleft backslash bracketright asciicircum underscore grave a b c d e f g h i j k l m n o p q r s t u v w x y z
+braceleft bar braceright asciitilde - Euro - quotesinglbase florin quotedblbase ellipsis dagger daggerdbl circumflex
+perthousand Scaron guilsinglleft OE - Zcaron - - quoteleft quoteright quotedblleft quotedblright bullet endash emdash
+tilde trademark scaron guilsinglright oe - zcaron Ydieresis nbspace exclamdown cent sterling currency yen brokenbar
+section dieresis copyright ordfeminine guillemotleft logicalnot sfthyphen registered macron degree plusminus
+twosuperior threesuperior acute mu paragraph periodcentered cedilla onesuperior ordmasculine guillemotright
+onequarter onehalf threequarters questiondown Agrave Aacute Acircumflex Atilde Adieresis Aring AE Ccedilla Egrave
+Eacute Ecircumflex Edieresis Igrave Iacute Icircumflex Idieresis Eth Ntilde Ograve Oacute Ocircumflex Otilde
+Odieresis multiply Oslash Ugrave Uacute Ucircumflex Udieresis Yacute Thorn germandbls agrave aacute acircumflex
+atilde adieresis aring ae ccedilla egrave eacute ecircumflex edieresis igrave iacute icircumflex idieresis eth
+ntilde ograve oacute ocircumflex otilde odieresis divide oslash ugrave uacute ucircumflex udieresis yacute thorn
+ydieresis`)
+
+// win1252High son los caracteres de Windows-1252 en 0x80-0x9F.
+const win1252High = "€�‚ƒ„…†‡ˆ‰Š‹Œ�Ž��‘’“”•–—˜™š›œ�žŸ"
+
+// macRomanHigh son los caracteres de MacRomanEncoding en 0x80-0xFF.
+const macRomanHigh = "ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø" +
+	"¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ"
+
+var (
+	latin1Runes   [256]rune
+	winAnsiRunes  [256]rune
+	macRomanRunes [256]rune
+	standardRunes [256]rune
+	glyphNames    = map[string]rune{
+		"fi": 'ﬁ', "fl": 'ﬂ', "ff": 'ﬀ', "ffi": 'ﬃ', "ffl": 'ﬄ',
+		"dotlessi": 'ı', "minus": '−', "fraction": '⁄', "Lslash": 'Ł', "lslash": 'ł',
+		"space": ' ', "nbspace": ' ', "sfthyphen": '­', "mu": 'µ',
+	}
+)
+
+func init() {
+	for i := 0; i < 256; i++ {
+		latin1Runes[i] = rune(i)
+		winAnsiRunes[i] = rune(i)
+	}
+	for i, r := range []rune(win1252High) {
+		if r != '�' {
+			winAnsiRunes[0x80+i] = r
+		} else {
+			winAnsiRunes[0x80+i] = 0
+		}
+	}
+	for i := 0; i < 128; i++ {
+		macRomanRunes[i] = rune(i)
+	}
+	for i, r := range []rune(macRomanHigh) {
+		macRomanRunes[0x80+i] = r
+	}
+
+	// StandardEncoding coincide con ASCII salvo por las comillas.
+	for i := 0; i < 128; i++ {
+		standardRunes[i] = rune(i)
+	}
+	standardRunes['\''] = '’'
+	standardRunes['`'] = '‘'
+
+	for i, name := range winAnsiGlyphNames {
+		if name == "-" {
+			continue
+		}
+		if _, ok := glyphNames[name]; !ok {
+			glyphNames[name] = winAnsiRunes[32+i]
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// Categorías de problemas de validación.
+const (
+	ProblemHeader     = "header"
+	ProblemXRef       = "xref"
+	ProblemTrailer    = "trailer"
+	ProblemEncryption = "encryption"
+	ProblemObject     = "object"
+	ProblemStructure  = "structure"
+)
+
+// Validate valida el PDF en modo estricto y relajado y analiza la xref y el trailer.
+// A diferencia de ValidateFile no falla si el PDF es inválido: el resultado se
+// devuelve en el informe. Solo devuelve error si el archivo no se puede leer o
+// ctx se cancela.
+func (p *Processor) Validate(ctx context.Context, inputPath string) (*types.ValidationReport, error) {
+	p.logger.Debug("validating PDF",
+		slog.String("input", inputPath))
+
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	raw := scanRawStructure(data)
+	report := &types.ValidationReport{
+		InputPath:     inputPath,
+		FileSize:      int64(len(data)),
+		HeaderVersion: raw.headerVersion,
+		PDFVersion:    raw.headerVersion,
+		Encrypted:     raw.encrypted,
+		XRef:          xrefHealth(raw),
+	}
+
+	var strictVersion, relaxedVersion string
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	report.Strict, strictVersion = validationPass(data, model.ValidationStrict)
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	report.Relaxed, relaxedVersion = validationPass(data, model.ValidationRelaxed)
+	if strictVersion != "" {
+		report.PDFVersion = strictVersion
+	} else if relaxedVersion != "" {
+		report.PDFVersion = relaxedVersion
+	}
+
+	report.RelaxedOnly = report.Relaxed.Valid && !report.Strict.Valid
+	switch {
+	case report.Strict.Valid:
+		report.RecommendedMode = "strict"
+	case report.Relaxed.Valid:
+		report.RecommendedMode = "relaxed"
+	}
+
+	report.RepairNeeded = !report.Relaxed.Valid || !report.XRef.Healthy
+	report.RepairPossible, report.RepairReason = repairAssessment(raw, report)
+
+	p.logger.Debug("PDF validation complete",
+		slog.Bool("strict", report.Strict.Valid),
+		slog.Bool("relaxed", report.Relaxed.Valid),
+		slog.Bool("xref_healthy", report.XRef.Healthy))
+
+	return report, nil
+}
+
+// validationPass lee y valida data con el modo indicado. Devuelve también la
+// versión efectiva del PDF cuando se ha podido leer.
+func validationPass(data []byte, mode int) (pass types.ValidationPass, version string) {
+	pass.Mode = "strict"
+	if mode == model.ValidationRelaxed {
+		pass.Mode = "relaxed"
+	}
+	pass.Problems = []types.ValidationProblem{}
+
+	defer func() {
+		if r := recover(); r != nil {
+			pass.Valid = false
+			pass.Problems = append(pass.Problems, types.ValidationProblem{
+				Category: ProblemStructure,
+				Message:  fmt.Sprintf("parser failure: %v", r),
+			})
+		}
+	}()
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = mode
+
+	pdfCtx, err := api.ReadContext(bytes.NewReader(data), conf)
+	if err != nil {
+		pass.Problems = append(pass.Problems, problemFromError(err, 0))
+		return pass, ""
+	}
+	version = pdfCtx.XRefTable.VersionString()
+
+	if err := api.ValidateContext(pdfCtx); err != nil {
+		pass.Problems = append(pass.Problems, problemFromError(err, pdfCtx.XRefTable.CurObj))
+		return pass, version
+	}
+
+	pass.Valid = true
+	pass.PageCount = pdfCtx.PageCount
+	return pass, version
+}
+
+// problemFromError clasifica un error de pdfcpu por su mensaje.
+func problemFromError(err error, objNr int) types.ValidationProblem {
+	msg := strings.TrimSpace(strings.TrimPrefix(err.Error(), "pdfcpu: "))
+	lower := strings.ToLower(msg)
+
+	category := ProblemStructure
+	switch {
+	case strings.Contains(lower, "xref"), strings.Contains(lower, "startxref"):
+		category = ProblemXRef
+	case strings.Contains(lower, "trailer"):
+		category = ProblemTrailer
+	case strings.Contains(lower, "header"), strings.Contains(lower, "version"):
+		category = ProblemHeader
+	case strings.Contains(lower, "encrypt"), strings.Contains(lower, "decrypt"), strings.Contains(lower, "password"):
+		category = ProblemEncryption
+	case objNr > 0, strings.Contains(lower, "validate"), strings.Contains(lower, "dict"), strings.Contains(lower, "stream"):
+		category = ProblemObject
+	}
+
+	return types.ValidationProblem{Category: category, Object: objNr, Message: msg}
+}
+
+func xrefHealth(raw rawStructure) types.XRefHealth {
+	h := types.XRefHealth{
+		StartXRefFound: raw.startXRef >= 0,
+		StartXRefValid: raw.startXRefValid,
+		XRefStream:     raw.xrefStream,
+		TrailerFound:   raw.trailerFound,
+		RootFound:      raw.trailerRoot,
+		EOFMarkers:     raw.eofMarkers,
+		Truncated:      raw.truncated,
+		ObjectsFound:   len(raw.objects),
+		Problems:       []types.ValidationProblem{},
+	}
+	if h.StartXRefFound {
+		h.StartXRefOffset = raw.startXRef
+	}
+
+	add := func(category, msg string, objNr int) {
+		h.Problems = append(h.Problems, types.ValidationProblem{Category: category, Object: objNr, Message: msg})
+	}
+	if raw.headerVersion == "" {
+		add(ProblemHeader, "missing %PDF header", 0)
+	}
+	if !h.StartXRefFound {
+		add(ProblemXRef, "startxref not found", 0)
+	} else if !h.StartXRefValid {
+		add(ProblemXRef, fmt.Sprintf("startxref offset %d does not point to a cross-reference section", raw.startXRef), 0)
+	}
+	if !h.TrailerFound {
+		add(ProblemTrailer, "trailer not found", 0)
+	} else if !h.RootFound {
+		add(ProblemTrailer, "trailer has no /Root entry", 0)
+	}
+	if h.Truncated {
+		add(ProblemStructure, "file does not end with %%EOF (possibly truncated)", 0)
+	}
+	for _, o := range raw.objects {
+		if !o.complete {
+			add(ProblemObject, "object has no endobj", o.num)
+		}
+	}
+
+	h.Healthy = len(h.Problems) == 0
+	return h
+}
+
+// repairAssessment indica si Repair podría reconstruir el archivo.
+func repairAssessment(raw rawStructure, report *types.ValidationReport) (bool, string) {
+	switch {
+	case !report.RepairNeeded:
+		return false, "no repair needed"
+	case raw.encrypted:
+		return false, "encrypted files cannot be rebuilt"
+	case raw.headerVersion == "":
+		return false, "no PDF header found"
+	case len(raw.objects) == 0:
+		return false, "no objects found"
+	case len(raw.catalogs) == 0:
+		return false, "no document catalog found"
+	}
+	return true, fmt.Sprintf("%d objects and a document catalog can be recovered by scanning", len(raw.objects))
+}
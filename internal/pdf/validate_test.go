@@ -0,0 +1,127 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestValidateHealthyPDF(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestPDF(t, dir, "ok.pdf", []string{"page one", "page two"})
+
+	report, err := newTestProcessor().Validate(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if !report.Strict.Valid || !report.Relaxed.Valid {
+		t.Fatalf("expected valid in both modes, got strict=%+v relaxed=%+v", report.Strict, report.Relaxed)
+	}
+	if report.Strict.PageCount != 2 {
+		t.Errorf("PageCount = %d, want 2", report.Strict.PageCount)
+	}
+	if !report.XRef.Healthy {
+		t.Errorf("expected healthy xref, got problems %+v", report.XRef.Problems)
+	}
+	if report.RecommendedMode != "strict" || report.RelaxedOnly {
+		t.Errorf("RecommendedMode = %q, RelaxedOnly = %v", report.RecommendedMode, report.RelaxedOnly)
+	}
+	if report.RepairNeeded || report.RepairPossible {
+		t.Errorf("unexpected repair flags: needed=%v possible=%v", report.RepairNeeded, report.RepairPossible)
+	}
+	if report.HeaderVersion != "1.4" || report.PDFVersion == "" {
+		t.Errorf("versions = %q/%q", report.HeaderVersion, report.PDFVersion)
+	}
+}
+
+func TestValidateDamagedPDF(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestPDF(t, dir, "ok.pdf", []string{"hello"})
+	data, err := os.ReadFile(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		problem string
+	}{
+		{
+			name:    "bad startxref",
+			data:    regexp.MustCompile(`startxref\n\d+`).ReplaceAll(data, []byte("startxref\n12")),
+			problem: ProblemXRef,
+		},
+		{
+			name:    "truncated",
+			data:    data[:bytes.Index(data, []byte("xref\n"))],
+			problem: ProblemXRef,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "damaged.pdf")
+			if err := os.WriteFile(path, tt.data, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := newTestProcessor().Validate(context.Background(), path)
+			if err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+			if report.XRef.Healthy {
+				t.Fatal("expected unhealthy xref")
+			}
+			found := false
+			for _, pr := range report.XRef.Problems {
+				if pr.Category == tt.problem {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("no %s problem in %+v", tt.problem, report.XRef.Problems)
+			}
+			if !report.RepairNeeded || !report.RepairPossible {
+				t.Errorf("repair needed=%v possible=%v (%s)", report.RepairNeeded, report.RepairPossible, report.RepairReason)
+			}
+		})
+	}
+}
+
+func TestScanRawObjects(t *testing.T) {
+	data := []byte("%PDF-1.7\n" +
+		"1 0 obj\n<< /Type /Catalog >>\nendobj\n" +
+		"2 0 obj\n<< /Length 20 >>\nstream\n3 0 obj fake endobj\nendstream\nendobj\n" +
+		"4 0 obj\n<< /Broken true >>\n" +
+		"5 0 obj\n(last)\nendobj\n")
+
+	objs := scanRawObjects(data)
+	if len(objs) != 4 {
+		t.Fatalf("found %d objects, want 4: %+v", len(objs), objs)
+	}
+
+	want := []struct {
+		num      int
+		stream   bool
+		complete bool
+	}{{1, false, true}, {2, true, true}, {4, false, false}, {5, false, true}}
+	for i, w := range want {
+		o := objs[i]
+		if o.num != w.num || (o.streamStart >= 0) != w.stream || o.complete != w.complete {
+			t.Errorf("object %d = %+v, want %+v", i, o, w)
+		}
+	}
+
+	if got := string(data[objs[1].streamStart:objs[1].streamEnd]); got != "3 0 obj fake endobj\n" {
+		t.Errorf("stream data = %q", got)
+	}
+
+	raw := scanRawStructure(data)
+	if len(raw.catalogs) != 1 || raw.headerVersion != "1.7" || !raw.truncated || raw.trailerFound {
+		t.Errorf("unexpected raw structure: %+v", raw)
+	}
+}
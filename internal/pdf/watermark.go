@@ -0,0 +1,129 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	pdftypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+// Valores por defecto de las marcas de agua de texto.
+const (
+	defaultWatermarkFontSize = 48
+	defaultWatermarkOpacity  = 0.3
+)
+
+// validateWatermarkOptions comprueba las opciones sin necesidad del documento.
+func validateWatermarkOptions(opts types.WatermarkOptions) error {
+	if strings.TrimSpace(opts.Text) == "" {
+		return fmt.Errorf("watermark text is required")
+	}
+	if opts.FontSize < 0 {
+		return fmt.Errorf("invalid font size %d: must be positive", opts.FontSize)
+	}
+	if opts.Opacity < 0 || opts.Opacity > 1 {
+		return fmt.Errorf("invalid opacity %g: must be between 0 and 1", opts.Opacity)
+	}
+	if opts.Pages != "" {
+		if _, err := ParsePageSelection(opts.Pages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watermarkDescription traduce las opciones a la descripción de pdfcpu. La
+// escala absoluta 1 hace que el texto use el tamaño indicado en lugar de
+// ajustarse al ancho de la página.
+func watermarkDescription(opts types.WatermarkOptions) string {
+	size := opts.FontSize
+	if size == 0 {
+		size = defaultWatermarkFontSize
+	}
+	opacity := opts.Opacity
+	if opacity == 0 {
+		opacity = defaultWatermarkOpacity
+	}
+	desc := []string{
+		"font:Helvetica",
+		"points:" + strconv.Itoa(size),
+		"scale:1 abs",
+		"opacity:" + strconv.FormatFloat(opacity, 'f', -1, 64),
+		"fillcolor:0.5 0.5 0.5",
+	}
+	if opts.Rotation != nil {
+		desc = append(desc, "rotation:"+strconv.FormatFloat(*opts.Rotation, 'f', -1, 64))
+	}
+	return strings.Join(desc, ", ")
+}
+
+// Watermark añade una marca de agua de texto a las páginas seleccionadas
+// (todas si opts.Pages está vacío). Por defecto va debajo del contenido, en
+// diagonal, en gris y con opacidad 0.3; con OnTop se estampa encima, lo que
+// hace que también se vea sobre páginas escaneadas.
+func (p *Processor) Watermark(ctx context.Context, inputPath, outputPath string, opts types.WatermarkOptions) (_ *types.WatermarkResult, err error) {
+	p.logger.Debug("adding text watermark",
+		slog.String("input", inputPath),
+		slog.String("output", outputPath),
+		slog.String("pages", opts.Pages),
+		slog.Bool("on_top", opts.OnTop))
+
+	if err := validateWatermarkOptions(opts); err != nil {
+		return nil, err
+	}
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateFile(inputPath); err != nil {
+		return nil, err
+	}
+
+	pdfCtx, err := p.readContext(inputPath)
+	if err != nil {
+		p.logger.Error("failed to read PDF context", err)
+		return nil, err
+	}
+
+	var pages []int
+	if opts.Pages == "" {
+		for i := 1; i <= pdfCtx.PageCount; i++ {
+			pages = append(pages, i)
+		}
+	} else {
+		pages, err = parsePageSelection(opts.Pages, pdfCtx.PageCount, documentPageLabels(pdfCtx.XRefTable))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	wm, err := api.TextWatermark(opts.Text, watermarkDescription(opts), opts.OnTop, false, pdftypes.POINTS)
+	if err != nil {
+		return nil, fmt.Errorf("invalid watermark: %w", err)
+	}
+
+	if err := ensureOutputDir(outputPath); err != nil {
+		p.logger.Error("failed to create output directory", err)
+		return nil, err
+	}
+	if err := checkCancelled(ctx); err != nil {
+		return nil, err
+	}
+	defer guardOutput(ctx, outputPath).cleanup(&err)
+	if err := api.AddWatermarksFile(inputPath, outputPath, intsToPageSelectionSlice(pages), wm, p.newConfiguration()); err != nil {
+		p.logger.Error("failed to add watermark", err)
+		return nil, fmt.Errorf("failed to add watermark: %w", err)
+	}
+
+	return &types.WatermarkResult{
+		OutputPath:  outputPath,
+		TotalPages:  pdfCtx.PageCount,
+		Text:        opts.Text,
+		MarkedPages: pages,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package pdf
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scopweb/mcp-go-pdf-tools/internal/types"
+)
+
+func TestWatermark(t *testing.T) {
+	dir := t.TempDir()
+	in := writeTestPDF(t, dir, "in.pdf", []string{"First page", "Second page", "Third page"})
+	p := newTestProcessor()
+
+	t.Run("selected pages", func(t *testing.T) {
+		out := filepath.Join(dir, "out", "marked.pdf")
+		result, err := p.Watermark(context.Background(), in, out, types.WatermarkOptions{Text: "CONFIDENTIAL", Pages: "2-"})
+		if err != nil {
+			t.Fatalf("Watermark failed: %v", err)
+		}
+		if result.TotalPages != 3 || !reflect.DeepEqual(result.MarkedPages, []int{2, 3}) {
+			t.Errorf("result = %+v", result)
+		}
+		if err := p.ValidateFile(out); err != nil {
+			t.Fatalf("watermarked PDF does not validate: %v", err)
+		}
+		for page, want := range map[int]bool{1: false, 2: true, 3: true} {
+			text := extractTestText(t, out, page)
+			if strings.Contains(text, "CONFIDENTIAL") != want {
+				t.Errorf("page %d text = %q, watermark expected %v", page, text, want)
+			}
+		}
+	})
+
+	t.Run("stamp with options", func(t *testing.T) {
+		rotation := 0.0
+		out := filepath.Join(dir, "stamped.pdf")
+		opts := types.WatermarkOptions{Text: "DRAFT", FontSize: 24, Opacity: 1, Rotation: &rotation, OnTop: true}
+		if _, err := p.Watermark(context.Background(), in, out, opts); err != nil {
+			t.Fatalf("Watermark failed: %v", err)
+		}
+		if text := extractTestText(t, out, 1); !strings.Contains(text, "First page") || !strings.Contains(text, "DRAFT") {
+			t.Errorf("page text = %q", text)
+		}
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, opts := range []types.WatermarkOptions{
+			{Text: " "},
+			{Text: "x", Opacity: 1.5},
+			{Text: "x", FontSize: -1},
+			{Text: "x", Pages: "first"},
+		} {
+			if _, err := p.Watermark(context.Background(), in, filepath.Join(dir, "bad.pdf"), opts); err == nil {
+				t.Errorf("%+v: expected an error", opts)
+			}
+		}
+	})
+}
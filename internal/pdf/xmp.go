@@ -0,0 +1,191 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Espacios de nombres XMP usados por PDF/A.
+const (
+	nsRDF    = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsXML    = "http://www.w3.org/XML/1998/namespace"
+	nsDC     = "http://purl.org/dc/elements/1.1/"
+	nsXMP    = "http://ns.adobe.com/xap/1.0/"
+	nsPDF    = "http://ns.adobe.com/pdf/1.3/"
+	nsPDFAID = "http://www.aiim.org/pdfa/ns/id/"
+)
+
+var xmpPrefixes = map[string]string{
+	nsDC:     "dc",
+	nsXMP:    "xmp",
+	nsPDF:    "pdf",
+	nsPDFAID: "pdfaid",
+}
+
+// xmpInfoFields relaciona las entradas del diccionario Info con sus propiedades
+// XMP equivalentes (ISO 19005-1, 6.7.3).
+var xmpInfoFields = []struct {
+	info string
+	xmp  string
+	date bool
+}{
+	{"Title", "dc:title", false},
+	{"Author", "dc:creator", false},
+	{"Subject", "dc:description", false},
+	{"Keywords", "pdf:Keywords", false},
+	{"Creator", "xmp:CreatorTool", false},
+	{"Producer", "pdf:Producer", false},
+	{"CreationDate", "xmp:CreateDate", true},
+	{"ModDate", "xmp:ModifyDate", true},
+}
+
+// parseXMP extrae de un paquete XMP las propiedades de dc, xmp, pdf y pdfaid,
+// escritas como atributos de rdf:Description o como elementos. De los arrays
+// (rdf:Alt, rdf:Seq, rdf:Bag) toma la entrada x-default o la primera.
+func parseXMP(data []byte) (map[string]string, error) {
+	props := map[string]string{}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	var (
+		prop      string // propiedad abierta, p. ej. "dc:title"
+		depth     int    // profundidad dentro de la propiedad
+		items     int    // rdf:li vistos en la propiedad
+		capturing bool
+		text      strings.Builder
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XMP: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if prop != "" {
+				depth++
+				if t.Name.Space == nsRDF && t.Name.Local == "li" {
+					lang := ""
+					for _, a := range t.Attr {
+						if a.Name.Space == nsXML && a.Name.Local == "lang" {
+							lang = a.Value
+						}
+					}
+					capturing = items == 0 || lang == "x-default"
+					if capturing {
+						text.Reset()
+					}
+					items++
+				}
+				continue
+			}
+			if t.Name.Space == nsRDF && t.Name.Local == "Description" {
+				for _, a := range t.Attr {
+					if pfx, ok := xmpPrefixes[a.Name.Space]; ok {
+						props[pfx+":"+a.Name.Local] = strings.TrimSpace(a.Value)
+					}
+				}
+				continue
+			}
+			if pfx, ok := xmpPrefixes[t.Name.Space]; ok {
+				prop = pfx + ":" + t.Name.Local
+				depth, items, capturing = 0, 0, true
+				text.Reset()
+			}
+		case xml.CharData:
+			if prop != "" && capturing {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if prop == "" {
+				continue
+			}
+			if depth > 0 {
+				if t.Name.Space == nsRDF && t.Name.Local == "li" {
+					capturing = false
+				}
+				depth--
+				continue
+			}
+			if _, dup := props[prop]; !dup {
+				props[prop] = strings.TrimSpace(text.String())
+			}
+			prop = ""
+		}
+	}
+	return props, nil
+}
+
+// buildXMP genera un paquete XMP con la identificación PDF/A y las propiedades dadas.
+func buildXMP(props map[string]string, part, conformance string, metadataDate time.Time) []byte {
+	esc := func(s string) string {
+		var b strings.Builder
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"" + nsRDF + "\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"")
+	for _, ns := range []string{nsPDFAID, nsDC, nsXMP, nsPDF} {
+		fmt.Fprintf(&b, " xmlns:%s=\"%s\"", xmpPrefixes[ns], ns)
+	}
+	b.WriteString(">\n")
+	fmt.Fprintf(&b, "   <pdfaid:part>%s</pdfaid:part>\n", part)
+	fmt.Fprintf(&b, "   <pdfaid:conformance>%s</pdfaid:conformance>\n", conformance)
+
+	for _, f := range xmpInfoFields {
+		v, ok := props[f.xmp]
+		if !ok {
+			continue
+		}
+		switch f.xmp {
+		case "dc:title", "dc:description":
+			fmt.Fprintf(&b, "   <%s><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></%s>\n", f.xmp, esc(v), f.xmp)
+		case "dc:creator":
+			fmt.Fprintf(&b, "   <%s><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></%s>\n", f.xmp, esc(v), f.xmp)
+		default:
+			fmt.Fprintf(&b, "   <%s>%s</%s>\n", f.xmp, esc(v), f.xmp)
+		}
+	}
+	fmt.Fprintf(&b, "   <xmp:MetadataDate>%s</xmp:MetadataDate>\n", formatXMPDate(metadataDate))
+
+	b.WriteString("  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n")
+	// Relleno recomendado para que el XMP se pueda editar sin reescribir el archivo.
+	for i := 0; i < 20; i++ {
+		b.WriteString(strings.Repeat(" ", 99) + "\n")
+	}
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return []byte(b.String())
+}
+
+func formatXMPDate(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05Z07:00")
+}
+
+// parseXMPDate interpreta una fecha XMP (ISO 8601 con precisión variable).
+func parseXMPDate(s string) (time.Time, bool) {
+	for _, layout := range []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02T15:04",
+		"2006-01-02",
+		"2006-01",
+		"2006",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -1,5 +1,7 @@
 package types
 
+import "encoding/json"
+
 // PageRemovalMode define el modo de eliminación de páginas.
 type PageRemovalMode string
 
@@ -30,12 +32,13 @@ func ParseMode(s string) (PageRemovalMode, bool) {
 	}
 }
 
-// SplitResult contiene el resultado de una operación de división.
+// SplitResult contiene el resultado de una operación de división. ZipPath se
+// serializa como "zip", el nombre que ya usaba pdf_split.
 type SplitResult struct {
 	Files      []string `json:"files"`
 	TotalPages int      `json:"total_pages"`
 	OutputDir  string   `json:"output_dir"`
-	ZipPath    string   `json:"zip_path,omitempty"`
+	ZipPath    string   `json:"zip,omitempty"`
 	ZipB64     string   `json:"zip_b64,omitempty"`
 }
 
@@ -44,14 +47,63 @@ type PDFInfoResult struct {
 	TotalPages int   `json:"total_pages"`
 	SizeBytes  int64 `json:"size_bytes"`
 	Filename   string `json:"filename"`
+	Linearized bool   `json:"linearized"`
+}
+
+// CompressProfile es un perfil predefinido de compresión.
+type CompressProfile string
+
+const (
+	ProfileLossless CompressProfile = "lossless" // Sin pérdida: estructura, fuentes sin usar e imágenes duplicadas
+	ProfilePrint    CompressProfile = "print"    // JPEG calidad 85, máximo 300 DPI
+	ProfileEbook    CompressProfile = "ebook"    // JPEG calidad 75, máximo 150 DPI
+	ProfileScreen   CompressProfile = "screen"   // JPEG calidad 50, máximo 72 DPI
+	ProfileCustom   CompressProfile = "custom"   // Calidad y resolución indicadas por el usuario
+)
+
+// ParseCompressProfile convierte un string a CompressProfile.
+func ParseCompressProfile(s string) (CompressProfile, bool) {
+	switch p := CompressProfile(s); p {
+	case ProfileLossless, ProfilePrint, ProfileEbook, ProfileScreen, ProfileCustom:
+		return p, true
+	default:
+		return "", false
+	}
+}
+
+// CompressOptions contiene los parámetros de una compresión.
+// ImageQuality, MaxDPI y Grayscale sustituyen a los valores del perfil.
+type CompressOptions struct {
+	Profile      CompressProfile `json:"profile,omitempty"`
+	ImageQuality int             `json:"image_quality,omitempty"` // Calidad JPEG 1-100
+	MaxDPI       int             `json:"max_dpi,omitempty"`       // Resolución máxima de las imágenes
+	Grayscale    bool            `json:"grayscale,omitempty"`
+	Linearize    bool            `json:"linearize,omitempty"`
+}
+
+// CompressSavings desglosa por categoría los bytes ahorrados en una compresión.
+type CompressSavings struct {
+	Images          int64 `json:"images"`           // Recompresión JPEG, reducción de resolución y escala de grises
+	DuplicateImages int64 `json:"duplicate_images"` // Imágenes idénticas fusionadas
+	UnusedFonts     int64 `json:"unused_fonts"`     // Fuentes que ningún contenido usa
+	Structure       int64 `json:"structure"`        // Resto: streams, objetos y tabla xref
 }
 
 // CompressResult contiene el resultado de una compresión.
 type CompressResult struct {
-	OutputPath       string `json:"output_path"`
-	OriginalSize     int64  `json:"original_size"`
-	CompressedSize   int64  `json:"compressed_size"`
-	CompressionRatio float64 `json:"compression_ratio"`
+	OutputPath        string          `json:"output_path"`
+	Profile           CompressProfile `json:"profile"`
+	OriginalSize      int64           `json:"original_size"`
+	CompressedSize    int64           `json:"compressed_size"`
+	CompressionRatio  float64         `json:"compression_ratio"`
+	Savings           CompressSavings `json:"savings"`
+	ImagesReencoded   int             `json:"images_reencoded"`
+	ImagesDownsampled int             `json:"images_downsampled"`
+	ImagesGrayscale   int             `json:"images_grayscale"`
+	DuplicateImages   int             `json:"duplicate_images"`
+	FontsRemoved      int             `json:"fonts_removed"`
+	Linearized        bool            `json:"linearized,omitempty"`
+	Warnings          []string        `json:"warnings,omitempty"`
 }
 
 // RemovePagesResult contiene el resultado de eliminación de páginas.
@@ -64,12 +116,712 @@ type RemovePagesResult struct {
 	Mode           PageRemovalMode `json:"mode"`
 }
 
+// RotateResult contiene el resultado de girar páginas.
+type RotateResult struct {
+	OutputPath   string `json:"output_path"`
+	TotalPages   int    `json:"total_pages"`
+	Degrees      int    `json:"degrees"`
+	RotatedPages []int  `json:"rotated_pages"`
+}
+
+// WatermarkOptions configura Watermark.
+type WatermarkOptions struct {
+	Text     string   `json:"text"`
+	Pages    string   `json:"pages,omitempty"`     // selección de páginas; todas si está vacío
+	FontSize int      `json:"font_size,omitempty"` // puntos; 48 por defecto
+	Opacity  float64  `json:"opacity,omitempty"`   // de 0 a 1; 0.3 por defecto
+	Rotation *float64 `json:"rotation,omitempty"`  // grados; en diagonal si se omite
+	OnTop    bool     `json:"on_top,omitempty"`    // estampar encima del contenido
+}
+
+// WatermarkResult contiene el resultado de añadir una marca de agua.
+type WatermarkResult struct {
+	OutputPath  string `json:"output_path"`
+	TotalPages  int    `json:"total_pages"`
+	Text        string `json:"text"`
+	MarkedPages []int  `json:"marked_pages"`
+}
+
+// MergeOptions contiene los parámetros de una operación de merge.
+type MergeOptions struct {
+	Linearize bool `json:"linearize,omitempty"`
+}
+
 // MergeResult contiene el resultado de una operación de merge.
 type MergeResult struct {
 	OutputPath  string   `json:"output_path"`
 	InputFiles  []string `json:"input_files"`
 	InputCount  int      `json:"input_count"`
 	OutputSize  int64    `json:"output_size"`
+	Linearized  bool     `json:"linearized,omitempty"`
+}
+
+// LinearizeResult contiene el resultado de linealizar un PDF.
+type LinearizeResult struct {
+	OutputPath   string `json:"output_path"`
+	TotalPages   int    `json:"total_pages"`
+	OriginalSize int64  `json:"original_size"`
+	OutputSize   int64  `json:"output_size"`
+	Linearized   bool   `json:"linearized"`
+}
+
+// SizeReportOptions configura AnalyzeSize.
+type SizeReportOptions struct {
+	TopN          int  `json:"top_n,omitempty"`          // Número de objetos más grandes a listar (por defecto 10)
+	SkipEstimates bool `json:"skip_estimates,omitempty"` // No estimar el ahorro de cada perfil
+}
+
+// SizeCategory son los bytes del archivo atribuidos a una categoría.
+type SizeCategory struct {
+	Category string  `json:"category"`
+	Bytes    int64   `json:"bytes"`
+	Objects  int     `json:"objects"`
+	Percent  float64 `json:"percent"`
+}
+
+// SizeObject es uno de los objetos más grandes del archivo.
+type SizeObject struct {
+	Object      int    `json:"object"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	Bytes       int64  `json:"bytes"`
+	Pages       string `json:"pages,omitempty"` // Páginas que usan el objeto, p. ej. "1-3,7"
+}
+
+// ProfileEstimate es el resultado estimado de comprimir con un perfil.
+type ProfileEstimate struct {
+	Profile          CompressProfile `json:"profile"`
+	EstimatedSize    int64           `json:"estimated_size"`
+	EstimatedSavings int64           `json:"estimated_savings"`
+	SavingsRatio     float64         `json:"savings_ratio"`
+	Savings          CompressSavings `json:"savings"`
+	Error            string          `json:"error,omitempty"`
+}
+
+// SizeReport es el informe de pdf_size_report.
+type SizeReport struct {
+	InputPath  string            `json:"input_path"`
+	FileSize   int64             `json:"file_size"`
+	TotalPages int               `json:"total_pages"`
+	Categories []SizeCategory    `json:"categories"`
+	TopObjects []SizeObject      `json:"top_objects"`
+	Estimates  []ProfileEstimate `json:"estimates,omitempty"`
+	Warnings   []string          `json:"warnings,omitempty"`
+}
+
+// PDFALevel es un nivel de conformidad PDF/A (ISO 19005): parte y conformidad.
+type PDFALevel string
+
+const (
+	PDFA1B PDFALevel = "1b"
+	PDFA2B PDFALevel = "2b" // Nivel por defecto
+	PDFA2U PDFALevel = "2u"
+	PDFA3B PDFALevel = "3b"
+	PDFA3U PDFALevel = "3u"
+)
+
+// ParsePDFALevel convierte un string a PDFALevel. Vacío equivale a PDFA2B.
+func ParsePDFALevel(s string) (PDFALevel, bool) {
+	switch l := PDFALevel(s); l {
+	case "":
+		return PDFA2B, true
+	case PDFA1B, PDFA2B, PDFA2U, PDFA3B, PDFA3U:
+		return l, true
+	default:
+		return "", false
+	}
+}
+
+// PDFAViolation es una regla PDF/A que el documento no cumple.
+type PDFAViolation struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	Page        int    `json:"page,omitempty"`
+	Object      int    `json:"object,omitempty"`
+	Fixable     bool   `json:"fixable"` // ConvertPDFA puede corregirla
+}
+
+// PDFAReport es el resultado de comprobar la conformidad PDF/A de un documento.
+type PDFAReport struct {
+	InputPath    string          `json:"input_path"`
+	Level        PDFALevel       `json:"level"`
+	ClaimedLevel string          `json:"claimed_level,omitempty"` // pdfaid del XMP
+	Conformant   bool            `json:"conformant"`
+	Convertible  bool            `json:"convertible"` // todas las violaciones son corregibles
+	Violations   []PDFAViolation `json:"violations"`
+}
+
+// PDFAConvertResult contiene el resultado de una conversión a PDF/A.
+type PDFAConvertResult struct {
+	OutputPath   string          `json:"output_path"`
+	Level        PDFALevel       `json:"level"`
+	TotalPages   int             `json:"total_pages"`
+	OriginalSize int64           `json:"original_size"`
+	OutputSize   int64           `json:"output_size"`
+	Fixed        []PDFAViolation `json:"fixed"`
+	Warnings     []string        `json:"warnings,omitempty"`
+}
+
+// Estados de verificación de una firma.
+const (
+	SignatureValid     = "valid"     // íntegra, criptográficamente correcta y de confianza
+	SignatureUntrusted = "untrusted" // correcta, pero el certificado no encadena con el almacén de confianza
+	SignatureInvalid   = "invalid"   // contenido alterado o firma que no verifica
+	SignatureUnknown   = "unknown"   // formato no soportado o firma ilegible
+)
+
+// SignatureInfo describe una firma digital del documento y su verificación.
+type SignatureInfo struct {
+	FieldName            string   `json:"field_name,omitempty"`
+	Type                 string   `json:"type"` // approval, certification o document_timestamp
+	SubFilter            string   `json:"sub_filter"`
+	Page                 int      `json:"page,omitempty"`
+	Visible              bool     `json:"visible"`
+	SignerSubject        string   `json:"signer_subject,omitempty"`
+	SignerIssuer         string   `json:"signer_issuer,omitempty"`
+	SerialNumber         string   `json:"serial_number,omitempty"`
+	SigningTime          string   `json:"signing_time,omitempty"`
+	SigningTimeSource    string   `json:"signing_time_source,omitempty"` // cms o signature_dict
+	Reason               string   `json:"reason,omitempty"`
+	Location             string   `json:"location,omitempty"`
+	ContactInfo          string   `json:"contact_info,omitempty"`
+	ByteRange            []int64  `json:"byte_range"`
+	CoveredBytes         int64    `json:"covered_bytes"`
+	CoversWholeDocument  bool     `json:"covers_whole_document"`
+	ModifiedAfterSigning bool     `json:"modified_after_signing"`
+	IntegrityValid       bool     `json:"integrity_valid"` // el digest coincide con los bytes firmados
+	SignatureValid       bool     `json:"signature_valid"`
+	Trusted              bool     `json:"trusted"`
+	Status               string   `json:"status"`
+	Problems             []string `json:"problems,omitempty"`
+}
+
+// SignatureReport es el resultado de pdf_verify_signatures.
+type SignatureReport struct {
+	InputPath     string          `json:"input_path"`
+	FileSize      int64           `json:"file_size"`
+	TrustStoreDir string          `json:"trust_store_dir,omitempty"`
+	TrustedRoots  int             `json:"trusted_roots"`
+	Signed        bool            `json:"signed"`
+	AllValid      bool            `json:"all_valid"`
+	Signatures    []SignatureInfo `json:"signatures"`
+	Warnings      []string        `json:"warnings,omitempty"`
+}
+
+// SignKeySource indica de dónde cargar la clave de firma: un archivo PKCS#12 o
+// un certificado y una clave PEM. La contraseña no forma parte de la fuente; se
+// lee siempre de la configuración (PDF_SIGN_KEY_PASSWORD).
+type SignKeySource struct {
+	PKCS12Path string `json:"pkcs12_path,omitempty"`
+	CertPath   string `json:"cert_path,omitempty"` // puede incluir la cadena y también la clave
+	KeyPath    string `json:"key_path,omitempty"`
+}
+
+// SignOptions configura una firma. Sin Appearance la firma es invisible.
+type SignOptions struct {
+	FieldName   string    `json:"field_name,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	ContactInfo string    `json:"contact_info,omitempty"`
+	Appearance  *PageRect `json:"appearance,omitempty"`
+}
+
+// SignResult contiene el resultado de firmar un PDF.
+type SignResult struct {
+	OutputPath    string  `json:"output_path"`
+	FieldName     string  `json:"field_name"`
+	SubFilter     string  `json:"sub_filter"`
+	PAdESLevel    string  `json:"pades_level"`
+	SignerSubject string  `json:"signer_subject"`
+	SignerIssuer  string  `json:"signer_issuer"`
+	SigningTime   string  `json:"signing_time"`
+	Visible       bool    `json:"visible"`
+	Page          int     `json:"page"`
+	Rect          *Rect   `json:"rect,omitempty"`
+	ByteRange     []int64 `json:"byte_range"`
+	OriginalSize  int64   `json:"original_size"`
+	OutputSize    int64   `json:"output_size"`
+}
+
+// Niveles de gravedad de un hallazgo de seguridad y de riesgo del documento.
+const (
+	RiskNone     = "none"
+	RiskLow      = "low"
+	RiskMedium   = "medium"
+	RiskHigh     = "high"
+	RiskCritical = "critical"
+)
+
+// SecurityFinding es contenido activo o sospechoso encontrado en un PDF.
+type SecurityFinding struct {
+	Category    string `json:"category"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	Location    string `json:"location"` // document, page, field, annotation, outline, attachment o unreferenced
+	Page        int    `json:"page,omitempty"`
+	Object      int    `json:"object,omitempty"`
+	Detail      string `json:"detail,omitempty"` // extracto del código, URI, nombre de archivo...
+	Removable   bool   `json:"removable"`        // Sanitize puede eliminarlo
+}
+
+// SecurityReport es el resultado de SecurityScan.
+type SecurityReport struct {
+	InputPath     string            `json:"input_path"`
+	FileSize      int64             `json:"file_size"`
+	TotalPages    int               `json:"total_pages"`
+	Encrypted     bool              `json:"encrypted"`
+	ObjectStreams int               `json:"object_streams"`
+	RiskScore     int               `json:"risk_score"` // 0-100
+	RiskLevel     string            `json:"risk_level"`
+	Counts        map[string]int    `json:"counts"` // hallazgos por categoría
+	Findings      []SecurityFinding `json:"findings"`
+	Warnings      []string          `json:"warnings,omitempty"`
+}
+
+// SanitizeOptions configura qué conserva Sanitize. Por defecto elimina todo el
+// contenido activo, los enlaces externos y los archivos incrustados; los
+// metadatos y los formularios solo se tocan si se pide.
+type SanitizeOptions struct {
+	KeepLinks       bool `json:"keep_links,omitempty"`       // conservar acciones URI
+	KeepAttachments bool `json:"keep_attachments,omitempty"` // conservar adjuntos que no son ejecutables
+	StripMetadata   bool `json:"strip_metadata,omitempty"`   // vaciar Info y quitar XMP y PieceInfo
+	FlattenForms    bool `json:"flatten_forms,omitempty"`    // dibujar los campos en la página y quitar el formulario
+}
+
+// SanitizeResult contiene el resultado de Sanitize.
+type SanitizeResult struct {
+	OutputPath      string            `json:"output_path"`
+	TotalPages      int               `json:"total_pages"`
+	OriginalSize    int64             `json:"original_size"`
+	OutputSize      int64             `json:"output_size"`
+	RiskScoreBefore int               `json:"risk_score_before"`
+	RiskScoreAfter  int               `json:"risk_score_after"`
+	RiskLevelAfter  string            `json:"risk_level_after"`
+	Removed         []SecurityFinding `json:"removed"`
+	Remaining       []SecurityFinding `json:"remaining"`                  // hallazgos que siguen en la copia limpia
+	MetadataRemoved []string          `json:"metadata_removed,omitempty"` // con StripMetadata: "Info/Author", "XMP"...
+	FieldsFlattened int               `json:"fields_flattened,omitempty"` // con FlattenForms: widgets dibujados en la página
+	Warnings        []string          `json:"warnings,omitempty"`
+}
+
+// Tipos de cambio que informa Diff.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// DiffOptions configura la comparación de dos PDFs.
+type DiffOptions struct {
+	// AnnotatedOutputPath, si no está vacío, recibe una copia del segundo PDF
+	// con resaltados sobre el texto añadido o modificado.
+	AnnotatedOutputPath string `json:"annotated_output_path,omitempty"`
+}
+
+// LineChange es una línea de texto que solo aparece en uno de los PDFs.
+// Los números de línea cuentan solo las líneas no vacías de la página.
+type LineChange struct {
+	Change string `json:"change"` // added o removed
+	LineA  int    `json:"line_a,omitempty"`
+	LineB  int    `json:"line_b,omitempty"`
+	Text   string `json:"text"`
+	Rects  []Rect `json:"rects,omitempty"` // posición en la página del segundo PDF
+}
+
+// PageDiff describe una página añadida, eliminada o con texto distinto.
+// PageA y PageB son 0 cuando la página no existe en ese PDF.
+type PageDiff struct {
+	PageA        int          `json:"page_a,omitempty"`
+	PageB        int          `json:"page_b,omitempty"`
+	Change       string       `json:"change"`
+	LinesAdded   int          `json:"lines_added"`
+	LinesRemoved int          `json:"lines_removed"`
+	Lines        []LineChange `json:"lines,omitempty"`
+}
+
+// ValueChange es un metadato o un valor de campo de formulario distinto.
+type ValueChange struct {
+	Name   string `json:"name"`
+	Change string `json:"change"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// AnnotationChange es una anotación que solo aparece en uno de los PDFs.
+// Page es la página en el PDF donde aparece.
+type AnnotationChange struct {
+	Change   string `json:"change"` // added o removed
+	Page     int    `json:"page"`
+	Subtype  string `json:"subtype"`
+	Contents string `json:"contents,omitempty"`
+	Rect     Rect   `json:"rect"`
+}
+
+// AttachmentChange es un archivo adjunto añadido, eliminado o con contenido distinto.
+type AttachmentChange struct {
+	Name         string `json:"name"`
+	Change       string `json:"change"`
+	SizeBefore   int64  `json:"size_before,omitempty"`
+	SizeAfter    int64  `json:"size_after,omitempty"`
+	SHA256Before string `json:"sha256_before,omitempty"`
+	SHA256After  string `json:"sha256_after,omitempty"`
+}
+
+// DiffResult contiene las diferencias entre dos PDFs. Identical indica que no
+// hay diferencias en ninguno de los aspectos comparados, aunque los archivos
+// no sean idénticos byte a byte.
+type DiffResult struct {
+	PathA               string             `json:"path_a"`
+	PathB               string             `json:"path_b"`
+	PagesA              int                `json:"pages_a"`
+	PagesB              int                `json:"pages_b"`
+	Identical           bool               `json:"identical"`
+	ChangedPages        int                `json:"changed_pages"`
+	Pages               []PageDiff         `json:"pages"`
+	Metadata            []ValueChange      `json:"metadata"`
+	FormFields          []ValueChange      `json:"form_fields"`
+	Annotations         []AnnotationChange `json:"annotations"`
+	Attachments         []AttachmentChange `json:"attachments"`
+	AnnotatedOutputPath string             `json:"annotated_output_path,omitempty"`
+	Highlights          int                `json:"highlights,omitempty"` // anotaciones añadidas al PDF anotado
+	Warnings            []string           `json:"warnings,omitempty"`
+}
+
+// PageLabelStyle es el estilo de numeración de un tramo de etiquetas de página.
+type PageLabelStyle string
+
+const (
+	LabelDecimal      PageLabelStyle = "decimal"       // 1, 2, 3
+	LabelRomanUpper   PageLabelStyle = "roman_upper"   // I, II, III
+	LabelRomanLower   PageLabelStyle = "roman_lower"   // i, ii, iii
+	LabelLettersUpper PageLabelStyle = "letters_upper" // A..Z, AA..ZZ
+	LabelLettersLower PageLabelStyle = "letters_lower" // a..z, aa..zz
+	LabelNone         PageLabelStyle = "none"          // solo el prefijo
+)
+
+// IsValid verifica si el estilo es válido.
+func (s PageLabelStyle) IsValid() bool {
+	switch s {
+	case LabelDecimal, LabelRomanUpper, LabelRomanLower, LabelLettersUpper, LabelLettersLower, LabelNone:
+		return true
+	}
+	return false
+}
+
+// PageLabelRange es un tramo de etiquetas: se aplica desde StartPage (desde 1)
+// hasta la página anterior al siguiente tramo. StartValue es el número de la
+// primera página del tramo (1 si se omite).
+type PageLabelRange struct {
+	StartPage  int            `json:"start_page"`
+	Style      PageLabelStyle `json:"style"`
+	Prefix     string         `json:"prefix,omitempty"`
+	StartValue int            `json:"start_value,omitempty"`
+}
+
+// PageLabelsResult contiene los tramos de etiquetas de un PDF y la etiqueta de
+// cada página. Sin tramos, las etiquetas son los números de página.
+type PageLabelsResult struct {
+	InputPath  string           `json:"input_path"`
+	OutputPath string           `json:"output_path,omitempty"`
+	TotalPages int              `json:"total_pages"`
+	HasLabels  bool             `json:"has_labels"`
+	Ranges     []PageLabelRange `json:"ranges"`
+	Labels     []string         `json:"labels"`
+}
+
+// PipelineStep es un paso de un pipeline: el nombre de la operación y sus
+// argumentos, con los mismos nombres que la herramienta equivalente pero sin
+// rutas de entrada ni de salida.
+type PipelineStep struct {
+	Operation string          `json:"operation"`
+	Args      json.RawMessage `json:"args,omitempty"`
+}
+
+// Estados de un paso de pipeline.
+const (
+	StepOK      = "ok"
+	StepFailed  = "failed"
+	StepSkipped = "skipped"
+)
+
+// PipelineStepResult es el resultado de un paso. Result es el resultado de la
+// operación; en los pasos intermedios su output_path apunta al directorio
+// temporal del pipeline, que se elimina al terminar.
+type PipelineStepResult struct {
+	Step       int    `json:"step"`
+	Operation  string `json:"operation"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Result     any    `json:"result,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// PipelineResult contiene el informe de un pipeline. Si un paso falla, el
+// pipeline se detiene, FailedStep indica el paso (1-based) y no se escribe la
+// salida.
+type PipelineResult struct {
+	InputPaths []string             `json:"input_paths"`
+	OutputPath string               `json:"output_path,omitempty"`
+	OutputSize int64                `json:"output_size,omitempty"`
+	Success    bool                 `json:"success"`
+	FailedStep int                  `json:"failed_step,omitempty"`
+	Error      string               `json:"error,omitempty"`
+	Steps      []PipelineStepResult `json:"steps"`
+	DurationMs int64                `json:"duration_ms"`
+}
+
+// BatchOptions configura una operación por lotes.
+type BatchOptions struct {
+	// OutputDir recibe los PDFs generados; no hace falta en las operaciones de
+	// solo lectura.
+	OutputDir string `json:"output_dir,omitempty"`
+	// FilenameTemplate es el nombre de cada salida. Admite {name} (nombre de la
+	// entrada sin extensión), {ext}, {index} (posición, desde 1) y {op}; por
+	// defecto "{name}-{op}.pdf".
+	FilenameTemplate string `json:"filename_template,omitempty"`
+	// Workers es el número de archivos que se procesan a la vez.
+	Workers int `json:"workers,omitempty"`
+}
+
+// BatchFileResult es el resultado de la operación sobre un archivo.
+type BatchFileResult struct {
+	InputPath  string `json:"input_path"`
+	OutputPath string `json:"output_path,omitempty"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Result     any    `json:"result,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// BatchResult contiene el resultado de una operación por lotes. Los archivos
+// aparecen en el orden de entrada; un fallo no detiene el resto.
+type BatchResult struct {
+	Operation  string            `json:"operation"`
+	Total      int               `json:"total"`
+	Succeeded  int               `json:"succeeded"`
+	Failed     int               `json:"failed"`
+	Files      []BatchFileResult `json:"files"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// CollateResult contiene el resultado de intercalar anversos y reversos.
+type CollateResult struct {
+	OutputPath    string `json:"output_path"`
+	PagesA        int    `json:"pages_a"`
+	PagesB        int    `json:"pages_b"`
+	TotalPages    int    `json:"total_pages"`
+	ReverseB      bool   `json:"reverse_b"`
+	CountMismatch bool   `json:"count_mismatch"`
+	Warning       string `json:"warning,omitempty"`
+	OutputSize    int64  `json:"output_size"`
+}
+
+// Rect es un rectángulo en puntos PDF (espacio de usuario, origen abajo a la izquierda).
+type Rect struct {
+	LLX float64 `json:"llx"`
+	LLY float64 `json:"lly"`
+	URX float64 `json:"urx"`
+	URY float64 `json:"ury"`
+}
+
+// PageRect es un rectángulo asociado a una página (numeración desde 1).
+type PageRect struct {
+	Page int `json:"page"`
+	Rect
+}
+
+// RedactPattern describe un texto a redactar, literal o expresión regular.
+type RedactPattern struct {
+	Pattern         string `json:"pattern"`
+	Regex           bool   `json:"regex,omitempty"`
+	CaseInsensitive bool   `json:"case_insensitive,omitempty"`
+}
+
+// RedactOptions contiene los parámetros de una operación de redacción.
+type RedactOptions struct {
+	Areas    []PageRect      `json:"areas,omitempty"`
+	Patterns []RedactPattern `json:"patterns,omitempty"`
+	DryRun   bool            `json:"dry_run,omitempty"`
+}
+
+// RedactMatch es una coincidencia de texto encontrada durante la redacción.
+type RedactMatch struct {
+	Page    int    `json:"page"`
+	Pattern string `json:"pattern"`
+	Text    string `json:"text"`
+	Rects   []Rect `json:"rects"`
+}
+
+// RedactResult contiene el resultado de una operación de redacción.
+type RedactResult struct {
+	OutputPath         string        `json:"output_path,omitempty"`
+	DryRun             bool          `json:"dry_run"`
+	PagesAffected      []int         `json:"pages_affected"`
+	Matches            []RedactMatch `json:"matches"`
+	Areas              []PageRect    `json:"areas"`
+	GlyphsRemoved      int           `json:"glyphs_removed"`
+	ImagesRemoved      int           `json:"images_removed"`
+	ImagesModified     int           `json:"images_modified"`
+	AnnotationsRemoved int           `json:"annotations_removed"`
+	MetadataCleared    []string      `json:"metadata_cleared"`
+	Warnings           []string      `json:"warnings,omitempty"`
+	OutputSize         int64         `json:"output_size,omitempty"`
+}
+
+// PIICustomDetector es un detector definido por el usuario mediante una expresión regular.
+type PIICustomDetector struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// PIIScanOptions configura los detectores de ScanPII.
+// Si Detectors está vacío se ejecutan todos los detectores integrados.
+type PIIScanOptions struct {
+	Detectors []string            `json:"detectors,omitempty"`
+	Custom    []PIICustomDetector `json:"custom,omitempty"`
+}
+
+// PIIFinding es un dato personal encontrado en una página.
+type PIIFinding struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail,omitempty"`
+	Page   int    `json:"page"`
+	Text   string `json:"text"`
+	Rects  []Rect `json:"rects"`
+}
+
+// PIIScanResult contiene el resultado de un escaneo de datos personales.
+// Areas puede pasarse directamente como áreas de redacción.
+type PIIScanResult struct {
+	InputPath  string         `json:"input_path"`
+	TotalPages int            `json:"total_pages"`
+	Findings   []PIIFinding   `json:"findings"`
+	Counts     map[string]int `json:"counts"`
+	Areas      []PageRect     `json:"areas"`
+	Warnings   []string       `json:"warnings,omitempty"`
+}
+
+// SearchMode define cómo se interpreta la consulta de búsqueda.
+type SearchMode string
+
+const (
+	SearchLiteral         SearchMode = "literal"          // Texto exacto
+	SearchCaseInsensitive SearchMode = "case_insensitive" // Texto exacto sin distinguir mayúsculas
+	SearchRegex           SearchMode = "regex"            // Expresión regular Go
+)
+
+// SearchOptions configura una búsqueda de texto.
+type SearchOptions struct {
+	Mode         SearchMode `json:"mode,omitempty"`
+	ContextChars int        `json:"context_chars,omitempty"`
+	MaxResults   int        `json:"max_results,omitempty"`
+}
+
+// SearchHit es una coincidencia de búsqueda en una página.
+type SearchHit struct {
+	Page    int    `json:"page"`
+	Text    string `json:"text"`
+	Snippet string `json:"snippet"`
+	Rects   []Rect `json:"rects"`
+}
+
+// SearchResult contiene las coincidencias de una búsqueda en un PDF.
+type SearchResult struct {
+	InputPath  string      `json:"input_path"`
+	Query      string      `json:"query"`
+	Mode       SearchMode  `json:"mode"`
+	TotalPages int         `json:"total_pages"`
+	TotalHits  int         `json:"total_hits"`
+	Truncated  bool        `json:"truncated,omitempty"`
+	Hits       []SearchHit `json:"hits"`
+	Warnings   []string    `json:"warnings,omitempty"`
+}
+
+// FileError asocia un error a un archivo en operaciones sobre varios archivos.
+type FileError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// MultiSearchResult agrupa por archivo los resultados de una búsqueda en varios PDFs.
+type MultiSearchResult struct {
+	Query         string         `json:"query"`
+	Mode          SearchMode     `json:"mode"`
+	FilesSearched int            `json:"files_searched"`
+	FilesWithHits int            `json:"files_with_hits"`
+	TotalHits     int            `json:"total_hits"`
+	Files         []SearchResult `json:"files"`
+	Errors        []FileError    `json:"errors,omitempty"`
+}
+
+// ValidationProblem es un problema detectado al validar un PDF.
+type ValidationProblem struct {
+	Category string `json:"category"`
+	Object   int    `json:"object,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ValidationPass es el resultado de validar un PDF en un modo concreto.
+type ValidationPass struct {
+	Mode      string              `json:"mode"`
+	Valid     bool                `json:"valid"`
+	PageCount int                 `json:"page_count,omitempty"`
+	Problems  []ValidationProblem `json:"problems"`
+}
+
+// XRefHealth describe el estado de la tabla de referencias cruzadas y el trailer.
+type XRefHealth struct {
+	Healthy         bool                `json:"healthy"`
+	StartXRefFound  bool                `json:"startxref_found"`
+	StartXRefOffset int64               `json:"startxref_offset,omitempty"`
+	StartXRefValid  bool                `json:"startxref_valid"`
+	XRefStream      bool                `json:"xref_stream"`
+	TrailerFound    bool                `json:"trailer_found"`
+	RootFound       bool                `json:"root_found"`
+	EOFMarkers      int                 `json:"eof_markers"`
+	Truncated       bool                `json:"truncated"`
+	ObjectsFound    int                 `json:"objects_found"`
+	Problems        []ValidationProblem `json:"problems"`
+}
+
+// ValidationReport es el informe estructurado de pdf_validate.
+type ValidationReport struct {
+	InputPath       string         `json:"input_path"`
+	FileSize        int64          `json:"file_size"`
+	PDFVersion      string         `json:"pdf_version,omitempty"`
+	HeaderVersion   string         `json:"header_version,omitempty"`
+	Encrypted       bool           `json:"encrypted"`
+	Strict          ValidationPass `json:"strict"`
+	Relaxed         ValidationPass `json:"relaxed"`
+	RelaxedOnly     bool           `json:"relaxed_only"`
+	RecommendedMode string         `json:"recommended_mode,omitempty"`
+	XRef            XRefHealth     `json:"xref"`
+	RepairNeeded    bool           `json:"repair_needed"`
+	RepairPossible  bool           `json:"repair_possible"`
+	RepairReason    string         `json:"repair_reason,omitempty"`
+}
+
+// RepairChange describe un cambio aplicado por Repair sobre un objeto.
+type RepairChange struct {
+	Object int    `json:"object"`
+	Action string `json:"action"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// RepairResult es el informe de pdf_repair.
+type RepairResult struct {
+	InputPath          string              `json:"input_path"`
+	OutputPath         string              `json:"output_path"`
+	PDFVersion         string              `json:"pdf_version"`
+	PageCount          int                 `json:"page_count"`
+	ObjectsFound       int                 `json:"objects_found"`
+	ObjectsWritten     int                 `json:"objects_written"`
+	XRefProblems       []ValidationProblem `json:"xref_problems"`
+	StreamLengthsFixed int                 `json:"stream_lengths_fixed"`
+	CorruptDropped     int                 `json:"corrupt_dropped"`
+	UnreachableDropped int                 `json:"unreachable_dropped"`
+	Changes            []RepairChange      `json:"changes"`
+	Warnings           []string            `json:"warnings,omitempty"`
 }
 
 // ToolResult es el resultado genérico de una herramienta MCP.